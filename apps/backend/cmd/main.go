@@ -1,9 +1,15 @@
 package main
 
 import (
+	"os"
+
 	"github.com/kisnikita/safe-disputes/backend/internal"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "move-inline-images" {
+		internal.MoveInlineImages()
+		return
+	}
 	internal.StartApp()
 }
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type ConflictPolicyManager interface {
+	GetConflictPolicy(ctx context.Context) (models.ConflictPolicy, error)
+	UpdateConflictPolicy(ctx context.Context, policy models.ConflictPolicy) (models.ConflictPolicy, error)
+}
+
+type VerdictPolicyManager interface {
+	GetVerdictPolicy(ctx context.Context) (models.VerdictPolicy, error)
+	UpdateVerdictPolicy(ctx context.Context, policy models.VerdictPolicy) (models.VerdictPolicy, error)
+}
+
+type RationaleModerator interface {
+	ListRationaleFlags(ctx context.Context, status string) ([]models.RationaleFlagCard, error)
+	ResolveRationaleFlag(ctx context.Context, flagID string, hide bool) error
+}
+
+type JuryExclusionGetter interface {
+	ListJuryExclusions(ctx context.Context, investigationID string) ([]models.JuryExclusionCard, error)
+}
+
+// AdminOnly lets through the users listed in the comma-separated ADMIN_USERNAMES env variable. It must run after
+// Middleware.
+func AdminOnly() gin.HandlerFunc {
+	var admins []string
+	for _, username := range strings.Split(os.Getenv("ADMIN_USERNAMES"), ",") {
+		if username = strings.TrimSpace(username); username != "" {
+			admins = append(admins, username)
+		}
+	}
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if !slices.Contains(admins, actorUsername) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func GetJuryExclusions(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	conflictSrv, err := services.NewConflictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create conflict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetJuryExclusions"))
+	return getJuryExclusions(log, conflictSrv)
+}
+
+func getJuryExclusions(log log.Logger, getter JuryExclusionGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		exclusions, err := getter.ListJuryExclusions(c, c.Param("id"))
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": exclusions})
+	}
+}
+
+func GetConflictPolicy(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	conflictSrv, err := services.NewConflictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create conflict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetConflictPolicy"))
+	return getConflictPolicy(log, conflictSrv)
+}
+
+func getConflictPolicy(log log.Logger, manager ConflictPolicyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		policy, err := manager.GetConflictPolicy(c)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": policy})
+	}
+}
+
+func UpdateConflictPolicy(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	conflictSrv, err := services.NewConflictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create conflict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "UpdateConflictPolicy"))
+	return updateConflictPolicy(log, conflictSrv)
+}
+
+func updateConflictPolicy(log log.Logger, manager ConflictPolicyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			DisputeWindowDays *int `json:"disputeWindowDays" binding:"required"`
+			CoJurorWindowDays *int `json:"coJurorWindowDays" binding:"required"`
+			CoJurorLimit      *int `json:"coJurorLimit" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		policy, err := manager.UpdateConflictPolicy(c, models.ConflictPolicy{
+			DisputeWindowDays: *req.DisputeWindowDays,
+			CoJurorWindowDays: *req.CoJurorWindowDays,
+			CoJurorLimit:      *req.CoJurorLimit,
+		})
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": policy})
+	}
+}
+
+func GetVerdictPolicy(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	verdictSrv, err := services.NewVerdictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create verdict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetVerdictPolicy"))
+	return getVerdictPolicy(log, verdictSrv)
+}
+
+func getVerdictPolicy(log log.Logger, manager VerdictPolicyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		policy, err := manager.GetVerdictPolicy(c)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": policy})
+	}
+}
+
+func UpdateVerdictPolicy(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	verdictSrv, err := services.NewVerdictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create verdict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "UpdateVerdictPolicy"))
+	return updateVerdictPolicy(log, verdictSrv)
+}
+
+func updateVerdictPolicy(log log.Logger, manager VerdictPolicyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Quorum           *int `json:"quorum" binding:"required"`
+			RequireRationale bool `json:"requireRationale"`
+			CommitReveal     bool `json:"commitReveal"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		policy, err := manager.UpdateVerdictPolicy(c, models.VerdictPolicy{
+			Quorum:           *req.Quorum,
+			RequireRationale: req.RequireRationale,
+			CommitReveal:     req.CommitReveal,
+		})
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": policy})
+	}
+}
+
+func ListRationaleFlags(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	transparencySrv, err := services.NewTransparencyService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create transparency service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "ListRationaleFlags"))
+	return listRationaleFlags(log, transparencySrv)
+}
+
+func listRationaleFlags(log log.Logger, moderator RationaleModerator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		status := c.DefaultQuery("status", string(models.RationaleFlagStatusOpen))
+		flags, err := moderator.ListRationaleFlags(c, status)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": flags})
+	}
+}
+
+func ResolveRationaleFlag(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	transparencySrv, err := services.NewTransparencyService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create transparency service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "ResolveRationaleFlag"))
+	return resolveRationaleFlag(log, transparencySrv)
+}
+
+func resolveRationaleFlag(log log.Logger, moderator RationaleModerator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Hide *bool `json:"hide" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		flagID := c.Param("id")
+		err := moderator.ResolveRationaleFlag(c, flagID, *req.Hide)
+		switch {
+		case errors.Is(err, services.ErrFlagNotFound):
+			log.Error("rationale flag not found", zap.String("flagID", flagID), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "rationale flag not found"})
+			return
+		case err != nil:
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+)
+
+type fakeConflictPolicyManager struct {
+	policy  models.ConflictPolicy
+	err     error
+	updated bool
+}
+
+func (f *fakeConflictPolicyManager) GetConflictPolicy(context.Context) (models.ConflictPolicy, error) {
+	return f.policy, f.err
+}
+
+func (f *fakeConflictPolicyManager) UpdateConflictPolicy(_ context.Context, policy models.ConflictPolicy,
+) (models.ConflictPolicy, error) {
+	f.updated = true
+	f.policy = policy
+	return policy, f.err
+}
+
+func TestAdminOnly(t *testing.T) {
+	t.Setenv("ADMIN_USERNAMES", "alice, carol")
+
+	newRouter := func(username string) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", username)
+		}, AdminOnly())
+		r.GET("/ping", func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		return r
+	}
+
+	cases := map[string]int{"alice": http.StatusNoContent, "carol": http.StatusNoContent, "bob": http.StatusForbidden}
+	for username, want := range cases {
+		rr := httptest.NewRecorder()
+		newRouter(username).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		if rr.Code != want {
+			t.Fatalf("%s: expected %d, got %d", username, want, rr.Code)
+		}
+	}
+}
+
+func TestUpdateConflictPolicy(t *testing.T) {
+	newRouter := func(manager *fakeConflictPolicyManager) *gin.Engine {
+		r := gin.New()
+		r.POST("/policy", func(c *gin.Context) {
+			c.Set("username", "alice")
+			updateConflictPolicy(noopLogger{}, manager)(c)
+		})
+		return r
+	}
+
+	t.Run("returns bad request when a field is missing", func(t *testing.T) {
+		manager := &fakeConflictPolicyManager{}
+		req := httptest.NewRequest(http.MethodPost, "/policy", strings.NewReader(`{"disputeWindowDays":30}`))
+		rr := httptest.NewRecorder()
+		newRouter(manager).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest || manager.updated {
+			t.Fatalf("expected %d without update, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("returns bad request for an invalid policy", func(t *testing.T) {
+		manager := &fakeConflictPolicyManager{err: services.ErrValidation}
+		req := httptest.NewRequest(http.MethodPost, "/policy",
+			strings.NewReader(`{"disputeWindowDays":30,"coJurorWindowDays":30,"coJurorLimit":0}`))
+		rr := httptest.NewRecorder()
+		newRouter(manager).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("returns the updated policy", func(t *testing.T) {
+		manager := &fakeConflictPolicyManager{}
+		req := httptest.NewRequest(http.MethodPost, "/policy",
+			strings.NewReader(`{"disputeWindowDays":0,"coJurorWindowDays":60,"coJurorLimit":2}`))
+		rr := httptest.NewRecorder()
+		newRouter(manager).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+		}
+		data, _ := decodeJSONMap(t, rr)["data"].(map[string]any)
+		if data["coJurorLimit"] != float64(2) || data["disputeWindowDays"] != float64(0) {
+			t.Fatalf("unexpected response: %v", data)
+		}
+	})
+}
+
+type fakeVerdictPolicyManager struct {
+	policy  models.VerdictPolicy
+	err     error
+	updated bool
+}
+
+func (f *fakeVerdictPolicyManager) GetVerdictPolicy(context.Context) (models.VerdictPolicy, error) {
+	return f.policy, f.err
+}
+
+func (f *fakeVerdictPolicyManager) UpdateVerdictPolicy(_ context.Context, policy models.VerdictPolicy,
+) (models.VerdictPolicy, error) {
+	f.updated = true
+	f.policy = policy
+	return policy, f.err
+}
+
+func TestUpdateVerdictPolicy(t *testing.T) {
+	newRouter := func(manager *fakeVerdictPolicyManager) *gin.Engine {
+		r := gin.New()
+		r.POST("/policy", func(c *gin.Context) {
+			c.Set("username", "alice")
+			updateVerdictPolicy(noopLogger{}, manager)(c)
+		})
+		return r
+	}
+
+	t.Run("returns bad request when the quorum is missing", func(t *testing.T) {
+		manager := &fakeVerdictPolicyManager{}
+		req := httptest.NewRequest(http.MethodPost, "/policy", strings.NewReader(`{"requireRationale":true}`))
+		rr := httptest.NewRecorder()
+		newRouter(manager).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest || manager.updated {
+			t.Fatalf("expected %d without update, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("returns the updated policy", func(t *testing.T) {
+		manager := &fakeVerdictPolicyManager{}
+		req := httptest.NewRequest(http.MethodPost, "/policy", strings.NewReader(
+			`{"quorum":3,"requireRationale":true,"commitReveal":true}`))
+		rr := httptest.NewRecorder()
+		newRouter(manager).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+		}
+		data, _ := decodeJSONMap(t, rr)["data"].(map[string]any)
+		if data["quorum"] != float64(3) || data["requireRationale"] != true || data["commitReveal"] != true {
+			t.Fatalf("unexpected response: %v", data)
+		}
+	})
+}
+
+type fakeRationaleModerator struct {
+	flags    []models.RationaleFlagCard
+	err      error
+	resolved string
+	hide     bool
+}
+
+func (f *fakeRationaleModerator) ListRationaleFlags(context.Context, string) ([]models.RationaleFlagCard, error) {
+	return f.flags, f.err
+}
+
+func (f *fakeRationaleModerator) ResolveRationaleFlag(_ context.Context, flagID string, hide bool) error {
+	f.resolved = flagID
+	f.hide = hide
+	return f.err
+}
+
+func TestResolveRationaleFlag(t *testing.T) {
+	newRouter := func(moderator *fakeRationaleModerator) *gin.Engine {
+		r := gin.New()
+		r.POST("/rationale-flags/:id/resolve", func(c *gin.Context) {
+			c.Set("username", "alice")
+			resolveRationaleFlag(noopLogger{}, moderator)(c)
+		})
+		return r
+	}
+
+	t.Run("returns bad request without a decision", func(t *testing.T) {
+		moderator := &fakeRationaleModerator{}
+		req := httptest.NewRequest(http.MethodPost, "/rationale-flags/1/resolve", strings.NewReader(`{}`))
+		rr := httptest.NewRecorder()
+		newRouter(moderator).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest || moderator.resolved != "" {
+			t.Fatalf("expected %d without resolving, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("hides the rationale", func(t *testing.T) {
+		moderator := &fakeRationaleModerator{}
+		req := httptest.NewRequest(http.MethodPost, "/rationale-flags/1/resolve", strings.NewReader(`{"hide":true}`))
+		rr := httptest.NewRecorder()
+		newRouter(moderator).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent || moderator.resolved != "1" || !moderator.hide {
+			t.Fatalf("expected %d with the rationale hidden, got %d", http.StatusNoContent, rr.Code)
+		}
+	})
+
+	t.Run("returns not found for an unknown flag", func(t *testing.T) {
+		moderator := &fakeRationaleModerator{err: services.ErrFlagNotFound}
+		req := httptest.NewRequest(http.MethodPost, "/rationale-flags/1/resolve", strings.NewReader(`{"hide":false}`))
+		rr := httptest.NewRecorder()
+		newRouter(moderator).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
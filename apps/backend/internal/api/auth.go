@@ -14,16 +14,24 @@ type userCreator interface {
 	CreateIfNotExist(ctx context.Context, username string, photoUrl *string) error
 }
 
-func TelegramAuth(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+type invitationAttacher interface {
+	AttachInvitations(ctx context.Context, username string) error
+}
+
+func TelegramAuth(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
 	userSrv, err := services.NewUserService(repo, log)
 	if err != nil {
 		log.Fatal("failed to create user service", zap.Error(err))
 	}
+	invitationSrv, err := services.NewInvitationService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create invitation service", zap.Error(err))
+	}
 	log = log.With(zap.String("handler", "TelegramAuth"))
-	return telegramAuth(log, userSrv)
+	return telegramAuth(log, userSrv, invitationSrv)
 }
 
-func telegramAuth(log log.Logger, userSrv userCreator) gin.HandlerFunc {
+func telegramAuth(log log.Logger, userSrv userCreator, invitationSrv invitationAttacher) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		actorUsername, ok := getActorUsername(c)
 		if !ok {
@@ -48,6 +56,11 @@ func telegramAuth(log log.Logger, userSrv userCreator) gin.HandlerFunc {
 			return
 		}
 
+		// Invitations are picked up again on the next login, so they don't block this one.
+		if err = invitationSrv.AttachInvitations(c, actorUsername); err != nil {
+			log.Error("failed to attach invitations", zap.String("actor", actorUsername), zap.Error(err))
+		}
+
 		c.Status(200)
 	}
 }
@@ -22,10 +22,20 @@ func (f *fakeUserCreator) CreateIfNotExist(_ context.Context, username string, _
 	return f.err
 }
 
+type fakeInvitationAttacher struct {
+	err         error
+	gotUsername string
+}
+
+func (f *fakeInvitationAttacher) AttachInvitations(_ context.Context, username string) error {
+	f.gotUsername = username
+	return f.err
+}
+
 func TestTelegramAuth(t *testing.T) {
 	t.Run("returns unauthorized when username missing", func(t *testing.T) {
 		r := gin.New()
-		r.GET("/auth", telegramAuth(noopLogger{}, &fakeUserCreator{}))
+		r.GET("/auth", telegramAuth(noopLogger{}, &fakeUserCreator{}, &fakeInvitationAttacher{}))
 
 		req := httptest.NewRequest(http.MethodGet, "/auth", nil)
 		rr := httptest.NewRecorder()
@@ -42,7 +52,7 @@ func TestTelegramAuth(t *testing.T) {
 			c.Set("username", 42)
 			c.Next()
 		})
-		r.GET("/auth", telegramAuth(noopLogger{}, &fakeUserCreator{}))
+		r.GET("/auth", telegramAuth(noopLogger{}, &fakeUserCreator{}, &fakeInvitationAttacher{}))
 
 		req := httptest.NewRequest(http.MethodGet, "/auth", nil)
 		rr := httptest.NewRecorder()
@@ -61,7 +71,7 @@ func TestTelegramAuth(t *testing.T) {
 			c.Set("username", "alice")
 			c.Next()
 		})
-		r.GET("/auth", telegramAuth(noopLogger{}, svc))
+		r.GET("/auth", telegramAuth(noopLogger{}, svc, &fakeInvitationAttacher{}))
 
 		req := httptest.NewRequest(http.MethodGet, "/auth", nil)
 		rr := httptest.NewRecorder()
@@ -77,13 +87,14 @@ func TestTelegramAuth(t *testing.T) {
 
 	t.Run("returns ok and calls service", func(t *testing.T) {
 		svc := &fakeUserCreator{}
+		attacher := &fakeInvitationAttacher{err: errors.New("boom")}
 
 		r := gin.New()
 		r.Use(func(c *gin.Context) {
 			c.Set("username", "alice")
 			c.Next()
 		})
-		r.GET("/auth", telegramAuth(noopLogger{}, svc))
+		r.GET("/auth", telegramAuth(noopLogger{}, svc, attacher))
 
 		req := httptest.NewRequest(http.MethodGet, "/auth", nil)
 		rr := httptest.NewRecorder()
@@ -95,5 +106,8 @@ func TestTelegramAuth(t *testing.T) {
 		if svc.gotUsername != "alice" {
 			t.Fatalf("expected username alice, got %q", svc.gotUsername)
 		}
+		if attacher.gotUsername != "alice" {
+			t.Fatalf("expected invitations attached for alice, got %q", attacher.gotUsername)
+		}
 	})
 }
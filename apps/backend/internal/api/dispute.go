@@ -22,13 +22,22 @@ type DisputePrechecker interface {
 }
 
 type DisputeCreator interface {
-	CreateDispute(ctx context.Context, req models.CreateDisputeReq, actorUsername string) error
+	CreateDispute(ctx context.Context, req models.CreateDisputeReq, actorUsername string) (models.PendingOperation, error)
 }
 
 type DisputeLister interface {
 	ListDisputes(ctx context.Context, opts models.DisputeListOpts, actorUsername string) ([]models.DisputeCard, error)
 }
 
+type OpenDisputeLister interface {
+	ListOpenDisputes(ctx context.Context, opts models.OpenDisputeListOpts, actorUsername string,
+	) ([]models.OpenDisputeCard, error)
+}
+
+type OpenDisputeGetter interface {
+	GetOpenDispute(ctx context.Context, disputeID string) (models.OpenDisputeCard, error)
+}
+
 type DisputeSeener interface {
 	MarkDisputesSeen(ctx context.Context, actorUsername string, disputeIDs []string) error
 }
@@ -38,8 +47,21 @@ type DisputeGetter interface {
 	GetDisputeForEvidence(ctx context.Context, disputeID string) (models.Dispute, error)
 }
 
+type DisputeHistoryGetter interface {
+	GetDisputeHistory(ctx context.Context, disputeID string, actorUsername string) ([]models.DisputeEventCard, error)
+}
+
+type TransparencyReportGetter interface {
+	GetTransparencyReport(ctx context.Context, disputeID, actorUsername string) (models.TransparencyReport, error)
+}
+
+type RationaleFlagger interface {
+	FlagRationale(ctx context.Context, disputeID, voteID, actorUsername, reason string) error
+}
+
 type DisputeAcceptor interface {
-	AcceptDispute(ctx context.Context, disputeID string, acceptorUsername string, boc string) error
+	AcceptDispute(ctx context.Context, disputeID string, acceptorUsername string, boc string,
+	) (models.PendingOperation, error)
 }
 
 type DisputeRejector interface {
@@ -47,11 +69,12 @@ type DisputeRejector interface {
 }
 
 type DisputeClaimer interface {
-	ClaimDispute(ctx context.Context, disputeID string, claimerUsername string, boc string) error
+	ClaimDispute(ctx context.Context, disputeID string, claimerUsername string, boc string) (models.PendingOperation, error)
 }
 
 type DisputeVoter interface {
-	VoteDispute(ctx context.Context, disputeID string, claimerUsername string, win bool, boc string) error
+	VoteDispute(ctx context.Context, disputeID string, claimerUsername string, win bool, boc string,
+	) (models.PendingOperation, error)
 }
 
 func PrecheckDispute(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
@@ -115,13 +138,17 @@ func precheckDispute(log log.Logger, prechecker DisputePrechecker) gin.HandlerFu
 }
 
 func CreateDispute(repo *repository.Repository, log log.Logger, sender services.MessageSender,
-	txMonitor services.TransactionMonitor,
+	txMonitor services.TransactionMonitor, betMaster string, blobs services.BlobStore,
 ) gin.HandlerFunc {
 	disputeSrv, err := services.NewDisputeService(repo, log, sender)
 	if err != nil {
 		log.Fatal("failed to create dispute service", zap.Error(err))
 	}
-	disputeSrv = disputeSrv.WithTransactionMonitor(txMonitor)
+	mediaSrv, err := services.NewMediaService(repo, log, blobs)
+	if err != nil {
+		log.Fatal("failed to create media service", zap.Error(err))
+	}
+	disputeSrv = disputeSrv.WithTransactionMonitor(txMonitor).WithBetMaster(betMaster).WithMediaStore(mediaSrv)
 	log = log.With(zap.String("handler", "CreateDispute"))
 	return createDispute(log, disputeSrv)
 }
@@ -133,6 +160,10 @@ func createDispute(log log.Logger, disputeCreator DisputeCreator) gin.HandlerFun
 			return
 		}
 
+		if err := parseUpload(c, maxDisputeImageSize); err != nil {
+			handleUploadError(c, log, err, maxDisputeImageSize)
+			return
+		}
 		var req models.CreateDisputeReq
 		err := c.ShouldBind(&req)
 		if err != nil {
@@ -142,18 +173,18 @@ func createDispute(log log.Logger, disputeCreator DisputeCreator) gin.HandlerFun
 		}
 		log.Info("CreateDispute params", zap.Any("params", req))
 
-		if req.ImageData, req.ImageType, err = getFile(c, "image"); err != nil {
-			log.Error("failed to get file", zap.Error(err))
-			c.JSON(500, gin.H{"error": "cannot open uploaded file"})
+		if req.ImageData, err = getUpload(c, "image", maxDisputeImageSize); err != nil {
+			handleUploadError(c, log, err, maxDisputeImageSize)
 			return
 		}
 
-		if err = disputeCreator.CreateDispute(c, req, actorUsername); err != nil {
+		op, err := disputeCreator.CreateDispute(c, req, actorUsername)
+		if err != nil {
 			handleApiError(c, log, actorUsername, err)
 			return
 		}
 
-		c.Status(201)
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
 	}
 }
 
@@ -224,6 +255,91 @@ func listDisputes(log log.Logger, lister DisputeLister) gin.HandlerFunc {
 	}
 }
 
+func ListOpenDisputes(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	disputeSrv, err := services.NewDisputeService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create dispute service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "ListOpenDisputes"))
+	return listOpenDisputes(log, disputeSrv)
+}
+
+func listOpenDisputes(log log.Logger, lister OpenDisputeLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		limit := 10
+		if limStr := c.Query("limit"); limStr != "" {
+			if l, err := strconv.Atoi(limStr); err == nil && l > 0 {
+				limit = l
+			}
+		}
+
+		opts := models.OpenDisputeListOpts{
+			Limit:  limit,
+			Cursor: c.Query("cursor"),
+		}
+
+		disputes, err := lister.ListOpenDisputes(c, opts, actorUsername)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		var nextCursor *string
+		if len(disputes) > limit {
+			ts := disputes[limit].CreatedAt.Format(time.RFC3339Nano)
+			nextCursor = &ts
+			disputes = disputes[:limit]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":       disputes,
+			"nextCursor": nextCursor,
+		})
+	}
+}
+
+func GetOpenDispute(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	disputeSrv, err := services.NewDisputeService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create dispute service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetOpenDispute"))
+	return getOpenDispute(log, disputeSrv)
+}
+
+func getOpenDispute(log log.Logger, getter OpenDisputeGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		disputeID := c.Param("id")
+		if disputeID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dispute ID is required"})
+			return
+		}
+
+		dispute, err := getter.GetOpenDispute(c, disputeID)
+		switch {
+		case errors.Is(err, services.ErrOpenDisputeNotFound):
+			log.Error("open dispute not found", zap.String("disputeID", disputeID), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "open dispute not found"})
+			return
+		case err != nil:
+			handleApiError(c, log.With(zap.String("disputeID", disputeID)), actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": dispute})
+	}
+}
+
 func MarkDisputesSeen(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
 	disputeSrv, err := services.NewDisputeService(repo, log, sender)
 	if err != nil {
@@ -299,6 +415,118 @@ func getDispute(log log.Logger, getter DisputeGetter) gin.HandlerFunc {
 	}
 }
 
+func GetDisputeHistory(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	disputeSrv, err := services.NewDisputeService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create dispute service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetDisputeHistory"))
+	return getDisputeHistory(log, disputeSrv)
+}
+
+func getDisputeHistory(log log.Logger, getter DisputeHistoryGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		disputeID := c.Param("id")
+		events, err := getter.GetDisputeHistory(c, disputeID, actorUsername)
+		switch {
+		case errors.Is(err, services.ErrDisputeNotFound):
+			log.Error("dispute not found", zap.String("disputeID", disputeID), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispute not found"})
+			return
+		case err != nil:
+			handleApiError(c, log.With(zap.String("disputeID", disputeID)), actorUsername, err)
+			return
+		}
+		if events == nil {
+			events = []models.DisputeEventCard{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": events})
+	}
+}
+
+func GetTransparencyReport(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	transparencySrv, err := services.NewTransparencyService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create transparency service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetTransparencyReport"))
+	return getTransparencyReport(log, transparencySrv)
+}
+
+func getTransparencyReport(log log.Logger, getter TransparencyReportGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		disputeID := c.Param("id")
+		report, err := getter.GetTransparencyReport(c, disputeID, actorUsername)
+		if err != nil {
+			handleReportError(c, log.With(zap.String("disputeID", disputeID)), actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": report})
+	}
+}
+
+func FlagRationale(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	transparencySrv, err := services.NewTransparencyService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create transparency service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "FlagRationale"))
+	return flagRationale(log, transparencySrv)
+}
+
+func flagRationale(log log.Logger, flagger RationaleFlagger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		disputeID := c.Param("id")
+		if err := flagger.FlagRationale(c, disputeID, c.Param("voteID"), actorUsername, req.Reason); err != nil {
+			handleReportError(c, log.With(zap.String("disputeID", disputeID)), actorUsername, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleReportError hides the report of a dispute the actor isn't a participant of, and of one whose
+// investigation hasn't closed yet.
+func handleReportError(c *gin.Context, log log.Logger, actorUsername string, err error) {
+	switch {
+	case errors.Is(err, services.ErrDisputeNotFound):
+		log.Error("dispute not found", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "dispute not found"})
+	case errors.Is(err, services.ErrReportNotFound):
+		log.Error("transparency report not found", zap.Error(err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "transparency report not found"})
+	default:
+		handleApiError(c, log, actorUsername, err)
+	}
+}
+
 func AcceptDispute(repo *repository.Repository, log log.Logger, sender services.MessageSender,
 	txMonitor services.TransactionMonitor,
 ) gin.HandlerFunc {
@@ -329,12 +557,13 @@ func acceptDispute(log log.Logger, acceptor DisputeAcceptor) gin.HandlerFunc {
 			return
 		}
 
-		if err := acceptor.AcceptDispute(c, disputeID, actorUsername, boc); err != nil {
+		op, err := acceptor.AcceptDispute(c, disputeID, actorUsername, boc)
+		if err != nil {
 			handleApiError(c, log, actorUsername, err)
 			return
 		}
 
-		c.Status(http.StatusNoContent)
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
 	}
 }
 
@@ -399,12 +628,13 @@ func refundDispute(log log.Logger, claimer DisputeClaimer) gin.HandlerFunc {
 			return
 		}
 
-		if err := claimer.ClaimDispute(c, disputeID, actorUsername, boc); err != nil {
+		op, err := claimer.ClaimDispute(c, disputeID, actorUsername, boc)
+		if err != nil {
 			handleApiError(c, log, actorUsername, err)
 			return
 		}
 
-		c.Status(http.StatusNoContent)
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
 	}
 }
 
@@ -440,12 +670,13 @@ func voteDispute(log log.Logger, voter DisputeVoter) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 			return
 		}
-		if err := voter.VoteDispute(c, disputeID, actorUsername, body.Vote, body.Boc); err != nil {
+		op, err := voter.VoteDispute(c, disputeID, actorUsername, body.Vote, body.Boc)
+		if err != nil {
 			handleApiError(c, log, actorUsername, err)
 			return
 		}
 
-		c.Status(http.StatusNoContent)
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
 	}
 }
 
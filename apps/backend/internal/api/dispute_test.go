@@ -44,14 +44,17 @@ type fakeDisputeCreator struct {
 	creator string
 	boc     string
 	req     models.CreateDisputeReq
+	opID    uuid.UUID
 }
 
-func (f *fakeDisputeCreator) CreateDispute(_ context.Context, req models.CreateDisputeReq, actorUsername string) error {
+func (f *fakeDisputeCreator) CreateDispute(_ context.Context, req models.CreateDisputeReq, actorUsername string,
+) (models.PendingOperation, error) {
 	f.called = true
 	f.creator = actorUsername
 	f.boc = req.Boc
 	f.req = req
-	return f.err
+	return models.PendingOperation{ID: f.opID, Action: models.OperationActionCreateDispute,
+		Status: models.OperationStatusPending}, f.err
 }
 
 type fakeDisputePrechecker struct {
@@ -116,6 +119,30 @@ func (f *fakeDisputeGetter) GetDisputeForEvidence(_ context.Context, disputeID s
 	return f.disputeRaw, nil
 }
 
+type fakeOpenDisputeGetter struct {
+	err      error
+	dispute  models.OpenDisputeCard
+	calledID string
+}
+
+func (f *fakeOpenDisputeGetter) GetOpenDispute(_ context.Context, disputeID string) (models.OpenDisputeCard, error) {
+	f.calledID = disputeID
+	return f.dispute, f.err
+}
+
+type fakeDisputeHistoryGetter struct {
+	err      error
+	events   []models.DisputeEventCard
+	calledID string
+	actor    string
+}
+
+func (f *fakeDisputeHistoryGetter) GetDisputeHistory(_ context.Context, disputeID string, actorUsername string,
+) ([]models.DisputeEventCard, error) {
+	f.calledID, f.actor = disputeID, actorUsername
+	return f.events, f.err
+}
+
 type fakeDisputeVoter struct {
 	err      error
 	called   bool
@@ -126,18 +153,18 @@ type fakeDisputeVoter struct {
 }
 
 func (f *fakeDisputeVoter) VoteDispute(_ context.Context, disputeID string, username string, win bool, boc string,
-) error {
+) (models.PendingOperation, error) {
 	f.called = true
 	f.id = disputeID
 	f.username = username
 	f.vote = win
 	f.boc = boc
-	return f.err
+	return models.PendingOperation{Action: models.OperationActionVoteDispute, Status: models.OperationStatusPending}, f.err
 }
 
 func TestCreateDispute(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		creator := &fakeDisputeCreator{}
+		creator := &fakeDisputeCreator{opID: uuid.New()}
 		r := gin.New()
 		r.Use(func(c *gin.Context) {
 			c.Set("username", "alice")
@@ -159,8 +186,8 @@ func TestCreateDispute(t *testing.T) {
 		rr := httptest.NewRecorder()
 		r.ServeHTTP(rr, req)
 
-		if rr.Code != http.StatusCreated {
-			t.Fatalf("expected %d, got %d", http.StatusCreated, rr.Code)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
 		}
 		if !creator.called {
 			t.Fatal("expected CreateDispute to be called")
@@ -177,6 +204,10 @@ func TestCreateDispute(t *testing.T) {
 		if creator.boc == "" {
 			t.Fatal("expected boc to be passed")
 		}
+		if !strings.Contains(rr.Body.String(), creator.opID.String()) ||
+			!strings.Contains(rr.Body.String(), `"status":"pending"`) {
+			t.Fatalf("expected pending operation in body, got %s", rr.Body.String())
+		}
 	})
 
 	t.Run("invalid past endsAt returns bad request", func(t *testing.T) {
@@ -237,6 +268,34 @@ func TestCreateDispute(t *testing.T) {
 			t.Fatalf("expected %d, got %d", http.StatusConflict, rr.Code)
 		}
 	})
+
+	t.Run("maps reused transaction to conflict", func(t *testing.T) {
+		creator := &fakeDisputeCreator{err: fmt.Errorf("%w: hash", services.ErrIdempotencyConflict)}
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.POST("/disputes", createDispute(noopLogger{}, creator))
+
+		form := url.Values{}
+		form.Set("title", "test")
+		form.Set("description", "desc")
+		form.Set("opponent", "bob")
+		form.Set("amountNano", "100000000000")
+		form.Set("depositNano", "20000000000")
+		form.Set("endsAt", time.Now().Add(48*time.Hour).UTC().Format(time.RFC3339))
+		form.Set("contractAddress", "addr")
+		form.Set("boc", "te6cckEBAQEAAgAAAA==")
+
+		req := newMultipartRequest(t, http.MethodPost, "/disputes", form)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("expected %d, got %d", http.StatusConflict, rr.Code)
+		}
+	})
 }
 
 func TestPrecheckDispute(t *testing.T) {
@@ -403,6 +462,138 @@ func TestGetDispute(t *testing.T) {
 	})
 }
 
+func TestGetOpenDispute(t *testing.T) {
+	newRouter := func(getter OpenDisputeGetter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "carol")
+			c.Next()
+		})
+		r.GET("/disputes/open/:id", getOpenDispute(noopLogger{}, getter))
+		return r
+	}
+
+	t.Run("returns open dispute", func(t *testing.T) {
+		disputeID := uuid.NewString()
+		getter := &fakeOpenDisputeGetter{dispute: models.OpenDisputeCard{ID: disputeID, Creator: "alice"}}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/disputes/open/"+disputeID, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+		}
+		if getter.calledID != disputeID {
+			t.Fatalf("expected id %s, got %s", disputeID, getter.calledID)
+		}
+		if !strings.Contains(rr.Body.String(), `"creator":"alice"`) {
+			t.Fatalf("expected creator in body, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("returns not found once accepted", func(t *testing.T) {
+		getter := &fakeOpenDisputeGetter{err: services.ErrOpenDisputeNotFound}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/disputes/open/"+uuid.NewString(), nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestGetDisputeHistory(t *testing.T) {
+	newRouter := func(getter DisputeHistoryGetter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.GET("/disputes/:id/history", getDisputeHistory(noopLogger{}, getter))
+		return r
+	}
+
+	t.Run("returns events", func(t *testing.T) {
+		disputeID := uuid.NewString()
+		getter := &fakeDisputeHistoryGetter{events: []models.DisputeEventCard{
+			{Action: models.DisputeActionCreate, Actor: models.ActorSelf, ToResult: new(models.DisputesResultSent)},
+		}}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/disputes/"+disputeID+"/history", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+		}
+		if getter.calledID != disputeID || getter.actor != "alice" {
+			t.Fatalf("unexpected call args: %+v", getter)
+		}
+		if !strings.Contains(rr.Body.String(), `"action":"create"`) {
+			t.Fatalf("expected create event in body, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("returns not found to outsiders", func(t *testing.T) {
+		getter := &fakeDisputeHistoryGetter{err: services.ErrDisputeNotFound}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/disputes/"+uuid.NewString()+"/history", nil)
+		newRouter(getter).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+type fakeTransparencyReportGetter struct {
+	report models.TransparencyReport
+	err    error
+}
+
+func (f *fakeTransparencyReportGetter) GetTransparencyReport(context.Context, string, string,
+) (models.TransparencyReport, error) {
+	return f.report, f.err
+}
+
+func TestGetTransparencyReport(t *testing.T) {
+	newRouter := func(getter TransparencyReportGetter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.GET("/disputes/:id/transparency-report", getTransparencyReport(noopLogger{}, getter))
+		return r
+	}
+
+	t.Run("returns the report", func(t *testing.T) {
+		getter := &fakeTransparencyReportGetter{report: models.TransparencyReport{Total: 1, P1: 1,
+			Votes: []models.TransparencyVote{{ID: uuid.New(), Vote: "p1", Rationale: new("clear photo")}}}}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/disputes/"+uuid.NewString()+"/transparency-report", nil)
+		newRouter(getter).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"rationale":"clear photo"`) {
+			t.Fatalf("expected %d with the rationale, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+	})
+
+	for _, err := range []error{services.ErrDisputeNotFound, services.ErrReportNotFound} {
+		t.Run("returns not found for "+err.Error(), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/disputes/"+uuid.NewString()+"/transparency-report", nil)
+			newRouter(&fakeTransparencyReportGetter{err: err}).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusNotFound {
+				t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+			}
+		})
+	}
+}
+
 func TestVoteDispute(t *testing.T) {
 	t.Run("passes default boc when missing in body", func(t *testing.T) {
 		voter := &fakeDisputeVoter{}
@@ -418,8 +609,8 @@ func TestVoteDispute(t *testing.T) {
 		rr := httptest.NewRecorder()
 		r.ServeHTTP(rr, req)
 
-		if rr.Code != http.StatusNoContent {
-			t.Fatalf("expected %d, got %d", http.StatusNoContent, rr.Code)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
 		}
 		if !voter.called {
 			t.Fatal("voter should be called")
@@ -441,8 +632,8 @@ func TestVoteDispute(t *testing.T) {
 		rr := httptest.NewRecorder()
 		r.ServeHTTP(rr, req)
 
-		if rr.Code != http.StatusNoContent {
-			t.Fatalf("expected %d, got %d", http.StatusNoContent, rr.Code)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
 		}
 		if !voter.called {
 			t.Fatal("expected WinDispute to be called")
@@ -454,4 +645,28 @@ func TestVoteDispute(t *testing.T) {
 			t.Fatalf("unexpected vote payload: vote=%t boc=%q", voter.vote, voter.boc)
 		}
 	})
+
+	t.Run("maps message mismatch to bad request", func(t *testing.T) {
+		voter := &fakeDisputeVoter{
+			err: &services.MessageMismatchError{Field: "result", Expected: "1", Actual: "0"},
+		}
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.POST("/disputes/:id/vote", voteDispute(noopLogger{}, voter))
+
+		req := httptest.NewRequest(http.MethodPost, "/disputes/123/vote", strings.NewReader(`{"vote":true,"boc":"boc"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "message result mismatch") {
+			t.Fatalf("expected mismatch in body, got %s", rr.Body.String())
+		}
+	})
 }
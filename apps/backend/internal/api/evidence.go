@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,21 +14,29 @@ import (
 )
 
 type DisputeEvidencer interface {
-	ProvideEvidence(ctx context.Context, evidence models.EvidenceOpts) error
+	ProvideEvidence(ctx context.Context, evidence models.EvidenceOpts) (models.PendingOperation, error)
 }
 
 type EvidenceGetter interface {
-	GetEvidences(ctx context.Context, disputeID string) ([]models.Evidence, error)
+	GetEvidences(ctx context.Context, disputeID, actorUsername string) ([]models.Evidence, error)
+}
+
+type EvidenceVerifier interface {
+	VerifyEvidence(ctx context.Context, evidenceID, actorUsername string) (models.EvidenceVerification, error)
 }
 
 func ProvideEvidence(repo *repository.Repository, log log.Logger, sender services.MessageSender,
-	txMonitor services.TransactionMonitor,
+	txMonitor services.TransactionMonitor, blobs services.BlobStore,
 ) gin.HandlerFunc {
 	disputeSrv, err := services.NewEvidenceService(repo, log, sender)
 	if err != nil {
 		log.Fatal("failed to create dispute service", zap.Error(err))
 	}
-	disputeSrv = disputeSrv.WithTransactionMonitor(txMonitor)
+	mediaSrv, err := services.NewMediaService(repo, log, blobs)
+	if err != nil {
+		log.Fatal("failed to create media service", zap.Error(err))
+	}
+	disputeSrv = disputeSrv.WithTransactionMonitor(txMonitor).WithMediaStore(mediaSrv)
 	log = log.With(zap.String("handler", "ProvideEvidence"))
 	return provideEvidence(log, disputeSrv)
 }
@@ -44,6 +53,10 @@ func provideEvidence(log log.Logger, evidencer DisputeEvidencer) gin.HandlerFunc
 			c.JSON(http.StatusBadRequest, gin.H{"error": "dispute ID is required"})
 			return
 		}
+		if err := parseUpload(c, maxEvidenceUploadSize); err != nil {
+			handleUploadError(c, log, err, maxEvidenceUploadSize)
+			return
+		}
 		description := c.PostForm("description")
 		if description == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "description is required"})
@@ -54,10 +67,8 @@ func provideEvidence(log log.Logger, evidencer DisputeEvidencer) gin.HandlerFunc
 			c.JSON(http.StatusBadRequest, gin.H{"error": "boc is required"})
 			return
 		}
-		data, extension, err := getFile(c, "evidence")
-		if err != nil {
-			log.Error("failed to get file", zap.Error(err))
-			c.JSON(500, gin.H{"error": "cannot open uploaded file"})
+		uploads, ok := getAttachmentUploads(c, log)
+		if !ok {
 			return
 		}
 
@@ -66,17 +77,50 @@ func provideEvidence(log log.Logger, evidencer DisputeEvidencer) gin.HandlerFunc
 			Username:    actorUsername,
 			Boc:         boc,
 			Description: description,
-			ImageData:   data,
-			ImageType:   extension,
+			Uploads:     uploads,
 		}
 
-		if err := evidencer.ProvideEvidence(c, req); err != nil {
+		op, err := evidencer.ProvideEvidence(c, req)
+		if err != nil {
 			handleApiError(c, log, actorUsername, err)
 			return
 		}
 
-		c.Status(http.StatusNoContent)
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
+	}
+}
+
+// getAttachmentUploads reads the files sent in the attachments field, in order, with the caption sent at the same
+// index of the captions field. A file in the evidence field, sent by clients predating attachments, comes first.
+func getAttachmentUploads(c *gin.Context, log log.Logger) ([]models.AttachmentUpload, bool) {
+	legacy, err := getUpload(c, "evidence", models.MaxAttachmentSize)
+	if err != nil {
+		handleUploadError(c, log, err, models.MaxAttachmentSize)
+		return nil, false
+	}
+	files, err := getUploads(c, "attachments", models.MaxAttachmentSize)
+	if err != nil {
+		handleUploadError(c, log, err, models.MaxAttachmentSize)
+		return nil, false
+	}
+	captions := c.PostFormArray("captions")
+	if len(captions) > len(files) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "every caption must belong to an attachment"})
+		return nil, false
 	}
+
+	var uploads []models.AttachmentUpload
+	if legacy != nil {
+		uploads = append(uploads, models.AttachmentUpload{Data: legacy})
+	}
+	for i, data := range files {
+		upload := models.AttachmentUpload{Data: data}
+		if i < len(captions) {
+			upload.Caption = captions[i]
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, true
 }
 
 func GetEvidencesByDispute(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
@@ -90,17 +134,59 @@ func GetEvidencesByDispute(repo *repository.Repository, log log.Logger, sender s
 
 func getEvidencesByDispute(log log.Logger, getter EvidenceGetter) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
 		disputeID := c.Query("disputeID")
 		if disputeID == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "dispute ID is required"})
 			return
 		}
 
-		evidences, err := getter.GetEvidences(c, disputeID)
-		if err != nil {
-			handleApiError(c, log, "", err)
+		evidences, err := getter.GetEvidences(c, disputeID, actorUsername)
+		switch {
+		case errors.Is(err, services.ErrDisputeNotFound):
+			log.Error("dispute not found", zap.String("disputeID", disputeID), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispute not found"})
+			return
+		case err != nil:
+			handleApiError(c, log.With(zap.String("disputeID", disputeID)), actorUsername, err)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"data": evidences})
 	}
 }
+
+func VerifyEvidence(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	evidenceSrv, err := services.NewEvidenceService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create evidence service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "VerifyEvidence"))
+	return verifyEvidence(log, evidenceSrv)
+}
+
+func verifyEvidence(log log.Logger, verifier EvidenceVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		evidenceID := c.Param("id")
+		verification, err := verifier.VerifyEvidence(c, evidenceID, actorUsername)
+		switch {
+		case errors.Is(err, services.ErrEvidenceNotFound):
+			log.Error("evidence not found", zap.String("evidenceID", evidenceID), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "evidence not found"})
+			return
+		case err != nil:
+			handleApiError(c, log.With(zap.String("evidenceID", evidenceID)), actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": verification})
+	}
+}
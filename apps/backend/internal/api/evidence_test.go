@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
 )
 
 type fakeEvidencer struct {
@@ -20,20 +21,22 @@ type fakeEvidencer struct {
 	opts   models.EvidenceOpts
 }
 
-func (f *fakeEvidencer) ProvideEvidence(_ context.Context, evidence models.EvidenceOpts) error {
+func (f *fakeEvidencer) ProvideEvidence(_ context.Context, evidence models.EvidenceOpts) (models.PendingOperation, error) {
 	f.called = true
 	f.opts = evidence
-	return f.err
+	return models.PendingOperation{Action: models.OperationActionProvideEvidence, Status: models.OperationStatusPending}, f.err
 }
 
 type fakeEvidenceGetter struct {
 	err      error
 	calledID string
+	actor    string
 	items    []models.Evidence
 }
 
-func (f *fakeEvidenceGetter) GetEvidences(_ context.Context, disputeID string) ([]models.Evidence, error) {
-	f.calledID = disputeID
+func (f *fakeEvidenceGetter) GetEvidences(_ context.Context, disputeID, actorUsername string,
+) ([]models.Evidence, error) {
+	f.calledID, f.actor = disputeID, actorUsername
 	if f.err != nil {
 		return nil, f.err
 	}
@@ -79,8 +82,8 @@ func TestEvidenceDispute(t *testing.T) {
 		rr := httptest.NewRecorder()
 		r.ServeHTTP(rr, req)
 
-		if rr.Code != http.StatusNoContent {
-			t.Fatalf("expected %d, got %d", http.StatusNoContent, rr.Code)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
 		}
 		if !evidencer.called {
 			t.Fatal("expected ProvideEvidence to be called")
@@ -92,12 +95,141 @@ func TestEvidenceDispute(t *testing.T) {
 			t.Fatalf("expected description to be parsed, got %q", evidencer.opts.Description)
 		}
 	})
+
+	newUpload := func(t *testing.T, field string, files [][]byte, captions ...string) *http.Request {
+		t.Helper()
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		for name, value := range map[string]string{"description": "test evidence", "boc": "boc"} {
+			if err := w.WriteField(name, value); err != nil {
+				t.Fatalf("write field: %v", err)
+			}
+		}
+		for _, caption := range captions {
+			if err := w.WriteField("captions", caption); err != nil {
+				t.Fatalf("write field: %v", err)
+			}
+		}
+		for _, file := range files {
+			part, err := w.CreateFormFile(field, "photo.png")
+			if err != nil {
+				t.Fatalf("create file: %v", err)
+			}
+			if _, err = part.Write(file); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("close multipart: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/disputes/123/evidence", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+	newRouter := func(evidencer DisputeEvidencer) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.POST("/disputes/:id/evidence", provideEvidence(noopLogger{}, evidencer))
+		return r
+	}
+
+	t.Run("passes the legacy evidence file as the first attachment", func(t *testing.T) {
+		evidencer := &fakeEvidencer{}
+		rr := httptest.NewRecorder()
+		newRouter(evidencer).ServeHTTP(rr, newUpload(t, "evidence", [][]byte{[]byte("png")}))
+
+		if rr.Code != http.StatusAccepted || len(evidencer.opts.Uploads) != 1 ||
+			string(evidencer.opts.Uploads[0].Data) != "png" || evidencer.opts.ImageData != nil {
+			t.Fatalf("expected the file passed, got %d: %#v", rr.Code, evidencer.opts)
+		}
+	})
+
+	t.Run("passes attachments in order with their captions", func(t *testing.T) {
+		evidencer := &fakeEvidencer{}
+		rr := httptest.NewRecorder()
+		files := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+		newRouter(evidencer).ServeHTTP(rr, newUpload(t, "attachments", files, "chat", "receipt"))
+
+		uploads := evidencer.opts.Uploads
+		if rr.Code != http.StatusAccepted || len(uploads) != 3 {
+			t.Fatalf("expected 3 uploads, got %d: %#v", rr.Code, uploads)
+		}
+		for i, want := range []models.AttachmentUpload{
+			{Data: []byte("first"), Caption: "chat"},
+			{Data: []byte("second"), Caption: "receipt"},
+			{Data: []byte("third")},
+		} {
+			if string(uploads[i].Data) != string(want.Data) || uploads[i].Caption != want.Caption {
+				t.Fatalf("upload %d: expected %q %q, got %q %q", i, want.Data, want.Caption, uploads[i].Data,
+					uploads[i].Caption)
+			}
+		}
+	})
+
+	t.Run("rejects captions without attachments", func(t *testing.T) {
+		evidencer := &fakeEvidencer{}
+		rr := httptest.NewRecorder()
+		newRouter(evidencer).ServeHTTP(rr, newUpload(t, "attachments", [][]byte{[]byte("file")}, "one", "two"))
+
+		if rr.Code != http.StatusBadRequest || evidencer.called {
+			t.Fatalf("expected %d without calling the service, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("rejects files over the limit", func(t *testing.T) {
+		evidencer := &fakeEvidencer{}
+		rr := httptest.NewRecorder()
+		files := [][]byte{make([]byte, models.MaxAttachmentSize+1)}
+		newRouter(evidencer).ServeHTTP(rr, newUpload(t, "attachments", files))
+
+		if rr.Code != http.StatusRequestEntityTooLarge || evidencer.called {
+			t.Fatalf("expected %d without calling the service, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+		}
+	})
+
+	t.Run("rejects requests over the total limit while reading", func(t *testing.T) {
+		evidencer := &fakeEvidencer{}
+		rr := httptest.NewRecorder()
+		files := [][]byte{make([]byte, maxEvidenceUploadSize/2), make([]byte, maxEvidenceUploadSize/2+maxFormOverhead)}
+		newRouter(evidencer).ServeHTTP(rr, newUpload(t, "attachments", files))
+
+		if rr.Code != http.StatusRequestEntityTooLarge || evidencer.called {
+			t.Fatalf("expected %d without calling the service, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+		}
+	})
+
+	for err, code := range map[error]int{
+		services.ErrUnsupportedMedia: http.StatusUnsupportedMediaType,
+		services.ErrMalformedMedia:   http.StatusBadRequest,
+		services.ErrMediaTooLarge:    http.StatusRequestEntityTooLarge,
+	} {
+		t.Run("maps "+err.Error(), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			newRouter(&fakeEvidencer{err: err}).ServeHTTP(rr, newUpload(t, "attachments", [][]byte{[]byte("file")}))
+
+			if rr.Code != code {
+				t.Fatalf("expected %d, got %d: %s", code, rr.Code, rr.Body.String())
+			}
+		})
+	}
 }
 
 func TestGetEvidencesByDispute(t *testing.T) {
-	t.Run("returns bad request when disputeID missing", func(t *testing.T) {
+	newRouter := func(getter EvidenceGetter) *gin.Engine {
 		r := gin.New()
-		r.GET("/evidences", getEvidencesByDispute(noopLogger{}, &fakeEvidenceGetter{}))
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.GET("/evidences", getEvidencesByDispute(noopLogger{}, getter))
+		return r
+	}
+
+	t.Run("returns bad request when disputeID missing", func(t *testing.T) {
+		r := newRouter(&fakeEvidenceGetter{})
 
 		req := httptest.NewRequest(http.MethodGet, "/evidences", nil)
 		rr := httptest.NewRecorder()
@@ -110,8 +242,7 @@ func TestGetEvidencesByDispute(t *testing.T) {
 
 	t.Run("returns internal error on service failure", func(t *testing.T) {
 		getter := &fakeEvidenceGetter{err: errors.New("boom")}
-		r := gin.New()
-		r.GET("/evidences", getEvidencesByDispute(noopLogger{}, getter))
+		r := newRouter(getter)
 
 		req := httptest.NewRequest(http.MethodGet, "/evidences?disputeID=123", nil)
 		rr := httptest.NewRecorder()
@@ -124,8 +255,7 @@ func TestGetEvidencesByDispute(t *testing.T) {
 
 	t.Run("returns evidences on success", func(t *testing.T) {
 		getter := &fakeEvidenceGetter{items: []models.Evidence{{ID: uuid.New()}}}
-		r := gin.New()
-		r.GET("/evidences", getEvidencesByDispute(noopLogger{}, getter))
+		r := newRouter(getter)
 
 		req := httptest.NewRequest(http.MethodGet, "/evidences?disputeID=123", nil)
 		rr := httptest.NewRecorder()
@@ -134,8 +264,70 @@ func TestGetEvidencesByDispute(t *testing.T) {
 		if rr.Code != http.StatusOK {
 			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
 		}
-		if getter.calledID != "123" {
-			t.Fatalf("expected dispute id 123, got %q", getter.calledID)
+		if getter.calledID != "123" || getter.actor != "alice" {
+			t.Fatalf("expected dispute id 123 for alice, got %q for %q", getter.calledID, getter.actor)
 		}
 	})
+
+	t.Run("returns not found to outsiders", func(t *testing.T) {
+		r := newRouter(&fakeEvidenceGetter{err: services.ErrDisputeNotFound})
+
+		req := httptest.NewRequest(http.MethodGet, "/evidences?disputeID=123", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("returns unauthorized without user", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/evidences", getEvidencesByDispute(noopLogger{}, &fakeEvidenceGetter{}))
+
+		req := httptest.NewRequest(http.MethodGet, "/evidences?disputeID=123", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+}
+
+type fakeEvidenceVerifier struct {
+	err   error
+	actor string
+}
+
+func (f *fakeEvidenceVerifier) VerifyEvidence(_ context.Context, evidenceID, actorUsername string,
+) (models.EvidenceVerification, error) {
+	f.actor = actorUsername
+	return models.EvidenceVerification{Verified: true}, f.err
+}
+
+func TestVerifyEvidence(t *testing.T) {
+	newRouter := func(verifier EvidenceVerifier) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "juror")
+			c.Next()
+		})
+		r.GET("/evidence/:id/verification", verifyEvidence(noopLogger{}, verifier))
+		return r
+	}
+
+	for err, code := range map[error]int{
+		nil:                          http.StatusOK,
+		services.ErrEvidenceNotFound: http.StatusNotFound,
+		services.ErrValidation:       http.StatusBadRequest,
+	} {
+		verifier := &fakeEvidenceVerifier{err: err}
+		rr := httptest.NewRecorder()
+		newRouter(verifier).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/evidence/123/verification", nil))
+
+		if rr.Code != code || verifier.actor != "juror" {
+			t.Fatalf("%v: expected %d for the actor, got %d: %s", err, code, rr.Code, rr.Body.String())
+		}
+	}
 }
@@ -2,7 +2,9 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -27,28 +29,93 @@ func getActorUsername(c *gin.Context) (string, bool) {
 	return actorUsername, true
 }
 
-func getFile(c *gin.Context, name string) ([]byte, string, error) {
+const (
+	maxDisputeImageSize = 5 << 20
+	// maxEvidenceUploadSize bounds all files sent with one evidence submission together; each file is further
+	// limited by its kind.
+	maxEvidenceUploadSize = 100 << 20
+	// maxFormOverhead leaves room for the other form fields sent with a file.
+	maxFormOverhead = 64 << 10
+)
+
+var errUploadTooLarge = errors.New("upload is too large")
+
+// parseUpload parses the multipart form of a request uploading files of at most maxSize bytes in total. Bigger requests
+// are cut off while being read instead of being buffered in full. Requests without a multipart form are left as is.
+func parseUpload(c *gin.Context, maxSize int64) error {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+maxFormOverhead)
+	err := c.Request.ParseMultipartForm(maxSize + maxFormOverhead)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return errUploadTooLarge
+	case err != nil && !errors.Is(err, http.ErrNotMultipart):
+		return err
+	}
+	return nil
+}
+
+// getUpload returns the file uploaded in the form field, or nil without one. The file type isn't taken from the
+// client; the media service sniffs it from the content.
+func getUpload(c *gin.Context, name string, maxSize int64) ([]byte, error) {
 	fileHeader, err := c.FormFile(name)
 	switch {
-	case errors.Is(err, http.ErrMissingFile):
-		return nil, "", nil
+	case errors.Is(err, http.ErrMissingFile), errors.Is(err, http.ErrNotMultipart):
+		return nil, nil
 	case err != nil:
-		return nil, "", err
+		return nil, err
+	}
+	return readUpload(fileHeader, maxSize)
+}
+
+// getUploads returns the files uploaded in the form field, in the order they were sent.
+func getUploads(c *gin.Context, name string, maxSize int64) ([][]byte, error) {
+	if c.Request.MultipartForm == nil {
+		return nil, nil
+	}
+	fileHeaders := c.Request.MultipartForm.File[name]
+	files := make([][]byte, 0, len(fileHeaders))
+	for _, fileHeader := range fileHeaders {
+		data, err := readUpload(fileHeader, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, data)
+	}
+	return files, nil
+}
+
+func readUpload(fileHeader *multipart.FileHeader, maxSize int64) ([]byte, error) {
+	if fileHeader.Size > maxSize {
+		return nil, errUploadTooLarge
 	}
 
 	file, err := fileHeader.Open()
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	defer file.Close()
 
-	data, err := io.ReadAll(file)
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errUploadTooLarge
 	}
-	extension := fileHeader.Header.Get("Content-Type")
+	return data, nil
+}
 
-	return data, extension, nil
+// handleUploadError answers a request whose upload couldn't be read.
+func handleUploadError(c *gin.Context, log log.Logger, err error, maxSize int64) {
+	if errors.Is(err, errUploadTooLarge) {
+		log.Error("upload is too large", zap.Error(err))
+		c.JSON(http.StatusRequestEntityTooLarge,
+			gin.H{"error": fmt.Sprintf("upload must be at most %d MB", maxSize>>20)})
+		return
+	}
+	log.Error("failed to get file", zap.Error(err))
+	c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read uploaded file"})
 }
 
 func handleApiError(c *gin.Context, logger log.Logger, actor string, err error) {
@@ -62,7 +129,12 @@ func handleApiError(c *gin.Context, logger log.Logger, actor string, err error)
 }
 
 func handleTxServiceError(c *gin.Context, log log.Logger, err error) bool {
+	var mismatch *services.MessageMismatchError
 	switch {
+	case errors.As(err, &mismatch):
+		log.Error("transaction does not match operation")
+		c.JSON(http.StatusBadRequest, gin.H{"error": mismatch.Error()})
+		return true
 	case errors.Is(err, services.ErrInvalidBOC):
 		log.Error("invalid transaction boc")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction boc"})
@@ -75,6 +147,10 @@ func handleTxServiceError(c *gin.Context, log log.Logger, err error) bool {
 		log.Error("transaction failed")
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return true
+	case errors.Is(err, services.ErrIdempotencyConflict):
+		log.Error("transaction reused for another operation")
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction already used for another operation"})
+		return true
 	case errors.Is(err, services.ErrTxMonitorUnavailable):
 		log.Error("transaction monitor unavailable")
 		c.JSON(http.StatusBadGateway, gin.H{"error": "transaction monitor unavailable"})
@@ -90,6 +166,23 @@ func handleValidationError(c *gin.Context, log log.Logger, err error) bool {
 		log.Error("failed to validate dispute")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed"})
 		return true
+	case errors.Is(err, services.ErrDisputeTaken):
+		log.Error("dispute already accepted")
+		c.JSON(http.StatusConflict, gin.H{"error": "dispute already accepted by another user"})
+		return true
+	case errors.Is(err, services.ErrUnsupportedMedia):
+		log.Error("unsupported media type")
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported file type, images must be JPEG, PNG " +
+			"or GIF; evidence may also attach MP4 or WebM videos, PDFs and plain text"})
+		return true
+	case errors.Is(err, services.ErrMalformedMedia):
+		log.Error("malformed media")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is malformed or truncated"})
+		return true
+	case errors.Is(err, services.ErrMediaTooLarge):
+		log.Error("media is too large")
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file or image dimensions are too large for its type"})
+		return true
 	default:
 		return false
 	}
@@ -24,7 +24,16 @@ type InvestigationGetter interface {
 }
 
 type InvestigationVoter interface {
-	VoteInvestigation(ctx context.Context, id, username, vote, boc string) error
+	VoteInvestigation(ctx context.Context, id, username, vote, salt, rationale, boc string,
+	) (models.PendingOperation, error)
+}
+
+type InvestigationCommitter interface {
+	CommitVote(ctx context.Context, id, username, commitment string) error
+}
+
+type InvestigationRecuser interface {
+	RecuseJuror(ctx context.Context, id, username, reason string) error
 }
 
 type InvestigationSeener interface {
@@ -138,6 +147,25 @@ func VoteInvestigation(repo *repository.Repository, log log.Logger, sender servi
 	return voteInvestigations(log, investigationSrv)
 }
 
+func CommitInvestigationVote(repo *repository.Repository, log log.Logger, sender services.MessageSender,
+) gin.HandlerFunc {
+	investigationSrv, err := services.NewInvestigationService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create investigation service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "CommitInvestigationVote"))
+	return commitInvestigationVote(log, investigationSrv)
+}
+
+func RecuseInvestigation(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	investigationSrv, err := services.NewInvestigationService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create investigation service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "RecuseInvestigation"))
+	return recuseInvestigation(log, investigationSrv)
+}
+
 func MarkInvestigationsSeen(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
 	investigationSrv, err := services.NewInvestigationService(repo, log, sender)
 	if err != nil {
@@ -170,13 +198,74 @@ func voteInvestigations(log log.Logger, voter InvestigationVoter) gin.HandlerFun
 			c.JSON(http.StatusBadRequest, gin.H{"error": "boc is required"})
 			return
 		}
+		// The salt is only needed to reveal a vote committed in a commit-reveal investigation.
+		salt := c.Query("salt")
+		// The rationale explains the vote to the parties; the verdict policy may require it.
+		rationale := c.Query("rationale")
 
-		err := voter.VoteInvestigation(c, invID, actorUsername, vote, boc)
+		op, err := voter.VoteInvestigation(c, invID, actorUsername, vote, salt, rationale, boc)
 		if err != nil {
 			handleApiError(c, log, actorUsername, err)
 			return
 		}
 
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
+	}
+}
+
+func commitInvestigationVote(log log.Logger, committer InvestigationCommitter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		invID := c.Param("id")
+		if invID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "investigation ID is required"})
+			return
+		}
+		commitment := c.Query("commitment")
+		if commitment == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "commitment is required"})
+			return
+		}
+
+		if err := committer.CommitVote(c, invID, actorUsername, commitment); err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func recuseInvestigation(log log.Logger, recuser InvestigationRecuser) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		invID := c.Param("id")
+		if invID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "investigation ID is required"})
+			return
+		}
+		var req struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		if err := recuser.RecuseJuror(c, invID, actorUsername, req.Reason); err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
 		c.Status(http.StatusNoContent)
 	}
 }
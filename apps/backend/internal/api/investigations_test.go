@@ -5,12 +5,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
 )
 
 type fakeInvestigationLister struct {
@@ -47,17 +49,22 @@ func (f *fakeInvestigationGetter) GetInvestigation(_ context.Context, id, userna
 }
 
 type fakeInvestigationVoter struct {
-	err      error
-	id       string
-	username string
-	vote     string
+	err       error
+	id        string
+	username  string
+	vote      string
+	salt      string
+	rationale string
 }
 
-func (f *fakeInvestigationVoter) VoteInvestigation(_ context.Context, id, username, vote, _ string) error {
+func (f *fakeInvestigationVoter) VoteInvestigation(_ context.Context, id, username, vote, salt, rationale, _ string,
+) (models.PendingOperation, error) {
 	f.id = id
 	f.username = username
 	f.vote = vote
-	return f.err
+	f.salt = salt
+	f.rationale = rationale
+	return models.PendingOperation{Action: models.OperationActionVoteInvestigation, Status: models.OperationStatusPending}, f.err
 }
 
 func TestListInvestigations(t *testing.T) {
@@ -131,14 +138,119 @@ func TestVoteInvestigations(t *testing.T) {
 	})
 	r.POST("/investigations/:id/vote", voteInvestigations(noopLogger{}, voter))
 
-	req := httptest.NewRequest(http.MethodPost, "/investigations/123/vote?vote=p1&boc=te6cckEBAQEAAgAAAA==", nil)
+	req := httptest.NewRequest(http.MethodPost,
+		"/investigations/123/vote?vote=p1&salt=s&rationale=clear+photo&boc=te6cckEBAQEAAgAAAA==", nil)
 	rr := httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	if rr.Code != http.StatusNoContent {
-		t.Fatalf("expected %d, got %d", http.StatusNoContent, rr.Code)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
+	}
+	if voter.id != "123" || voter.username != "alice" || voter.vote != "p1" || voter.salt != "s" ||
+		voter.rationale != "clear photo" {
+		t.Fatalf("unexpected call args: id=%q user=%q vote=%q salt=%q", voter.id, voter.username, voter.vote,
+			voter.salt)
 	}
-	if voter.id != "123" || voter.username != "alice" || voter.vote != "p1" {
-		t.Fatalf("unexpected call args: id=%q user=%q vote=%q", voter.id, voter.username, voter.vote)
+}
+
+type fakeInvestigationCommitter struct {
+	err        error
+	commitment string
+}
+
+func (f *fakeInvestigationCommitter) CommitVote(_ context.Context, _, _, commitment string) error {
+	f.commitment = commitment
+	return f.err
+}
+
+func TestCommitInvestigationVote(t *testing.T) {
+	newRouter := func(committer *fakeInvestigationCommitter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.POST("/investigations/:id/commit", commitInvestigationVote(noopLogger{}, committer))
+		return r
 	}
+
+	t.Run("returns bad request without commitment", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newRouter(&fakeInvestigationCommitter{}).ServeHTTP(rr,
+			httptest.NewRequest(http.MethodPost, "/investigations/123/commit", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("returns bad request outside the commit phase", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newRouter(&fakeInvestigationCommitter{err: services.ErrValidation}).ServeHTTP(rr,
+			httptest.NewRequest(http.MethodPost, "/investigations/123/commit?commitment=ab", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("records the commitment", func(t *testing.T) {
+		committer := &fakeInvestigationCommitter{}
+		rr := httptest.NewRecorder()
+		newRouter(committer).ServeHTTP(rr,
+			httptest.NewRequest(http.MethodPost, "/investigations/123/commit?commitment=ab", nil))
+		if rr.Code != http.StatusNoContent || committer.commitment != "ab" {
+			t.Fatalf("expected %d with the commitment, got %d and %q", http.StatusNoContent, rr.Code,
+				committer.commitment)
+		}
+	})
+}
+
+type fakeInvestigationRecuser struct {
+	err    error
+	reason string
+}
+
+func (f *fakeInvestigationRecuser) RecuseJuror(_ context.Context, _, _, reason string) error {
+	f.reason = reason
+	return f.err
+}
+
+func TestRecuseInvestigation(t *testing.T) {
+	newRouter := func(recuser *fakeInvestigationRecuser) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.POST("/investigations/:id/recuse", recuseInvestigation(noopLogger{}, recuser))
+		return r
+	}
+
+	t.Run("returns bad request without reason", func(t *testing.T) {
+		recuser := &fakeInvestigationRecuser{}
+		rr := httptest.NewRecorder()
+		newRouter(recuser).ServeHTTP(rr,
+			httptest.NewRequest(http.MethodPost, "/investigations/123/recuse", strings.NewReader(`{}`)))
+		if rr.Code != http.StatusBadRequest || recuser.reason != "" {
+			t.Fatalf("expected %d without recusing, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("returns bad request after voting", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newRouter(&fakeInvestigationRecuser{err: services.ErrValidation}).ServeHTTP(rr,
+			httptest.NewRequest(http.MethodPost, "/investigations/123/recuse", strings.NewReader(`{"reason":"x"}`)))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("recuses the juror", func(t *testing.T) {
+		recuser := &fakeInvestigationRecuser{}
+		rr := httptest.NewRecorder()
+		newRouter(recuser).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/investigations/123/recuse",
+			strings.NewReader(`{"reason":"I know the creator"}`)))
+		if rr.Code != http.StatusNoContent || recuser.reason != "I know the creator" {
+			t.Fatalf("expected %d with the reason, got %d and %q", http.StatusNoContent, rr.Code, recuser.reason)
+		}
+	})
 }
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type InvitationRedeemer interface {
+	RedeemInvitation(ctx context.Context, token string, username string) error
+}
+
+func RedeemInvitation(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	invitationSrv, err := services.NewInvitationService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create invitation service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "RedeemInvitation"))
+	return redeemInvitation(log, invitationSrv)
+}
+
+func redeemInvitation(log log.Logger, redeemer InvitationRedeemer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		token := c.Param("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invite token is required"})
+			return
+		}
+
+		err := redeemer.RedeemInvitation(c, token, actorUsername)
+		switch {
+		case errors.Is(err, services.ErrInvitationNotFound):
+			log.Error("invitation not found", zap.String("actor", actorUsername), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "invitation not found or expired"})
+			return
+		case err != nil:
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
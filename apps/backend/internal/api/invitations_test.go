@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+)
+
+type fakeInvitationRedeemer struct {
+	err      error
+	token    string
+	username string
+}
+
+func (f *fakeInvitationRedeemer) RedeemInvitation(_ context.Context, token string, username string) error {
+	f.token = token
+	f.username = username
+	return f.err
+}
+
+func TestRedeemInvitation(t *testing.T) {
+	newRouter := func(redeemer InvitationRedeemer) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "bob")
+			c.Next()
+		})
+		r.POST("/invitations/:token/redeem", redeemInvitation(noopLogger{}, redeemer))
+		return r
+	}
+
+	t.Run("redeems invitation for actor", func(t *testing.T) {
+		redeemer := &fakeInvitationRedeemer{}
+
+		rr := httptest.NewRecorder()
+		newRouter(redeemer).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/invitations/abc/redeem", nil))
+
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("expected %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		if redeemer.token != "abc" || redeemer.username != "bob" {
+			t.Fatalf("unexpected call args: token=%q user=%q", redeemer.token, redeemer.username)
+		}
+	})
+
+	t.Run("returns not found for unknown or expired token", func(t *testing.T) {
+		redeemer := &fakeInvitationRedeemer{err: services.ErrInvitationNotFound}
+
+		rr := httptest.NewRecorder()
+		newRouter(redeemer).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/invitations/abc/redeem", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
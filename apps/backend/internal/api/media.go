@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type MediaGetter interface {
+	GetMedia(ctx context.Context, hash, actorUsername string) (models.Media, []byte, error)
+}
+
+func GetMedia(repo *repository.Repository, log log.Logger, blobs services.BlobStore) gin.HandlerFunc {
+	mediaSrv, err := services.NewMediaService(repo, log, blobs)
+	if err != nil {
+		log.Fatal("failed to create media service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetMedia"))
+	return getMedia(log, mediaSrv)
+}
+
+// getMedia serves media by its hash to users who may view it. The content behind a hash never changes, so clients
+// cache it for good, but only privately: whether it may be served depends on who asks. Access is checked before
+// answering a conditional request too.
+func getMedia(log log.Logger, getter MediaGetter) gin.HandlerFunc {
+	cacheControl := fmt.Sprintf("private, max-age=%d, immutable", int(models.MediaCacheMaxAge.Seconds()))
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+		hash := c.Param("hash")
+		log := log.With(zap.String("hash", hash))
+
+		media, data, err := getter.GetMedia(c, hash, actorUsername)
+		if err != nil {
+			if errors.Is(err, services.ErrMediaNotFound) {
+				log.Error("media not found", zap.String("actor", actorUsername), zap.Error(err))
+				c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+				return
+			}
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		etag := strconv.Quote(media.Hash)
+		c.Header("Cache-Control", cacheControl)
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(http.StatusOK, media.ContentType, data)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+)
+
+type fakeMediaGetter struct {
+	media models.Media
+	data  []byte
+	err   error
+	actor string
+}
+
+func (f *fakeMediaGetter) GetMedia(_ context.Context, _, actorUsername string) (models.Media, []byte, error) {
+	f.actor = actorUsername
+	return f.media, f.data, f.err
+}
+
+func TestGetMedia(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	newRouter := func(getter MediaGetter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+		})
+		r.GET("/media/:hash", getMedia(noopLogger{}, getter))
+		return r
+	}
+
+	t.Run("serves the media to the user with private cache headers", func(t *testing.T) {
+		getter := &fakeMediaGetter{media: models.Media{Hash: hash, ContentType: "image/png"}, data: []byte("png")}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/"+hash, nil))
+
+		if rr.Code != http.StatusOK || rr.Body.String() != "png" || rr.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("expected the media, got %d %q: %v", rr.Code, rr.Body.String(), rr.Header())
+		}
+		if getter.actor != "alice" {
+			t.Fatalf("expected the media loaded for alice, got %q", getter.actor)
+		}
+		if rr.Header().Get("Cache-Control") != "private, max-age=31536000, immutable" ||
+			rr.Header().Get("ETag") != `"`+hash+`"` {
+			t.Fatalf("expected cache headers, got %v", rr.Header())
+		}
+	})
+
+	t.Run("answers a matching etag without the body", func(t *testing.T) {
+		getter := &fakeMediaGetter{media: models.Media{Hash: hash, ContentType: "image/png"}, data: []byte("png")}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/media/"+hash, nil)
+		req.Header.Set("If-None-Match", `"`+hash+`"`)
+		newRouter(getter).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+			t.Fatalf("expected %d without a body, got %d %q", http.StatusNotModified, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("hides media the user may not view behind a matching etag", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/media/"+hash, nil)
+		req.Header.Set("If-None-Match", `"`+hash+`"`)
+		newRouter(&fakeMediaGetter{err: services.ErrMediaNotFound}).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/media/:hash", getMedia(noopLogger{}, &fakeMediaGetter{}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/"+hash, nil))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	for err, code := range map[error]int{
+		services.ErrMediaNotFound: http.StatusNotFound,
+		services.ErrValidation:    http.StatusBadRequest,
+	} {
+		t.Run("maps "+err.Error(), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			newRouter(&fakeMediaGetter{err: err}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/x", nil))
+
+			if rr.Code != code {
+				t.Fatalf("expected %d, got %d", code, rr.Code)
+			}
+		})
+	}
+}
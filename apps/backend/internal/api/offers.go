@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type OfferLister interface {
+	ListOffers(ctx context.Context, disputeID string, actorUsername string) ([]models.OfferCard, error)
+}
+
+type OfferProposer interface {
+	ProposeOffer(ctx context.Context, disputeID string, actorUsername string, req models.DisputeOfferReq,
+	) (models.PendingOperation, error)
+}
+
+type OfferAcceptor interface {
+	AcceptOffer(ctx context.Context, disputeID string, version int, actorUsername string, contractAddress, boc string,
+	) (models.PendingOperation, error)
+}
+
+type OfferDecliner interface {
+	DeclineOffer(ctx context.Context, disputeID string, version int, actorUsername string) error
+}
+
+func ListOffers(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	offerSrv, err := services.NewOfferService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create offer service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "ListOffers"))
+	return listOffers(log, offerSrv)
+}
+
+func listOffers(log log.Logger, lister OfferLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		offers, err := lister.ListOffers(c, c.Param("id"), actorUsername)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+		if offers == nil {
+			offers = []models.OfferCard{}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": offers})
+	}
+}
+
+func ProposeOffer(repo *repository.Repository, log log.Logger, sender services.MessageSender,
+	txMonitor services.TransactionMonitor, betMaster string,
+) gin.HandlerFunc {
+	offerSrv, err := services.NewOfferService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create offer service", zap.Error(err))
+	}
+	offerSrv = offerSrv.WithTransactionMonitor(txMonitor).WithBetMaster(betMaster)
+	log = log.With(zap.String("handler", "ProposeOffer"))
+	return proposeOffer(log, offerSrv)
+}
+
+func proposeOffer(log log.Logger, proposer OfferProposer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		var req models.DisputeOfferReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+		op, err := proposer.ProposeOffer(c, c.Param("id"), actorUsername, req)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
+	}
+}
+
+func AcceptOffer(repo *repository.Repository, log log.Logger, sender services.MessageSender,
+	txMonitor services.TransactionMonitor, betMaster string,
+) gin.HandlerFunc {
+	offerSrv, err := services.NewOfferService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create offer service", zap.Error(err))
+	}
+	offerSrv = offerSrv.WithTransactionMonitor(txMonitor).WithBetMaster(betMaster)
+	log = log.With(zap.String("handler", "AcceptOffer"))
+	return acceptOffer(log, offerSrv)
+}
+
+func acceptOffer(log log.Logger, acceptor OfferAcceptor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		version, ok := offerVersion(c)
+		if !ok {
+			return
+		}
+		// A body is only needed when the offer changes the stake and a new bet funds it.
+		var body struct {
+			ContractAddress string `json:"contractAddress"`
+			Boc             string `json:"boc"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.BindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+				return
+			}
+		}
+		op, err := acceptor.AcceptOffer(c, c.Param("id"), version, actorUsername, body.ContractAddress, body.Boc)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"data": op.Details()})
+	}
+}
+
+func DeclineOffer(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	offerSrv, err := services.NewOfferService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create offer service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "DeclineOffer"))
+	return declineOffer(log, offerSrv)
+}
+
+func declineOffer(log log.Logger, decliner OfferDecliner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		version, ok := offerVersion(c)
+		if !ok {
+			return
+		}
+		if err := decliner.DeclineOffer(c, c.Param("id"), version, actorUsername); err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func offerVersion(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offer version"})
+		return 0, false
+	}
+	return version, true
+}
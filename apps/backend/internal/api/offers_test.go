@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+)
+
+type fakeOfferService struct {
+	err             error
+	disputeID       string
+	version         int
+	username        string
+	req             models.DisputeOfferReq
+	contractAddress string
+	boc             string
+}
+
+func (f *fakeOfferService) ProposeOffer(_ context.Context, disputeID string, username string,
+	req models.DisputeOfferReq,
+) (models.PendingOperation, error) {
+	f.disputeID, f.username, f.req = disputeID, username, req
+	return models.PendingOperation{Status: models.OperationStatusSucceeded}, f.err
+}
+
+func (f *fakeOfferService) AcceptOffer(_ context.Context, disputeID string, version int, username string,
+	contractAddress, boc string,
+) (models.PendingOperation, error) {
+	f.disputeID, f.version, f.username, f.contractAddress, f.boc = disputeID, version, username, contractAddress, boc
+	return models.PendingOperation{Status: models.OperationStatusPending}, f.err
+}
+
+func (f *fakeOfferService) DeclineOffer(_ context.Context, disputeID string, version int, username string) error {
+	f.disputeID, f.version, f.username = disputeID, version, username
+	return f.err
+}
+
+func TestOfferHandlers(t *testing.T) {
+	newRouter := func(srv *fakeOfferService) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.POST("/disputes/:id/offers", proposeOffer(noopLogger{}, srv))
+		r.POST("/disputes/:id/offers/:version/accept", acceptOffer(noopLogger{}, srv))
+		r.POST("/disputes/:id/offers/:version/decline", declineOffer(noopLogger{}, srv))
+		return r
+	}
+
+	t.Run("proposes offer", func(t *testing.T) {
+		srv := &fakeOfferService{}
+		body := strings.NewReader(`{"amountNano":"200","contractAddress":"bet","boc":"boc"}`)
+
+		rr := httptest.NewRecorder()
+		newRouter(srv).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/disputes/d1/offers", body))
+
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		if srv.disputeID != "d1" || srv.username != "alice" || srv.req.AmountNano != "200" || srv.req.Boc != "boc" {
+			t.Fatalf("unexpected call args: %+v", srv)
+		}
+	})
+
+	t.Run("accepts offer without a new bet", func(t *testing.T) {
+		srv := &fakeOfferService{}
+
+		rr := httptest.NewRecorder()
+		newRouter(srv).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/disputes/d1/offers/2/accept", nil))
+
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		if srv.version != 2 || srv.boc != "" {
+			t.Fatalf("unexpected call args: %+v", srv)
+		}
+	})
+
+	t.Run("rejects bad version", func(t *testing.T) {
+		srv := &fakeOfferService{}
+
+		rr := httptest.NewRecorder()
+		newRouter(srv).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/disputes/d1/offers/x/decline", nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if srv.disputeID != "" {
+			t.Fatal("expected service not to be called")
+		}
+	})
+
+	t.Run("maps validation error on decline", func(t *testing.T) {
+		srv := &fakeOfferService{err: services.ErrValidation}
+
+		rr := httptest.NewRecorder()
+		newRouter(srv).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/disputes/d1/offers/2/decline", nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type OperationGetter interface {
+	GetOperation(ctx context.Context, operationID, actorUsername string) (models.OperationDetails, error)
+}
+
+func GetOperation(repo *repository.Repository, log log.Logger, sender services.MessageSender) gin.HandlerFunc {
+	operationSrv, err := services.NewOperationService(repo, log, sender)
+	if err != nil {
+		log.Fatal("failed to create operation service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetOperation"))
+	return getOperation(log, operationSrv)
+}
+
+func getOperation(log log.Logger, getter OperationGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		operationID := c.Param("id")
+		if operationID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "operation ID is required"})
+			return
+		}
+
+		op, err := getter.GetOperation(c, operationID, actorUsername)
+		switch {
+		case errors.Is(err, services.ErrOperationNotFound):
+			log.Error("operation not found", zap.String("operationID", operationID), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "operation not found"})
+			return
+		case err != nil:
+			handleApiError(c, log.With(zap.String("operationID", operationID)), actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": op})
+	}
+}
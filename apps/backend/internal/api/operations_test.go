@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+)
+
+type fakeOperationGetter struct {
+	err      error
+	id       string
+	username string
+	op       models.OperationDetails
+}
+
+func (f *fakeOperationGetter) GetOperation(_ context.Context, operationID, actorUsername string,
+) (models.OperationDetails, error) {
+	f.id = operationID
+	f.username = actorUsername
+	return f.op, f.err
+}
+
+func TestGetOperation(t *testing.T) {
+	newRouter := func(getter OperationGetter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", "alice")
+			c.Next()
+		})
+		r.GET("/operations/:id", getOperation(noopLogger{}, getter))
+		return r
+	}
+
+	t.Run("returns operation progress", func(t *testing.T) {
+		opID := uuid.New()
+		getter := &fakeOperationGetter{op: models.OperationDetails{ID: opID, Status: models.OperationStatusSucceeded}}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/operations/"+opID.String(), nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+		}
+		if getter.id != opID.String() || getter.username != "alice" {
+			t.Fatalf("unexpected call args: id=%q user=%q", getter.id, getter.username)
+		}
+		if !strings.Contains(rr.Body.String(), `"status":"succeeded"`) {
+			t.Fatalf("expected status in body, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("returns not found for unknown operation", func(t *testing.T) {
+		getter := &fakeOperationGetter{err: services.ErrOperationNotFound}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/operations/"+uuid.NewString(), nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("returns bad request for invalid id", func(t *testing.T) {
+		getter := &fakeOperationGetter{err: services.ErrValidation}
+
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/operations/nope", nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
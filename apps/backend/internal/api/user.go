@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
 	"github.com/kisnikita/safe-disputes/backend/internal/repository"
@@ -21,6 +22,16 @@ type UserUpdater interface {
 	UpdateByUsername(ctx context.Context, opts models.UserUpdateOpts) error
 }
 
+type ReputationGetter interface {
+	GetReputation(ctx context.Context, username string) (models.Reputation, error)
+}
+
+type JurorBlocker interface {
+	BlockJuror(ctx context.Context, actorUsername, username string) error
+	UnblockJuror(ctx context.Context, actorUsername, username string) error
+	ListJurorBlocks(ctx context.Context, actorUsername string) ([]models.JurorBlockCard, error)
+}
+
 func GetMe(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
 	userSrv, err := services.NewUserService(repo, log)
 	if err != nil {
@@ -68,7 +79,6 @@ func updateUser(log log.Logger, updater UserUpdater) gin.HandlerFunc {
 			DisputeReadiness         *bool   `json:"disputeReadiness"`
 			InvestigationReadiness   *bool   `json:"investigationReadiness"`
 			MinimumDisputeAmountNano *string `json:"minimumDisputeAmountNano"`
-			Rating                   *int    `json:"rating"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
@@ -90,7 +100,6 @@ func updateUser(log log.Logger, updater UserUpdater) gin.HandlerFunc {
 			DisputeReadiness:         req.DisputeReadiness,
 			InvestigationReadiness:   req.InvestigationReadiness,
 			MinimumDisputeAmountNano: minimumDisputeAmountNano,
-			Rating:                   req.Rating,
 		}
 
 		if err := updater.UpdateByUsername(c, opts); err != nil {
@@ -122,3 +131,121 @@ func getTop(log log.Logger, getter UserGetter) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"data": users})
 	}
 }
+
+func GetMyReputation(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	reputationSrv, err := services.NewReputationService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create reputation service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetMyReputation"))
+	return getReputation(log, reputationSrv, func(_ *gin.Context, actorUsername string) string {
+		return actorUsername
+	})
+}
+
+func GetUserReputation(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	reputationSrv, err := services.NewReputationService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create reputation service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "GetUserReputation"))
+	return getReputation(log, reputationSrv, func(c *gin.Context, _ string) string {
+		return c.Param("username")
+	})
+}
+
+func getReputation(log log.Logger, getter ReputationGetter, username func(c *gin.Context, actorUsername string) string,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		reputation, err := getter.GetReputation(c, username(c, actorUsername))
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		case err != nil:
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": reputation})
+	}
+}
+
+func ListJurorBlocks(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	conflictSrv, err := services.NewConflictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create conflict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "ListJurorBlocks"))
+	return listJurorBlocks(log, conflictSrv)
+}
+
+func listJurorBlocks(log log.Logger, blocker JurorBlocker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		blocks, err := blocker.ListJurorBlocks(c, actorUsername)
+		if err != nil {
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": blocks})
+	}
+}
+
+func BlockJuror(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	conflictSrv, err := services.NewConflictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create conflict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "BlockJuror"))
+	return changeJurorBlock(log, conflictSrv.BlockJuror)
+}
+
+func UnblockJuror(repo *repository.Repository, log log.Logger) gin.HandlerFunc {
+	conflictSrv, err := services.NewConflictService(repo, log)
+	if err != nil {
+		log.Fatal("failed to create conflict service", zap.Error(err))
+	}
+	log = log.With(zap.String("handler", "UnblockJuror"))
+	return changeJurorBlock(log, conflictSrv.UnblockJuror)
+}
+
+func changeJurorBlock(log log.Logger, change func(ctx context.Context, actorUsername, username string) error,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorUsername, ok := getActorUsername(c)
+		if !ok {
+			return
+		}
+
+		var req models.JurorBlockReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error("invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		err := change(c, actorUsername, req.Username)
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			log.Error("user not found", zap.String("username", req.Username), zap.Error(err))
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		case err != nil:
+			handleApiError(c, log, actorUsername, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
 )
 
 type fakeUserGetter struct {
@@ -196,3 +197,52 @@ func TestGetTop(t *testing.T) {
 		t.Fatalf("expected limit 100, got %d", getter.gotTop)
 	}
 }
+
+type fakeReputationGetter struct {
+	reputation models.Reputation
+	err        error
+	gotUser    string
+}
+
+func (f *fakeReputationGetter) GetReputation(_ context.Context, username string) (models.Reputation, error) {
+	f.gotUser = username
+	return f.reputation, f.err
+}
+
+func TestGetReputation(t *testing.T) {
+	newRouter := func(getter *fakeReputationGetter) *gin.Engine {
+		r := gin.New()
+		r.GET("/users/:username/reputation", func(c *gin.Context) {
+			c.Set("username", "alice")
+			getReputation(noopLogger{}, getter, func(c *gin.Context, _ string) string {
+				return c.Param("username")
+			})(c)
+		})
+		return r
+	}
+
+	t.Run("returns not found for an unknown user", func(t *testing.T) {
+		getter := &fakeReputationGetter{err: services.ErrUserNotFound}
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/bob/reputation", nil))
+
+		if rr.Code != http.StatusNotFound || getter.gotUser != "bob" {
+			t.Fatalf("expected %d for bob, got %d for %q", http.StatusNotFound, rr.Code, getter.gotUser)
+		}
+	})
+
+	t.Run("returns the reputation", func(t *testing.T) {
+		getter := &fakeReputationGetter{reputation: models.Reputation{Rating: 42, Accuracy: 0.9,
+			ParticipationRate: 0.5, History: []models.RatingPoint{{Rating: 42}}}}
+		rr := httptest.NewRecorder()
+		newRouter(getter).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/bob/reputation", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+		}
+		data, _ := decodeJSONMap(t, rr)["data"].(map[string]any)
+		if data["rating"] != float64(42) || data["participationRate"] != 0.5 || len(data["history"].([]any)) != 1 {
+			t.Fatalf("unexpected response: %v", data)
+		}
+	})
+}
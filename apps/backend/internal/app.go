@@ -10,6 +10,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
+	"github.com/kisnikita/safe-disputes/backend/internal/integrations/blob"
 	"github.com/kisnikita/safe-disputes/backend/internal/integrations/ton"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
 	"github.com/kisnikita/safe-disputes/backend/internal/services"
@@ -53,15 +54,142 @@ func StartApp() {
 	if err != nil {
 		logger.Fatal("failed to create TON monitor", zap.Error(err))
 	}
+	betMaster := os.Getenv("BET_MASTER_ADDRESS")
+	if betMaster == "" {
+		logger.Fatal("BET_MASTER_ADDRESS is not set")
+	}
+
+	blobs, err := newBlobStore()
+	if err != nil {
+		logger.Fatal("failed to create blob store", zap.Error(err))
+	}
 
+	msgSender := services.NewMessageService(logger, bot)
+
+	invitationSrv, err := services.NewInvitationService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create invitation service", zap.Error(err))
+	}
 	userData := checkChat(logger, bot)
-	go updateChatID(logger, repo, userData)
+	go updateChatID(logger, repo, invitationSrv, userData)
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	evidenceSrv, err := services.NewEvidenceService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create evidence service", zap.Error(err))
+	}
+	// Opening an investigation reads the number of jurors it needs from the chain.
+	evidenceSrv = evidenceSrv.WithTransactionMonitor(txMonitor)
+
+	deadlineSrv, err := services.NewDeadlineService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create deadline service", zap.Error(err))
+	}
+	deadlineSrv = deadlineSrv.WithInvestigationOpener(evidenceSrv)
+	go deadlineSrv.Run(workersCtx, workerInterval("DEADLINE_WORKER_INTERVAL_MS"))
+
+	investigationSrv, err := services.NewInvestigationService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create investigation service", zap.Error(err))
+	}
+	go investigationSrv.RunExpiry(workersCtx, workerInterval("INVESTIGATION_WORKER_INTERVAL_MS"))
 
-	server := NewServer(logger, services.NewMessageService(logger, bot), txMonitor)
+	jurySrv, err := services.NewJuryService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create jury service", zap.Error(err))
+	}
+	go jurySrv.Run(workersCtx, workerInterval("JURY_WORKER_INTERVAL_MS"))
+
+	reputationSrv, err := services.NewReputationService(repo, logger)
+	if err != nil {
+		logger.Fatal("failed to create reputation service", zap.Error(err))
+	}
+	go reputationSrv.Run(workersCtx, workerInterval("REPUTATION_WORKER_INTERVAL_MS"))
+
+	mediaSrv, err := services.NewMediaService(repo, logger, blobs)
+	if err != nil {
+		logger.Fatal("failed to create media service", zap.Error(err))
+	}
+
+	disputeSrv, err := services.NewDisputeService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create dispute service", zap.Error(err))
+	}
+
+	operationSrv, err := services.NewOperationService(repo, logger, msgSender)
+	if err != nil {
+		logger.Fatal("failed to create operation service", zap.Error(err))
+	}
+	operationSrv = operationSrv.WithTransactionMonitor(txMonitor).
+		WithApplier(investigationSrv, models.OperationActionVoteInvestigation).
+		WithApplier(disputeSrv.WithMediaStore(mediaSrv), models.OperationActionCreateDispute).
+		WithApplier(evidenceSrv.WithMediaStore(mediaSrv), models.OperationActionProvideEvidence)
+	go operationSrv.Run(workersCtx, workerInterval("OPERATION_WORKER_INTERVAL_MS"))
+
+	server := NewServer(logger, msgSender, txMonitor, betMaster, blobs)
 	server.RegisterRoutes(repo)
 	go server.StartServer()
 
-	gracefulShutdown(db, server, logger)
+	gracefulShutdown(db, server, logger, stopWorkers)
+}
+
+// MoveInlineImages moves the dispute and evidence images still kept in the database into the blob store and exits.
+// It is run once by hand after upgrading to the blob store, instead of on every start of the server.
+func MoveInlineImages() {
+	logger := zapadapter.New()
+	defer logger.Sync()
+
+	if err := godotenv.Load(); err != nil {
+		logger.Fatal("failed to load .env file", zap.Error(err))
+	}
+
+	db, err := postgres.NewConnection()
+	if err != nil {
+		logger.Fatal("failed to connect to database", zap.Error(err))
+	}
+	defer db.Close()
+
+	repo, err := repository.New(db, logger)
+	if err != nil {
+		logger.Fatal("failed to create repository", zap.Error(err))
+	}
+	blobs, err := newBlobStore()
+	if err != nil {
+		logger.Fatal("failed to create blob store", zap.Error(err))
+	}
+	mediaSrv, err := services.NewMediaService(repo, logger, blobs)
+	if err != nil {
+		logger.Fatal("failed to create media service", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err = mediaSrv.MoveInlineImages(ctx); err != nil {
+		logger.Fatal("failed to move inline images to the blob store", zap.Error(err))
+	}
+	logger.Info("inline images are in the blob store")
+}
+
+func newBlobStore() (blob.Store, error) {
+	return blob.New(blob.Config{
+		Backend:         os.Getenv("BLOB_BACKEND"),
+		Dir:             os.Getenv("BLOB_DIR"),
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		Timeout:         durationFromEnvMS("S3_TIMEOUT_MS"),
+	})
+}
+
+const defaultWorkerInterval = 30 * time.Second
+
+func workerInterval(key string) time.Duration {
+	if interval := durationFromEnvMS(key); interval > 0 {
+		return interval
+	}
+	return defaultWorkerInterval
 }
 
 func durationFromEnvMS(key string) time.Duration {
@@ -78,11 +206,12 @@ func durationFromEnvMS(key string) time.Duration {
 	return time.Duration(ms) * time.Millisecond
 }
 
-func gracefulShutdown(db *sql.DB, server *Server, logger log.Logger) {
+func gracefulShutdown(db *sql.DB, server *Server, logger log.Logger, stopWorkers context.CancelFunc) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	logger.Info("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -130,7 +259,9 @@ func checkChat(log log.Logger, bot *tgbotapi.BotAPI) chan userChatData {
 	return ch
 }
 
-func updateChatID(log log.Logger, repo *repository.Repository, ch chan userChatData) {
+func updateChatID(log log.Logger, repo *repository.Repository, invitationSrv services.InvitationService,
+	ch chan userChatData,
+) {
 	for {
 		userData := <-ch
 		ctx := context.Background()
@@ -150,6 +281,9 @@ func updateChatID(log log.Logger, repo *repository.Repository, ch chan userChatD
 				continue
 			}
 			log.Info("new user inserted", zap.String("username", userData.Username), zap.Int64("chatID", userData.ChatID))
+			if err = invitationSrv.AttachInvitations(ctx, userData.Username); err != nil {
+				log.Error("failed to attach invitations", zap.String("username", userData.Username), zap.Error(err))
+			}
 			continue
 		}
 
@@ -0,0 +1,61 @@
+// Package blob stores media content-addressed by key in a local directory or an S3-compatible bucket.
+package blob
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	BackendFS = "fs"
+	BackendS3 = "s3"
+
+	defaultDir     = "./media"
+	defaultRegion  = "us-east-1"
+	defaultTimeout = 30 * time.Second
+)
+
+// Store keeps blobs by key. Get returns an error wrapping fs.ErrNotExist for a missing key.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type Config struct {
+	// Backend is BackendFS, the default, or BackendS3.
+	Backend string
+	// Dir is where BackendFS keeps blobs.
+	Dir string
+
+	// Endpoint is the base URL of the S3-compatible service; the bucket is addressed path-style.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Timeout         time.Duration
+}
+
+// New returns the store of the configured backend.
+func New(cfg Config) (Store, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendFS:
+		if cfg.Dir == "" {
+			cfg.Dir = defaultDir
+		}
+		return NewFSStore(cfg.Dir)
+	case BackendS3:
+		return NewS3Store(cfg)
+	}
+	return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+}
+
+// checkKey keeps keys from escaping the directory or the bucket.
+func checkKey(key string) error {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FSStore keeps blobs as files of a local directory, sharded by the first two characters of the key.
+type FSStore struct {
+	dir string
+}
+
+func NewFSStore(dir string) (FSStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return FSStore{}, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return FSStore{dir: dir}, nil
+}
+
+// Put writes the blob unless it is already stored. Keys address content, so a stored blob never changes.
+func (s FSStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(path); err == nil {
+		return nil
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Readers never see a partly written blob: it is written aside and renamed into place.
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (s FSStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("blob %s: %w", key, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+func (s FSStore) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(s.dir, shard, key), nil
+}
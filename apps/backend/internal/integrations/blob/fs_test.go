@@ -0,0 +1,35 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestFSStore(t *testing.T) {
+	store, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	if err = store.Put(ctx, "abc123", []byte("image"), "image/png"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = store.Put(ctx, "abc123", []byte("image"), "image/png"); err != nil {
+		t.Fatalf("expected storing the same blob again to succeed, got %v", err)
+	}
+	data, err := store.Get(ctx, "abc123")
+	if err != nil || string(data) != "image" {
+		t.Fatalf("expected the stored blob, got %q, %v", data, err)
+	}
+	if _, err = store.Get(ctx, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+	for _, key := range []string{"", "../etc", "a/b"} {
+		if err = store.Put(ctx, key, []byte("x"), ""); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service   = "s3"
+	s3Algorithm = "AWS4-HMAC-SHA256"
+	amzDate     = "20060102T150405Z"
+)
+
+// S3Store keeps blobs in a bucket of an S3-compatible service such as AWS S3 or MinIO. Requests are signed with
+// AWS Signature Version 4.
+type S3Store struct {
+	client          *http.Client
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	now             func() time.Time
+}
+
+func NewS3Store(cfg Config) (S3Store, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return S3Store{}, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return S3Store{}, fmt.Errorf("S3 bucket is not set")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return S3Store{}, fmt.Errorf("S3 credentials are not set")
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return S3Store{
+		client:          &http.Client{Timeout: cfg.Timeout},
+		endpoint:        endpoint,
+		region:          cfg.Region,
+		bucket:          cfg.Bucket,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		now:             time.Now,
+	}, nil
+}
+
+func (s S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req, data)
+	if err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to put blob: %s", s3Error(resp))
+	}
+	return nil
+}
+
+func (s S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("blob %s: %w", key, fs.ErrNotExist)
+	default:
+		return nil, fmt.Errorf("failed to get blob: %s", s3Error(resp))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+func (s S3Store) request(ctx context.Context, method, key string, data []byte) (*http.Request, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	u := s.endpoint.JoinPath(s.bucket, key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	return req, nil
+}
+
+func (s S3Store) do(req *http.Request, data []byte) (*http.Response, error) {
+	sum := sha256.Sum256(data)
+	signRequest(req, hex.EncodeToString(sum[:]), s.accessKeyID, s.secretAccessKey, s.region, s.now())
+	return s.client.Do(req)
+}
+
+// signRequest sets the x-amz-date, x-amz-content-sha256 and Authorization headers of the request. Every header
+// already set on the request is signed together with the host.
+func signRequest(req *http.Request, payloadHash, accessKeyID, secretAccessKey, region string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("x-amz-date", now.Format(amzDate))
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	scope := strings.Join([]string{now.Format("20060102"), region, s3Service, "aws4_request"}, "/")
+	signedHeaders, canonicalRequest := canonicalRequest(req, payloadHash)
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, now.Format(amzDate), scope, hex.EncodeToString(sum[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), now.Format("20060102"))
+	for _, part := range []string{region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalRequest returns the signed header names and the canonical request of Signature Version 4.
+func canonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return signedHeaders, strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func s3Error(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Sprintf("S3 responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
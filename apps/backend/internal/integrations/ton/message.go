@@ -0,0 +1,435 @@
+package ton
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	tonapi "github.com/tonkeeper/tonapi-go"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	signatureBits = 512
+	// walletV5Prefix opens the signed external body of wallet v5 ("sign").
+	walletV5Prefix = 0x7369676e
+	// actionSendMsg is the tag of an out action that sends a message.
+	actionSendMsg = 0x0ec3c86d
+)
+
+// VerifyMessage checks that the external message in boc makes the wallet send the contract call described by
+// want, either alone or after the calls want lets precede it.
+func (m TonAPIMonitor) VerifyMessage(ctx context.Context, boc string, want models.ExpectedMessage) error {
+	msg, err := parseExternalMessage(boc)
+	if err != nil {
+		return fmt.Errorf("%w: %v", services.ErrInvalidBOC, err)
+	}
+	if err = matchWallet(msg.DstAddr, want.Wallet); err != nil {
+		return err
+	}
+	out, err := walletMessages(msg.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", services.ErrInvalidBOC, err)
+	}
+	if len(out) != len(want.Preceding)+1 && (len(out) != 1 || !want.PrecedingOptional) {
+		return &services.MessageMismatchError{Field: "messages", Expected: messageCount(want),
+			Actual: strconv.Itoa(len(out))}
+	}
+
+	dst, err := m.resolveDestination(ctx, want)
+	if err != nil {
+		return err
+	}
+	if want.ValueGetter != "" {
+		if want.ValueNano, err = m.CallGetter(ctx, dst.String(), want.ValueGetter); err != nil {
+			return err
+		}
+	}
+	preceding := make([]expectedCall, 0, len(want.Preceding))
+	for _, p := range want.Preceding {
+		call := expectedCall{dst: dst, want: p}
+		if p.Destination != "" {
+			if call.dst, err = m.resolveDestination(ctx, p); err != nil {
+				return err
+			}
+		}
+		preceding = append(preceding, call)
+	}
+	return matchMessages(out, preceding, expectedCall{dst: dst, want: want})
+}
+
+// SenderWallet returns the raw address of the wallet the external message in boc is signed for.
+func (m TonAPIMonitor) SenderWallet(boc string) (string, error) {
+	msg, err := parseExternalMessage(boc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", services.ErrInvalidBOC, err)
+	}
+	if msg.DstAddr == nil || msg.DstAddr.Type() != address.StdAddress {
+		return "", fmt.Errorf("%w: external message has no wallet address", services.ErrInvalidBOC)
+	}
+	return msg.DstAddr.StringRaw(), nil
+}
+
+// matchWallet checks that the external message goes to the wallet want, ignoring the flags of its address.
+func matchWallet(wallet *address.Address, want string) error {
+	if want == "" {
+		return nil
+	}
+	expected, err := parseAddress(want)
+	if err != nil {
+		return fmt.Errorf("invalid wallet %q: %w", want, err)
+	}
+	if wallet == nil || wallet.Type() != address.StdAddress {
+		return &services.MessageMismatchError{Field: "wallet", Expected: expected.StringRaw(), Actual: "none"}
+	}
+	if !wallet.Equals(expected) {
+		return &services.MessageMismatchError{Field: "wallet", Expected: expected.StringRaw(),
+			Actual: wallet.StringRaw()}
+	}
+	return nil
+}
+
+// expectedCall is a contract call the wallet must send, with its destination resolved.
+type expectedCall struct {
+	dst  *address.Address
+	want models.ExpectedMessage
+}
+
+// matchMessages compares the messages with the calls: the last one is the checked call and the ones before it are
+// the preceding calls, which the wallet may leave out only all together and only when the checked call lets it.
+func matchMessages(out []*tlb.InternalMessage, preceding []expectedCall, checked expectedCall) error {
+	if len(out) == 1 && checked.want.PrecedingOptional {
+		preceding = nil
+	}
+	if len(out) != len(preceding)+1 {
+		return &services.MessageMismatchError{Field: "messages", Expected: messageCount(checked.want),
+			Actual: strconv.Itoa(len(out))}
+	}
+	for i, call := range preceding {
+		if err := matchMessage(out[i], call.dst, call.want); err != nil {
+			return err
+		}
+	}
+	return matchMessage(out[len(preceding)], checked.dst, checked.want)
+}
+
+func messageCount(want models.ExpectedMessage) string {
+	calls := strconv.Itoa(len(want.Preceding) + 1)
+	if len(want.Preceding) == 0 || !want.PrecedingOptional {
+		return calls
+	}
+	return "1 or " + calls
+}
+
+func parseExternalMessage(boc string) (*tlb.ExternalMessage, error) {
+	raw, err := base64.StdEncoding.DecodeString(boc)
+	if err != nil {
+		return nil, err
+	}
+
+	msgCell, err := cell.FromBOC(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boc payload: %w", err)
+	}
+
+	var msg tlb.Message
+	if err = tlb.LoadFromCell(&msg, msgCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse message from boc: %w", err)
+	}
+	if msg.MsgType != tlb.MsgTypeExternalIn {
+		return nil, fmt.Errorf("boc does not contain external inbound message")
+	}
+	return msg.AsExternalIn(), nil
+}
+
+// walletMessages returns the internal messages a signed wallet v3, v4 or v5 body asks the wallet to send.
+func walletMessages(body *cell.Cell) ([]*tlb.InternalMessage, error) {
+	if body == nil {
+		return nil, fmt.Errorf("external message has no body")
+	}
+	s := body.BeginParse()
+	if s.BitsLeft() >= 32 {
+		prefix, err := body.BeginParse().LoadUInt(32)
+		if err == nil && prefix == walletV5Prefix {
+			return walletV5Messages(s)
+		}
+	}
+	return walletV4Messages(s)
+}
+
+// walletV4Messages reads the v3 and v4 layout: signature, subwallet, valid_until and seqno, the v4 op, then
+// a send mode and a message reference per message.
+func walletV4Messages(s *cell.Slice) ([]*tlb.InternalMessage, error) {
+	if _, err := s.LoadSlice(signatureBits + 32*3); err != nil {
+		return nil, fmt.Errorf("failed to read wallet header: %w", err)
+	}
+	refs := uint(s.RefsNum())
+	switch s.BitsLeft() {
+	case 8 * refs:
+	case 8*refs + 8:
+		op, err := s.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read wallet op: %w", err)
+		}
+		if op != 0 {
+			return nil, fmt.Errorf("unsupported wallet op %d", op)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported wallet message layout")
+	}
+
+	msgs := make([]*tlb.InternalMessage, 0, refs)
+	for range refs {
+		if _, err := s.LoadUInt(8); err != nil {
+			return nil, fmt.Errorf("failed to read send mode: %w", err)
+		}
+		ref, err := s.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message: %w", err)
+		}
+		msg, err := loadInternalMessage(ref)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// walletV5Messages reads the v5 layout: prefix, wallet_id, valid_until and seqno, the optional out list and
+// the extended actions flag, then the signature.
+func walletV5Messages(s *cell.Slice) ([]*tlb.InternalMessage, error) {
+	if _, err := s.LoadSlice(32 * 4); err != nil {
+		return nil, fmt.Errorf("failed to read wallet header: %w", err)
+	}
+	list, err := s.LoadMaybeRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read out actions: %w", err)
+	}
+	extended, err := s.LoadBoolBit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extended actions flag: %w", err)
+	}
+	if extended {
+		return nil, fmt.Errorf("extended wallet actions are not supported")
+	}
+
+	var msgs []*tlb.InternalMessage
+	// The out list is a chain of cells from the last action to the first, each referencing the previous one.
+	for list != nil && list.BitsLeft() > 0 {
+		prev, err := list.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read out list: %w", err)
+		}
+		tag, err := list.LoadUInt(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read out action: %w", err)
+		}
+		if tag != actionSendMsg {
+			return nil, fmt.Errorf("unsupported out action %#x", tag)
+		}
+		if _, err = list.LoadUInt(8); err != nil {
+			return nil, fmt.Errorf("failed to read send mode: %w", err)
+		}
+		ref, err := list.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message: %w", err)
+		}
+		msg, err := loadInternalMessage(ref)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+		list = prev
+	}
+	slices.Reverse(msgs)
+	return msgs, nil
+}
+
+func loadInternalMessage(s *cell.Slice) (*tlb.InternalMessage, error) {
+	var msg tlb.InternalMessage
+	if err := tlb.LoadFromCell(&msg, s); err != nil {
+		return nil, fmt.Errorf("failed to parse internal message: %w", err)
+	}
+	return &msg, nil
+}
+
+// resolveDestination returns the address the message must be sent to, calling the destination getter when set.
+func (m TonAPIMonitor) resolveDestination(ctx context.Context, want models.ExpectedMessage) (*address.Address, error) {
+	if want.DestinationGetter == "" {
+		dst, err := parseAddress(want.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination %q: %w", want.Destination, err)
+		}
+		return dst, nil
+	}
+
+	return m.callAddressGetter(ctx, want.Destination, want.DestinationGetter)
+}
+
+// CallAddressGetter returns the address the getter of contract returns.
+func (m TonAPIMonitor) CallAddressGetter(ctx context.Context, contract, getter string) (string, error) {
+	addr, err := m.callAddressGetter(ctx, contract, getter)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+func (m TonAPIMonitor) callAddressGetter(ctx context.Context, contract, getter string) (*address.Address, error) {
+	res, err := m.client.ExecGetMethodForBlockchainAccount(ctx, tonapi.ExecGetMethodForBlockchainAccountParams{
+		AccountID:  contract,
+		MethodName: getter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to call %s: %v", services.ErrTxMonitorUnavailable, getter, err)
+	}
+	if !res.Success || len(res.Stack) == 0 {
+		return nil, fmt.Errorf("%w: %s of %s exited with code %d", services.ErrValidation, getter, contract,
+			res.ExitCode)
+	}
+	addr, err := stackAddress(res.Stack[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s result: %w", getter, err)
+	}
+	return addr, nil
+}
+
+// CallGetter returns the positive integer the getter of contract returns.
+func (m TonAPIMonitor) CallGetter(ctx context.Context, contract, getter string) (int64, error) {
+	res, err := m.client.ExecGetMethodForBlockchainAccount(ctx, tonapi.ExecGetMethodForBlockchainAccountParams{
+		AccountID:  contract,
+		MethodName: getter,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to call %s: %v", services.ErrTxMonitorUnavailable, getter, err)
+	}
+	if !res.Success || len(res.Stack) == 0 {
+		return 0, fmt.Errorf("%w: %s of %s exited with code %d", services.ErrValidation, getter, contract, res.ExitCode)
+	}
+	value, err := stackInt(res.Stack[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s result: %w", getter, err)
+	}
+	if value.Sign() <= 0 || !value.IsInt64() {
+		return 0, fmt.Errorf("%w: %s of %s returned %s", services.ErrValidation, getter, contract, value)
+	}
+	return value.Int64(), nil
+}
+
+func stackInt(rec tonapi.TvmStackRecord) (*big.Int, error) {
+	raw, ok := rec.Num.Get()
+	if !ok {
+		return nil, fmt.Errorf("unexpected stack record type %s", rec.Type)
+	}
+	value, ok := new(big.Int).SetString(raw, 0)
+	if !ok {
+		return nil, fmt.Errorf("malformed number %q", raw)
+	}
+	return value, nil
+}
+
+func stackAddress(rec tonapi.TvmStackRecord) (*address.Address, error) {
+	raw, ok := rec.Slice.Get()
+	if !ok {
+		if raw, ok = rec.Cell.Get(); !ok {
+			return nil, fmt.Errorf("unexpected stack record type %s", rec.Type)
+		}
+	}
+	boc, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	c, err := cell.FromBOC(boc)
+	if err != nil {
+		return nil, err
+	}
+	return c.BeginParse().LoadAddr()
+}
+
+func parseAddress(addr string) (*address.Address, error) {
+	if a, err := address.ParseAddr(addr); err == nil {
+		return a, nil
+	}
+	return address.ParseRawAddr(addr)
+}
+
+// matchMessage compares the message with want and reports the first difference.
+func matchMessage(msg *tlb.InternalMessage, dst *address.Address, want models.ExpectedMessage) error {
+	if msg.DstAddr == nil {
+		return &services.MessageMismatchError{Field: "destination", Expected: dst.String(), Actual: "none"}
+	}
+	if !msg.DstAddr.Equals(dst) {
+		return &services.MessageMismatchError{Field: "destination", Expected: dst.String(), Actual: msg.DstAddr.String()}
+	}
+	if want.ValueNano != 0 {
+		expected := big.NewInt(want.ValueNano)
+		if actual := msg.Amount.Nano(); actual.Cmp(expected) != 0 {
+			return &services.MessageMismatchError{Field: "value", Expected: expected.String(), Actual: actual.String()}
+		}
+	}
+
+	if msg.Body == nil || msg.Body.BitsSize() < 32 {
+		return &services.MessageMismatchError{Field: "opcode", Expected: opcodes(want.Opcodes), Actual: "none"}
+	}
+	body := msg.Body.BeginParse()
+	op, err := body.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("%w: %v", services.ErrInvalidBOC, err)
+	}
+	if !slices.Contains(want.Opcodes, uint32(op)) {
+		return &services.MessageMismatchError{Field: "opcode", Expected: opcodes(want.Opcodes), Actual: opcode(uint32(op))}
+	}
+
+	for _, field := range want.Fields {
+		value, err := body.LoadBigUInt(field.Bits)
+		if err != nil {
+			return &services.MessageMismatchError{Field: field.Name, Expected: fieldValue(field), Actual: "none"}
+		}
+		if expected := expectedValue(field); expected != nil && value.Cmp(expected) != 0 {
+			return &services.MessageMismatchError{Field: field.Name, Expected: fieldValue(field), Actual: value.String()}
+		}
+	}
+	return nil
+}
+
+func expectedValue(field models.MessageField) *big.Int {
+	switch {
+	case field.BigValue != nil:
+		return field.BigValue
+	case field.Value != nil:
+		return new(big.Int).SetUint64(*field.Value)
+	default:
+		return nil
+	}
+}
+
+func fieldValue(field models.MessageField) string {
+	if expected := expectedValue(field); expected != nil {
+		return expected.String()
+	}
+	return "any"
+}
+
+func opcode(op uint32) string {
+	return fmt.Sprintf("%#08x", op)
+}
+
+func opcodes(ops []uint32) string {
+	if len(ops) == 1 {
+		return opcode(ops[0])
+	}
+	names := make([]string, 0, len(ops))
+	for _, op := range ops {
+		names = append(names, opcode(op))
+	}
+	return fmt.Sprint(names)
+}
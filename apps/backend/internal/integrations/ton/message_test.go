@@ -0,0 +1,291 @@
+package ton
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/services"
+	tonapi "github.com/tonkeeper/tonapi-go"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	testBet       = "EQC9siZ7Ss9MZVqU1ywih597rSaekr7gKvWxYmuJNkj2q7Il"
+	testBetMaster = "EQABCA8WHSQrMjlAR05VXGNqcXh_ho2Um6KpsLe-xczT2qP-"
+)
+
+func internalMessageCell(t *testing.T, dst string, nano uint64, body *cell.Cell) *cell.Cell {
+	t.Helper()
+	c, err := tlb.ToCell(&tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		SrcAddr:     address.NewAddressNone(),
+		DstAddr:     address.MustParseAddr(dst),
+		Amount:      tlb.FromNanoTONU(nano),
+		Body:        body,
+	})
+	if err != nil {
+		t.Fatalf("failed to build internal message: %v", err)
+	}
+	return c
+}
+
+func voteResultBody(result uint64) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(uint64(models.OpVoteResult), 32).MustStoreUInt(result, 8).EndCell()
+}
+
+func walletV4Body(msgs ...*cell.Cell) *cell.Cell {
+	b := cell.BeginCell().
+		MustStoreSlice(make([]byte, signatureBits/8), signatureBits).
+		MustStoreUInt(698983191, 32).
+		MustStoreUInt(1700000000, 32).
+		MustStoreUInt(7, 32).
+		MustStoreUInt(0, 8)
+	for _, msg := range msgs {
+		b.MustStoreUInt(3, 8).MustStoreRef(msg)
+	}
+	return b.EndCell()
+}
+
+func walletV5Body(msgs ...*cell.Cell) *cell.Cell {
+	list := cell.BeginCell().EndCell()
+	for _, msg := range msgs {
+		list = cell.BeginCell().
+			MustStoreRef(list).
+			MustStoreUInt(actionSendMsg, 32).
+			MustStoreUInt(3, 8).
+			MustStoreRef(msg).
+			EndCell()
+	}
+	return cell.BeginCell().
+		MustStoreUInt(walletV5Prefix, 32).
+		MustStoreUInt(2147483409, 32).
+		MustStoreUInt(1700000000, 32).
+		MustStoreUInt(7, 32).
+		MustStoreMaybeRef(list).
+		MustStoreBoolBit(false).
+		MustStoreSlice(make([]byte, signatureBits/8), signatureBits).
+		EndCell()
+}
+
+func TestWalletMessages(t *testing.T) {
+	first := internalMessageCell(t, testBet, 20_000_000, voteResultBody(models.VoteResultWin))
+	second := internalMessageCell(t, testBet, 30_000_000, voteResultBody(models.VoteResultLose))
+
+	for name, body := range map[string]*cell.Cell{
+		"wallet v4": walletV4Body(first, second),
+		"wallet v5": walletV5Body(first, second),
+	} {
+		t.Run(name, func(t *testing.T) {
+			msgs, err := walletMessages(body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(msgs) != 2 {
+				t.Fatalf("expected 2 messages, got %d", len(msgs))
+			}
+			if msgs[0].Amount.Nano().Uint64() != 20_000_000 || msgs[1].Amount.Nano().Uint64() != 30_000_000 {
+				t.Fatalf("unexpected message order: %s, %s", msgs[0].Amount, msgs[1].Amount)
+			}
+		})
+	}
+}
+
+func TestStackInt(t *testing.T) {
+	num := func(raw string) tonapi.TvmStackRecord {
+		return tonapi.TvmStackRecord{Type: tonapi.TvmStackRecordTypeNum, Num: tonapi.NewOptString(raw)}
+	}
+	for raw, want := range map[string]int64{"0x2540be400": 10_000_000_000, "110000000": 110_000_000, "-0x1": -1} {
+		got, err := stackInt(num(raw))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", raw, err)
+		}
+		if got.Int64() != want {
+			t.Fatalf("%s: expected %d, got %s", raw, want, got)
+		}
+	}
+	if _, err := stackInt(tonapi.TvmStackRecord{Type: tonapi.TvmStackRecordTypeCell}); err == nil {
+		t.Fatal("expected an error for a cell")
+	}
+}
+
+func TestMatchMessage(t *testing.T) {
+	bet := address.MustParseAddr(testBet)
+	win := models.VoteResultWin
+	want := models.ExpectedMessage{
+		Destination: testBet,
+		Opcodes:     []uint32{models.OpVoteResult},
+		Fields:      []models.MessageField{{Name: "result", Bits: 8, Value: &win}},
+	}
+
+	tests := []struct {
+		name  string
+		msg   *cell.Cell
+		field string
+	}{
+		{name: "matching call", msg: internalMessageCell(t, testBet, 20_000_000, voteResultBody(win))},
+		{
+			name:  "other contract",
+			msg:   internalMessageCell(t, "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAM9c", 20_000_000, voteResultBody(win)),
+			field: "destination",
+		},
+		{
+			name: "other opcode",
+			msg: internalMessageCell(t, testBet, 20_000_000,
+				cell.BeginCell().MustStoreUInt(uint64(models.OpClaim), 32).EndCell()),
+			field: "opcode",
+		},
+		{name: "plain transfer", msg: internalMessageCell(t, testBet, 20_000_000, nil), field: "opcode"},
+		{
+			name:  "other vote",
+			msg:   internalMessageCell(t, testBet, 20_000_000, voteResultBody(models.VoteResultLose)),
+			field: "result",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := walletMessages(walletV4Body(tt.msg))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err = matchMessage(msgs[0], bet, want)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var mismatch *services.MessageMismatchError
+			if !errors.As(err, &mismatch) || mismatch.Field != tt.field {
+				t.Fatalf("expected %s mismatch, got %v", tt.field, err)
+			}
+		})
+	}
+
+	t.Run("evidence hash", func(t *testing.T) {
+		digest, _ := new(big.Int).SetString("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", 16)
+		provide := func(hash *big.Int) *cell.Cell {
+			body := cell.BeginCell().MustStoreUInt(uint64(models.OpProvideEvidence), 32).MustStoreBigUInt(hash, 256)
+			return internalMessageCell(t, testBet, 20_000_000, body.EndCell())
+		}
+		evidence := models.ProvideEvidenceMessage(testBet, digest)
+
+		msgs, err := walletMessages(walletV4Body(provide(digest)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = matchMessage(msgs[0], bet, evidence); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if msgs, err = walletMessages(walletV4Body(provide(big.NewInt(11)))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var mismatch *services.MessageMismatchError
+		if err = matchMessage(msgs[0], bet, evidence); !errors.As(err, &mismatch) || mismatch.Field != "hash" ||
+			mismatch.Expected != digest.String() || mismatch.Actual != "11" {
+			t.Fatalf("expected hash mismatch, got %v", err)
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		msgs, err := walletMessages(walletV4Body(internalMessageCell(t, testBet, 20_000_000, voteResultBody(win))))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		valued := want
+		valued.ValueNano = 120_000_000_000
+		var mismatch *services.MessageMismatchError
+		if err = matchMessage(msgs[0], bet, valued); !errors.As(err, &mismatch) || mismatch.Field != "value" {
+			t.Fatalf("expected value mismatch, got %v", err)
+		}
+	})
+}
+
+func TestMatchMessages(t *testing.T) {
+	bet := address.MustParseAddr(testBet)
+	master := address.MustParseAddr(testBetMaster)
+	call := func(dst string, op uint32) *cell.Cell {
+		return internalMessageCell(t, dst, 20_000_000, cell.BeginCell().MustStoreUInt(uint64(op), 32).EndCell())
+	}
+	claim := expectedCall{dst: bet, want: models.ExpectedMessage{
+		Destination:       testBet,
+		Opcodes:           []uint32{models.OpClaim},
+		Preceding:         []models.ExpectedMessage{{Opcodes: []uint32{models.OpFinalize}}},
+		PrecedingOptional: true,
+	}}
+	finalize := []expectedCall{{dst: bet, want: claim.want.Preceding[0]}}
+	createBet := expectedCall{dst: master, want: models.ExpectedMessage{
+		Destination: testBetMaster,
+		Opcodes:     []uint32{models.OpCreateBet},
+		Preceding:   []models.ExpectedMessage{{Destination: testBet, Opcodes: []uint32{models.OpCancel}}},
+	}}
+	cancel := []expectedCall{{dst: bet, want: createBet.want.Preceding[0]}}
+
+	tests := []struct {
+		name      string
+		msgs      []*cell.Cell
+		preceding []expectedCall
+		checked   expectedCall
+		field     string
+	}{
+		{name: "call alone", msgs: []*cell.Cell{call(testBet, models.OpClaim)}, preceding: finalize, checked: claim},
+		{name: "call after the preceding one", preceding: finalize, checked: claim,
+			msgs: []*cell.Cell{call(testBet, models.OpFinalize), call(testBet, models.OpClaim)}},
+		{name: "other preceding call", preceding: finalize, checked: claim, field: "opcode",
+			msgs: []*cell.Cell{call(testBet, models.OpClaim), call(testBet, models.OpClaim)}},
+		{name: "calls swapped", preceding: finalize, checked: claim, field: "opcode",
+			msgs: []*cell.Cell{call(testBet, models.OpClaim), call(testBet, models.OpFinalize)}},
+		{name: "required preceding call", preceding: cancel, checked: createBet,
+			msgs: []*cell.Cell{call(testBet, models.OpCancel), call(testBetMaster, models.OpCreateBet)}},
+		{name: "required preceding call left out", preceding: cancel, checked: createBet, field: "messages",
+			msgs: []*cell.Cell{call(testBetMaster, models.OpCreateBet)}},
+		{name: "preceding call to another contract", preceding: cancel, checked: createBet, field: "destination",
+			msgs: []*cell.Cell{call(testBetMaster, models.OpCancel), call(testBetMaster, models.OpCreateBet)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := walletMessages(walletV4Body(tt.msgs...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err = matchMessages(msgs, tt.preceding, tt.checked)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var mismatch *services.MessageMismatchError
+			if !errors.As(err, &mismatch) || mismatch.Field != tt.field {
+				t.Fatalf("expected %s mismatch, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestMatchWallet(t *testing.T) {
+	wallet := address.MustParseAddr(testBet)
+
+	if err := matchWallet(wallet, ""); err != nil {
+		t.Fatalf("expected an unchecked wallet to match, got %v", err)
+	}
+	if err := matchWallet(wallet, wallet.StringRaw()); err != nil {
+		t.Fatalf("expected the raw wallet address to match, got %v", err)
+	}
+	if err := matchWallet(wallet, wallet.Bounce(false).String()); err != nil {
+		t.Fatalf("expected the wallet address to match whatever its flags, got %v", err)
+	}
+
+	var mismatch *services.MessageMismatchError
+	if err := matchWallet(wallet, testBetMaster); !errors.As(err, &mismatch) || mismatch.Field != "wallet" {
+		t.Fatalf("expected wallet mismatch, got %v", err)
+	}
+	if err := matchWallet(nil, testBet); !errors.As(err, &mismatch) || mismatch.Actual != "none" {
+		t.Fatalf("expected wallet mismatch for a message without one, got %v", err)
+	}
+}
@@ -2,7 +2,6 @@ package ton
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -13,8 +12,6 @@ import (
 
 	"github.com/kisnikita/safe-disputes/backend/internal/services"
 	tonapi "github.com/tonkeeper/tonapi-go"
-	"github.com/xssnick/tonutils-go/tlb"
-	"github.com/xssnick/tonutils-go/tvm/cell"
 	"go.uber.org/zap"
 )
 
@@ -69,26 +66,22 @@ func (m TonAPIMonitor) WaitForSuccess(ctx context.Context, boc string) error {
 	}
 }
 
-func normalizedExternalMessageHash(boc string) (string, error) {
-	raw, err := base64.StdEncoding.DecodeString(boc)
+// MessageHash returns the normalized hash of the external message in boc. It stays the same for every
+// resubmission of the signed message and is the trace ID tonapi reports for it.
+func (m TonAPIMonitor) MessageHash(boc string) (string, error) {
+	msgHash, err := normalizedExternalMessageHash(boc)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %v", services.ErrInvalidBOC, err)
 	}
+	return msgHash, nil
+}
 
-	msgCell, err := cell.FromBOC(raw)
+func normalizedExternalMessageHash(boc string) (string, error) {
+	msg, err := parseExternalMessage(boc)
 	if err != nil {
-		return "", fmt.Errorf("invalid boc payload: %w", err)
-	}
-
-	var msg tlb.Message
-	if err = tlb.LoadFromCell(&msg, msgCell.BeginParse()); err != nil {
-		return "", fmt.Errorf("failed to parse message from boc: %w", err)
-	}
-	if msg.MsgType != tlb.MsgTypeExternalIn {
-		return "", fmt.Errorf("boc does not contain external inbound message")
+		return "", err
 	}
-
-	return hex.EncodeToString(msg.AsExternalIn().NormalizedHash()), nil
+	return hex.EncodeToString(msg.NormalizedHash()), nil
 }
 
 func isNotFound(err error) bool {
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+type AttachmentKind string
+
+const (
+	AttachmentKindImage AttachmentKind = "image"
+	AttachmentKindVideo AttachmentKind = "video"
+	AttachmentKindPDF   AttachmentKind = "pdf"
+	AttachmentKindText  AttachmentKind = "text"
+)
+
+const (
+	// MaxEvidenceAttachments bounds the files sent with one evidence submission.
+	MaxEvidenceAttachments = 10
+	// MaxCaptionLength bounds attachment captions, in characters.
+	MaxCaptionLength = 500
+	// MaxAttachmentSize is the size limit of the largest attachment kind.
+	MaxAttachmentSize = 50 << 20
+)
+
+// attachmentKinds maps the sniffed content types accepted as evidence attachments to their kinds. Text covers chat
+// exports; HTML is left out, it would run as a page of ours when opened.
+var attachmentKinds = map[string]AttachmentKind{
+	"image/jpeg":                AttachmentKindImage,
+	"image/png":                 AttachmentKindImage,
+	"image/gif":                 AttachmentKindImage,
+	"video/mp4":                 AttachmentKindVideo,
+	"video/webm":                AttachmentKindVideo,
+	"application/pdf":           AttachmentKindPDF,
+	"text/plain; charset=utf-8": AttachmentKindText,
+}
+
+var attachmentSizeLimits = map[AttachmentKind]int64{
+	AttachmentKindImage: 10 << 20,
+	AttachmentKindVideo: MaxAttachmentSize,
+	AttachmentKindPDF:   20 << 20,
+	AttachmentKindText:  2 << 20,
+}
+
+var (
+	ErrUnsupportedAttachment = errors.New("unsupported attachment type")
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+	ErrAttachmentValidation  = errors.New("invalid attachment")
+)
+
+// MaxSize is the size limit of attachments of the kind.
+func (k AttachmentKind) MaxSize() int64 {
+	return attachmentSizeLimits[k]
+}
+
+// ClassifyAttachment tells the kind and content type of an attachment by its content rather than by what the
+// client claims, and checks it against the size limit of its kind.
+func ClassifyAttachment(data []byte) (AttachmentKind, string, error) {
+	if len(data) == 0 {
+		return "", "", fmt.Errorf("%w: attachment is empty", ErrAttachmentValidation)
+	}
+	contentType := http.DetectContentType(data)
+	kind, ok := attachmentKinds[contentType]
+	if !ok {
+		return "", "", fmt.Errorf("%w %s: image, MP4 or WebM video, PDF or plain text expected",
+			ErrUnsupportedAttachment, contentType)
+	}
+	if kind == AttachmentKindText && !utf8.Valid(data) {
+		return "", "", fmt.Errorf("%w: text is not valid UTF-8", ErrUnsupportedAttachment)
+	}
+	if int64(len(data)) > kind.MaxSize() {
+		return "", "", fmt.Errorf("%w: %s must be at most %d MB", ErrAttachmentTooLarge, kind, kind.MaxSize()>>20)
+	}
+	return kind, contentType, nil
+}
+
+// ParseCaption trims an attachment caption; a blank one means no caption.
+func ParseCaption(caption string) (*string, error) {
+	caption = strings.TrimSpace(caption)
+	if caption == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(caption) > MaxCaptionLength {
+		return nil, fmt.Errorf("%w: caption must be at most %d characters", ErrAttachmentValidation, MaxCaptionLength)
+	}
+	return &caption, nil
+}
+
+// AttachmentUpload is a file uploaded with evidence, with its caption.
+type AttachmentUpload struct {
+	Data    []byte
+	Caption string
+}
+
+// StoredAttachment addresses an attachment in the blob store. Only images have a thumbnail. UploadHash is the
+// hash of the file as uploaded, which differs from Hash for re-encoded images.
+type StoredAttachment struct {
+	Kind          AttachmentKind
+	Hash          string
+	UploadHash    string
+	ThumbnailHash *string
+	Caption       *string
+}
+
+func (a StoredAttachment) digested() DigestedAttachment {
+	return DigestedAttachment{UploadHash: a.UploadHash, Caption: a.Caption}
+}
+
+// EvidenceAttachment is a file of an evidence submission. Its content is served by the media endpoint at URL.
+type EvidenceAttachment struct {
+	ID            uuid.UUID      `db:"id"             json:"id"`
+	EvidenceID    uuid.UUID      `db:"evidence_id"    json:"evidenceID"`
+	Position      int            `db:"position"       json:"position"`
+	Kind          AttachmentKind `db:"kind"           json:"kind"`
+	MediaHash     string         `db:"media_hash"     json:"mediaHash"`
+	UploadHash    *string        `db:"upload_hash"    json:"uploadHash"`
+	ThumbnailHash *string        `db:"thumbnail_hash" json:"thumbnailHash"`
+	ContentType   string         `db:"content_type"   json:"contentType"`
+	Size          int64          `db:"size"           json:"size"`
+	Caption       *string        `db:"caption"        json:"caption"`
+	CreatedAt     time.Time      `db:"created_at"     json:"createdAt"`
+	URL           string         `json:"url"`
+	ThumbnailURL  *string        `json:"thumbnailURL"`
+}
+
+func NewEvidenceAttachment(evidenceID uuid.UUID, position int, a StoredAttachment) EvidenceAttachment {
+	attachment := EvidenceAttachment{
+		ID:            uuid.New(),
+		EvidenceID:    evidenceID,
+		Position:      position,
+		Kind:          a.Kind,
+		MediaHash:     a.Hash,
+		ThumbnailHash: a.ThumbnailHash,
+		Caption:       a.Caption,
+		CreatedAt:     time.Now(),
+	}
+	if a.UploadHash != "" {
+		attachment.UploadHash = &a.UploadHash
+	}
+	return attachment
+}
+
+// SetURLs points the attachment at the media endpoint.
+func (a *EvidenceAttachment) SetURLs() {
+	a.URL = MediaURL(a.MediaHash)
+	a.ThumbnailURL = nil
+	if a.ThumbnailHash != nil {
+		a.ThumbnailURL = new(MediaURL(*a.ThumbnailHash))
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClassifyAttachment(t *testing.T) {
+	var pngData bytes.Buffer
+	pngData.WriteString("\x89PNG\r\n\x1a\n")
+	mp4 := []byte("\x00\x00\x00\x10ftypmp42\x00\x00\x00\x00")
+
+	for name, tc := range map[string]struct {
+		data        []byte
+		kind        AttachmentKind
+		contentType string
+	}{
+		"png":  {pngData.Bytes(), AttachmentKindImage, "image/png"},
+		"mp4":  {mp4, AttachmentKindVideo, "video/mp4"},
+		"webm": {[]byte("\x1a\x45\xdf\xa3webm"), AttachmentKindVideo, "video/webm"},
+		"pdf":  {[]byte("%PDF-1.7\n"), AttachmentKindPDF, "application/pdf"},
+		"text": {[]byte("[12.05.2026 10:01] alice: I paid"), AttachmentKindText, "text/plain; charset=utf-8"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			kind, contentType, err := ClassifyAttachment(tc.data)
+			if err != nil || kind != tc.kind || contentType != tc.contentType {
+				t.Fatalf("expected %s %s, got %s %s: %v", tc.kind, tc.contentType, kind, contentType, err)
+			}
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		data []byte
+		err  error
+	}{
+		"empty":      {nil, ErrAttachmentValidation},
+		"html":       {[]byte("<!DOCTYPE html><script>alert(1)</script>"), ErrUnsupportedAttachment},
+		"binary":     {[]byte{0x00, 0x01, 0x02, 0x03}, ErrUnsupportedAttachment},
+		"large text": {bytes.Repeat([]byte("a"), int(AttachmentKindText.MaxSize())+1), ErrAttachmentTooLarge},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ClassifyAttachment(tc.data); !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseCaption(t *testing.T) {
+	if caption, err := ParseCaption("  "); err != nil || caption != nil {
+		t.Fatalf("expected no caption, got %v: %v", caption, err)
+	}
+	if caption, err := ParseCaption(" receipt "); err != nil || *caption != "receipt" {
+		t.Fatalf("expected a trimmed caption, got %v: %v", caption, err)
+	}
+	// The limit counts characters, not bytes.
+	if _, err := ParseCaption(strings.Repeat("я", MaxCaptionLength)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ParseCaption(strings.Repeat("я", MaxCaptionLength+1)); !errors.Is(err, ErrAttachmentValidation) {
+		t.Fatalf("expected ErrAttachmentValidation, got %v", err)
+	}
+}
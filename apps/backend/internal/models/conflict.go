@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrConflictPolicyValidation = errors.New("invalid conflict-of-interest policy")
+
+// ConflictPolicy decides who has a conflict of interest with the parties of a dispute and can't sit on its jury.
+type ConflictPolicy struct {
+	// DisputeWindowDays is how far back a dispute with either party counts.
+	DisputeWindowDays int `db:"dispute_window_days" json:"disputeWindowDays"`
+	// CoJurorWindowDays is how far back investigations shared with either party as co-jurors are counted.
+	CoJurorWindowDays int `db:"co_juror_window_days" json:"coJurorWindowDays"`
+	// CoJurorLimit is how many shared investigations within the window make a co-juror too close to a party.
+	CoJurorLimit int       `db:"co_juror_limit" json:"coJurorLimit"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// DefaultConflictPolicy is used until an admin sets a policy.
+var DefaultConflictPolicy = ConflictPolicy{DisputeWindowDays: 180, CoJurorWindowDays: 90, CoJurorLimit: 3}
+
+func (p ConflictPolicy) Validate() error {
+	if p.DisputeWindowDays < 0 || p.CoJurorWindowDays < 0 {
+		return fmt.Errorf("%w: windows can't be negative", ErrConflictPolicyValidation)
+	}
+	if p.CoJurorLimit < 1 {
+		return fmt.Errorf("%w: co-juror limit must be positive", ErrConflictPolicyValidation)
+	}
+	return nil
+}
+
+// DisputesSince is the start of the window in which disputes with a party count.
+func (p ConflictPolicy) DisputesSince(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.DisputeWindowDays)
+}
+
+// CoJurorsSince is the start of the window in which shared investigations count.
+func (p ConflictPolicy) CoJurorsSince(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.CoJurorWindowDays)
+}
+
+// ConflictReason tells why a user was kept off a jury.
+type ConflictReason string
+
+const (
+	// ConflictReasonParty is a participant of the dispute itself.
+	ConflictReasonParty ConflictReason = "party"
+	// ConflictReasonDisputePartner had a dispute with a party within the policy window.
+	ConflictReasonDisputePartner ConflictReason = "dispute_partner"
+	// ConflictReasonCoJuror shared at least the policy limit of investigations with a party as co-jurors.
+	ConflictReasonCoJuror ConflictReason = "frequent_co_juror"
+	// ConflictReasonBlocked blocked a party or was blocked by one.
+	ConflictReasonBlocked ConflictReason = "blocked"
+)
+
+// JuryExclusion is a user kept off the jury of an investigation because of a conflict with PartyID.
+type JuryExclusion struct {
+	InvestigationID uuid.UUID      `db:"investigation_id"`
+	UserID          uuid.UUID      `db:"user_id"`
+	PartyID         uuid.UUID      `db:"party_id"`
+	Reason          ConflictReason `db:"reason"`
+	CreatedAt       time.Time      `db:"created_at"`
+}
+
+// NewJuryExclusion keeps the user off the jury of the investigation because of a conflict with the party.
+func NewJuryExclusion(invID, userID, partyID uuid.UUID, reason ConflictReason) JuryExclusion {
+	return JuryExclusion{
+		InvestigationID: invID,
+		UserID:          userID,
+		PartyID:         partyID,
+		Reason:          reason,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// JuryExclusionCard is an entry of the exclusion report of an investigation.
+type JuryExclusionCard struct {
+	Username  string         `db:"username" json:"username"`
+	Party     string         `db:"party" json:"party"`
+	Reason    ConflictReason `db:"reason" json:"reason"`
+	CreatedAt time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// JurorBlock is a user who blocked another one from judging their disputes.
+type JurorBlock struct {
+	UserID        uuid.UUID `db:"user_id"`
+	BlockedUserID uuid.UUID `db:"blocked_user_id"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+type JurorBlockReq struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// JurorBlockCard is a user the actor blocked from judging their disputes.
+type JurorBlockCard struct {
+	Username  string    `db:"username" json:"username"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConflictPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy ConflictPolicy
+		valid  bool
+	}{
+		{name: "default", policy: DefaultConflictPolicy, valid: true},
+		{name: "windows off", policy: ConflictPolicy{CoJurorLimit: 1}, valid: true},
+		{name: "negative window", policy: ConflictPolicy{DisputeWindowDays: -1, CoJurorLimit: 1}},
+		{name: "zero co-juror limit", policy: ConflictPolicy{DisputeWindowDays: 30}},
+	}
+	for _, c := range cases {
+		err := c.policy.Validate()
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, ErrConflictPolicyValidation) {
+			t.Errorf("%s: expected a validation error, got %v", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"math/big"
+	"time"
+)
+
+// Opcodes of the contract messages the web app asks the wallet to sign, see blockchain/contracts.
+const (
+	OpCreateBet       uint32 = 0x1f6f3de1
+	OpAccept          uint32 = 0x59b045f8
+	OpCancel          uint32 = 0x368dde63
+	OpClaim           uint32 = 0xd1f45f36
+	OpVoteResult      uint32 = 0x75caa6a0
+	OpFinalize        uint32 = 0x9e2071c5
+	OpProvideEvidence uint32 = 0x2d172ca4
+	OpJurorVote       uint32 = 0x5f0d6f2a
+)
+
+// Getters of the bet contract.
+const (
+	// InvestigationAddressGetter returns the address of the investigation of the bet.
+	InvestigationAddressGetter = "investigationAddress"
+	// StakeWithDepositGetter returns the stake and the deposit each side pays in, as fixed when the bet was funded.
+	StakeWithDepositGetter = "stakeWithDeposit"
+)
+
+// RequiredVotesCountGetter of the investigation contract returns how many juror votes it waits for before it
+// resolves; the bet sets it to one per minimum juror reward its deposit pays.
+const RequiredVotesCountGetter = "requiredVotesCount"
+
+// MinDepositGetter of the BetMaster contract returns the smallest deposit it takes from a bet.
+const MinDepositGetter = "minDeposit"
+
+// BetDeposit is the deposit BetMaster takes on top of the stake amountNano: a tenth of the stake, but no less than
+// minDepositNano.
+func BetDeposit(amountNano, minDepositNano int64) int64 {
+	return max(amountNano/10, minDepositNano)
+}
+
+// Values of the VoteResult and JurorVote message fields.
+const (
+	VoteResultLose uint64 = 0
+	VoteResultWin  uint64 = 1
+
+	JurorVoteP1   uint64 = 1
+	JurorVoteP2   uint64 = 2
+	JurorVoteDraw uint64 = 3
+)
+
+// ExpectedMessage describes the contract call the signed wallet message of an operation must carry.
+type ExpectedMessage struct {
+	// Destination is the contract the message is sent to. With DestinationGetter set, the message is sent to
+	// the address that getter of Destination returns instead.
+	Destination       string
+	DestinationGetter string
+	// Opcodes lists the accepted opcodes of the message body.
+	Opcodes []uint32
+	// Fields follow the opcode in the body, in layout order. Only the leading fields up to the last checked one
+	// need to be listed.
+	Fields []MessageField
+	// ValueNano is the exact amount the message carries; zero leaves it unchecked. With ValueGetter set, the
+	// amount is what that getter of the destination returns instead.
+	ValueNano   int64
+	ValueGetter string
+	// Preceding lists the calls the wallet sends ahead of the checked one in the same external message, in this
+	// order; a preceding call without a Destination goes to the destination of the checked one. With
+	// PrecedingOptional set, the wallet may also send the checked call alone.
+	Preceding         []ExpectedMessage
+	PrecedingOptional bool
+	// Wallet is the wallet that must have signed the message; empty leaves it unchecked.
+	Wallet string
+}
+
+// CreateBetMessage is the CreateBet call that has betMaster deploy a bet funded with valueNano and settling at
+// endsAt.
+func CreateBetMessage(betMaster string, valueNano int64, endsAt time.Time) ExpectedMessage {
+	resultDeadline := uint64(endsAt.Unix())
+	return ExpectedMessage{
+		Destination: betMaster,
+		Opcodes:     []uint32{OpCreateBet},
+		Fields: []MessageField{
+			{Name: "id", Bits: 128},
+			{Name: "resultDeadline", Bits: 32, Value: &resultDeadline},
+		},
+		ValueNano: valueNano,
+	}
+}
+
+// ProvideEvidenceMessage is the ProvideEvidence call to the investigation of bet that commits the evidence
+// digest.
+func ProvideEvidenceMessage(bet string, digest *big.Int) ExpectedMessage {
+	return ExpectedMessage{
+		Destination:       bet,
+		DestinationGetter: InvestigationAddressGetter,
+		Opcodes:           []uint32{OpProvideEvidence},
+		Fields:            []MessageField{{Name: "hash", Bits: 256, BigValue: digest}},
+	}
+}
+
+// MessageField is an unsigned integer field of a message body. Fields wider than 64 bits are checked against
+// BigValue instead of Value; fields with neither are skipped.
+type MessageField struct {
+	Name     string
+	Bits     uint
+	Value    *uint64
+	BigValue *big.Int
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+)
+
+// EvidenceDigestAlgorithm names the canonical evidence encoding EvidenceDigest hashes:
+//
+//	"safe-disputes/evidence/v1" 0x00
+//	uint32 length, description bytes
+//	uint32 attachment count
+//	for each attachment, in order: 32-byte SHA-256 of the uploaded file, uint32 length, caption bytes
+//
+// Integers are big-endian; captions are trimmed, and an attachment without a caption has an empty one. The
+// digest is committed on-chain as the hash of the ProvideEvidence message.
+const EvidenceDigestAlgorithm = "sha256:safe-disputes/evidence/v1"
+
+const evidenceDigestDomain = "safe-disputes/evidence/v1"
+
+// DigestedAttachment is what an attachment contributes to the evidence digest.
+type DigestedAttachment struct {
+	UploadHash string
+	Caption    *string
+}
+
+// EvidenceDigest returns the hex SHA-256 of the canonical encoding of the evidence. Attachments are addressed by
+// the hash of the file as uploaded, before images are re-encoded, so the client can compute the digest before
+// signing.
+func EvidenceDigest(description string, attachments []DigestedAttachment) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(evidenceDigestDomain)
+	buf.WriteByte(0)
+	writeDigestField(&buf, description)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(attachments)))
+	for i, a := range attachments {
+		hash, err := hex.DecodeString(a.UploadHash)
+		if err != nil || len(hash) != sha256.Size {
+			return "", fmt.Errorf("%w: attachment %d has no valid upload hash", ErrAttachmentValidation, i+1)
+		}
+		buf.Write(hash)
+		var caption string
+		if a.Caption != nil {
+			caption = *a.Caption
+		}
+		writeDigestField(&buf, caption)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func writeDigestField(buf *bytes.Buffer, s string) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+// DigestInt is the digest as the uint256 the contract stores.
+func DigestInt(digest string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(digest, 16)
+	if !ok || len(digest) != hex.EncodedLen(sha256.Size) {
+		return nil, fmt.Errorf("invalid digest %q", digest)
+	}
+	return n, nil
+}
+
+// EvidenceVerification recomputes the digest of stored evidence, so jurors can check it against the hash its
+// party committed on-chain. Files stored as uploaded can be checked against their upload hashes as well; images are
+// re-encoded on upload, so only the hash of the original is kept.
+type EvidenceVerification struct {
+	EvidenceID  uuid.UUID            `json:"evidenceID"`
+	Algorithm   string               `json:"algorithm"`
+	Description string               `json:"description"`
+	Attachments []EvidenceAttachment `json:"attachments"`
+	// ContentHash is the hash of the ProvideEvidence message the evidence was submitted with. Evidence submitted
+	// before digests has none, and can't be verified.
+	ContentHash  *string `json:"contentHash"`
+	ComputedHash *string `json:"computedHash"`
+	Verified     bool    `json:"verified"`
+}
+
+func NewEvidenceVerification(e Evidence) EvidenceVerification {
+	v := EvidenceVerification{
+		EvidenceID:  e.ID,
+		Algorithm:   EvidenceDigestAlgorithm,
+		Description: e.Description,
+		Attachments: e.Attachments,
+		ContentHash: e.ContentHash,
+	}
+	if v.Attachments == nil {
+		v.Attachments = []EvidenceAttachment{}
+	}
+	digested := make([]DigestedAttachment, 0, len(e.Attachments))
+	for _, a := range e.Attachments {
+		if a.UploadHash == nil {
+			return v
+		}
+		digested = append(digested, DigestedAttachment{UploadHash: *a.UploadHash, Caption: a.Caption})
+	}
+	computed, err := EvidenceDigest(e.Description, digested)
+	if err != nil {
+		return v
+	}
+	v.ComputedHash = &computed
+	v.Verified = e.ContentHash != nil && *e.ContentHash == computed
+	return v
+}
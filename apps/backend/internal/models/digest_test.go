@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEvidenceDigest(t *testing.T) {
+	attachments := []DigestedAttachment{
+		{UploadHash: MediaHash([]byte("first file")), Caption: new("receipt")},
+		{UploadHash: MediaHash([]byte("second file"))},
+	}
+
+	// The vector pins the encoding clients reproduce before signing.
+	digest, err := EvidenceDigest("Я заплатил", attachments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if digest != "317f581e0503bc67a57f3d33a1f7660d85f7daa2104c7464e78c792e97882296" {
+		t.Fatalf("unexpected digest %s", digest)
+	}
+	empty, _ := EvidenceDigest("", nil)
+	if empty != "7c0790eaa6e4a02a4dd24d72afbfe5046dc5330768cc11633732432969d4b84c" {
+		t.Fatalf("unexpected digest of empty evidence %s", empty)
+	}
+
+	swapped, _ := EvidenceDigest("Я заплатил", []DigestedAttachment{attachments[1], attachments[0]})
+	if swapped == digest {
+		t.Fatal("expected the digest to depend on the attachment order")
+	}
+	// Length prefixes keep text from moving between fields unnoticed.
+	moved, _ := EvidenceDigest("Я заплатилreceipt", []DigestedAttachment{
+		{UploadHash: attachments[0].UploadHash}, attachments[1],
+	})
+	if moved == digest {
+		t.Fatal("expected the digest to depend on field boundaries")
+	}
+
+	_, err = EvidenceDigest("x", []DigestedAttachment{{UploadHash: "ab12"}})
+	if !errors.Is(err, ErrAttachmentValidation) {
+		t.Fatalf("expected ErrAttachmentValidation, got %v", err)
+	}
+	if n, err := DigestInt(digest); err != nil || n.Text(16) != digest {
+		t.Fatalf("expected the digest as an integer, got %v: %v", n, err)
+	}
+}
+
+func TestNewEvidenceVerification(t *testing.T) {
+	upload := MediaHash([]byte("file"))
+	opts := EvidenceOpts{
+		Description: "paid",
+		Attachments: []StoredAttachment{{Kind: AttachmentKindPDF, Hash: upload, UploadHash: upload}},
+	}
+	var err error
+	if opts.ContentHash, err = opts.Digest(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	evidence := NewEvidence(uuid.New(), opts)
+
+	if v := NewEvidenceVerification(evidence); !v.Verified || *v.ComputedHash != opts.ContentHash ||
+		v.Algorithm != EvidenceDigestAlgorithm {
+		t.Fatalf("expected the evidence verified, got %+v", v)
+	}
+
+	tampered := evidence
+	tampered.Description = "not paid"
+	if v := NewEvidenceVerification(tampered); v.Verified || *v.ComputedHash == opts.ContentHash {
+		t.Fatalf("expected tampered evidence unverified, got %+v", v)
+	}
+
+	legacy := NewEvidence(uuid.New(), EvidenceOpts{
+		Description: "paid",
+		Attachments: []StoredAttachment{{Kind: AttachmentKindImage, Hash: upload}},
+	})
+	if v := NewEvidenceVerification(legacy); v.Verified || v.ComputedHash != nil || v.ContentHash != nil {
+		t.Fatalf("expected evidence without digest unverified, got %+v", v)
+	}
+}
@@ -10,40 +10,61 @@ import (
 
 var ErrDisputeValidation = errors.New("dispute validation error")
 
+// DisputeVisibility tells who can accept a dispute. A direct dispute names its opponent upfront; public and link
+// disputes are open challenges that anyone can accept, but only public ones are listed.
+type DisputeVisibility string
+
+const (
+	DisputeVisibilityDirect DisputeVisibility = "direct"
+	DisputeVisibilityPublic DisputeVisibility = "public"
+	DisputeVisibilityLink   DisputeVisibility = "link"
+)
+
+// IsOpen reports whether the dispute was created without an opponent.
+func (d Dispute) IsOpen() bool {
+	return d.Visibility == DisputeVisibilityPublic || d.Visibility == DisputeVisibilityLink
+}
+
 type DisputeCard struct {
-	ID           string    `db:"id"            json:"id"`
-	Title        string    `db:"title"         json:"title"`
-	CreatedAt    time.Time `db:"created_at"    json:"createdAt"`
-	AmountNano   int64     `db:"amount_nano"   json:"amountNano"`
-	EndsAt       time.Time `db:"ends_at"       json:"endsAt"`
-	NextDeadline time.Time `db:"next_deadline" json:"nextDeadline"`
-	Opponent     string    `db:"opponent"      json:"opponent"`
-	PhotoUrl     *string   `db:"photo_url"     json:"photoUrl"`
-	Result       Result    `db:"result"        json:"result"`
-	IsWin        bool      `db:"is_win"        json:"isWin"`       
-	IsClaimable  bool      `db:"is_claimable"  json:"isClaimable"`
-	IsUnread     bool      `db:"is_unread"     json:"isUnread"`
+	ID            string    `db:"id"             json:"id"`
+	Title         string    `db:"title"          json:"title"`
+	CreatedAt     time.Time `db:"created_at"     json:"createdAt"`
+	AmountNano    int64     `db:"amount_nano"    json:"amountNano"`
+	EndsAt        time.Time `db:"ends_at"        json:"endsAt"`
+	NextDeadline  time.Time `db:"next_deadline"  json:"nextDeadline"`
+	Opponent      *string   `db:"opponent"       json:"opponent"`
+	PhotoUrl      *string   `db:"photo_url"      json:"photoUrl"`
+	Result        Result    `db:"result"         json:"result"`
+	IsWin         bool      `db:"is_win"         json:"isWin"`
+	IsClaimable   bool      `db:"is_claimable"   json:"isClaimable"`
+	IsUnread      bool      `db:"is_unread"      json:"isUnread"`
+	ThumbnailHash *string   `db:"thumbnail_hash" json:"thumbnailHash"`
 }
 
 type DisputeDetails struct {
-	ID              string    `db:"id"               json:"id"`
-	Title           string    `db:"title"            json:"title"`
-	Description     string    `db:"description"      json:"description"`
-	CreatedAt       time.Time `db:"created_at"       json:"createdAt"`
-	UpdatedAt       time.Time `db:"updated_at"       json:"updatedAt"`
-	Cryptocurrency  string    `db:"cryptocurrency"   json:"cryptocurrency"`
-	AmountNano      int64     `db:"amount_nano"      json:"amountNano"`
-	DepositNano     int64     `db:"deposit_nano"     json:"depositNano"`
-	ImageData       []byte    `db:"image_data"       json:"imageData"`
-	ImageType       *string   `db:"image_type"       json:"imageType"`
-	ContractAddress string    `db:"contract_address" json:"contractAddress"`
-	EndsAt          time.Time `db:"ends_at"          json:"endsAt"`
-	NextDeadline    time.Time `db:"next_deadline"    json:"nextDeadline"`
-	Opponent        string    `db:"opponent"         json:"opponent"`
-	PhotoUrl        *string   `db:"photo_url"        json:"photoUrl"`
-	Result          Result    `db:"result"           json:"result"`
-	IsWin           bool      `db:"is_win"           json:"isWin"`       
-	IsClaimable     bool      `db:"is_claimable"     json:"isClaimable"`
+	ID              string            `db:"id"               json:"id"`
+	Title           string            `db:"title"            json:"title"`
+	Description     string            `db:"description"      json:"description"`
+	CreatedAt       time.Time         `db:"created_at"       json:"createdAt"`
+	UpdatedAt       time.Time         `db:"updated_at"       json:"updatedAt"`
+	Cryptocurrency  string            `db:"cryptocurrency"   json:"cryptocurrency"`
+	AmountNano      int64             `db:"amount_nano"      json:"amountNano"`
+	DepositNano     int64             `db:"deposit_nano"     json:"depositNano"`
+	ImageHash       *string           `db:"image_hash"       json:"imageHash"`
+	ImageType       *string           `db:"image_type"       json:"imageType"`
+	ThumbnailHash   *string           `db:"thumbnail_hash"   json:"thumbnailHash"`
+	ContractAddress string            `db:"contract_address" json:"contractAddress"`
+	EndsAt          time.Time         `db:"ends_at"          json:"endsAt"`
+	NextDeadline    time.Time         `db:"next_deadline"    json:"nextDeadline"`
+	Visibility      DisputeVisibility `db:"visibility"       json:"visibility"`
+	Opponent        *string           `db:"opponent"         json:"opponent"`
+	PhotoUrl        *string           `db:"photo_url"        json:"photoUrl"`
+	Result          Result            `db:"result"           json:"result"`
+	IsWin           bool              `db:"is_win"           json:"isWin"`
+	IsClaimable     bool              `db:"is_claimable"     json:"isClaimable"`
+	Invitee         *string           `db:"invitee"          json:"invitee"`
+	InviteToken     *string           `db:"invite_token"     json:"inviteToken"`
+	TermsVersion    int               `db:"terms_version"    json:"termsVersion"`
 }
 
 type DisputeListOpts struct {
@@ -54,17 +75,52 @@ type DisputeListOpts struct {
 	Cursor  string
 }
 
+// OpenDisputeCard is an open challenge as seen by users who may accept it.
+type OpenDisputeCard struct {
+	ID              string            `db:"id"               json:"id"`
+	Title           string            `db:"title"            json:"title"`
+	Description     string            `db:"description"      json:"description"`
+	CreatedAt       time.Time         `db:"created_at"       json:"createdAt"`
+	AmountNano      int64             `db:"amount_nano"      json:"amountNano"`
+	DepositNano     int64             `db:"deposit_nano"     json:"depositNano"`
+	ContractAddress string            `db:"contract_address" json:"contractAddress"`
+	EndsAt          time.Time         `db:"ends_at"          json:"endsAt"`
+	Visibility      DisputeVisibility `db:"visibility"       json:"visibility"`
+	Creator         string            `db:"creator"          json:"creator"`
+	PhotoUrl        *string           `db:"photo_url"        json:"photoUrl"`
+	ThumbnailHash   *string           `db:"thumbnail_hash"   json:"thumbnailHash"`
+}
+
+type OpenDisputeListOpts struct {
+	Limit  int
+	Cursor string
+}
+
+// CreateDisputeReq without an Opponent creates an open challenge with the requested Visibility, public by default.
 type CreateDisputeReq struct {
 	Title           string `form:"title"           binding:"required"`
 	Description     string `form:"description"     binding:"required"`
-	Opponent        string `form:"opponent"        binding:"required"`
+	Opponent        string `form:"opponent"`
+	Visibility      string `form:"visibility"`
 	AmountNano      string `form:"amountNano"      binding:"required"`
 	DepositNano     string `form:"depositNano"     binding:"required"`
 	EndsAt          string `form:"endsAt"          binding:"required"`
 	ContractAddress string `form:"contractAddress" binding:"required"`
 	Boc             string `form:"boc"             binding:"required"`
-	ImageData       []byte
-	ImageType       string
+	// ImageData is the uploaded image until it's moved to the blob store; from then on it's addressed by ImageHash
+	// and its thumbnail by ThumbnailHash.
+	ImageData     []byte
+	ImageType     string
+	ImageHash     *string
+	ThumbnailHash *string
+}
+
+// SetImage replaces the uploaded image with the stored one.
+func (req *CreateDisputeReq) SetImage(img StoredImage) {
+	req.ImageData = nil
+	req.ImageType = img.ContentType
+	req.ImageHash = &img.Hash
+	req.ThumbnailHash = &img.ThumbnailHash
 }
 
 func NewDispute(opts CreateDisputeReq) (Dispute, error) {
@@ -83,6 +139,10 @@ func NewDispute(opts CreateDisputeReq) (Dispute, error) {
 	if !endsAt.After(time.Now()) {
 		return Dispute{}, fmt.Errorf("%w: EndsAt must be in the future", ErrDisputeValidation)
 	}
+	visibility, err := disputeVisibility(opts.Opponent, opts.Visibility)
+	if err != nil {
+		return Dispute{}, err
+	}
 
 	createdAt := time.Now()
 	d := Dispute{
@@ -94,13 +154,34 @@ func NewDispute(opts CreateDisputeReq) (Dispute, error) {
 		Cryptocurrency:  "TON",
 		AmountNano:      amountNano,
 		DepositNano:     depositNano,
-		ImageData:       opts.ImageData,
+		ImageHash:       opts.ImageHash,
+		ThumbnailHash:   opts.ThumbnailHash,
 		ContractAddress: opts.ContractAddress,
 		EndsAt:          endsAt,
 		NextDeadline:    endsAt,
+		Visibility:      visibility,
+		TermsVersion:    1,
 	}
 	if opts.ImageType != "" {
 		d.ImageType = &opts.ImageType
 	}
 	return d, nil
 }
+
+func disputeVisibility(opponent, raw string) (DisputeVisibility, error) {
+	visibility := DisputeVisibility(raw)
+	if opponent != "" {
+		if visibility != "" && visibility != DisputeVisibilityDirect {
+			return "", fmt.Errorf("%w: dispute with an opponent can't be %s", ErrDisputeValidation, visibility)
+		}
+		return DisputeVisibilityDirect, nil
+	}
+	switch visibility {
+	case "":
+		return DisputeVisibilityPublic, nil
+	case DisputeVisibilityPublic, DisputeVisibilityLink:
+		return visibility, nil
+	default:
+		return "", fmt.Errorf("%w: open dispute can't be %q", ErrDisputeValidation, raw)
+	}
+}
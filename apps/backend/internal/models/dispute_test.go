@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -61,3 +62,47 @@ func TestNewDisputeRejectsPastEndsAt(t *testing.T) {
 		t.Fatal("expected error for past endsAt")
 	}
 }
+
+func TestNewDisputeVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		opponent   string
+		visibility string
+		want       DisputeVisibility
+		wantErr    bool
+	}{
+		{name: "opponent makes direct dispute", opponent: "bob", want: DisputeVisibilityDirect},
+		{name: "open dispute is public by default", want: DisputeVisibilityPublic},
+		{name: "open dispute shared by link", visibility: "link", want: DisputeVisibilityLink},
+		{name: "opponent with public visibility", opponent: "bob", visibility: "public", wantErr: true},
+		{name: "open dispute can't be direct", visibility: "direct", wantErr: true},
+		{name: "unknown visibility", visibility: "friends", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispute, err := NewDispute(CreateDisputeReq{
+				Title:           "t",
+				Description:     "d",
+				Opponent:        tt.opponent,
+				Visibility:      tt.visibility,
+				AmountNano:      "100000000000",
+				DepositNano:     "20000000000",
+				EndsAt:          time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+				ContractAddress: "addr",
+				Boc:             "boc",
+			})
+			if tt.wantErr {
+				if !errors.Is(err, ErrDisputeValidation) {
+					t.Fatalf("expected ErrDisputeValidation, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dispute.Visibility != tt.want || dispute.IsOpen() != (tt.opponent == "") {
+				t.Fatalf("unexpected visibility %s, open=%t", dispute.Visibility, dispute.IsOpen())
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Steps of a dispute that are recorded in its history but don't go through the state machine.
+const (
+	DisputeActionCreate             DisputeAction = "create"
+	DisputeActionOpenInvestigation  DisputeAction = "open_investigation"
+	DisputeActionCloseInvestigation DisputeAction = "close_investigation"
+)
+
+// DisputeEvent is an entry of the append-only dispute history. ParticipantID is nil for events of the dispute as
+// a whole. ActorID and TxHash are filled in by the repository from the context when left empty.
+type DisputeEvent struct {
+	ID            uuid.UUID     `db:"id"`
+	DisputeID     uuid.UUID     `db:"dispute_id"`
+	ParticipantID *uuid.UUID    `db:"participant_id"`
+	ActorID       *uuid.UUID    `db:"actor_id"`
+	Action        DisputeAction `db:"action"`
+	Actor         Actor         `db:"actor"`
+	FromResult    *Result       `db:"from_result"`
+	ToResult      *Result       `db:"to_result"`
+	TxHash        *string       `db:"tx_hash"`
+	CreatedAt     time.Time     `db:"created_at"`
+}
+
+// DisputeEventCard is an entry of the dispute history as shown to its participants and jurors.
+type DisputeEventCard struct {
+	Action DisputeAction `db:"action" json:"action"`
+	Actor  Actor         `db:"actor" json:"actor"`
+	// Username is who acted; it is empty for deadlines and confirmations.
+	Username *string `db:"username" json:"username"`
+	// Participant is whose result changed; it is empty for events of the dispute as a whole.
+	Participant *string   `db:"participant" json:"participant"`
+	FromResult  *Result   `db:"from_result" json:"fromResult"`
+	ToResult    *Result   `db:"to_result" json:"toResult"`
+	TxHash      *string   `db:"tx_hash" json:"txHash"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// NewDisputeEvent returns an event of the dispute as a whole.
+func NewDisputeEvent(disputeID uuid.UUID, action DisputeAction, actor Actor) DisputeEvent {
+	return DisputeEvent{
+		ID:        uuid.New(),
+		DisputeID: disputeID,
+		Action:    action,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Event returns the event that moves the participant from its current result to to.
+func (p Participant) Event(action DisputeAction, actor Actor, to Result) DisputeEvent {
+	event := NewDisputeEvent(p.DisputeID, action, actor)
+	event.ParticipantID = &p.ID
+	event.FromResult = &p.Result
+	event.ToResult = &to
+	return event
+}
+
+// JoinEvent returns the event of the participant's user entering the dispute with its current result.
+func (p Participant) JoinEvent(action DisputeAction) DisputeEvent {
+	event := p.Event(action, ActorSelf, p.Result)
+	event.ActorID = &p.UserID
+	event.FromResult = nil
+	return event
+}
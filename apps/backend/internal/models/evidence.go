@@ -7,19 +7,57 @@ type EvidenceOpts struct {
 	Username    string
 	Boc         string
 	Description string
-	ImageData   []byte
-	ImageType   string
+	// Uploads are the files sent with the evidence, in order. They are moved to the blob store before the operation
+	// is recorded, and addressed by Attachments from then on.
+	Uploads     []AttachmentUpload `json:"-"`
+	Attachments []StoredAttachment
+	// ContentHash is the digest of the description and attachments, checked against the signed message.
+	ContentHash string
+	// ImageData, ImageType, ImageHash and ThumbnailHash carry the single image of operations recorded before
+	// attachments; see AttachLegacyImage.
+	ImageData     []byte
+	ImageType     string
+	ImageHash     *string
+	ThumbnailHash *string
 }
 
-func NewEvidence(participantID uuid.UUID, description string, imageData []byte, imageType string) Evidence {
+// AttachLegacyImage turns the single image of an operation recorded before attachments into its first attachment.
+func (opts *EvidenceOpts) AttachLegacyImage() {
+	if opts.ImageHash == nil {
+		return
+	}
+	img := StoredAttachment{Kind: AttachmentKindImage, Hash: *opts.ImageHash, ThumbnailHash: opts.ThumbnailHash}
+	opts.Attachments = append([]StoredAttachment{img}, opts.Attachments...)
+	opts.ImageData, opts.ImageType, opts.ImageHash, opts.ThumbnailHash = nil, "", nil, nil
+}
+
+// Digest computes the evidence digest of the description and the stored attachments.
+func (opts EvidenceOpts) Digest() (string, error) {
+	attachments := make([]DigestedAttachment, 0, len(opts.Attachments))
+	for _, a := range opts.Attachments {
+		attachments = append(attachments, a.digested())
+	}
+	return EvidenceDigest(opts.Description, attachments)
+}
+
+// SealedFor tells whether the evidence is hidden from the participant with viewerID; jurors view as uuid.Nil.
+// Evidence stays sealed until it is released, so no party reads the other side before submitting; its own party
+// always sees it.
+func (e Evidence) SealedFor(viewerID uuid.UUID) bool {
+	return e.ReleasedAt == nil && e.ParticipantID != viewerID
+}
+
+func NewEvidence(participantID uuid.UUID, opts EvidenceOpts) Evidence {
 	e := Evidence{
 		ID:            uuid.New(),
 		ParticipantID: participantID,
-		Description:   description,
-		ImageData:     imageData,
+		Description:   opts.Description,
+	}
+	if opts.ContentHash != "" {
+		e.ContentHash = &opts.ContentHash
 	}
-	if imageType != "" {
-		e.ImageType = &imageType
+	for i, a := range opts.Attachments {
+		e.Attachments = append(e.Attachments, NewEvidenceAttachment(e.ID, i, a))
 	}
 	return e
 }
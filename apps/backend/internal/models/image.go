@@ -0,0 +1,229 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxImagePixels bounds decoded images, so a small file can't expand into gigabytes of pixels.
+	MaxImagePixels = 40_000_000
+	// ThumbnailSize is the longest side of thumbnails shown in card lists.
+	ThumbnailSize = 320
+
+	ThumbnailContentType = "image/jpeg"
+
+	imageQuality     = 85
+	thumbnailQuality = 80
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrMalformedImage   = errors.New("malformed image")
+	ErrImageTooLarge    = errors.New("image is too large")
+)
+
+// ProcessedImage is an uploaded image re-encoded without its metadata, with its thumbnail.
+type ProcessedImage struct {
+	Data        []byte
+	ContentType string
+	Thumbnail   []byte
+}
+
+// StoredImage addresses a processed image and its thumbnail in the blob store.
+type StoredImage struct {
+	Hash          string
+	ThumbnailHash string
+	ContentType   string
+}
+
+// ProcessImage checks that data is a JPEG, PNG or GIF image by its content rather than by what the client claims.
+// The image is decoded and encoded again, which drops EXIF data such as the GPS position; JPEG orientation is
+// applied to the pixels first, so the image keeps its look. GIF animations are reduced to their first frame.
+func ProcessImage(data []byte) (ProcessedImage, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return ProcessedImage{}, fmt.Errorf("%w %s: JPEG, PNG or GIF expected", ErrUnsupportedImage, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("%w: %w", ErrMalformedImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return ProcessedImage{}, fmt.Errorf("%w: image has no pixels", ErrMalformedImage)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return ProcessedImage{}, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrImageTooLarge, cfg.Width, cfg.Height,
+			MaxImagePixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("%w: %w", ErrMalformedImage, err)
+	}
+
+	var out bytes.Buffer
+	switch contentType {
+	case "image/jpeg":
+		img = orient(img, jpegOrientation(data))
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: imageQuality})
+	case "image/png":
+		err = png.Encode(&out, img)
+	case "image/gif":
+		err = gif.Encode(&out, img, nil)
+	}
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	var thumb bytes.Buffer
+	if err = jpeg.Encode(&thumb, thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return ProcessedImage{}, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return ProcessedImage{Data: out.Bytes(), ContentType: contentType, Thumbnail: thumb.Bytes()}, nil
+}
+
+// thumbnail scales img down to fit size x size, averaging the pixels each thumbnail pixel covers. Transparent
+// areas turn white, since thumbnails are JPEG.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/b.Dx())
+		} else {
+			w, h = max(1, w*size/b.Dy()), size
+		}
+	}
+
+	src := image.NewRGBA(b)
+	draw.Draw(src, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, b, img, b.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+max((y+1)*b.Dy()/h, y*b.Dy()/h+1)
+		for x := range w {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+max((x+1)*b.Dx()/w, x*b.Dx()/w+1)
+			var r, g, bl, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.RGBAAt(sx, sy)
+					r, g, bl, n = r+uint32(c.R), g+uint32(c.G), bl+uint32(c.B), n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 0xff})
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, from 1 to 8, or 1 when it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			// Image data starts; metadata segments come before it.
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := range entries {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient turns img upright according to its EXIF orientation.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// Orientations 5 to 8 swap width and height.
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
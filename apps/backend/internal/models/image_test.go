@@ -0,0 +1,130 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	return img
+}
+
+// withExif inserts an APP1 segment with the orientation and a GPS IFD pointer right after the SOI marker.
+func withExif(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	for _, v := range []any{uint16(42), uint32(8), uint16(2),
+		uint16(0x0112), uint16(3), uint32(1), orientation, uint16(0),
+		uint16(0x8825), uint16(4), uint32(1), uint32(0),
+		uint32(0),
+	} {
+		if err := binary.Write(&tiff, binary.BigEndian, v); err != nil {
+			t.Fatalf("write exif: %v", err)
+		}
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessImage(t *testing.T) {
+	t.Run("re-encodes PNG and makes a thumbnail", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, testImage(640, 320)); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+
+		img, err := ProcessImage(buf.Bytes())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if img.ContentType != "image/png" {
+			t.Fatalf("expected image/png, got %s", img.ContentType)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(img.Thumbnail))
+		if err != nil || format != "jpeg" || cfg.Width != ThumbnailSize || cfg.Height != ThumbnailSize/2 {
+			t.Fatalf("expected a %dx%d JPEG thumbnail, got %s %+v %v", ThumbnailSize, ThumbnailSize/2, format, cfg, err)
+		}
+	})
+
+	t.Run("strips EXIF and applies the orientation", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		data := withExif(t, buf.Bytes(), 6)
+		if jpegOrientation(data) != 6 {
+			t.Fatalf("expected orientation 6, got %d", jpegOrientation(data))
+		}
+
+		img, err := ProcessImage(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if img.ContentType != "image/jpeg" || bytes.Contains(img.Data, []byte("Exif")) {
+			t.Fatalf("expected a JPEG without EXIF, got %s", img.ContentType)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(img.Data))
+		if err != nil || cfg.Width != 20 || cfg.Height != 40 {
+			t.Fatalf("expected the image turned upright to 20x40, got %+v %v", cfg, err)
+		}
+	})
+
+	t.Run("keeps small images at their size", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gif.Encode(&buf, testImage(10, 30), nil); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+
+		img, err := ProcessImage(buf.Bytes())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Thumbnail))
+		if err != nil || img.ContentType != "image/gif" || cfg.Width != 10 || cfg.Height != 30 {
+			t.Fatalf("expected a 10x30 thumbnail of a GIF, got %s %+v %v", img.ContentType, cfg, err)
+		}
+	})
+
+	t.Run("rejects bad files", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gif.Encode(&buf, testImage(1, 1), nil); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		huge := bytes.Clone(buf.Bytes())
+		binary.LittleEndian.PutUint16(huge[6:], 10000)
+		binary.LittleEndian.PutUint16(huge[8:], 10000)
+
+		for name, tc := range map[string]struct {
+			data []byte
+			want error
+		}{
+			"text":      {[]byte("hello, world"), ErrUnsupportedImage},
+			"pdf":       {[]byte("%PDF-1.7\n"), ErrUnsupportedImage},
+			"truncated": {append([]byte("\x89PNG\r\n\x1a\n"), 0, 0), ErrMalformedImage},
+			"too large": {huge, ErrImageTooLarge},
+		} {
+			if _, err := ProcessImage(tc.data); !errors.Is(err, tc.want) {
+				t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+			}
+		}
+	})
+}
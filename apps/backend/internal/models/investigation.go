@@ -1,6 +1,9 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +16,56 @@ const (
 	InvestigationStatusPassed  InvestigationStatus = "passed"
 )
 
+// InvestigationDuration is how long jurors have to vote before the investigation is closed with the votes cast.
+const InvestigationDuration = 3 * time.Hour
+
+// InvestigationCommitDuration is how long jurors of a commit-reveal investigation have to commit their votes; the
+// rest of InvestigationDuration is left to reveal them.
+const InvestigationCommitDuration = 2 * time.Hour
+
+// InvestigationRevealDuration is how long jurors have to reveal the votes they committed.
+const InvestigationRevealDuration = InvestigationDuration - InvestigationCommitDuration
+
+// VotingMode tells how jurors vote on an investigation.
+type VotingMode string
+
+const (
+	// VotingModeOpen counts votes as they come and shows the tallies to every juror.
+	VotingModeOpen VotingMode = "open"
+	// VotingModeCommitReveal has jurors commit a hash of their vote and salt first and reveal the vote only after
+	// the commit phase closes, so nobody can follow the majority. Tallies stay hidden until the reveal phase.
+	VotingModeCommitReveal VotingMode = "commit_reveal"
+)
+
+// VotingPhase is what jurors can do with an investigation at the moment.
+type VotingPhase string
+
+const (
+	// VotingPhaseOpen takes votes of an open investigation.
+	VotingPhaseOpen VotingPhase = "open"
+	// VotingPhaseCommit takes vote commitments.
+	VotingPhaseCommit VotingPhase = "commit"
+	// VotingPhaseReveal takes votes matching the commitments.
+	VotingPhaseReveal VotingPhase = "reveal"
+	// VotingPhaseClosed takes nothing anymore.
+	VotingPhaseClosed VotingPhase = "closed"
+)
+
+// VoteCommitment is the hex SHA-256 of "<vote>:<salt>" a juror commits to in the commit phase.
+func VoteCommitment(vote, salt string) string {
+	sum := sha256.Sum256([]byte(vote + ":" + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseVoteCommitment checks that raw is a hex SHA-256 and returns it lowercased.
+func ParseVoteCommitment(raw string) (string, error) {
+	b, err := hex.DecodeString(raw)
+	if err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("commitment must be a hex sha256, got %q", raw)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 type InvestigationCard struct {
 	ID        string              `db:"id"         json:"id"`
 	DisputeID string              `db:"dispute_id" json:"disputeID"`
@@ -38,6 +91,17 @@ type InvestigationDetails struct {
 	Title     string              `db:"title"      json:"title"`
 	Result    InvestigationResult `db:"result"     json:"result"`
 	Vote      string              `db:"vote"       json:"vote"`
+
+	VotingMode   VotingMode  `db:"voting_mode"    json:"votingMode"`
+	CommitEndsAt *time.Time  `db:"commit_ends_at" json:"commitEndsAt"`
+	Phase        VotingPhase `json:"phase"`
+	// Committed tells whether the juror committed a vote in the commit phase.
+	Committed bool `db:"committed" json:"committed"`
+
+	VerdictPolicy VerdictPolicy `db:"verdict_policy" json:"verdictPolicy"`
+	Round         int           `db:"round"          json:"round"`
+	// Verdict explains how the jury decided once the investigation is closed.
+	Verdict *Verdict `db:"verdict" json:"verdict"`
 }
 
 type InvestigationListOpts struct {
@@ -47,21 +111,62 @@ type InvestigationListOpts struct {
 	Cursor string
 }
 
+// PhaseAt is Investigation.PhaseAt for the investigation the details describe.
+func (d InvestigationDetails) PhaseAt(now time.Time) VotingPhase {
+	return Investigation{Status: d.Status, VotingMode: d.VotingMode, CommitEndsAt: d.CommitEndsAt}.PhaseAt(now)
+}
+
+// HideTallies blanks the vote counts while they could still sway jurors.
+func (d *InvestigationDetails) HideTallies() {
+	d.P1, d.P2, d.Draw = 0, 0, 0
+}
+
 type InvestigationUpdateOpts struct {
-	ID     uuid.UUID            `json:"id"`
-	Status *InvestigationStatus `json:"status"`
-	P1     *int
-	P2     *int
-	Draw   *int
-	Total  *int
+	ID           uuid.UUID            `json:"id"`
+	Status       *InvestigationStatus `json:"status"`
+	P1           *int
+	P2           *int
+	Draw         *int
+	Total        *int
+	EndsAt       *time.Time
+	CommitEndsAt *time.Time
+	Round        *int
+	Verdict      *Verdict
+}
+
+// NewInvestigation opens an investigation under the policy. Jurors vote openly until EndsAt unless the policy asks
+// for commit-reveal voting, where they commit for InvestigationCommitDuration and reveal until EndsAt. It is decided
+// once requiredVotes jurors voted, as the investigation contract is.
+func NewInvestigation(disputeID uuid.UUID, title string, requiredVotes int, policy VerdictPolicy) Investigation {
+	now := time.Now()
+	investigation := Investigation{
+		ID:            uuid.New(),
+		DisputeID:     disputeID,
+		Total:         requiredVotes,
+		Status:        InvestigationStatusCurrent,
+		EndsAt:        now.Add(InvestigationDuration),
+		Title:         title,
+		VotingMode:    VotingModeOpen,
+		VerdictPolicy: policy,
+		Round:         1,
+	}
+	if policy.CommitReveal {
+		investigation.VotingMode = VotingModeCommitReveal
+		investigation.CommitEndsAt = new(now.Add(InvestigationCommitDuration))
+	}
+	return investigation
 }
 
-func NewInvestigation(disputeID uuid.UUID, title string) Investigation {
-	return Investigation{
-		ID:        uuid.New(),
-		DisputeID: disputeID,
-		Status:    InvestigationStatusCurrent,
-		EndsAt:    time.Now().Add(3 * time.Hour),
-		Title:     title,
+// PhaseAt returns the voting phase of the investigation at now.
+func (i Investigation) PhaseAt(now time.Time) VotingPhase {
+	switch {
+	case i.Status == InvestigationStatusPassed:
+		return VotingPhaseClosed
+	case i.VotingMode != VotingModeCommitReveal:
+		return VotingPhaseOpen
+	case i.CommitEndsAt != nil && now.Before(*i.CommitEndsAt):
+		return VotingPhaseCommit
+	default:
+		return VotingPhaseReveal
 	}
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestInvestigationPhaseAt(t *testing.T) {
+	now := time.Now()
+	if got := NewInvestigation(uuid.New(), "title", 3, DefaultVerdictPolicy).PhaseAt(now); got != VotingPhaseOpen {
+		t.Fatalf("expected a new investigation to take votes openly by default, got %s", got)
+	}
+
+	inv := NewInvestigation(uuid.New(), "title", 3, VerdictPolicy{Quorum: 1, CommitReveal: true})
+	if got := inv.PhaseAt(now); got != VotingPhaseCommit {
+		t.Fatalf("expected a new investigation in the commit phase, got %s", got)
+	}
+	if got := inv.PhaseAt(*inv.CommitEndsAt); got != VotingPhaseReveal {
+		t.Fatalf("expected the reveal phase after the commit phase, got %s", got)
+	}
+	inv.Status = InvestigationStatusPassed
+	if got := inv.PhaseAt(now); got != VotingPhaseClosed {
+		t.Fatalf("expected a passed investigation closed, got %s", got)
+	}
+	details := InvestigationDetails{VotingMode: inv.VotingMode, CommitEndsAt: inv.CommitEndsAt}
+	if got := details.PhaseAt(now); got != VotingPhaseCommit {
+		t.Fatalf("expected the details in the phase of their investigation, got %s", got)
+	}
+}
+
+func TestParseVoteCommitment(t *testing.T) {
+	commitment := VoteCommitment("p1", "salt")
+	if got, err := ParseVoteCommitment(strings.ToUpper(commitment)); err != nil || got != commitment {
+		t.Fatalf("expected %s, got %s, %v", commitment, got, err)
+	}
+	for _, raw := range []string{"", "zz", commitment[:10]} {
+		if _, err := ParseVoteCommitment(raw); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+	if VoteCommitment("p1", "salt") == VoteCommitment("p2", "salt") {
+		t.Fatal("expected different votes to commit differently")
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	// InvitationStatusRejected is an invitation the dispute could no longer take the invitee for.
+	InvitationStatusRejected InvitationStatus = "rejected"
+	InvitationStatusExpired  InvitationStatus = "expired"
+)
+
+// InvitationTTL is how long an opponent who hasn't registered yet can join a dispute they were invited to.
+const InvitationTTL = 7 * 24 * time.Hour
+
+// NewInvitation invites the Telegram user username to a dispute. The invitation also carries a random token,
+// so the creator can share it as a link when the username is unknown or mistyped. It never outlives the dispute.
+func NewInvitation(disputeID, creatorID uuid.UUID, username string, endsAt time.Time) (Invitation, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return Invitation{}, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	createdAt := time.Now()
+	expiresAt := createdAt.Add(InvitationTTL)
+	if endsAt.Before(expiresAt) {
+		expiresAt = endsAt
+	}
+	return Invitation{
+		ID:        uuid.New(),
+		DisputeID: disputeID,
+		CreatorID: creatorID,
+		Username:  NormalizeUsername(username),
+		Token:     hex.EncodeToString(token),
+		Status:    InvitationStatusPending,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// NormalizeUsername strips the @ people type in front of Telegram usernames.
+func NormalizeUsername(username string) string {
+	return strings.TrimPrefix(strings.TrimSpace(username), "@")
+}
@@ -16,6 +16,8 @@ type JurorUpdateOpts struct {
 	Vote   *string
 	Result *InvestigationResult
 	SeenAt *bool
+	// Commitment sets the vote commitment of a commit-reveal investigation.
+	Commitment *string
 }
 
 func NewJuror(investigationID, userID uuid.UUID) Juror {
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// JuryResponseTimeout is how long a selected juror has to open or vote on the investigation before an
+	// alternate replaces them.
+	JuryResponseTimeout = 30 * time.Minute
+	// JuryActivityWindow is how far back the votes that make a candidate more likely to be drawn are counted.
+	JuryActivityWindow = 30 * 24 * time.Hour
+
+	// maxRatingWeight and maxActivityWeight keep a few veterans from taking every panel.
+	maxRatingWeight   = 20
+	maxActivityWeight = 10
+)
+
+// JuryVacancy is an investigation whose jury has fewer jurors than the votes its contract waits for.
+type JuryVacancy struct {
+	InvestigationID uuid.UUID
+	DisputeID       uuid.UUID
+	// Seats is how many jurors are still to be enrolled.
+	Seats int
+}
+
+// JuryCandidate is a user ready to investigate who may be drawn into a jury.
+type JuryCandidate struct {
+	UserID uuid.UUID `json:"userID"`
+	Rating int       `json:"rating"`
+	// RecentVotes counts the investigation votes of the user within JuryActivityWindow.
+	RecentVotes int `json:"recentVotes"`
+}
+
+// Weight is how likely the candidate is drawn relative to the others: every candidate has a chance, a higher
+// rating and recent activity raise it.
+func (c JuryCandidate) Weight() int {
+	return 1 + min(max(c.Rating, 0), maxRatingWeight) + min(c.RecentVotes, maxActivityWeight)
+}
+
+// JuryDraw is one round of jury selection for an investigation. The first round selects the panel; later ones
+// draw alternates for jurors who didn't respond. Candidates and Seed are kept so the draw can be repeated with
+// DrawJury for an audit.
+type JuryDraw struct {
+	ID              uuid.UUID       `db:"id" json:"id"`
+	InvestigationID uuid.UUID       `db:"investigation_id" json:"investigationID"`
+	Round           int             `db:"round" json:"round"`
+	Seed            int64           `db:"seed" json:"seed"`
+	Size            int             `db:"size" json:"size"`
+	Candidates      []JuryCandidate `db:"candidates" json:"candidates"`
+	Selected        []uuid.UUID     `db:"selected" json:"selected"`
+	CreatedAt       time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// NewJuryDraw draws size jurors out of candidates with seed.
+func NewJuryDraw(investigationID uuid.UUID, round int, seed int64, size int, candidates []JuryCandidate) JuryDraw {
+	return JuryDraw{
+		ID:              uuid.New(),
+		InvestigationID: investigationID,
+		Round:           round,
+		Seed:            seed,
+		Size:            size,
+		Candidates:      candidates,
+		Selected:        DrawJury(candidates, size, seed),
+		CreatedAt:       time.Now(),
+	}
+}
+
+// DrawJury picks up to size candidates at random without replacement, each with a chance proportional to its
+// weight. The same candidates and seed always give the same jury.
+func DrawJury(candidates []JuryCandidate, size int, seed int64) []uuid.UUID {
+	type key struct {
+		userID uuid.UUID
+		key    float64
+	}
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	keys := make([]key, 0, len(candidates))
+	for _, c := range candidates {
+		// Efraimidis-Spirakis: the size largest u^(1/w) form a weighted sample.
+		keys = append(keys, key{userID: c.UserID, key: math.Pow(rng.Float64(), 1/float64(c.Weight()))})
+	}
+	slices.SortStableFunc(keys, func(a, b key) int { return cmp.Compare(b.key, a.key) })
+
+	selected := make([]uuid.UUID, 0, min(size, len(keys)))
+	for _, k := range keys[:min(size, len(keys))] {
+		selected = append(selected, k.userID)
+	}
+	return selected
+}
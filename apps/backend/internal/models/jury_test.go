@@ -0,0 +1,54 @@
+package models
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDrawJury(t *testing.T) {
+	candidates := make([]JuryCandidate, 0, 20)
+	for range 20 {
+		candidates = append(candidates, JuryCandidate{UserID: uuid.New()})
+	}
+
+	t.Run("same seed gives the same jury", func(t *testing.T) {
+		first := DrawJury(candidates, 5, 42)
+		if len(first) != 5 {
+			t.Fatalf("expected 5 jurors, got %d", len(first))
+		}
+		if again := DrawJury(candidates, 5, 42); !slices.Equal(first, again) {
+			t.Fatalf("expected a reproducible draw, got %v and %v", first, again)
+		}
+		seen := map[uuid.UUID]bool{}
+		for _, id := range first {
+			if seen[id] {
+				t.Fatalf("juror %s drawn twice", id)
+			}
+			seen[id] = true
+		}
+	})
+
+	t.Run("takes everyone when candidates are short", func(t *testing.T) {
+		if got := DrawJury(candidates[:3], 5, 1); len(got) != 3 {
+			t.Fatalf("expected 3 jurors, got %d", len(got))
+		}
+	})
+
+	t.Run("favors rating and activity", func(t *testing.T) {
+		weighted := slices.Clone(candidates)
+		weighted[0].Rating = 100
+		weighted[0].RecentVotes = 100
+		drawn := 0
+		for seed := range int64(200) {
+			if slices.Contains(DrawJury(weighted, 1, seed), weighted[0].UserID) {
+				drawn++
+			}
+		}
+		// Weight 31 against 19 others of weight 1 is drawn about 62% of the time, 5% uniformly.
+		if drawn < 80 {
+			t.Fatalf("expected the rated candidate to be drawn most often, got %d of 200", drawn)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MediaCacheMaxAge is how long clients may cache media. Media is addressed by the hash of its content, so it never
+// changes under the same URL.
+const MediaCacheMaxAge = 365 * 24 * time.Hour
+
+// MediaPath is where the media endpoint serves media, by hash.
+const MediaPath = "/api/v1/media/"
+
+const (
+	InlineImageDispute  = "dispute"
+	InlineImageEvidence = "evidence"
+)
+
+var ErrMediaValidation = errors.New("invalid media")
+
+// Media is a blob of the blob store, keyed by the hex SHA-256 of its content.
+type Media struct {
+	Hash        string    `db:"hash"         json:"hash"`
+	ContentType string    `db:"content_type" json:"contentType"`
+	Size        int64     `db:"size"         json:"size"`
+	CreatedAt   time.Time `db:"created_at"   json:"createdAt"`
+}
+
+// NewMedia addresses data by its hash. Without a content type it's sniffed from data.
+func NewMedia(data []byte, contentType string) (Media, error) {
+	if len(data) == 0 {
+		return Media{}, fmt.Errorf("%w: media is empty", ErrMediaValidation)
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return Media{
+		Hash:        MediaHash(data),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// MediaHash is the hex SHA-256 that addresses data.
+func MediaHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseMediaHash checks that hash is a hex SHA-256 and returns it in lower case.
+func ParseMediaHash(hash string) (string, error) {
+	hash = strings.ToLower(hash)
+	if len(hash) != hex.EncodedLen(sha256.Size) {
+		return "", fmt.Errorf("%w: hash must be %d hex characters", ErrMediaValidation, hex.EncodedLen(sha256.Size))
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", fmt.Errorf("%w: hash must be hex: %w", ErrMediaValidation, err)
+	}
+	return hash, nil
+}
+
+// MediaURL is the path the media with the hash is served at.
+func MediaURL(hash string) string {
+	return MediaPath + hash
+}
+
+// InlineImage is a dispute or evidence image still kept in the database.
+type InlineImage struct {
+	Kind        string
+	ID          string
+	Data        []byte
+	ContentType *string
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewMedia(t *testing.T) {
+	media, err := NewMedia([]byte("abc"), "image/png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if media.Hash != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("expected the SHA-256 of the content, got %s", media.Hash)
+	}
+	if media.ContentType != "image/png" || media.Size != 3 {
+		t.Fatalf("unexpected media: %+v", media)
+	}
+
+	media, err = NewMedia([]byte("\x89PNG\r\n\x1a\n"), "")
+	if err != nil || media.ContentType != "image/png" {
+		t.Fatalf("expected the content type to be sniffed, got %q, %v", media.ContentType, err)
+	}
+	if _, err = NewMedia(nil, "image/png"); !errors.Is(err, ErrMediaValidation) {
+		t.Fatalf("expected ErrMediaValidation, got %v", err)
+	}
+}
+
+func TestParseMediaHash(t *testing.T) {
+	valid := strings.Repeat("AB", 32)
+	hash, err := ParseMediaHash(valid)
+	if err != nil || hash != strings.ToLower(valid) {
+		t.Fatalf("expected a lower case hash, got %q, %v", hash, err)
+	}
+	for _, hash := range []string{"", "abc", strings.Repeat("zz", 32), strings.Repeat("ab", 33)} {
+		if _, err = ParseMediaHash(hash); !errors.Is(err, ErrMediaValidation) {
+			t.Errorf("expected %q to be rejected, got %v", hash, err)
+		}
+	}
+}
@@ -11,41 +11,79 @@ import (
 )
 
 type Dispute struct {
-	ID              uuid.UUID `db:"id" json:"id"`
-	Title           string    `db:"title" json:"title"`
-	Description     string    `db:"description" json:"description"`
-	CreatedAt       time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updatedAt"`
-	Cryptocurrency  string    `db:"cryptocurrency" json:"cryptocurrency"`
-	ImageData       []byte    `db:"image_data" json:"imageData"`
-	ImageType       *string   `db:"image_type" json:"imageType"`
-	ContractAddress string    `db:"contract_address" json:"contractAddress"`
-	EndsAt          time.Time `db:"ends_at" json:"endsAt"`
-	NextDeadline    time.Time `db:"next_deadline" json:"nextDeadline"`
-	AmountNano      int64     `db:"amount_nano" json:"amountNano"`
-	DepositNano     int64     `db:"deposit_nano" json:"depositNano"`
+	ID                  uuid.UUID         `db:"id" json:"id"`
+	Title               string            `db:"title" json:"title"`
+	Description         string            `db:"description" json:"description"`
+	CreatedAt           time.Time         `db:"created_at" json:"createdAt"`
+	UpdatedAt           time.Time         `db:"updated_at" json:"updatedAt"`
+	Cryptocurrency      string            `db:"cryptocurrency" json:"cryptocurrency"`
+	ImageHash           *string           `db:"image_hash" json:"imageHash"`
+	ThumbnailHash       *string           `db:"thumbnail_hash" json:"thumbnailHash"`
+	ImageType           *string           `db:"image_type" json:"imageType"`
+	ContractAddress     string            `db:"contract_address" json:"contractAddress"`
+	EndsAt              time.Time         `db:"ends_at" json:"endsAt"`
+	NextDeadline        time.Time         `db:"next_deadline" json:"nextDeadline"`
+	AmountNano          int64             `db:"amount_nano" json:"amountNano"`
+	DepositNano         int64             `db:"deposit_nano" json:"depositNano"`
+	DeadlineLockedUntil *time.Time        `db:"deadline_locked_until" json:"deadlineLockedUntil"`
+	Visibility          DisputeVisibility `db:"visibility" json:"visibility"`
+	TermsVersion        int               `db:"terms_version" json:"termsVersion"`
+}
+
+type DisputeOffer struct {
+	ID              uuid.UUID   `db:"id" json:"id"`
+	DisputeID       uuid.UUID   `db:"dispute_id" json:"disputeID"`
+	Version         int         `db:"version" json:"version"`
+	ProposerID      uuid.UUID   `db:"proposer_id" json:"proposerID"`
+	AmountNano      int64       `db:"amount_nano" json:"amountNano"`
+	DepositNano     int64       `db:"deposit_nano" json:"depositNano"`
+	EndsAt          time.Time   `db:"ends_at" json:"endsAt"`
+	Description     string      `db:"description" json:"description"`
+	ContractAddress *string     `db:"contract_address" json:"contractAddress"`
+	Status          OfferStatus `db:"status" json:"status"`
+	CreatedAt       time.Time   `db:"created_at" json:"createdAt"`
+	DecidedAt       *time.Time  `db:"decided_at" json:"decidedAt"`
 }
 
 type Evidence struct {
-	ID            uuid.UUID `db:"id" json:"id"`
-	Description   string    `db:"description" json:"description"`
-	ImageData     []byte    `db:"image_data" json:"imageData"`
-	ImageType     *string   `db:"image_type" json:"imageType"`
-	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
-	ParticipantID uuid.UUID `db:"participant_id" json:"participantID"`
+	ID          uuid.UUID            `db:"id" json:"id"`
+	DisputeID   uuid.UUID            `db:"dispute_id" json:"disputeID"`
+	Description string               `db:"description" json:"description"`
+	Attachments []EvidenceAttachment `json:"attachments"`
+	// ContentHash is the evidence digest committed on-chain, see EvidenceDigest.
+	ContentHash *string   `db:"content_hash" json:"contentHash"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+	// ReleasedAt is when the investigation opened and the evidence became visible beyond its party; see SealedFor.
+	ReleasedAt    *time.Time `db:"released_at" json:"releasedAt"`
+	ParticipantID uuid.UUID  `db:"participant_id" json:"participantID"`
 }
 
 type Investigation struct {
-	ID        uuid.UUID           `db:"id" json:"id"`
-	DisputeID uuid.UUID           `db:"dispute_id" json:"disputeID"`
-	Total     int                 `db:"total" json:"total"`
-	P1        int                 `db:"p1" json:"p1"`
-	P2        int                 `db:"p2" json:"p2"`
-	Draw      int                 `db:"draw" json:"draw"`
-	Status    InvestigationStatus `db:"status" json:"status"`
-	CreatedAt time.Time           `db:"created_at" json:"createdAt"`
-	EndsAt    time.Time           `db:"ends_at" json:"endsAt"`
-	Title     string              `db:"title" json:"title"`
+	ID          uuid.UUID           `db:"id" json:"id"`
+	DisputeID   uuid.UUID           `db:"dispute_id" json:"disputeID"`
+	Total       int                 `db:"total" json:"total"`
+	P1          int                 `db:"p1" json:"p1"`
+	P2          int                 `db:"p2" json:"p2"`
+	Draw        int                 `db:"draw" json:"draw"`
+	Status      InvestigationStatus `db:"status" json:"status"`
+	CreatedAt   time.Time           `db:"created_at" json:"createdAt"`
+	EndsAt      time.Time           `db:"ends_at" json:"endsAt"`
+	Title       string              `db:"title" json:"title"`
+	LockedUntil *time.Time          `db:"locked_until" json:"lockedUntil"`
+	VotingMode  VotingMode          `db:"voting_mode" json:"votingMode"`
+	// CommitEndsAt closes the commit phase of a commit-reveal investigation; the reveal phase lasts until EndsAt.
+	CommitEndsAt *time.Time `db:"commit_ends_at" json:"commitEndsAt"`
+	// VerdictPolicy is the policy the investigation was opened with; Round is its voting round.
+	VerdictPolicy VerdictPolicy `db:"verdict_policy" json:"verdictPolicy"`
+	Round         int           `db:"round" json:"round"`
+}
+
+// VoteCounts are the votes cast in an investigation, counted from the vote stored for each juror.
+type VoteCounts struct {
+	P1    int
+	P2    int
+	Draw  int
+	Total int
 }
 
 type Juror struct {
@@ -56,6 +94,8 @@ type Juror struct {
 	Result          InvestigationResult `db:"result" json:"result"`
 	UpdatedAt       time.Time           `db:"updated_at" json:"updatedAt"`
 	SeenAt          *time.Time          `db:"seen_at" json:"seenAt"`
+	// Commitment is the VoteCommitment the juror committed to in a commit-reveal investigation.
+	Commitment *string `db:"commitment" json:"-"`
 }
 
 type Participant struct {
@@ -71,6 +111,43 @@ type Participant struct {
 	IsCreator   bool       `db:"is_creator" json:"isCreator"`
 }
 
+type PendingOperation struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	MsgHash     string          `db:"msg_hash" json:"msgHash"`
+	UserID      uuid.UUID       `db:"user_id" json:"userID"`
+	Action      OperationAction `db:"action" json:"action"`
+	EntityID    string          `db:"entity_id" json:"entityID"`
+	Boc         string          `db:"boc" json:"boc"`
+	Payload     []byte          `db:"payload" json:"payload"`
+	Status      OperationStatus `db:"status" json:"status"`
+	Error       *string         `db:"error" json:"error"`
+	Attempts    int             `db:"attempts" json:"attempts"`
+	LockedUntil *time.Time      `db:"locked_until" json:"lockedUntil"`
+	CreatedAt   time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updatedAt"`
+}
+
+type Invitation struct {
+	ID         uuid.UUID        `db:"id" json:"id"`
+	DisputeID  uuid.UUID        `db:"dispute_id" json:"disputeID"`
+	CreatorID  uuid.UUID        `db:"creator_id" json:"creatorID"`
+	Username   string           `db:"username" json:"username"`
+	Token      string           `db:"token" json:"token"`
+	Status     InvitationStatus `db:"status" json:"status"`
+	CreatedAt  time.Time        `db:"created_at" json:"createdAt"`
+	ExpiresAt  time.Time        `db:"expires_at" json:"expiresAt"`
+	AcceptedBy *uuid.UUID       `db:"accepted_by" json:"acceptedBy"`
+	AcceptedAt *time.Time       `db:"accepted_at" json:"acceptedAt"`
+}
+
+type ProcessedOperation struct {
+	MsgHash   string          `db:"msg_hash" json:"msgHash"`
+	UserID    uuid.UUID       `db:"user_id" json:"userID"`
+	Action    OperationAction `db:"action" json:"action"`
+	EntityID  string          `db:"entity_id" json:"entityID"`
+	CreatedAt time.Time       `db:"created_at" json:"createdAt"`
+}
+
 type User struct {
 	ID                       uuid.UUID `db:"id" json:"id"`
 	Username                 string    `db:"username" json:"username"`
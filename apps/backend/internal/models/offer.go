@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OfferStatus tells what became of a version of the dispute terms.
+type OfferStatus string
+
+const (
+	// OfferStatusPending is a counter-offer that waits for the creator.
+	OfferStatusPending OfferStatus = "pending"
+	// OfferStatusAccepted is a version the dispute terms were set to; the latest one is what the stake must match.
+	OfferStatusAccepted OfferStatus = "accepted"
+	OfferStatusDeclined OfferStatus = "declined"
+	// OfferStatusSuperseded is a counter-offer that a newer version replaced before anyone answered it.
+	OfferStatusSuperseded OfferStatus = "superseded"
+)
+
+// DisputeOfferReq proposes new terms of a dispute that nobody has accepted yet. Empty fields keep the current
+// terms. Boc and ContractAddress fund a creator revision that changes the stake or the deadline with a new bet.
+// The deposit is not negotiable: BetMaster takes it from the stake.
+type DisputeOfferReq struct {
+	AmountNano      string `json:"amountNano"`
+	EndsAt          string `json:"endsAt"`
+	Description     string `json:"description"`
+	ContractAddress string `json:"contractAddress"`
+	Boc             string `json:"boc"`
+}
+
+// OfferCard is a version of the dispute terms as listed in the negotiation history.
+type OfferCard struct {
+	Version         int         `db:"version"          json:"version"`
+	Proposer        string      `db:"proposer"         json:"proposer"`
+	AmountNano      int64       `db:"amount_nano"      json:"amountNano"`
+	DepositNano     int64       `db:"deposit_nano"     json:"depositNano"`
+	EndsAt          time.Time   `db:"ends_at"          json:"endsAt"`
+	Description     string      `db:"description"      json:"description"`
+	ContractAddress *string     `db:"contract_address" json:"contractAddress"`
+	Status          OfferStatus `db:"status"           json:"status"`
+	CreatedAt       time.Time   `db:"created_at"       json:"createdAt"`
+	DecidedAt       *time.Time  `db:"decided_at"       json:"decidedAt"`
+}
+
+// InitialOffer is the first version of the dispute terms, the ones the creator funded on creation.
+func InitialOffer(dispute Dispute, creatorID uuid.UUID) DisputeOffer {
+	return DisputeOffer{
+		ID:              uuid.New(),
+		DisputeID:       dispute.ID,
+		Version:         1,
+		ProposerID:      creatorID,
+		AmountNano:      dispute.AmountNano,
+		DepositNano:     dispute.DepositNano,
+		EndsAt:          dispute.EndsAt,
+		Description:     dispute.Description,
+		ContractAddress: &dispute.ContractAddress,
+		Status:          OfferStatusAccepted,
+		CreatedAt:       dispute.CreatedAt,
+		DecidedAt:       &dispute.CreatedAt,
+	}
+}
+
+// NewDisputeOffer builds the pending version of the dispute terms that follows version. It fails when the
+// request changes nothing.
+func NewDisputeOffer(dispute Dispute, proposerID uuid.UUID, version int, req DisputeOfferReq) (DisputeOffer, error) {
+	offer := DisputeOffer{
+		ID:          uuid.New(),
+		DisputeID:   dispute.ID,
+		Version:     version + 1,
+		ProposerID:  proposerID,
+		AmountNano:  dispute.AmountNano,
+		DepositNano: dispute.DepositNano,
+		EndsAt:      dispute.EndsAt,
+		Description: dispute.Description,
+		Status:      OfferStatusPending,
+		CreatedAt:   time.Now(),
+	}
+
+	var err error
+	if req.AmountNano != "" {
+		if offer.AmountNano, err = ParsePositiveNano(req.AmountNano); err != nil {
+			return DisputeOffer{}, fmt.Errorf("%w: failed to parse AmountNano: %w", ErrDisputeValidation, err)
+		}
+	}
+	if req.EndsAt != "" {
+		if offer.EndsAt, err = time.Parse(time.RFC3339, req.EndsAt); err != nil {
+			return DisputeOffer{}, fmt.Errorf("%w incorrect EndsAt format: %w", ErrDisputeValidation, err)
+		}
+		if !offer.EndsAt.After(offer.CreatedAt) {
+			return DisputeOffer{}, fmt.Errorf("%w: EndsAt must be in the future", ErrDisputeValidation)
+		}
+	}
+	if req.Description != "" {
+		offer.Description = req.Description
+	}
+
+	if !offer.ChangesStake(dispute) && offer.Description == dispute.Description {
+		return DisputeOffer{}, fmt.Errorf("%w: offer must change the dispute terms", ErrDisputeValidation)
+	}
+	return offer, nil
+}
+
+// ChangesStake reports whether the offer changes terms the bet contract of the dispute was funded with, so
+// the creator has to fund a new bet before the opponent can accept the offer.
+func (o DisputeOffer) ChangesStake(dispute Dispute) bool {
+	return o.AmountNano != dispute.AmountNano || !o.EndsAt.Equal(dispute.EndsAt)
+}
+
+// Negotiating reports whether the two sides of a dispute are still settling its terms: nobody has accepted
+// the dispute yet and one of them waits for the other to answer an offer.
+func Negotiating(p1, p2 Participant) bool {
+	if p1.Status != DisputesStatusNew || p2.Status != DisputesStatusNew {
+		return false
+	}
+	switch [2]Result{p1.Result, p2.Result} {
+	case [2]Result{DisputesResultSent, DisputesResultNew}, [2]Result{DisputesResultNew, DisputesResultSent},
+		[2]Result{DisputesResultCountered, DisputesResultOffered},
+		[2]Result{DisputesResultOffered, DisputesResultCountered}:
+		return true
+	default:
+		return false
+	}
+}
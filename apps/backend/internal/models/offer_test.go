@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewDisputeOffer(t *testing.T) {
+	dispute := Dispute{
+		ID:          uuid.New(),
+		AmountNano:  100,
+		DepositNano: 20,
+		EndsAt:      time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second),
+		Description: "desc",
+	}
+	proposer := uuid.New()
+
+	offer, err := NewDisputeOffer(dispute, proposer, 3, DisputeOfferReq{Description: "clearer"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if offer.Version != 4 || offer.Status != OfferStatusPending || offer.AmountNano != 100 {
+		t.Fatalf("unexpected offer: %+v", offer)
+	}
+	if offer.ChangesStake(dispute) {
+		t.Fatal("expected description change to keep the stake")
+	}
+
+	offer, err = NewDisputeOffer(dispute, proposer, 1, DisputeOfferReq{
+		EndsAt: dispute.EndsAt.Add(time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !offer.ChangesStake(dispute) {
+		t.Fatal("expected deadline change to need a new bet")
+	}
+
+	for name, req := range map[string]DisputeOfferReq{
+		"nothing changes": {Description: "desc"},
+		"bad amount":      {AmountNano: "-1"},
+		"past deadline":   {EndsAt: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	} {
+		if _, err := NewDisputeOffer(dispute, proposer, 1, req); !errors.Is(err, ErrDisputeValidation) {
+			t.Fatalf("%s: expected ErrDisputeValidation, got %v", name, err)
+		}
+	}
+}
+
+func TestNegotiating(t *testing.T) {
+	participant := func(status Status, result Result) Participant {
+		return Participant{Status: status, Result: result}
+	}
+	tests := []struct {
+		name   string
+		p1, p2 Participant
+		want   bool
+	}{
+		{"offer", participant(DisputesStatusNew, DisputesResultSent), participant(DisputesStatusNew, DisputesResultNew), true},
+		{"counter", participant(DisputesStatusNew, DisputesResultOffered),
+			participant(DisputesStatusNew, DisputesResultCountered), true},
+		{"accepted", participant(DisputesStatusCurrent, DisputesResultProcessed),
+			participant(DisputesStatusCurrent, DisputesResultProcessed), false},
+		{"mixed", participant(DisputesStatusNew, DisputesResultSent),
+			participant(DisputesStatusNew, DisputesResultCountered), false},
+	}
+	for _, tt := range tests {
+		if got := Negotiating(tt.p1, tt.p2); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OperationAction string
+
+const (
+	OperationActionCreateDispute     OperationAction = "create_dispute"
+	OperationActionAcceptDispute     OperationAction = "accept_dispute"
+	OperationActionVoteDispute       OperationAction = "vote_dispute"
+	OperationActionProposeOffer      OperationAction = "propose_offer"
+	OperationActionAcceptOffer       OperationAction = "accept_offer"
+	OperationActionClaimDispute      OperationAction = "claim_dispute"
+	OperationActionProvideEvidence   OperationAction = "provide_evidence"
+	OperationActionVoteInvestigation OperationAction = "vote_investigation"
+)
+
+func NewProcessedOperation(msgHash string, userID uuid.UUID, action OperationAction, entityID string,
+) ProcessedOperation {
+	return ProcessedOperation{
+		MsgHash:   msgHash,
+		UserID:    userID,
+		Action:    action,
+		EntityID:  entityID,
+		CreatedAt: time.Now(),
+	}
+}
+
+// SameAs reports whether other records the same user applying the same action to the same entity.
+func (o ProcessedOperation) SameAs(other ProcessedOperation) bool {
+	return o.UserID == other.UserID && o.Action == other.Action && o.EntityID == other.EntityID
+}
+
+type OperationStatus string
+
+const (
+	OperationStatusPending   OperationStatus = "pending"
+	OperationStatusSucceeded OperationStatus = "succeeded"
+	OperationStatusFailed    OperationStatus = "failed"
+)
+
+func NewPendingOperation(msgHash string, userID uuid.UUID, action OperationAction, entityID, boc string,
+	payload []byte,
+) PendingOperation {
+	now := time.Now()
+	return PendingOperation{
+		ID:        uuid.New(),
+		MsgHash:   msgHash,
+		UserID:    userID,
+		Action:    action,
+		EntityID:  entityID,
+		Boc:       boc,
+		Payload:   payload,
+		Status:    OperationStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Processed returns the record that marks the pending operation as applied.
+func (o PendingOperation) Processed() ProcessedOperation {
+	return NewProcessedOperation(o.MsgHash, o.UserID, o.Action, o.EntityID)
+}
+
+// OperationDetails is what the owner of a pending operation sees while it is being confirmed.
+type OperationDetails struct {
+	ID        uuid.UUID       `json:"id"`
+	Action    OperationAction `json:"action"`
+	EntityID  string          `json:"entityID"`
+	Status    OperationStatus `json:"status"`
+	Error     *string         `json:"error"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+func (o PendingOperation) Details() OperationDetails {
+	return OperationDetails{
+		ID:        o.ID,
+		Action:    o.Action,
+		EntityID:  o.EntityID,
+		Status:    o.Status,
+		Error:     o.Error,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+	}
+}
@@ -19,6 +19,8 @@ type Result string
 const (
 	DisputesResultNew              Result = "new"
 	DisputesResultSent             Result = "sent"
+	DisputesResultCountered        Result = "countered" // the opponent's counter-offer waits for the creator
+	DisputesResultOffered          Result = "offered"   // the creator has a counter-offer to answer
 	DisputesResultProcessed        Result = "processed"
 	DisputesResultAnswered         Result = "answered"
 	DisputesResultEvidence         Result = "evidence"
@@ -37,6 +39,10 @@ type ParticipantUpdateOpts struct {
 	IsWin       *bool     `json:"isWin"`
 	IsClaimable *bool     `json:"isClaimable"`
 	Seen        *bool     `json:"-"`
+	// From is the result the participant is expected to still have; the update doesn't apply otherwise.
+	From *Result `json:"-"`
+	// Event is recorded in the dispute history together with the update.
+	Event *DisputeEvent `json:"-"`
 }
 
 func NewParticipant(userID, disputeID uuid.UUID, result Result, isCreator bool) Participant {
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxRationaleLength is how many characters a juror may explain their vote with.
+	MaxRationaleLength = 2000
+	// MaxFlagReasonLength is how many characters a participant may explain a rationale flag with.
+	MaxFlagReasonLength = 500
+)
+
+var ErrRationaleValidation = errors.New("invalid rationale")
+
+// ParseRationale trims the rationale of a juror vote. A blank rationale is nil.
+func ParseRationale(rationale string) (*string, error) {
+	rationale = strings.TrimSpace(rationale)
+	if rationale == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(rationale) > MaxRationaleLength {
+		return nil, fmt.Errorf("%w: rationale must be at most %d characters", ErrRationaleValidation,
+			MaxRationaleLength)
+	}
+	return &rationale, nil
+}
+
+// TransparencyVote is a juror vote in the transparency report. It doesn't tell who cast it; ID only identifies the
+// vote to flag its rationale.
+type TransparencyVote struct {
+	ID        uuid.UUID `db:"id"        json:"id"`
+	Vote      string    `db:"vote"      json:"vote"`
+	Rationale *string   `db:"rationale" json:"rationale"`
+	// RationaleHidden tells that a moderator hid the rationale after it was flagged.
+	RationaleHidden bool `db:"rationale_hidden" json:"rationaleHidden"`
+}
+
+// TransparencyReport shows the parties of a dispute how the jury of its closed investigation voted and why,
+// without telling who the jurors were.
+type TransparencyReport struct {
+	InvestigationID uuid.UUID `db:"investigation_id" json:"investigationID"`
+	Total           int       `db:"total"            json:"total"`
+	P1              int       `db:"p1"               json:"p1"`
+	P2              int       `db:"p2"               json:"p2"`
+	Draw            int       `db:"draw"             json:"draw"`
+	// Verdict is nil for investigations closed before verdicts were recorded.
+	Verdict *Verdict           `db:"verdict" json:"verdict"`
+	Votes   []TransparencyVote `json:"votes"`
+}
+
+type RationaleFlagStatus string
+
+const (
+	RationaleFlagStatusOpen RationaleFlagStatus = "open"
+	// RationaleFlagStatusDismissed keeps the rationale in the report.
+	RationaleFlagStatusDismissed RationaleFlagStatus = "dismissed"
+	// RationaleFlagStatusHidden hides the rationale from the report.
+	RationaleFlagStatusHidden RationaleFlagStatus = "hidden"
+)
+
+func ParseRationaleFlagStatus(status string) (RationaleFlagStatus, error) {
+	switch s := RationaleFlagStatus(status); s {
+	case RationaleFlagStatusOpen, RationaleFlagStatusDismissed, RationaleFlagStatusHidden:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown rationale flag status %q", status)
+}
+
+// RationaleFlag is a participant's report of a juror rationale for moderation. JurorID is the vote the rationale
+// explains.
+type RationaleFlag struct {
+	ID         uuid.UUID           `db:"id"          json:"id"`
+	JurorID    uuid.UUID           `db:"juror_id"    json:"voteID"`
+	ReporterID uuid.UUID           `db:"reporter_id" json:"-"`
+	Reason     string              `db:"reason"      json:"reason"`
+	Status     RationaleFlagStatus `db:"status"      json:"status"`
+	CreatedAt  time.Time           `db:"created_at"  json:"createdAt"`
+	ResolvedAt *time.Time          `db:"resolved_at" json:"resolvedAt"`
+}
+
+// NewRationaleFlag validates the reason of the flag.
+func NewRationaleFlag(jurorID, reporterID uuid.UUID, reason string) (RationaleFlag, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return RationaleFlag{}, fmt.Errorf("%w: flag reason is required", ErrRationaleValidation)
+	}
+	if utf8.RuneCountInString(reason) > MaxFlagReasonLength {
+		return RationaleFlag{}, fmt.Errorf("%w: flag reason must be at most %d characters", ErrRationaleValidation,
+			MaxFlagReasonLength)
+	}
+	return RationaleFlag{
+		ID:         uuid.New(),
+		JurorID:    jurorID,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Status:     RationaleFlagStatusOpen,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// RationaleFlagCard is a flag in the moderation queue with the rationale it reports.
+type RationaleFlagCard struct {
+	RationaleFlag
+	InvestigationID uuid.UUID `db:"investigation_id" json:"investigationID"`
+	Vote            string    `db:"vote"             json:"vote"`
+	Rationale       string    `db:"rationale"        json:"rationale"`
+	RationaleHidden bool      `db:"rationale_hidden" json:"rationaleHidden"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseRationale(t *testing.T) {
+	if r, err := ParseRationale("   "); err != nil || r != nil {
+		t.Fatalf("expected a blank rationale to be nil, got %v, %v", r, err)
+	}
+	r, err := ParseRationale("  the photo is clear \n")
+	if err != nil || r == nil || *r != "the photo is clear" {
+		t.Fatalf("expected a trimmed rationale, got %v, %v", r, err)
+	}
+	if _, err = ParseRationale(strings.Repeat("я", MaxRationaleLength+1)); !errors.Is(err, ErrRationaleValidation) {
+		t.Fatalf("expected a validation error for a long rationale, got %v", err)
+	}
+}
+
+func TestNewRationaleFlag(t *testing.T) {
+	jurorID, reporterID := uuid.New(), uuid.New()
+	flag, err := NewRationaleFlag(jurorID, reporterID, " insulting ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flag.Reason != "insulting" || flag.Status != RationaleFlagStatusOpen || flag.JurorID != jurorID {
+		t.Fatalf("unexpected flag: %+v", flag)
+	}
+	for _, reason := range []string{"", "  ", strings.Repeat("a", MaxFlagReasonLength+1)} {
+		if _, err = NewRationaleFlag(jurorID, reporterID, reason); !errors.Is(err, ErrRationaleValidation) {
+			t.Errorf("reason %q: expected a validation error, got %v", reason, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxRecusalReasonLength is how many characters a juror may explain a recusal with.
+const MaxRecusalReasonLength = 500
+
+var ErrRecusalValidation = errors.New("invalid recusal")
+
+// JurorRecusal is a juror stepping down from an investigation before voting in its Round.
+type JurorRecusal struct {
+	ID              uuid.UUID `db:"id"               json:"id"`
+	InvestigationID uuid.UUID `db:"investigation_id" json:"investigationID"`
+	UserID          uuid.UUID `db:"user_id"          json:"userID"`
+	Round           int       `db:"round"            json:"round"`
+	Reason          string    `db:"reason"           json:"reason"`
+	CreatedAt       time.Time `db:"created_at"       json:"createdAt"`
+}
+
+// NewJurorRecusal validates the reason of the recusal.
+func NewJurorRecusal(investigation Investigation, userID uuid.UUID, reason string, now time.Time,
+) (JurorRecusal, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return JurorRecusal{}, fmt.Errorf("%w: recusal reason is required", ErrRecusalValidation)
+	}
+	if utf8.RuneCountInString(reason) > MaxRecusalReasonLength {
+		return JurorRecusal{}, fmt.Errorf("%w: recusal reason must be at most %d characters", ErrRecusalValidation,
+			MaxRecusalReasonLength)
+	}
+	return JurorRecusal{
+		ID:              uuid.New(),
+		InvestigationID: investigation.ID,
+		UserID:          userID,
+		Round:           investigation.Round,
+		Reason:          reason,
+		CreatedAt:       now,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ReputationHalfLife is how long it takes a juror judgement to count half as much as a fresh one.
+	ReputationHalfLife = 90 * 24 * time.Hour
+	// ReputationRescoreInterval is how often ratings are scored again for their judgements to decay.
+	ReputationRescoreInterval = 24 * time.Hour
+	// RatingHistoryWindow is how far back the rating chart of a profile goes.
+	RatingHistoryWindow = 365 * 24 * time.Hour
+	// ReputationMissedSeatAllowance is how many seats a juror may recuse from or not vote in without losing rating.
+	ReputationMissedSeatAllowance = 1
+	// ReputationMissedSeatPenalty is how many rating points every further missed seat costs.
+	ReputationMissedSeatPenalty = 5
+
+	// reputationZ is the z-score of the 95% confidence interval of a juror's accuracy.
+	reputationZ = 1.96
+	maxRating   = 100
+)
+
+// Judgement is a juror vote in a closed investigation, correct when it agreed with the final outcome.
+type Judgement struct {
+	Correct   bool      `db:"correct"`
+	DecidedAt time.Time `db:"decided_at"`
+}
+
+// RatingPoint is a rating a user had from CreatedAt on, for the rating chart.
+type RatingPoint struct {
+	Rating    int       `db:"rating" json:"rating"`
+	Accuracy  float64   `db:"accuracy" json:"accuracy"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// RatingChange is an entry of the append-only rating history. InvestigationID is the investigation whose outcome
+// changed the rating; it is nil when the rating changed because older judgements decayed.
+type RatingChange struct {
+	ID              uuid.UUID  `db:"id"`
+	UserID          uuid.UUID  `db:"user_id"`
+	InvestigationID *uuid.UUID `db:"investigation_id"`
+	Rating          int        `db:"rating"`
+	Accuracy        float64    `db:"accuracy"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
+
+// Reputation scores a juror on how often their votes agreed with final outcomes. Recent judgements count more:
+// the weight of a judgement halves every ReputationHalfLife. Rating is the lower bound of the 95% Wilson interval
+// of the decayed accuracy in percent, so it takes both accurate and repeated judgement to rate high, and voting
+// a lot with the majority by chance doesn't. Jurors who repeatedly recuse or don't vote lose
+// ReputationMissedSeatPenalty points per missed seat beyond ReputationMissedSeatAllowance.
+type Reputation struct {
+	Rating   int     `json:"rating"`
+	Accuracy float64 `json:"accuracy"`
+	// AccuracyLow and AccuracyHigh bound the accuracy with 95% confidence.
+	AccuracyLow  float64 `json:"accuracyLow"`
+	AccuracyHigh float64 `json:"accuracyHigh"`
+	Votes        int     `json:"votes"`
+	// Seats is how many closed investigations the juror was drawn for; ParticipationRate is the share they voted in.
+	Seats             int           `json:"seats"`
+	ParticipationRate float64       `json:"participationRate"`
+	History           []RatingPoint `json:"history"`
+}
+
+// NewReputation scores the judgements of a juror drawn for seats closed investigations as of now.
+func NewReputation(judgements []Judgement, seats int, now time.Time) Reputation {
+	rep := Reputation{Votes: len(judgements), Seats: max(seats, len(judgements)), History: []RatingPoint{}}
+	if rep.Seats > 0 {
+		rep.ParticipationRate = float64(rep.Votes) / float64(rep.Seats)
+	}
+
+	var n, correct float64
+	for _, j := range judgements {
+		age := max(now.Sub(j.DecidedAt), 0)
+		w := math.Exp2(-float64(age) / float64(ReputationHalfLife))
+		n += w
+		if j.Correct {
+			correct += w
+		}
+	}
+	if n == 0 {
+		return rep
+	}
+
+	p := correct / n
+	z2 := reputationZ * reputationZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	spread := reputationZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	rep.Accuracy = p
+	rep.AccuracyLow = max(center-spread, 0)
+	rep.AccuracyHigh = min(center+spread, 1)
+	rep.Rating = min(int(math.Round(rep.AccuracyLow*maxRating)), maxRating)
+	if missed := rep.Seats - rep.Votes - ReputationMissedSeatAllowance; missed > 0 {
+		rep.Rating = max(rep.Rating-missed*ReputationMissedSeatPenalty, 0)
+	}
+	return rep
+}
+
+// NewRatingChange records the rating the reputation gives the user.
+func NewRatingChange(userID uuid.UUID, investigationID *uuid.UUID, rep Reputation, now time.Time) RatingChange {
+	return RatingChange{
+		ID:              uuid.New(),
+		UserID:          userID,
+		InvestigationID: investigationID,
+		Rating:          rep.Rating,
+		Accuracy:        rep.Accuracy,
+		CreatedAt:       now,
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewReputation(t *testing.T) {
+	now := time.Now()
+	judgements := func(correct, incorrect int, at time.Time) []Judgement {
+		var res []Judgement
+		for range correct {
+			res = append(res, Judgement{Correct: true, DecidedAt: at})
+		}
+		for range incorrect {
+			res = append(res, Judgement{DecidedAt: at})
+		}
+		return res
+	}
+
+	if rep := NewReputation(nil, 0, now); rep.Rating != 0 || rep.ParticipationRate != 0 || rep.History == nil {
+		t.Fatalf("expected an empty reputation, got %+v", rep)
+	}
+
+	few := NewReputation(judgements(3, 0, now), 3, now)
+	many := NewReputation(judgements(30, 0, now), 30, now)
+	if few.Accuracy != 1 || few.Rating >= many.Rating || many.Rating > 100 {
+		t.Fatalf("expected more judgements to rate higher with more confidence, got %d and %d", few.Rating,
+			many.Rating)
+	}
+	if few.AccuracyLow > few.Accuracy || few.AccuracyHigh < few.Accuracy {
+		t.Fatalf("expected the accuracy within its interval, got %+v", few)
+	}
+
+	volume := NewReputation(judgements(30, 30, now), 60, now)
+	if volume.Rating >= few.Rating {
+		t.Fatalf("expected voting a lot without judgement to rate lower, got %d and %d", volume.Rating, few.Rating)
+	}
+
+	old := now.Add(-2 * ReputationHalfLife)
+	recovered := NewReputation(append(judgements(0, 10, old), judgements(10, 0, now)...), 20, now)
+	if recovered.Accuracy < 0.75 {
+		t.Fatalf("expected old mistakes to decay, got accuracy %f", recovered.Accuracy)
+	}
+
+	if rep := NewReputation(judgements(2, 0, now), 4, now); rep.ParticipationRate != 0.5 {
+		t.Fatalf("expected half participation, got %f", rep.ParticipationRate)
+	}
+
+	if once := NewReputation(judgements(30, 0, now), 31, now); once.Rating != many.Rating {
+		t.Fatalf("expected a single missed seat to be allowed, got %d and %d", once.Rating, many.Rating)
+	}
+	missing := NewReputation(judgements(30, 0, now), 34, now)
+	if missing.Rating != many.Rating-3*ReputationMissedSeatPenalty {
+		t.Fatalf("expected every further missed seat to cost rating, got %d and %d", missing.Rating, many.Rating)
+	}
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDisputeTransition reports a change of a participant result that the dispute state machine doesn't allow.
+var ErrDisputeTransition = errors.New("illegal dispute transition")
+
+// DisputeAction is a step of a dispute that changes the results of its participants.
+type DisputeAction string
+
+const (
+	// DisputeActionCounterOffer is the opponent proposing new terms to the creator.
+	DisputeActionCounterOffer DisputeAction = "counter_offer"
+	// DisputeActionSetTerms is the creator revising the terms or answering a counter-offer.
+	DisputeActionSetTerms DisputeAction = "set_terms"
+	DisputeActionAccept   DisputeAction = "accept"
+	DisputeActionReject   DisputeAction = "reject"
+	// DisputeActionExpire is the offer deadline passing before anyone accepted the dispute.
+	DisputeActionExpire DisputeAction = "expire"
+	// DisputeActionVote is a participant voting for the result or reporting the outcome before the others do.
+	DisputeActionVote DisputeAction = "vote"
+	// DisputeActionSettle closes the dispute with its final result.
+	DisputeActionSettle          DisputeAction = "settle"
+	DisputeActionRequestEvidence DisputeAction = "request_evidence"
+	DisputeActionProvideEvidence DisputeAction = "provide_evidence"
+	// DisputeActionInvestigate hands the dispute to jurors.
+	DisputeActionInvestigate DisputeAction = "investigate"
+	// DisputeActionClaim is a participant taking the payout of a finished dispute.
+	DisputeActionClaim DisputeAction = "claim"
+)
+
+// Actor tells whose action changes the result of a participant.
+type Actor string
+
+const (
+	// ActorSelf is the participant acting on its own result.
+	ActorSelf Actor = "self"
+	// ActorPeer is another participant of the dispute whose action also moves this one.
+	ActorPeer Actor = "peer"
+	// ActorSystem is a deadline, a confirmation or an investigation acting on the participant.
+	ActorSystem Actor = "system"
+)
+
+// DisputeTransition is a change of a participant result.
+type DisputeTransition struct {
+	Action DisputeAction
+	Actor  Actor
+	From   Result
+	To     Result
+}
+
+var (
+	negotiatingResults = []Result{DisputesResultNew, DisputesResultSent, DisputesResultCountered, DisputesResultOffered}
+	finalResults       = []Result{DisputesResultWin, DisputesResultLose, DisputesResultDraw}
+)
+
+// disputeTransitions lists every allowed change of a participant result; anything else is illegal.
+var disputeTransitions = transitionSet(
+	edges(DisputeActionCounterOffer, ActorSelf, []Result{DisputesResultNew, DisputesResultCountered},
+		DisputesResultCountered),
+	edges(DisputeActionCounterOffer, ActorPeer, []Result{DisputesResultSent, DisputesResultOffered},
+		DisputesResultOffered),
+	edges(DisputeActionSetTerms, ActorSelf, []Result{DisputesResultSent, DisputesResultOffered}, DisputesResultSent),
+	edges(DisputeActionSetTerms, ActorPeer, []Result{DisputesResultNew, DisputesResultCountered}, DisputesResultNew),
+
+	edges(DisputeActionAccept, ActorSelf, []Result{DisputesResultNew}, DisputesResultProcessed),
+	edges(DisputeActionAccept, ActorPeer, []Result{DisputesResultSent}, DisputesResultProcessed),
+	edges(DisputeActionReject, ActorSelf, negotiatingResults, DisputesResultRejected),
+	edges(DisputeActionReject, ActorPeer, negotiatingResults, DisputesResultRejected),
+	edges(DisputeActionExpire, ActorSystem, negotiatingResults, DisputesResultRejected),
+
+	edges(DisputeActionVote, ActorSelf, []Result{DisputesResultProcessed}, DisputesResultAnswered),
+	edges(DisputeActionSettle, ActorSelf, []Result{DisputesResultProcessed}, finalResults...),
+	edges(DisputeActionSettle, ActorPeer, []Result{DisputesResultAnswered}, finalResults...),
+	edges(DisputeActionSettle, ActorSystem,
+		[]Result{DisputesResultProcessed, DisputesResultAnswered, DisputesResultInspected}, finalResults...),
+	edges(DisputeActionRequestEvidence, ActorSelf, []Result{DisputesResultProcessed}, DisputesResultEvidence),
+	edges(DisputeActionRequestEvidence, ActorPeer, []Result{DisputesResultAnswered}, DisputesResultEvidence),
+	edges(DisputeActionRequestEvidence, ActorSystem, []Result{DisputesResultProcessed, DisputesResultAnswered},
+		DisputesResultEvidence),
+
+	edges(DisputeActionProvideEvidence, ActorSelf, []Result{DisputesResultEvidence}, DisputesResultEvidenceAnswered),
+	edges(DisputeActionInvestigate, ActorSelf, []Result{DisputesResultEvidence, DisputesResultEvidenceAnswered},
+		DisputesResultInspected),
+	edges(DisputeActionInvestigate, ActorPeer, []Result{DisputesResultEvidenceAnswered}, DisputesResultInspected),
+	edges(DisputeActionInvestigate, ActorSystem, []Result{DisputesResultEvidence, DisputesResultEvidenceAnswered},
+		DisputesResultInspected),
+
+	// Claiming keeps the final result, so it has to be reached first.
+	edges(DisputeActionClaim, ActorSelf, []Result{DisputesResultRejected}, DisputesResultRejected),
+	edges(DisputeActionClaim, ActorSelf, []Result{DisputesResultWin}, DisputesResultWin),
+	edges(DisputeActionClaim, ActorSelf, []Result{DisputesResultLose}, DisputesResultLose),
+	edges(DisputeActionClaim, ActorSelf, []Result{DisputesResultDraw}, DisputesResultDraw),
+)
+
+func edges(action DisputeAction, actor Actor, from []Result, to ...Result) []DisputeTransition {
+	var ts []DisputeTransition
+	for _, f := range from {
+		for _, t := range to {
+			ts = append(ts, DisputeTransition{Action: action, Actor: actor, From: f, To: t})
+		}
+	}
+	return ts
+}
+
+func transitionSet(groups ...[]DisputeTransition) map[DisputeTransition]struct{} {
+	set := make(map[DisputeTransition]struct{})
+	for _, g := range groups {
+		for _, t := range g {
+			set[t] = struct{}{}
+		}
+	}
+	return set
+}
+
+// Allowed reports whether the dispute state machine allows the transition.
+func (t DisputeTransition) Allowed() bool {
+	_, ok := disputeTransitions[t]
+	return ok
+}
+
+// Transition returns the update that moves the participant to result to by action of actor, along with its
+// history event. It fails with ErrDisputeTransition when the state machine doesn't allow it. The update only
+// applies while the participant still has the result it was read with.
+func (p Participant) Transition(action DisputeAction, actor Actor, to Result) (ParticipantUpdateOpts, error) {
+	t := DisputeTransition{Action: action, Actor: actor, From: p.Result, To: to}
+	if !t.Allowed() {
+		return ParticipantUpdateOpts{}, fmt.Errorf("%w: %s by %s can't move participant %s from %s to %s",
+			ErrDisputeTransition, action, actor, p.ID, p.Result, to)
+	}
+	event := p.Event(action, actor, to)
+	return ParticipantUpdateOpts{ID: p.ID, From: &p.Result, Result: &to, Event: &event}, nil
+}
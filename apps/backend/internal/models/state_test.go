@@ -0,0 +1,141 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDisputeScenarios(t *testing.T) {
+	type step struct {
+		action DisputeAction
+		actor  Actor
+		to     Result
+		ok     bool
+	}
+	tests := []struct {
+		name  string
+		from  Result
+		steps []step
+	}{
+		{
+			name: "can't vote twice",
+			from: DisputesResultProcessed,
+			steps: []step{
+				{DisputeActionVote, ActorSelf, DisputesResultAnswered, true},
+				{DisputeActionVote, ActorSelf, DisputesResultAnswered, false},
+			},
+		},
+		{
+			name: "can't claim before settle",
+			from: DisputesResultProcessed,
+			steps: []step{
+				{DisputeActionClaim, ActorSelf, DisputesResultProcessed, false},
+				{DisputeActionSettle, ActorSystem, DisputesResultWin, true},
+				{DisputeActionClaim, ActorSelf, DisputesResultWin, true},
+			},
+		},
+		{
+			name: "claim keeps the final result",
+			from: DisputesResultLose,
+			steps: []step{
+				{DisputeActionClaim, ActorSelf, DisputesResultWin, false},
+				{DisputeActionClaim, ActorSelf, DisputesResultLose, true},
+			},
+		},
+		{
+			name: "can't accept a rejected offer",
+			from: DisputesResultNew,
+			steps: []step{
+				{DisputeActionReject, ActorSelf, DisputesResultRejected, true},
+				{DisputeActionAccept, ActorSelf, DisputesResultProcessed, false},
+			},
+		},
+		{
+			name: "can't counter an expired offer",
+			from: DisputesResultSent,
+			steps: []step{
+				{DisputeActionExpire, ActorSystem, DisputesResultRejected, true},
+				{DisputeActionCounterOffer, ActorPeer, DisputesResultOffered, false},
+			},
+		},
+		{
+			name: "creator can't accept its own offer",
+			from: DisputesResultSent,
+			steps: []step{
+				{DisputeActionAccept, ActorSelf, DisputesResultProcessed, false},
+				{DisputeActionAccept, ActorPeer, DisputesResultProcessed, true},
+			},
+		},
+		{
+			name: "evidence only after it was requested",
+			from: DisputesResultProcessed,
+			steps: []step{
+				{DisputeActionProvideEvidence, ActorSelf, DisputesResultEvidenceAnswered, false},
+				{DisputeActionRequestEvidence, ActorSystem, DisputesResultEvidence, true},
+				{DisputeActionProvideEvidence, ActorSelf, DisputesResultEvidenceAnswered, true},
+				{DisputeActionProvideEvidence, ActorSelf, DisputesResultEvidenceAnswered, false},
+			},
+		},
+		{
+			name: "only jurors settle an investigated dispute",
+			from: DisputesResultInspected,
+			steps: []step{
+				{DisputeActionSettle, ActorSelf, DisputesResultWin, false},
+				{DisputeActionSettle, ActorPeer, DisputesResultWin, false},
+				{DisputeActionSettle, ActorSystem, DisputesResultWin, true},
+			},
+		},
+		{
+			name: "settled dispute can't be reopened",
+			from: DisputesResultDraw,
+			steps: []step{
+				{DisputeActionSettle, ActorSystem, DisputesResultWin, false},
+				{DisputeActionRequestEvidence, ActorSystem, DisputesResultEvidence, false},
+				{DisputeActionReject, ActorSelf, DisputesResultRejected, false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Participant{ID: uuid.New(), Result: tt.from}
+			for i, s := range tt.steps {
+				_, err := p.Transition(s.action, s.actor, s.to)
+				if s.ok && err != nil {
+					t.Fatalf("step %d: %s by %s from %s: unexpected error: %v", i, s.action, s.actor, p.Result, err)
+				}
+				if !s.ok && !errors.Is(err, ErrDisputeTransition) {
+					t.Fatalf("step %d: %s by %s from %s to %s: expected ErrDisputeTransition, got %v",
+						i, s.action, s.actor, p.Result, s.to, err)
+				}
+				if err == nil {
+					p.Result = s.to
+				}
+			}
+		})
+	}
+}
+
+func TestParticipantTransition(t *testing.T) {
+	p := Participant{ID: uuid.New(), Result: DisputesResultProcessed}
+
+	opts, err := p.Transition(DisputeActionVote, ActorSelf, DisputesResultAnswered)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ID != p.ID || opts.Result == nil || *opts.Result != DisputesResultAnswered ||
+		opts.From == nil || *opts.From != DisputesResultProcessed {
+		t.Fatalf("unexpected update: %+v", opts)
+	}
+	if e := opts.Event; e == nil || e.Action != DisputeActionVote || *e.ParticipantID != p.ID ||
+		*e.FromResult != DisputesResultProcessed || *e.ToResult != DisputesResultAnswered {
+		t.Fatalf("unexpected event: %+v", opts.Event)
+	}
+
+	p.Result = DisputesResultAnswered
+	if _, err = p.Transition(DisputeActionVote, ActorSelf, DisputesResultAnswered); !errors.Is(err, ErrDisputeTransition) {
+		t.Fatalf("expected a second vote to be illegal, got %v", err)
+	}
+}
@@ -10,7 +10,6 @@ type UserUpdateOpts struct {
 	DisputeReadiness         *bool  `json:"disputeReadiness"`
 	InvestigationReadiness   *bool  `json:"investigationReadiness"`
 	MinimumDisputeAmountNano *int64 `json:"minimumDisputeAmountNano"`
-	Rating                   *int   `json:"rating"`
 }
 
 func NewUser(username string, photoUrl *string) User {
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrVerdictPolicyValidation = errors.New("invalid verdict policy")
+
+const (
+	// ContractThresholdPercent is the share of the required votes the Investigation contract requires of the
+	// leading option.
+	ContractThresholdPercent = 60
+	// ContractMarginPercent is the share of the required votes the leading option must be ahead of the runner-up by.
+	ContractMarginPercent = 25
+)
+
+// VerdictPolicy is what the backend adds to the fixed rule the Investigation contract pays out by. Each
+// investigation keeps the policy it was opened with.
+type VerdictPolicy struct {
+	// Quorum is the least number of votes a verdict needs.
+	Quorum int `json:"quorum"`
+	// RequireRationale makes jurors explain their vote; otherwise the rationale is optional.
+	RequireRationale bool `json:"requireRationale"`
+	// CommitReveal opens investigations in VotingModeCommitReveal; otherwise jurors vote openly.
+	CommitReveal bool      `json:"commitReveal"`
+	UpdatedAt    time.Time `json:"updatedAt,omitzero"`
+}
+
+// DefaultVerdictPolicy is the rule of the Investigation contract with a quorum of one vote. It is used until an
+// admin sets a policy and is the policy of investigations opened before verdict policies existed.
+var DefaultVerdictPolicy = VerdictPolicy{Quorum: 1}
+
+// Validate checks the quorum; the rest of the rule is fixed by the Investigation contract.
+func (p VerdictPolicy) Validate() error {
+	if p.Quorum < 1 {
+		return fmt.Errorf("%w: quorum must be positive", ErrVerdictPolicyValidation)
+	}
+	return nil
+}
+
+// Ballot is a juror vote.
+type Ballot struct {
+	Vote string `db:"vote"`
+}
+
+// VerdictReason tells how a verdict was reached or why it wasn't.
+type VerdictReason string
+
+const (
+	VerdictReasonDecided  VerdictReason = "decided"
+	VerdictReasonNoQuorum VerdictReason = "no_quorum"
+	VerdictReasonTie      VerdictReason = "tie"
+	// VerdictReasonBelowThreshold is a leading vote short of ContractThresholdPercent of the required votes.
+	VerdictReasonBelowThreshold VerdictReason = "below_threshold"
+	// VerdictReasonNarrowMargin is a leading vote that isn't ContractMarginPercent of the required votes ahead of the
+	// runner-up.
+	VerdictReasonNarrowMargin VerdictReason = "narrow_margin"
+)
+
+// Verdict is the outcome of counting the ballots of an investigation round under its policy.
+type Verdict struct {
+	// Vote is the winning juror vote; it is empty when the jury is hung.
+	Vote   string        `json:"vote"`
+	Reason VerdictReason `json:"reason"`
+	Round  int           `json:"round"`
+	// Ballots is the number of votes cast out of Required, Votes the votes per option.
+	Ballots  int            `json:"ballots"`
+	Required int            `json:"required"`
+	Votes    map[string]int `json:"votes"`
+}
+
+func (v Verdict) Hung() bool {
+	return v.Reason != VerdictReasonDecided
+}
+
+// Decide counts the ballots under the policy the way the Investigation contract does once the jury has voted: the
+// threshold and the margin are shares of the votes the contract requires, not of the votes cast.
+func (p VerdictPolicy) Decide(ballots []Ballot, required int) Verdict {
+	verdict := Verdict{Ballots: len(ballots), Required: required, Votes: map[string]int{}}
+	for _, b := range ballots {
+		verdict.Votes[b.Vote]++
+	}
+	if len(ballots) < p.Quorum || len(ballots) == 0 || required < 1 {
+		verdict.Reason = VerdictReasonNoQuorum
+		return verdict
+	}
+
+	leader, best, second := "", 0, 0
+	for vote, w := range verdict.Votes {
+		switch {
+		case w > best:
+			leader, best, second = vote, w, best
+		case w > second:
+			second = w
+		}
+	}
+	switch {
+	case best == second:
+		verdict.Reason = VerdictReasonTie
+	case best*100 < ContractThresholdPercent*required:
+		verdict.Reason = VerdictReasonBelowThreshold
+	case (best-second)*100 < ContractMarginPercent*required:
+		verdict.Reason = VerdictReasonNarrowMargin
+	default:
+		verdict.Vote, verdict.Reason = leader, VerdictReasonDecided
+	}
+	return verdict
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerdictPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy VerdictPolicy
+		valid  bool
+	}{
+		{name: "default", policy: DefaultVerdictPolicy, valid: true},
+		{name: "quorum and rationale", policy: VerdictPolicy{Quorum: 3, RequireRationale: true}, valid: true},
+		{name: "no quorum", policy: VerdictPolicy{}},
+	}
+	for _, c := range cases {
+		err := c.policy.Validate()
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, ErrVerdictPolicyValidation) {
+			t.Errorf("%s: expected a validation error, got %v", c.name, err)
+		}
+	}
+}
+
+func TestVerdictPolicyDecide(t *testing.T) {
+	ballots := func(votes ...string) []Ballot {
+		res := make([]Ballot, 0, len(votes))
+		for _, v := range votes {
+			res = append(res, Ballot{Vote: v})
+		}
+		return res
+	}
+	cases := []struct {
+		name     string
+		policy   VerdictPolicy
+		ballots  []Ballot
+		required int
+		vote     string
+		reason   VerdictReason
+	}{
+		{name: "clear majority", policy: DefaultVerdictPolicy, ballots: ballots("p1", "p1", "p1", "p2"), required: 4,
+			vote: "p1", reason: VerdictReasonDecided},
+		{name: "tie", policy: DefaultVerdictPolicy, ballots: ballots("p1", "p2"), required: 2,
+			reason: VerdictReasonTie},
+		{name: "no votes", policy: DefaultVerdictPolicy, required: 3, reason: VerdictReasonNoQuorum},
+		{name: "short of quorum", policy: VerdictPolicy{Quorum: 3}, ballots: ballots("p1", "p1"), required: 5,
+			reason: VerdictReasonNoQuorum},
+		{name: "plurality short of the threshold", policy: DefaultVerdictPolicy,
+			ballots: ballots("p1", "p1", "p2", "draw"), required: 4, reason: VerdictReasonBelowThreshold},
+		{name: "threshold met by the narrowest margin", policy: DefaultVerdictPolicy,
+			ballots: ballots("p1", "p1", "p1", "p2", "p2"), required: 5, reason: VerdictReasonNarrowMargin},
+		{name: "threshold and margin met", policy: DefaultVerdictPolicy,
+			ballots: ballots("p1", "p1", "p1", "p1", "p1", "p2", "p2", "draw"), required: 8, vote: "p1",
+			reason: VerdictReasonDecided},
+		{name: "unanimous votes short of the required ones", policy: DefaultVerdictPolicy,
+			ballots: ballots("p1", "p1"), required: 5, reason: VerdictReasonBelowThreshold},
+		{name: "decisive draw", policy: DefaultVerdictPolicy, ballots: ballots("draw", "draw", "draw", "p1"),
+			required: 4, vote: "draw", reason: VerdictReasonDecided},
+	}
+	for _, c := range cases {
+		verdict := c.policy.Decide(c.ballots, c.required)
+		if verdict.Vote != c.vote || verdict.Reason != c.reason || verdict.Ballots != len(c.ballots) {
+			t.Errorf("%s: unexpected verdict %+v", c.name, verdict)
+		}
+	}
+}
@@ -11,7 +11,7 @@ import (
 func (repo *Repository) ListChanges(ctx context.Context, actorUsername string, since time.Time) (models.ChangesList, error) {
 	res := models.ChangesList{Disputes: make([]models.DisputeChange, 0), Investigations: make([]models.InvestigationChange, 0)}
 
-	dRows, err := repo.db.QueryContext(ctx, `
+	dRows, err := repo.conn(ctx).QueryContext(ctx, `
 		SELECT p.dispute_id, p.status, p.updated_at
 		FROM participants p
 		JOIN users me ON me.id = p.user_id
@@ -40,7 +40,7 @@ func (repo *Repository) ListChanges(ctx context.Context, actorUsername string, s
 		return res, fmt.Errorf("failed to iterate dispute changes: %w", err)
 	}
 
-	iRows, err := repo.db.QueryContext(ctx, `
+	iRows, err := repo.conn(ctx).QueryContext(ctx, `
 		SELECT j.investigation_id, i.status, j.updated_at
 		FROM jurors j
 		JOIN users me ON me.id = j.user_id
@@ -76,7 +76,7 @@ func (repo *Repository) ListChanges(ctx context.Context, actorUsername string, s
 func (repo *Repository) GetUnreadCounts(ctx context.Context, actorUsername string) (models.ChangesUnreadCounts, error) {
 	res := models.ChangesUnreadCounts{}
 
-	dRows, err := repo.db.QueryContext(ctx, `
+	dRows, err := repo.conn(ctx).QueryContext(ctx, `
 		SELECT p.status, COUNT(*)::int AS cnt
 		FROM participants p
 		JOIN users me ON me.id = p.user_id
@@ -107,7 +107,7 @@ func (repo *Repository) GetUnreadCounts(ctx context.Context, actorUsername strin
 		return res, fmt.Errorf("failed to iterate dispute unread counts: %w", err)
 	}
 
-	iRows, err := repo.db.QueryContext(ctx, `
+	iRows, err := repo.conn(ctx).QueryContext(ctx, `
 		SELECT i.status, COUNT(*)::int AS cnt
 		FROM jurors j
 		JOIN users me ON me.id = j.user_id
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+func (repo *Repository) GetConflictPolicy(ctx context.Context) (models.ConflictPolicy, error) {
+	var policy models.ConflictPolicy
+	err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT dispute_window_days, co_juror_window_days, co_juror_limit, updated_at
+		FROM jury_conflict_policy`,
+	).Scan(&policy.DisputeWindowDays, &policy.CoJurorWindowDays, &policy.CoJurorLimit, &policy.UpdatedAt)
+	if err != nil {
+		return models.ConflictPolicy{}, fmt.Errorf("failed to get conflict policy: %w", handleNotFoundError(err))
+	}
+	return policy, nil
+}
+
+func (repo *Repository) UpdateConflictPolicy(ctx context.Context, policy models.ConflictPolicy) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO jury_conflict_policy (id, dispute_window_days, co_juror_window_days, co_juror_limit, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET dispute_window_days  = EXCLUDED.dispute_window_days,
+		    co_juror_window_days = EXCLUDED.co_juror_window_days,
+		    co_juror_limit       = EXCLUDED.co_juror_limit,
+		    updated_at           = EXCLUDED.updated_at`,
+		policy.DisputeWindowDays, policy.CoJurorWindowDays, policy.CoJurorLimit, policy.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update conflict policy: %w", err)
+	}
+	return nil
+}
+
+// ListJuryConflicts returns the users who have a conflict of interest with any of the parties under the policy:
+// they had a dispute with a party within the dispute window, judged at least the co-juror limit of investigations
+// together with a party within the co-juror window, or one of them blocked the other. A user conflicting with
+// several parties or for several reasons is returned once per party and reason.
+func (repo *Repository) ListJuryConflicts(ctx context.Context, parties []uuid.UUID, policy models.ConflictPolicy,
+	now time.Time,
+) ([]models.JuryExclusion, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT other.user_id, party.user_id, $2::TEXT
+		FROM participants party
+		JOIN disputes d ON d.id = party.dispute_id
+		JOIN participants other ON other.dispute_id = party.dispute_id AND other.user_id <> party.user_id
+		WHERE party.user_id = ANY($1)
+		  AND d.created_at >= $3
+		UNION
+		SELECT other.user_id, party.user_id, $4::TEXT
+		FROM jurors party
+		JOIN investigations i ON i.id = party.investigation_id
+		JOIN jurors other ON other.investigation_id = party.investigation_id AND other.user_id <> party.user_id
+		WHERE party.user_id = ANY($1)
+		  AND i.created_at >= $5
+		GROUP BY other.user_id, party.user_id
+		HAVING COUNT(*) >= $6
+		UNION
+		SELECT b.blocked_user_id, b.user_id, $7::TEXT
+		FROM juror_blocks b
+		WHERE b.user_id = ANY($1)
+		UNION
+		SELECT b.user_id, b.blocked_user_id, $7::TEXT
+		FROM juror_blocks b
+		WHERE b.blocked_user_id = ANY($1)`,
+		pq.Array(parties),
+		models.ConflictReasonDisputePartner,
+		policy.DisputesSince(now),
+		models.ConflictReasonCoJuror,
+		policy.CoJurorsSince(now),
+		policy.CoJurorLimit,
+		models.ConflictReasonBlocked,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jury conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []models.JuryExclusion
+	for rows.Next() {
+		var c models.JuryExclusion
+		if err = rows.Scan(&c.UserID, &c.PartyID, &c.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan jury conflict: %w", err)
+		}
+		conflicts = append(conflicts, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jury conflicts: %w", err)
+	}
+	return conflicts, nil
+}
+
+func (repo *Repository) InsertJuryExclusions(ctx context.Context, exclusions []models.JuryExclusion) error {
+	for _, e := range exclusions {
+		if _, err := repo.conn(ctx).ExecContext(ctx, `
+			INSERT INTO jury_exclusions (investigation_id, user_id, party_id, reason, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING`,
+			e.InvestigationID, e.UserID, e.PartyID, e.Reason, e.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to insert jury exclusion: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListJuryExclusions returns the exclusions applied to the jury of the investigation, oldest first.
+func (repo *Repository) ListJuryExclusions(ctx context.Context, invID uuid.UUID) ([]models.JuryExclusionCard, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT u.username, party.username, e.reason, e.created_at
+		FROM jury_exclusions e
+		JOIN users u ON u.id = e.user_id
+		JOIN users party ON party.id = e.party_id
+		WHERE e.investigation_id = $1
+		ORDER BY e.created_at, u.username, party.username, e.reason`,
+		invID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jury exclusions: %w", err)
+	}
+	defer rows.Close()
+
+	var exclusions []models.JuryExclusionCard
+	for rows.Next() {
+		var e models.JuryExclusionCard
+		if err = rows.Scan(&e.Username, &e.Party, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan jury exclusion: %w", err)
+		}
+		exclusions = append(exclusions, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jury exclusions: %w", err)
+	}
+	return exclusions, nil
+}
+
+func (repo *Repository) InsertJurorBlock(ctx context.Context, block models.JurorBlock) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO juror_blocks (user_id, blocked_user_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`,
+		block.UserID, block.BlockedUserID, block.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert juror block: %w", err)
+	}
+	return nil
+}
+
+func (repo *Repository) DeleteJurorBlock(ctx context.Context, userID, blockedUserID uuid.UUID) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		DELETE FROM juror_blocks
+		WHERE user_id = $1 AND blocked_user_id = $2`,
+		userID, blockedUserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete juror block: %w", err)
+	}
+	return nil
+}
+
+// ListJurorBlocks returns the users the user blocked from judging their disputes, latest first.
+func (repo *Repository) ListJurorBlocks(ctx context.Context, userID uuid.UUID) ([]models.JurorBlockCard, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT u.username, b.created_at
+		FROM juror_blocks b
+		JOIN users u ON u.id = b.blocked_user_id
+		WHERE b.user_id = $1
+		ORDER BY b.created_at DESC, u.username`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list juror blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []models.JurorBlockCard
+	for rows.Next() {
+		var b models.JurorBlockCard
+		if err = rows.Scan(&b.Username, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan juror block: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list juror blocks: %w", err)
+	}
+	return blocks, nil
+}
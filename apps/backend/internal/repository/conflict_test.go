@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestListJuryConflicts(t *testing.T) {
+	party, partner := uuid.New(), uuid.New()
+	now := time.Now()
+	var gotArgs []driver.NamedValue
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(_ string, args []driver.NamedValue) (driver.Rows, error) {
+			gotArgs = args
+			return newRows(
+				[]string{"user_id", "party_id", "reason"},
+				[]driver.Value{partner.String(), party.String(), string(models.ConflictReasonDisputePartner)},
+			), nil
+		},
+	})
+
+	conflicts, err := repo.ListJuryConflicts(context.Background(), []uuid.UUID{party}, models.DefaultConflictPolicy, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].UserID != partner || conflicts[0].PartyID != party ||
+		conflicts[0].Reason != models.ConflictReasonDisputePartner {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+	if since, ok := gotArgs[2].Value.(time.Time); !ok || !since.Equal(now.AddDate(0, 0, -180)) {
+		t.Fatalf("expected the dispute window to start 180 days ago, got %v", gotArgs[2].Value)
+	}
+}
+
+func TestGetConflictPolicyNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"dispute_window_days", "co_juror_window_days", "co_juror_limit", "updated_at"}), nil
+		},
+	})
+
+	if _, err := repo.GetConflictPolicy(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
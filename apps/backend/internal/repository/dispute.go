@@ -8,15 +8,16 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/lib/pq"
 )
 
 func (repo *Repository) InsertDispute(ctx context.Context, dispute models.Dispute) error {
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := repo.conn(ctx).ExecContext(ctx, `
 	INSERT INTO disputes (
-		id, title, description, created_at, updated_at, cryptocurrency, amount_nano, deposit_nano, image_data, image_type,
-		contract_address, ends_at, next_deadline
+		id, title, description, created_at, updated_at, cryptocurrency, amount_nano, deposit_nano, image_hash, image_type,
+		contract_address, ends_at, next_deadline, visibility, thumbnail_hash
 	) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
 		dispute.ID,
 		dispute.Title,
 		dispute.Description,
@@ -25,11 +26,13 @@ func (repo *Repository) InsertDispute(ctx context.Context, dispute models.Disput
 		dispute.Cryptocurrency,
 		dispute.AmountNano,
 		dispute.DepositNano,
-		dispute.ImageData,
+		dispute.ImageHash,
 		dispute.ImageType,
 		dispute.ContractAddress,
 		dispute.EndsAt,
 		dispute.NextDeadline,
+		dispute.Visibility,
+		dispute.ThumbnailHash,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert dispute: %w", err)
@@ -92,18 +95,19 @@ func (repo *Repository) ListDisputeCards(ctx context.Context, actorUsername stri
 			opp_user.username AS opponent,
 			opp_user.photo_url,
 			self.result, self.is_win, self.is_claimable,
-			(self.seen_at IS NULL OR self.updated_at > self.seen_at) AS is_unread
+			(self.seen_at IS NULL OR self.updated_at > self.seen_at) AS is_unread,
+			d.thumbnail_hash
 		FROM disputes d
 		JOIN participants self ON self.dispute_id = d.id
 		JOIN users me ON me.id = self.user_id
-		JOIN participants opp ON opp.dispute_id = d.id AND opp.user_id <> self.user_id
-		JOIN users opp_user ON opp_user.id = opp.user_id
+		LEFT JOIN participants opp ON opp.dispute_id = d.id AND opp.user_id <> self.user_id
+		LEFT JOIN users opp_user ON opp_user.id = opp.user_id
 		%s
 		ORDER BY d.created_at DESC
 		LIMIT $%d
 	`, whereSQL, idx)
 
-	rows, err := repo.db.QueryContext(ctx, query, args...)
+	rows, err := repo.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute ListDisputeCards query: %w", err)
 	}
@@ -125,6 +129,7 @@ func (repo *Repository) ListDisputeCards(ctx context.Context, actorUsername stri
 			&d.IsWin,
 			&d.IsClaimable,
 			&d.IsUnread,
+			&d.ThumbnailHash,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan dispute read row: %w", err)
 		}
@@ -139,13 +144,14 @@ func (repo *Repository) ListDisputeCards(ctx context.Context, actorUsername stri
 
 func (repo *Repository) GetDisputeByID(ctx context.Context, disputeID uuid.UUID) (models.Dispute, error) {
 	var d models.Dispute
-	err := repo.db.QueryRowContext(ctx, `
+	err := repo.conn(ctx).QueryRowContext(ctx, `
 		SELECT 
 			d.id, d.title, d.description, 
 			d.created_at, d.updated_at, 
 			d.cryptocurrency, d.amount_nano, d.deposit_nano,
-			d.image_data, d.image_type, d.ends_at, d.next_deadline,
-			d.contract_address
+			d.image_hash, d.image_type, d.ends_at, d.next_deadline,
+			d.contract_address, d.visibility,
+			d.terms_version
 		FROM disputes d
 		WHERE d.id = $1`,
 		disputeID,
@@ -158,11 +164,13 @@ func (repo *Repository) GetDisputeByID(ctx context.Context, disputeID uuid.UUID)
 		&d.Cryptocurrency,
 		&d.AmountNano,
 		&d.DepositNano,
-		&d.ImageData,
+		&d.ImageHash,
 		&d.ImageType,
 		&d.EndsAt,
 		&d.NextDeadline,
 		&d.ContractAddress,
+		&d.Visibility,
+		&d.TermsVersion,
 	)
 	if err != nil {
 		return models.Dispute{}, fmt.Errorf("failed to get dispute by ID: %w", err)
@@ -173,22 +181,26 @@ func (repo *Repository) GetDisputeByID(ctx context.Context, disputeID uuid.UUID)
 func (repo *Repository) GetDisputeDetailsByID(ctx context.Context, disputeID uuid.UUID, actorUsername string,
 ) (models.DisputeDetails, error) {
 	var d models.DisputeDetails
-	err := repo.db.QueryRowContext(ctx, `
+	err := repo.conn(ctx).QueryRowContext(ctx, `
 		SELECT
 			d.id, d.title, d.description,
 			d.created_at, d.updated_at,
 			d.cryptocurrency, d.amount_nano, d.deposit_nano,
-			d.image_data, d.image_type,
+			d.image_hash, d.image_type,
 			d.contract_address,
-			d.ends_at, d.next_deadline,
+			d.ends_at, d.next_deadline, d.visibility,
 			opp_user.username AS opponent,
 			opp_user.photo_url,
-			self.result, self.is_win, self.is_claimable
+			self.result, self.is_win, self.is_claimable,
+			inv.username AS invitee,
+			CASE WHEN self.is_creator THEN inv.token END AS invite_token,
+			d.terms_version
 		FROM disputes d
 		JOIN participants self ON self.dispute_id = d.id
 		JOIN users me ON me.id = self.user_id
-		JOIN participants opp ON opp.dispute_id = d.id AND opp.user_id <> self.user_id
-		JOIN users opp_user ON opp_user.id = opp.user_id
+		LEFT JOIN participants opp ON opp.dispute_id = d.id AND opp.user_id <> self.user_id
+		LEFT JOIN users opp_user ON opp_user.id = opp.user_id
+		LEFT JOIN invitations inv ON inv.dispute_id = d.id AND inv.accepted_by IS NULL
 		WHERE d.id = $1 AND me.username = $2
 	`, disputeID, actorUsername).Scan(
 		&d.ID,
@@ -199,16 +211,20 @@ func (repo *Repository) GetDisputeDetailsByID(ctx context.Context, disputeID uui
 		&d.Cryptocurrency,
 		&d.AmountNano,
 		&d.DepositNano,
-		&d.ImageData,
+		&d.ImageHash,
 		&d.ImageType,
 		&d.ContractAddress,
 		&d.EndsAt,
 		&d.NextDeadline,
+		&d.Visibility,
 		&d.Opponent,
 		&d.PhotoUrl,
 		&d.Result,
 		&d.IsWin,
 		&d.IsClaimable,
+		&d.Invitee,
+		&d.InviteToken,
+		&d.TermsVersion,
 	)
 	if err != nil {
 		return models.DisputeDetails{}, fmt.Errorf("failed to get dispute details by ID: %w", err)
@@ -216,12 +232,159 @@ func (repo *Repository) GetDisputeDetailsByID(ctx context.Context, disputeID uui
 	return d, nil
 }
 
+// ApplyDisputeTerms sets the terms of the dispute to the offer, unless the dispute already has that version of
+// the terms or a later one. It reports whether the terms changed.
+func (repo *Repository) ApplyDisputeTerms(ctx context.Context, offer models.DisputeOffer) (bool, error) {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE disputes
+		SET amount_nano = $2,
+			deposit_nano = $3,
+			ends_at = $4,
+			next_deadline = $4,
+			description = $5,
+			contract_address = COALESCE($6, contract_address),
+			terms_version = $7,
+			updated_at = now()
+		WHERE id = $1 AND terms_version < $7`,
+		offer.DisputeID,
+		offer.AmountNano,
+		offer.DepositNano,
+		offer.EndsAt,
+		offer.Description,
+		offer.ContractAddress,
+		offer.Version,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply dispute terms: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// openDisputeFilter matches open disputes that nobody has accepted and whose creator hasn't withdrawn yet.
+const openDisputeFilter = `
+	d.visibility <> 'direct'
+	AND d.ends_at > now()
+	AND creator.status = 'new'
+	AND NOT EXISTS (
+		SELECT 1 FROM participants opp
+		WHERE opp.dispute_id = d.id AND opp.is_creator = FALSE
+	)`
+
+func (repo *Repository) ListOpenDisputes(ctx context.Context, actorUsername string, opts models.OpenDisputeListOpts,
+) ([]models.OpenDisputeCard, error) {
+	const maxLimit = 100
+
+	args := []interface{}{actorUsername}
+	cursorSQL := ""
+	if opts.Cursor != "" {
+		t, err := time.Parse(time.RFC3339Nano, opts.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor format: %w", err)
+		}
+		args = append(args, t)
+		cursorSQL = fmt.Sprintf("AND d.created_at <= $%d", len(args))
+	}
+
+	limit := opts.Limit
+	if limit <= 0 || limit > maxLimit {
+		limit = maxLimit
+	}
+	args = append(args, limit+1)
+
+	query := fmt.Sprintf(`
+		SELECT
+			d.id, d.title, d.description,
+			d.created_at, d.amount_nano, d.deposit_nano,
+			d.contract_address, d.ends_at, d.visibility,
+			creator_user.username AS creator,
+			creator_user.photo_url,
+			d.thumbnail_hash
+		FROM disputes d
+		JOIN participants creator ON creator.dispute_id = d.id AND creator.is_creator = TRUE
+		JOIN users creator_user ON creator_user.id = creator.user_id
+		WHERE %s
+		  AND d.visibility = 'public'
+		  AND creator_user.username <> $1
+		  %s
+		ORDER BY d.created_at DESC
+		LIMIT $%d
+	`, openDisputeFilter, cursorSQL, len(args))
+
+	rows, err := repo.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute ListOpenDisputes query: %w", err)
+	}
+	defer rows.Close()
+
+	var disputes []models.OpenDisputeCard
+	for rows.Next() {
+		d, err := scanOpenDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return disputes, nil
+}
+
+// GetOpenDispute returns a public or link-shared dispute while it's still waiting for an opponent.
+func (repo *Repository) GetOpenDispute(ctx context.Context, disputeID uuid.UUID) (models.OpenDisputeCard, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			d.id, d.title, d.description,
+			d.created_at, d.amount_nano, d.deposit_nano,
+			d.contract_address, d.ends_at, d.visibility,
+			creator_user.username AS creator,
+			creator_user.photo_url,
+			d.thumbnail_hash
+		FROM disputes d
+		JOIN participants creator ON creator.dispute_id = d.id AND creator.is_creator = TRUE
+		JOIN users creator_user ON creator_user.id = creator.user_id
+		WHERE d.id = $1 AND %s
+	`, openDisputeFilter), disputeID)
+
+	d, err := scanOpenDispute(row)
+	if err != nil {
+		return models.OpenDisputeCard{}, handleNotFoundError(err)
+	}
+	return d, nil
+}
+
+func scanOpenDispute(row rowScanner) (models.OpenDisputeCard, error) {
+	var d models.OpenDisputeCard
+	if err := row.Scan(
+		&d.ID,
+		&d.Title,
+		&d.Description,
+		&d.CreatedAt,
+		&d.AmountNano,
+		&d.DepositNano,
+		&d.ContractAddress,
+		&d.EndsAt,
+		&d.Visibility,
+		&d.Creator,
+		&d.PhotoUrl,
+		&d.ThumbnailHash,
+	); err != nil {
+		return models.OpenDisputeCard{}, fmt.Errorf("failed to scan open dispute: %w", err)
+	}
+	return d, nil
+}
+
 func (repo *Repository) GetDisputeForEvidence(ctx context.Context, disputeID uuid.UUID) (models.Dispute, error) {
 	var d models.Dispute
-	err := repo.db.QueryRowContext(ctx, `
+	err := repo.conn(ctx).QueryRowContext(ctx, `
 		SELECT 
 			d.id, d.title, d.description, 
-			d.image_data, d.image_type, d.contract_address
+			d.image_hash, d.image_type, d.contract_address
 		FROM disputes d
 		WHERE d.id = $1`,
 		disputeID,
@@ -229,7 +392,7 @@ func (repo *Repository) GetDisputeForEvidence(ctx context.Context, disputeID uui
 		&d.ID,
 		&d.Title,
 		&d.Description,
-		&d.ImageData,
+		&d.ImageHash,
 		&d.ImageType,
 		&d.ContractAddress,
 	)
@@ -241,7 +404,7 @@ func (repo *Repository) GetDisputeForEvidence(ctx context.Context, disputeID uui
 
 func (repo *Repository) UpdateDisputeNextDeadline(ctx context.Context, disputeID uuid.UUID, nextDeadline time.Time,
 ) error {
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := repo.conn(ctx).ExecContext(ctx, `
 		UPDATE disputes
 		SET next_deadline = $1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $2
@@ -251,3 +414,70 @@ func (repo *Repository) UpdateDisputeNextDeadline(ctx context.Context, disputeID
 	}
 	return nil
 }
+
+// ClaimOverdueDisputes leases disputes past next_deadline until lockUntil. A dispute is claimed only when the
+// results of all its participants belong to the same one of stages.
+func (repo *Repository) ClaimOverdueDisputes(ctx context.Context, now, lockUntil time.Time,
+	stages [][]models.Result, limit int,
+) ([]uuid.UUID, error) {
+	var results []string
+	var stageOf []int64
+	for i, stage := range stages {
+		for _, result := range stage {
+			results = append(results, string(result))
+			stageOf = append(stageOf, int64(i))
+		}
+	}
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		UPDATE disputes d
+		SET deadline_locked_until = $2
+		WHERE d.id IN (
+			SELECT od.id
+			FROM disputes od
+			WHERE od.next_deadline <= $1
+			  AND (od.deadline_locked_until IS NULL OR od.deadline_locked_until < $1)
+			  AND od.id IN (
+				SELECT p.dispute_id
+				FROM participants p
+				LEFT JOIN unnest($3::text[], $4::int[]) AS s(result, stage) ON s.result = p.result
+				GROUP BY p.dispute_id
+				HAVING bool_and(s.stage IS NOT NULL) AND count(DISTINCT s.stage) = 1
+			  )
+			ORDER BY od.next_deadline
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id
+	`, now, lockUntil, pq.Array(results), pq.Array(stageOf), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim overdue disputes: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan overdue dispute ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over overdue disputes: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ReleaseDisputeDeadline ends the lease ClaimOverdueDisputes took on the dispute.
+func (repo *Repository) ReleaseDisputeDeadline(ctx context.Context, disputeID uuid.UUID) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE disputes
+		SET deadline_locked_until = NULL
+		WHERE id = $1
+	`, disputeID)
+	if err != nil {
+		return fmt.Errorf("failed to release dispute deadline: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql/driver"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,9 +18,10 @@ func TestGetDisputeByID(t *testing.T) {
 		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
 			return newRows(
 				[]string{"id", "title", "description", "created_at", "updated_at", "cryptocurrency", "amount_nano", "deposit_nano",
-				"image_data", "image_type", "ends_at", "next_deadline", "contract_address"},
+				"image_hash", "image_type", "ends_at", "next_deadline", "contract_address", "visibility",
+				"terms_version"},
 				[]driver.Value{dID.String(), "t", "d", now, now, "TON", int64(100_000_000_000), int64(20_000_000_000),
-				[]byte{1}, "image/png", now.Add(2 * time.Hour), now.Add(1 * time.Hour), "addr"},
+				"ab12", "image/png", now.Add(2 * time.Hour), now.Add(1 * time.Hour), "addr", "public", int64(2)},
 			), nil
 		},
 	})
@@ -28,7 +30,8 @@ func TestGetDisputeByID(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if d.ID != dID || d.ContractAddress != "addr" || d.AmountNano != 100_000_000_000 {
+	if d.ID != dID || d.ContractAddress != "addr" || d.AmountNano != 100_000_000_000 ||
+		d.Visibility != models.DisputeVisibilityPublic || d.TermsVersion != 2 || *d.ImageHash != "ab12" {
 		t.Fatalf("unexpected dispute: %#v", d)
 	}
 }
@@ -63,3 +66,50 @@ func TestInsertDispute(t *testing.T) {
 		t.Fatalf("expected 1 exec, got %d", execCalls)
 	}
 }
+
+func TestClaimOverdueDisputes(t *testing.T) {
+	dID := uuid.New()
+	now := time.Now()
+	var gotArgs []driver.NamedValue
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(_ string, args []driver.NamedValue) (driver.Rows, error) {
+			gotArgs = args
+			return newRows([]string{"id"}, []driver.Value{dID.String()}), nil
+		},
+	})
+
+	ids, err := repo.ClaimOverdueDisputes(context.Background(), now, now.Add(time.Minute), [][]models.Result{
+		{models.DisputesResultNew, models.DisputesResultSent},
+		{models.DisputesResultAnswered},
+	}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != dID {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+	if len(gotArgs) != 5 || gotArgs[2].Value != "{\"new\",\"sent\",\"answered\"}" || gotArgs[3].Value != "{0,0,1}" ||
+		gotArgs[4].Value != int64(10) {
+		t.Fatalf("unexpected args: %#v", gotArgs)
+	}
+}
+
+func TestReleaseDisputeDeadline(t *testing.T) {
+	dID := uuid.New()
+	var gotQuery string
+	var gotArgs []driver.NamedValue
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			gotQuery, gotArgs = query, args
+			return driver.RowsAffected(1), nil
+		},
+	})
+
+	if err := repo.ReleaseDisputeDeadline(context.Background(), dID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(gotQuery, "deadline_locked_until = NULL") || len(gotArgs) != 1 ||
+		gotArgs[0].Value != dID.String() {
+		t.Fatalf("unexpected query %q with args %#v", gotQuery, gotArgs)
+	}
+}
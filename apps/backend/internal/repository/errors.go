@@ -7,6 +7,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrAlreadyVoted is returned when a juror who already voted in the round votes again.
+	ErrAlreadyVoted = errors.New("juror already voted")
 )
 
 func handleNotFoundError(err error) error {
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+type eventSourceKey struct{}
+
+// eventSource is who caused the changes made with a context and the message that confirmed them.
+type eventSource struct {
+	actorUsername string
+	txHash        string
+}
+
+// WithEventSource attributes dispute events recorded with the returned context to the user and the confirmed
+// message with txHash. Either may be empty.
+func WithEventSource(ctx context.Context, actorUsername, txHash string) context.Context {
+	return context.WithValue(ctx, eventSourceKey{}, eventSource{actorUsername: actorUsername, txHash: txHash})
+}
+
+// InsertDisputeEvent appends the event to the dispute history. An actor or a tx hash the event doesn't carry is
+// taken from the source set with WithEventSource.
+func (repo *Repository) InsertDisputeEvent(ctx context.Context, event models.DisputeEvent) error {
+	source, _ := ctx.Value(eventSourceKey{}).(eventSource)
+	txHash := event.TxHash
+	if txHash == nil && source.txHash != "" {
+		txHash = &source.txHash
+	}
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO dispute_events (id, dispute_id, participant_id, actor_id, action, actor, from_result, to_result,
+		tx_hash, created_at)
+	VALUES ($1, $2, $3, COALESCE($4, (SELECT id FROM users WHERE username = $5)), $6, $7, $8, $9, $10, $11)`,
+		event.ID,
+		event.DisputeID,
+		event.ParticipantID,
+		event.ActorID,
+		source.actorUsername,
+		event.Action,
+		event.Actor,
+		event.FromResult,
+		event.ToResult,
+		txHash,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert dispute event: %w", err)
+	}
+	return nil
+}
+
+// ListDisputeEvents returns the dispute history, oldest first.
+func (repo *Repository) ListDisputeEvents(ctx context.Context, disputeID uuid.UUID) ([]models.DisputeEventCard, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT e.action, e.actor, actor.username, owner.username, e.from_result, e.to_result, e.tx_hash, e.created_at
+		FROM dispute_events e
+		LEFT JOIN users actor ON actor.id = e.actor_id
+		LEFT JOIN participants p ON p.id = e.participant_id
+		LEFT JOIN users owner ON owner.id = p.user_id
+		WHERE e.dispute_id = $1
+		ORDER BY e.created_at, e.id`,
+		disputeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispute events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.DisputeEventCard
+	for rows.Next() {
+		var e models.DisputeEventCard
+		if err = rows.Scan(
+			&e.Action,
+			&e.Actor,
+			&e.Username,
+			&e.Participant,
+			&e.FromResult,
+			&e.ToResult,
+			&e.TxHash,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dispute event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list dispute events: %w", err)
+	}
+	return events, nil
+}
+
+// CanViewDisputeHistory reports whether the user is a participant of the dispute or a juror of its investigation.
+func (repo *Repository) CanViewDisputeHistory(ctx context.Context, disputeID uuid.UUID, actorUsername string,
+) (bool, error) {
+	var ok bool
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM participants p
+			JOIN users u ON u.id = p.user_id
+			WHERE p.dispute_id = $1 AND u.username = $2
+		) OR EXISTS (
+			SELECT 1
+			FROM jurors j
+			JOIN investigations i ON i.id = j.investigation_id
+			JOIN users u ON u.id = j.user_id
+			WHERE i.dispute_id = $1 AND u.username = $2
+		)`,
+		disputeID, actorUsername,
+	).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check dispute history access: %w", err)
+	}
+	return ok, nil
+}
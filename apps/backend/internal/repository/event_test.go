@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestUpdateParticipantRecordsEvent(t *testing.T) {
+	var eventArgs []driver.NamedValue
+	stub := &stubDB{
+		execFn: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.Contains(query, "INSERT INTO dispute_events") {
+				eventArgs = args
+			}
+			return driver.RowsAffected(1), nil
+		},
+	}
+	repo := newTestRepo(t, stub)
+
+	p := models.Participant{ID: uuid.New(), DisputeID: uuid.New(), Result: models.DisputesResultProcessed}
+	opts, err := p.Transition(models.DisputeActionVote, models.ActorSelf, models.DisputesResultAnswered)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := WithEventSource(context.Background(), "alice", "hash")
+	if err = repo.UpdateParticipant(ctx, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stub.begins != 1 || stub.commits != 1 {
+		t.Fatalf("expected update and event in one transaction, got %d begins and %d commits",
+			stub.begins, stub.commits)
+	}
+	if eventArgs == nil {
+		t.Fatal("expected dispute event to be recorded")
+	}
+	if eventArgs[4].Value != "alice" || eventArgs[7].Value != string(models.DisputesResultProcessed) ||
+		eventArgs[8].Value != string(models.DisputesResultAnswered) || eventArgs[9].Value != "hash" {
+		t.Fatalf("unexpected event args: %v", eventArgs)
+	}
+}
+
+func TestUpdateParticipantLosesRaceToConcurrentTransition(t *testing.T) {
+	var recorded bool
+	stub := &stubDB{
+		execFn: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			if strings.Contains(query, "INSERT INTO dispute_events") {
+				recorded = true
+				return driver.RowsAffected(1), nil
+			}
+			if args[6].Value != string(models.DisputesResultProcessed) {
+				t.Fatalf("expected update guarded by the read result, got %v", args[6].Value)
+			}
+			return driver.RowsAffected(0), nil
+		},
+	}
+	repo := newTestRepo(t, stub)
+
+	p := models.Participant{ID: uuid.New(), DisputeID: uuid.New(), Result: models.DisputesResultProcessed}
+	opts, err := p.Transition(models.DisputeActionVote, models.ActorSelf, models.DisputesResultAnswered)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = repo.UpdateParticipant(context.Background(), opts); !errors.Is(err, models.ErrDisputeTransition) {
+		t.Fatalf("expected ErrDisputeTransition, got %v", err)
+	}
+	if recorded || stub.rollbacks != 1 {
+		t.Fatalf("expected no event and a rollback, got recorded=%v rollbacks=%d", recorded, stub.rollbacks)
+	}
+}
+
+func TestListDisputeEvents(t *testing.T) {
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"action", "actor", "username", "participant", "from_result", "to_result", "tx_hash",
+					"created_at"},
+				[]driver.Value{"create", "self", "alice", "alice", nil, "sent", nil, now},
+				[]driver.Value{"expire", "system", nil, "alice", "sent", "rejected", nil, now},
+			), nil
+		},
+	})
+
+	events, err := repo.ListDisputeEvents(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].FromResult != nil || events[1].Username != nil ||
+		*events[1].ToResult != models.DisputesResultRejected {
+		t.Fatalf("unexpected events: %#v", events)
+	}
+}
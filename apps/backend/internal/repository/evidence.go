@@ -3,29 +3,50 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
 )
 
+// InsertEvidence records the evidence with its attachments.
 func (repo *Repository) InsertEvidence(ctx context.Context, evidence models.Evidence) error {
-	_, err := repo.db.ExecContext(ctx, `
-	INSERT INTO evidences (id, participant_id, description, image_data, image_type) 
-	VALUES ($1, $2, $3, $4, $5)`,
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO evidences (id, participant_id, description, content_hash) 
+	VALUES ($1, $2, $3, $4)`,
 		evidence.ID,
 		evidence.ParticipantID,
 		evidence.Description,
-		evidence.ImageData,
-		evidence.ImageType,
+		evidence.ContentHash,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert evidence: %w", err)
 	}
+	for _, a := range evidence.Attachments {
+		_, err = repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO evidence_attachments (id, evidence_id, position, kind, media_hash, upload_hash, thumbnail_hash,
+			caption, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			a.ID,
+			a.EvidenceID,
+			a.Position,
+			a.Kind,
+			a.MediaHash,
+			a.UploadHash,
+			a.ThumbnailHash,
+			a.Caption,
+			a.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert evidence attachment: %w", err)
+		}
+	}
 	return nil
 }
 
 func (repo *Repository) IsFirstEvidence(ctx context.Context, disputeID string) (bool, error) {
 	var count int
-	err := repo.db.QueryRowContext(ctx, `
+	err := repo.conn(ctx).QueryRowContext(ctx, `
 	SELECT COUNT(*)
 	FROM evidences e
 	JOIN participants p ON p.id = e.participant_id
@@ -38,8 +59,8 @@ func (repo *Repository) IsFirstEvidence(ctx context.Context, disputeID string) (
 
 func (repo *Repository) GetEvidences(ctx context.Context, disputeID uuid.UUID) ([]models.Evidence, error) {
 	var evidences []models.Evidence
-	rows, err := repo.db.QueryContext(ctx, `
-	SELECT e.id, e.participant_id, e.description, e.image_data, e.image_type
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+	SELECT e.id, p.dispute_id, e.participant_id, e.description, e.content_hash, e.released_at
 	FROM evidences e
 	JOIN participants p ON p.id = e.participant_id
 	WHERE p.dispute_id = $1
@@ -51,7 +72,8 @@ func (repo *Repository) GetEvidences(ctx context.Context, disputeID uuid.UUID) (
 
 	for rows.Next() {
 		var e models.Evidence
-		if err := rows.Scan(&e.ID, &e.ParticipantID, &e.Description, &e.ImageData, &e.ImageType); err != nil {
+		if err := rows.Scan(&e.ID, &e.DisputeID, &e.ParticipantID, &e.Description, &e.ContentHash,
+			&e.ReleasedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan evidence: %w", err)
 		}
 		evidences = append(evidences, e)
@@ -63,3 +85,64 @@ func (repo *Repository) GetEvidences(ctx context.Context, disputeID uuid.UUID) (
 
 	return evidences, nil
 }
+
+func (repo *Repository) GetEvidence(ctx context.Context, evidenceID uuid.UUID) (models.Evidence, error) {
+	var e models.Evidence
+	err := repo.conn(ctx).QueryRowContext(ctx, `
+	SELECT e.id, p.dispute_id, e.participant_id, e.description, e.content_hash, e.released_at
+	FROM evidences e
+	JOIN participants p ON p.id = e.participant_id
+	WHERE e.id = $1`, evidenceID,
+	).Scan(&e.ID, &e.DisputeID, &e.ParticipantID, &e.Description, &e.ContentHash, &e.ReleasedAt)
+	if err != nil {
+		return models.Evidence{}, fmt.Errorf("failed to get evidence: %w", handleNotFoundError(err))
+	}
+	return e, nil
+}
+
+// ReleaseEvidences unseals the evidence of every party of the dispute submitted so far.
+func (repo *Repository) ReleaseEvidences(ctx context.Context, disputeID uuid.UUID, releasedAt time.Time) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	UPDATE evidences e
+	SET released_at = $2
+	FROM participants p
+	WHERE p.id = e.participant_id AND p.dispute_id = $1 AND e.released_at IS NULL`,
+		disputeID, releasedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release evidences: %w", err)
+	}
+	return nil
+}
+
+// ListEvidenceAttachments returns the attachments of every evidence of the dispute, in order.
+func (repo *Repository) ListEvidenceAttachments(ctx context.Context, disputeID uuid.UUID,
+) ([]models.EvidenceAttachment, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+	SELECT a.id, a.evidence_id, a.position, a.kind, a.media_hash, a.upload_hash, a.thumbnail_hash, m.content_type,
+		m.size, a.caption, a.created_at
+	FROM evidence_attachments a
+	JOIN media m ON m.hash = a.media_hash
+	JOIN evidences e ON e.id = a.evidence_id
+	JOIN participants p ON p.id = e.participant_id
+	WHERE p.dispute_id = $1
+	ORDER BY a.evidence_id, a.position`, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evidence attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []models.EvidenceAttachment
+	for rows.Next() {
+		var a models.EvidenceAttachment
+		if err := rows.Scan(&a.ID, &a.EvidenceID, &a.Position, &a.Kind, &a.MediaHash, &a.UploadHash,
+			&a.ThumbnailHash, &a.ContentType, &a.Size, &a.Caption, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan evidence attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return attachments, nil
+}
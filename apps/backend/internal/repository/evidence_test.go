@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
@@ -30,9 +33,9 @@ func TestGetEvidences(t *testing.T) {
 	repo := newTestRepo(t, &stubDB{
 		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
 			return newRows(
-				[]string{"id", "participant_id", "description", "image_data", "image_type"},
-				[]driver.Value{uuid.NewString(), uuid.NewString(), "one", []byte{1}, "image/png"},
-				[]driver.Value{uuid.NewString(), uuid.NewString(), "two", []byte{2}, "image/jpeg"},
+				[]string{"id", "dispute_id", "participant_id", "description", "content_hash", "released_at"},
+				[]driver.Value{uuid.NewString(), dID.String(), uuid.NewString(), "one", "ab12", time.Now()},
+				[]driver.Value{uuid.NewString(), dID.String(), uuid.NewString(), "two", nil, nil},
 			), nil
 		},
 	})
@@ -41,25 +44,96 @@ func TestGetEvidences(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(evidences) != 2 || evidences[0].Description != "one" {
+	if len(evidences) != 2 || evidences[0].Description != "one" || evidences[0].DisputeID != dID ||
+		*evidences[0].ContentHash != "ab12" || evidences[0].ReleasedAt == nil || evidences[1].ContentHash != nil ||
+		evidences[1].ReleasedAt != nil {
 		t.Fatalf("unexpected evidences: %#v", evidences)
 	}
 }
 
+func TestListEvidenceAttachments(t *testing.T) {
+	eID := uuid.New()
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(query string, _ []driver.NamedValue) (driver.Rows, error) {
+			if !strings.Contains(query, "JOIN media m ON m.hash = a.media_hash") {
+				t.Fatalf("expected the media joined: %s", query)
+			}
+			return newRows(
+				[]string{"id", "evidence_id", "position", "kind", "media_hash", "upload_hash", "thumbnail_hash",
+					"content_type", "size", "caption", "created_at"},
+				[]driver.Value{uuid.NewString(), eID.String(), int64(0), "image", "ab12", "0f0f", "cd34", "image/png",
+					int64(10), "chat", now},
+				[]driver.Value{uuid.NewString(), eID.String(), int64(1), "pdf", "ef56", nil, nil, "application/pdf",
+					int64(20), nil, now},
+			), nil
+		},
+	})
+
+	attachments, err := repo.ListEvidenceAttachments(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attachments) != 2 || attachments[0].EvidenceID != eID || *attachments[0].ThumbnailHash != "cd34" ||
+		*attachments[0].Caption != "chat" || *attachments[0].UploadHash != "0f0f" ||
+		attachments[1].Kind != models.AttachmentKindPDF || attachments[1].Position != 1 || attachments[1].Size != 20 || attachments[1].Caption != nil {
+		t.Fatalf("unexpected attachments: %#v", attachments)
+	}
+}
+
+func TestGetEvidence(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"id", "dispute_id", "participant_id", "description", "content_hash",
+				"released_at"}), nil
+		},
+	})
+
+	if _, err := repo.GetEvidence(context.Background(), uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReleaseEvidences(t *testing.T) {
+	dID := uuid.New()
+	releasedAt := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			if !strings.Contains(query, "released_at IS NULL") {
+				t.Fatalf("expected only sealed evidence released: %s", query)
+			}
+			if len(args) != 2 || args[0].Value != dID.String() || args[1].Value != releasedAt {
+				t.Fatalf("unexpected args: %v", args)
+			}
+			return driver.RowsAffected(2), nil
+		},
+	})
+
+	if err := repo.ReleaseEvidences(context.Background(), dID, releasedAt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestInsertEvidence(t *testing.T) {
-	execCalls := 0
+	var queries []string
 	repo := newTestRepo(t, &stubDB{
-		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
-			execCalls++
+		execFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			queries = append(queries, query)
 			return driver.RowsAffected(1), nil
 		},
 	})
 
-	err := repo.InsertEvidence(context.Background(), models.Evidence{ID: uuid.New(), ParticipantID: uuid.New(), Description: "x"})
-	if err != nil {
+	evidence := models.NewEvidence(uuid.New(), models.EvidenceOpts{
+		Description: "x",
+		Attachments: []models.StoredAttachment{
+			{Kind: models.AttachmentKindImage, Hash: "ab12"},
+			{Kind: models.AttachmentKindVideo, Hash: "cd34"},
+		},
+	})
+	if err := repo.InsertEvidence(context.Background(), evidence); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if execCalls != 1 {
-		t.Fatalf("expected 1 exec, got %d", execCalls)
+	if len(queries) != 3 || !strings.Contains(queries[1], "evidence_attachments") {
+		t.Fatalf("expected the evidence and 2 attachments inserted, got %q", queries)
 	}
 }
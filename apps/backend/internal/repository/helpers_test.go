@@ -26,6 +26,10 @@ func (noopLogger) Sync() error { return nil }
 type stubDB struct {
 	queryFn func(query string, args []driver.NamedValue) (driver.Rows, error)
 	execFn  func(query string, args []driver.NamedValue) (driver.Result, error)
+
+	begins    int
+	commits   int
+	rollbacks int
 }
 
 type stubConnector struct{ stub *stubDB }
@@ -41,7 +45,22 @@ type stubConn struct{ stub *stubDB }
 
 func (c *stubConn) Prepare(string) (driver.Stmt, error) { return nil, fmt.Errorf("not supported") }
 func (c *stubConn) Close() error                        { return nil }
-func (c *stubConn) Begin() (driver.Tx, error)           { return nil, fmt.Errorf("not supported") }
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.stub.begins++
+	return &stubTx{stub: c.stub}, nil
+}
+
+type stubTx struct{ stub *stubDB }
+
+func (t *stubTx) Commit() error {
+	t.stub.commits++
+	return nil
+}
+
+func (t *stubTx) Rollback() error {
+	t.stub.rollbacks++
+	return nil
+}
 
 func (c *stubConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
 	if c.stub.queryFn == nil {
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
@@ -10,9 +11,14 @@ import (
 )
 
 func (repo *Repository) InsertInvestigation(ctx context.Context, investigation models.Investigation) error {
-	_, err := repo.db.ExecContext(ctx, `
-	INSERT INTO investigations (id, dispute_id, total, p1, p2, draw, status, ends_at, title) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+	policy, err := json.Marshal(investigation.VerdictPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verdict policy: %w", err)
+	}
+	_, err = repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO investigations (id, dispute_id, total, p1, p2, draw, status, ends_at, title, voting_mode, commit_ends_at,
+		verdict_policy, round)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
 		investigation.ID,
 		investigation.DisputeID,
 		investigation.Total,
@@ -22,6 +28,10 @@ func (repo *Repository) InsertInvestigation(ctx context.Context, investigation m
 		investigation.Status,
 		investigation.EndsAt,
 		investigation.Title,
+		investigation.VotingMode,
+		investigation.CommitEndsAt,
+		policy,
+		investigation.Round,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert investigation: %w", err)
@@ -84,7 +94,7 @@ func (repo *Repository) ListInvestigationCards(ctx context.Context, actorUsernam
 		LIMIT $%d
 	`, whereSQL, idx)
 
-	rows, err := repo.db.QueryContext(ctx, query, args...)
+	rows, err := repo.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute ListInvestigationCards query: %w", err)
 	}
@@ -120,15 +130,18 @@ func (repo *Repository) GetInvestigation(ctx context.Context, invID, userID uuid
 		SELECT
 		  i.id, i.dispute_id, i.title,
 		  i.total, i.p1, i.p2, i.draw,
-		  i.status, i.created_at, i.ends_at
+		  i.status, i.created_at, i.ends_at, i.voting_mode, i.commit_ends_at, i.verdict_policy, i.round
 		FROM investigations i
 		JOIN jurors u ON i.id = u.investigation_id
 		WHERE i.id = $1 AND u.user_id = $2
 	`
 
-	row := repo.db.QueryRowContext(ctx, query, invID, userID)
+	row := repo.conn(ctx).QueryRowContext(ctx, query, invID, userID)
 
-	var investigation models.Investigation
+	var (
+		investigation models.Investigation
+		policy        []byte
+	)
 	if err := row.Scan(
 		&investigation.ID,
 		&investigation.DisputeID,
@@ -140,9 +153,16 @@ func (repo *Repository) GetInvestigation(ctx context.Context, invID, userID uuid
 		&investigation.Status,
 		&investigation.CreatedAt,
 		&investigation.EndsAt,
+		&investigation.VotingMode,
+		&investigation.CommitEndsAt,
+		&policy,
+		&investigation.Round,
 	); err != nil {
 		return models.Investigation{}, fmt.Errorf("failed to scan investigation: %w", err)
 	}
+	if err := json.Unmarshal(policy, &investigation.VerdictPolicy); err != nil {
+		return models.Investigation{}, fmt.Errorf("failed to unmarshal verdict policy: %w", err)
+	}
 
 	return investigation, nil
 }
@@ -152,16 +172,20 @@ func (repo *Repository) GetInvestigationDetails(ctx context.Context, id uuid.UUI
 	query := `
 		SELECT
 		  i.id, i.dispute_id, i.total, i.p1, i.p2, i.draw, i.status, i.created_at, i.ends_at, i.title,
-		  u.result, u.vote
+		  u.result, u.vote, i.voting_mode, i.commit_ends_at, u.commitment IS NOT NULL,
+		  i.verdict_policy, i.round, i.verdict
 		FROM investigations i
 		JOIN jurors u ON i.id = u.investigation_id
 		JOIN users me ON me.id = u.user_id
 		WHERE i.id = $1 AND me.username = $2
 	`
 
-	row := repo.db.QueryRowContext(ctx, query, id, actorUsername)
+	row := repo.conn(ctx).QueryRowContext(ctx, query, id, actorUsername)
 
-	var investigation models.InvestigationDetails
+	var (
+		investigation   models.InvestigationDetails
+		policy, verdict []byte
+	)
 	if err := row.Scan(
 		&investigation.ID,
 		&investigation.DisputeID,
@@ -175,26 +199,175 @@ func (repo *Repository) GetInvestigationDetails(ctx context.Context, id uuid.UUI
 		&investigation.Title,
 		&investigation.Result,
 		&investigation.Vote,
+		&investigation.VotingMode,
+		&investigation.CommitEndsAt,
+		&investigation.Committed,
+		&policy,
+		&investigation.Round,
+		&verdict,
 	); err != nil {
 		return models.InvestigationDetails{}, fmt.Errorf("failed to scan investigation details: %w", err)
 	}
+	if err := json.Unmarshal(policy, &investigation.VerdictPolicy); err != nil {
+		return models.InvestigationDetails{}, fmt.Errorf("failed to unmarshal verdict policy: %w", err)
+	}
+	if verdict != nil {
+		investigation.Verdict = new(models.Verdict)
+		if err := json.Unmarshal(verdict, investigation.Verdict); err != nil {
+			return models.InvestigationDetails{}, fmt.Errorf("failed to unmarshal verdict: %w", err)
+		}
+	}
 
 	return investigation, nil
 }
 
 func (repo *Repository) UpdateInvestigation(ctx context.Context, opts models.InvestigationUpdateOpts) error {
+	var verdict []byte
+	if opts.Verdict != nil {
+		var err error
+		if verdict, err = json.Marshal(opts.Verdict); err != nil {
+			return fmt.Errorf("failed to marshal verdict: %w", err)
+		}
+	}
 	query := `
 		UPDATE investigations
 		SET status = COALESCE($1, status),
 			p1 = COALESCE($2, p1),
 			p2 = COALESCE($3, p2),
 			draw = COALESCE($4, draw),
-			total = COALESCE($5, total)
-		WHERE id = $6
+			total = COALESCE($5, total),
+			ends_at = COALESCE($6, ends_at),
+			commit_ends_at = COALESCE($7, commit_ends_at),
+			round = COALESCE($8, round),
+			verdict = COALESCE($9, verdict)
+		WHERE id = $10
 	`
-	_, err := repo.db.ExecContext(ctx, query, opts.Status, opts.P1, opts.P2, opts.Draw, opts.Total, opts.ID)
+	_, err := repo.conn(ctx).ExecContext(ctx, query, opts.Status, opts.P1, opts.P2, opts.Draw, opts.Total, opts.EndsAt,
+		opts.CommitEndsAt, opts.Round, verdict, opts.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update investigation: %w", err)
 	}
 	return nil
 }
+
+func (repo *Repository) ClaimExpiredInvestigations(ctx context.Context, now, lockUntil time.Time, limit int,
+) ([]models.Investigation, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		UPDATE investigations i
+		SET locked_until = $2
+		WHERE i.id IN (
+			SELECT ei.id
+			FROM investigations ei
+			WHERE ei.status = $3
+			  AND ei.ends_at <= $1
+			  AND (ei.locked_until IS NULL OR ei.locked_until < $1)
+			ORDER BY ei.ends_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING i.id, i.dispute_id, i.title, i.total, i.p1, i.p2, i.draw, i.status, i.created_at, i.ends_at,
+			i.voting_mode, i.commit_ends_at, i.verdict_policy, i.round
+	`, now, lockUntil, models.InvestigationStatusCurrent, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim expired investigations: %w", err)
+	}
+	defer rows.Close()
+
+	var investigations []models.Investigation
+	for rows.Next() {
+		var (
+			investigation models.Investigation
+			policy        []byte
+		)
+		if err := rows.Scan(
+			&investigation.ID,
+			&investigation.DisputeID,
+			&investigation.Title,
+			&investigation.Total,
+			&investigation.P1,
+			&investigation.P2,
+			&investigation.Draw,
+			&investigation.Status,
+			&investigation.CreatedAt,
+			&investigation.EndsAt,
+			&investigation.VotingMode,
+			&investigation.CommitEndsAt,
+			&policy,
+			&investigation.Round,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan expired investigation: %w", err)
+		}
+		if err := json.Unmarshal(policy, &investigation.VerdictPolicy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal verdict policy: %w", err)
+		}
+		investigations = append(investigations, investigation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over expired investigations: %w", err)
+	}
+
+	return investigations, nil
+}
+
+// LockInvestigation locks the investigation row until the transaction ends and returns it, so that votes are
+// counted and the investigation is finalized one transaction at a time.
+func (repo *Repository) LockInvestigation(ctx context.Context, invID uuid.UUID) (models.Investigation, error) {
+	var (
+		investigation models.Investigation
+		policy        []byte
+	)
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, dispute_id, title, total, p1, p2, draw, status, created_at, ends_at, voting_mode, commit_ends_at,
+			verdict_policy, round
+		FROM investigations
+		WHERE id = $1
+		FOR UPDATE`,
+		invID,
+	).Scan(
+		&investigation.ID,
+		&investigation.DisputeID,
+		&investigation.Title,
+		&investigation.Total,
+		&investigation.P1,
+		&investigation.P2,
+		&investigation.Draw,
+		&investigation.Status,
+		&investigation.CreatedAt,
+		&investigation.EndsAt,
+		&investigation.VotingMode,
+		&investigation.CommitEndsAt,
+		&policy,
+		&investigation.Round,
+	); err != nil {
+		return models.Investigation{}, fmt.Errorf("failed to lock investigation: %w", handleNotFoundError(err))
+	}
+	if err := json.Unmarshal(policy, &investigation.VerdictPolicy); err != nil {
+		return models.Investigation{}, fmt.Errorf("failed to unmarshal verdict policy: %w", err)
+	}
+	return investigation, nil
+}
+
+// RecountInvestigationVotes counts the votes stored for the jurors of the investigation and stores the counts.
+func (repo *Repository) RecountInvestigationVotes(ctx context.Context, invID uuid.UUID) (models.VoteCounts, error) {
+	var counts models.VoteCounts
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		UPDATE investigations i
+		SET p1 = c.p1, p2 = c.p2, draw = c.draw
+		FROM (
+			SELECT
+			  COUNT(*) FILTER (WHERE vote = 'p1') AS p1,
+			  COUNT(*) FILTER (WHERE vote = 'p2') AS p2,
+			  COUNT(*) FILTER (WHERE vote = 'draw') AS draw,
+			  COUNT(*) FILTER (WHERE vote <> '') AS total
+			FROM jurors
+			WHERE investigation_id = $1
+		) c
+		WHERE i.id = $1
+		RETURNING c.p1, c.p2, c.draw, c.total`,
+		invID,
+	).Scan(&counts.P1, &counts.P2, &counts.Draw, &counts.Total); err != nil {
+		return models.VoteCounts{}, fmt.Errorf("failed to count investigation votes: %w", handleNotFoundError(err))
+	}
+
+	return counts, nil
+}
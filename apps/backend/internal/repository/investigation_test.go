@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -28,7 +29,8 @@ func TestGetInvestigation(t *testing.T) {
 	repo := newTestRepo(t, &stubDB{
 		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
 			return newRows(
-				[]string{"id", "dispute_id", "title", "total", "p1", "p2", "draw", "status", "created_at", "ends_at"},
+				[]string{"id", "dispute_id", "title", "total", "p1", "p2", "draw", "status", "created_at", "ends_at",
+					"voting_mode", "commit_ends_at", "verdict_policy", "round"},
 				[]driver.Value{
 					invID.String(),
 					disputeID.String(),
@@ -40,6 +42,10 @@ func TestGetInvestigation(t *testing.T) {
 					string(models.InvestigationStatusCurrent),
 					now,
 					now,
+					string(models.VotingModeCommitReveal),
+					now,
+					[]byte(`{"quorum": 3, "requireRationale": true}`),
+					int64(2),
 				},
 			), nil
 		},
@@ -49,7 +55,9 @@ func TestGetInvestigation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if inv.ID != invID || inv.Title != "INV" {
+	if inv.ID != invID || inv.Title != "INV" || inv.VotingMode != models.VotingModeCommitReveal ||
+		inv.CommitEndsAt == nil || inv.Round != 2 || inv.VerdictPolicy.Quorum != 3 ||
+		!inv.VerdictPolicy.RequireRationale {
 		t.Fatalf("unexpected investigation: %#v", inv)
 	}
 }
@@ -72,22 +80,87 @@ func TestUpdateWinnersResult(t *testing.T) {
 	}
 }
 
-func TestGetDisputesUsers(t *testing.T) {
+func TestCastJurorVote(t *testing.T) {
+	affected := int64(1)
 	repo := newTestRepo(t, &stubDB{
-		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+		execFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			if !strings.Contains(query, "COALESCE(vote, '') = ''") {
+				t.Fatalf("expected the vote cast only once, got %s", query)
+			}
+			return driver.RowsAffected(affected), nil
+		},
+	})
+
+	if err := repo.CastJurorVote(context.Background(), uuid.New(), "p1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	affected = 0
+	err := repo.CastJurorVote(context.Background(), uuid.New(), "p1", new("because"))
+	if !errors.Is(err, ErrAlreadyVoted) {
+		t.Fatalf("expected ErrAlreadyVoted, got %v", err)
+	}
+}
+
+func TestLockInvestigation(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(query string, _ []driver.NamedValue) (driver.Rows, error) {
+			if !strings.Contains(query, "FOR UPDATE") {
+				t.Fatalf("expected the investigation row locked, got %s", query)
+			}
+			return newRows([]string{"id"}), nil
+		},
+	})
+
+	if _, err := repo.LockInvestigation(context.Background(), uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRecountInvestigationVotes(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(query string, _ []driver.NamedValue) (driver.Rows, error) {
+			if !strings.Contains(query, "FROM jurors") {
+				t.Fatalf("expected the votes counted from jurors, got %s", query)
+			}
+			return newRows(
+				[]string{"p1", "p2", "draw", "total"},
+				[]driver.Value{int64(2), int64(1), int64(0), int64(3)},
+			), nil
+		},
+	})
+
+	counts, err := repo.RecountInvestigationVotes(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counts != (models.VoteCounts{P1: 2, P2: 1, Total: 3}) {
+		t.Fatalf("unexpected counts: %+v", counts)
+	}
+}
+
+func TestClaimExpiredInvestigations(t *testing.T) {
+	invID := uuid.New()
+	dID := uuid.New()
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(_ string, args []driver.NamedValue) (driver.Rows, error) {
+			if args[2].Value != string(models.InvestigationStatusCurrent) {
+				t.Fatalf("unexpected status arg: %#v", args[2].Value)
+			}
 			return newRows(
-				[]string{"id", "username", "chat_id", "notification_enabled", "rating"},
-				[]driver.Value{uuid.NewString(), "alice", int64(10), true, 5},
-				[]driver.Value{uuid.NewString(), "bob", int64(20), false, 7},
+				[]string{"id", "dispute_id", "title", "total", "p1", "p2", "draw", "status", "created_at", "ends_at",
+					"voting_mode", "commit_ends_at", "verdict_policy", "round"},
+				[]driver.Value{invID.String(), dID.String(), "t", int64(3), int64(1), int64(0), int64(0), "current",
+					now, now, "open", nil, []byte(`{"quorum": 1}`), int64(1)},
 			), nil
 		},
 	})
 
-	users, err := repo.GetDisputesUsers(context.Background(), uuid.New())
+	invs, err := repo.ClaimExpiredInvestigations(context.Background(), now, now.Add(time.Minute), 10)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(users) != 2 || users[0].Username != "alice" || users[1].Rating != 7 {
-		t.Fatalf("unexpected users: %#v", users)
+	if len(invs) != 1 || invs[0].ID != invID || invs[0].P1 != 1 || invs[0].Total != 3 {
+		t.Fatalf("unexpected investigations: %#v", invs)
 	}
 }
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+const invitationColumns = `id, dispute_id, creator_id, username, token, status, created_at, expires_at, accepted_by, accepted_at`
+
+func (repo *Repository) InsertInvitation(ctx context.Context, inv models.Invitation) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO invitations (id, dispute_id, creator_id, username, token, status, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		inv.ID,
+		inv.DisputeID,
+		inv.CreatorID,
+		inv.Username,
+		inv.Token,
+		inv.Status,
+		inv.CreatedAt,
+		inv.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert invitation: %w", err)
+	}
+	return nil
+}
+
+// ExpireInvitations closes the pending invitations addressed to username whose deadline has passed.
+func (repo *Repository) ExpireInvitations(ctx context.Context, username string, now time.Time) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE invitations
+		SET status = $2
+		WHERE lower(username) = lower($1)
+		  AND status = $3
+		  AND expires_at <= $4`,
+		username, models.InvitationStatusExpired, models.InvitationStatusPending, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to expire invitations: %w", err)
+	}
+	return nil
+}
+
+// ListPendingInvitations returns the invitations addressed to username that userID can still claim.
+func (repo *Repository) ListPendingInvitations(ctx context.Context, username string, userID uuid.UUID,
+	now time.Time,
+) ([]models.Invitation, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT `+invitationColumns+`
+		FROM invitations
+		WHERE lower(username) = lower($1)
+		  AND status = $2
+		  AND expires_at > $3
+		  AND creator_id <> $4
+		ORDER BY created_at`,
+		username, models.InvitationStatusPending, now, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+	return invitations, nil
+}
+
+// ClaimInvitation hands the pending invitation id over to userID.
+func (repo *Repository) ClaimInvitation(ctx context.Context, id, userID uuid.UUID, now time.Time,
+) (models.Invitation, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+		UPDATE invitations
+		SET accepted_by = $2, accepted_at = $3, status = $4
+		WHERE id = $1
+		  AND status = $5
+		  AND expires_at > $3
+		  AND creator_id <> $2
+		RETURNING `+invitationColumns,
+		id, userID, now, models.InvitationStatusAccepted, models.InvitationStatusPending,
+	)
+	inv, err := scanInvitation(row)
+	if err != nil {
+		return models.Invitation{}, handleNotFoundError(err)
+	}
+	return inv, nil
+}
+
+// RejectInvitation closes the pending invitation id so it is no longer offered to the invitee.
+func (repo *Repository) RejectInvitation(ctx context.Context, id uuid.UUID) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE invitations
+		SET status = $2
+		WHERE id = $1
+		  AND status = $3`,
+		id, models.InvitationStatusRejected, models.InvitationStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reject invitation: %w", err)
+	}
+	return nil
+}
+
+// ClaimInvitationByToken hands the pending invitation with token over to userID, whatever username it names.
+func (repo *Repository) ClaimInvitationByToken(ctx context.Context, token string, userID uuid.UUID, now time.Time,
+) (models.Invitation, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+		UPDATE invitations
+		SET accepted_by = $2, accepted_at = $3, status = $4
+		WHERE token = $1
+		  AND status = $5
+		  AND expires_at > $3
+		  AND creator_id <> $2
+		RETURNING `+invitationColumns,
+		token, userID, now, models.InvitationStatusAccepted, models.InvitationStatusPending,
+	)
+	inv, err := scanInvitation(row)
+	if err != nil {
+		return models.Invitation{}, handleNotFoundError(err)
+	}
+	return inv, nil
+}
+
+func scanInvitation(row rowScanner) (models.Invitation, error) {
+	var inv models.Invitation
+	if err := row.Scan(
+		&inv.ID,
+		&inv.DisputeID,
+		&inv.CreatorID,
+		&inv.Username,
+		&inv.Token,
+		&inv.Status,
+		&inv.CreatedAt,
+		&inv.ExpiresAt,
+		&inv.AcceptedBy,
+		&inv.AcceptedAt,
+	); err != nil {
+		return models.Invitation{}, fmt.Errorf("failed to scan invitation: %w", err)
+	}
+	return inv, nil
+}
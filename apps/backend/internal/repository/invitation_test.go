@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+var invitationTestColumns = []string{"id", "dispute_id", "creator_id", "username", "token", "status",
+	"created_at", "expires_at", "accepted_by", "accepted_at"}
+
+func TestListPendingInvitations(t *testing.T) {
+	invID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(_ string, args []driver.NamedValue) (driver.Rows, error) {
+			if args[0].Value != "carol" || args[1].Value != string(models.InvitationStatusPending) ||
+				args[3].Value != userID.String() {
+				t.Fatalf("unexpected args: %#v", args)
+			}
+			return newRows(invitationTestColumns,
+				[]driver.Value{invID.String(), uuid.NewString(), uuid.NewString(), "carol", "tok", "pending", now,
+					now.Add(time.Hour), nil, nil},
+			), nil
+		},
+	})
+
+	invitations, err := repo.ListPendingInvitations(context.Background(), "carol", userID, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].ID != invID || invitations[0].Status != models.InvitationStatusPending {
+		t.Fatalf("unexpected invitations: %+v", invitations)
+	}
+}
+
+func TestClaimInvitationNoLongerPending(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(invitationTestColumns), nil
+		},
+	})
+
+	_, err := repo.ClaimInvitation(context.Background(), uuid.New(), uuid.New(), time.Now())
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClaimInvitationByTokenNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(invitationTestColumns), nil
+		},
+	})
+
+	_, err := repo.ClaimInvitationByToken(context.Background(), "tok", uuid.New(), time.Now())
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
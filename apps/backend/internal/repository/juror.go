@@ -8,62 +8,10 @@ import (
 	"github.com/lib/pq"
 )
 
-func (repo *Repository) BroadcastInvestigation(ctx context.Context, u2i models.Juror, p1, p2 uuid.UUID,
-) ([]uuid.UUID, error) {
-	var ids []uuid.UUID
-	rows, err := repo.db.QueryContext(ctx, `
-		SELECT id
-		FROM users
-		WHERE investigation_readiness = TRUE
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch users: %w", err)
-	}
-	defer rows.Close()
-
-	// Insert investigation for each user
-	for rows.Next() {
-		var (
-			userID uuid.UUID
-		)
-		if err := rows.Scan(&userID); err != nil {
-			return nil, fmt.Errorf("failed to scan user ID: %w", err)
-		}
-		if userID == p1 || userID == p2 {
-			continue
-		}
-
-		_, err := repo.db.ExecContext(ctx, `
-			INSERT INTO jurors (id, user_id, investigation_id, result, vote)
-			VALUES ($1, $2, $3, $4, $5)`,
-			uuid.New(), userID, u2i.InvestigationID, u2i.Result, u2i.Vote,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert jurors: %w", err)
-		}
-		ids = append(ids, userID)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over users: %w", err)
-	}
-
-	_, err = repo.db.ExecContext(ctx, `
-		UPDATE investigations
-		SET total = $1
-		WHERE id = $2
-	`, len(ids), u2i.InvestigationID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update investigation total: %w", err)
-	}
-
-	return ids, nil
-}
-
 func (repo *Repository) GetJuror(ctx context.Context, invID, userID uuid.UUID) (models.Juror, error) {
 	var juror models.Juror
-	if err := repo.db.QueryRowContext(ctx, `
-		SELECT id, investigation_id, user_id, vote, result, updated_at, seen_at
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, investigation_id, user_id, vote, result, updated_at, seen_at, commitment
 		FROM jurors
 		WHERE investigation_id = $1 AND user_id = $2`,
 		invID, userID,
@@ -75,8 +23,9 @@ func (repo *Repository) GetJuror(ctx context.Context, invID, userID uuid.UUID) (
 		&juror.Result,
 		&juror.UpdatedAt,
 		&juror.SeenAt,
+		&juror.Commitment,
 	); err != nil {
-		return models.Juror{}, fmt.Errorf("failed to get juror: %w", err)
+		return models.Juror{}, fmt.Errorf("failed to get juror: %w", handleNotFoundError(err))
 	}
 	return juror, nil
 }
@@ -87,22 +36,59 @@ func (repo *Repository) UpdateJuror(ctx context.Context, opts models.JurorUpdate
 		SET vote = COALESCE($1, vote),
 			result = COALESCE($2, result),
 			seen_at = CASE WHEN $3 THEN now() ELSE seen_at END,
+			commitment = COALESCE($4, commitment),
 			updated_at = now()
-		WHERE id = $4
+		WHERE id = $5
 	`
-	_, err := repo.db.ExecContext(ctx, query, opts.Vote, opts.Result, opts.SeenAt, opts.ID)
+	_, err := repo.conn(ctx).ExecContext(ctx, query, opts.Vote, opts.Result, opts.SeenAt, opts.Commitment, opts.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update jurors: %w", err)
 	}
 	return nil
 }
 
+// CastJurorVote stores the vote of a juror who hasn't voted in the current round yet, with its optional rationale,
+// and returns ErrAlreadyVoted otherwise, so a juror's vote is counted once however many requests race to cast it.
+func (repo *Repository) CastJurorVote(ctx context.Context, jurorID uuid.UUID, vote string, rationale *string) error {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE jurors
+		SET vote = $1, rationale = $2, result = $3, seen_at = now(), updated_at = now()
+		WHERE id = $4 AND COALESCE(vote, '') = ''`,
+		vote, rationale, models.InvestigationResultSent, jurorID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cast juror vote: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to cast juror vote: %w", err)
+	}
+	if n == 0 {
+		return ErrAlreadyVoted
+	}
+	return nil
+}
+
+// CountJurorCommitments returns how many jurors of the investigation committed a vote.
+func (repo *Repository) CountJurorCommitments(ctx context.Context, invID uuid.UUID) (int, error) {
+	var count int
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM jurors
+		WHERE investigation_id = $1 AND commitment IS NOT NULL`,
+		invID,
+	).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count juror commitments: %w", err)
+	}
+	return count, nil
+}
+
 func (repo *Repository) DeleteUsersWithoutVote(ctx context.Context, invID uuid.UUID) error {
 	query := `
 		DELETE FROM jurors
 		WHERE investigation_id = $1 AND vote = ''
 	`
-	_, err := repo.db.ExecContext(ctx, query, invID)
+	_, err := repo.conn(ctx).ExecContext(ctx, query, invID)
 	if err != nil {
 		return fmt.Errorf("failed to delete users without vote: %w", err)
 	}
@@ -112,7 +98,7 @@ func (repo *Repository) DeleteUsersWithoutVote(ctx context.Context, invID uuid.U
 func (repo *Repository) GetWinnersIDs(ctx context.Context, invID uuid.UUID, winner string) ([]uuid.UUID, error) {
 	var ids []uuid.UUID
 
-	rows, err := repo.db.QueryContext(ctx, `
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
 		SELECT user_id
 		FROM jurors
 		WHERE investigation_id = $1 AND vote = $2`,
@@ -144,7 +130,7 @@ func (repo *Repository) UpdateWinnersResult(ctx context.Context, invID uuid.UUID
 		SET result = $1, updated_at = now()
 		WHERE investigation_id = $2 AND user_id = ANY($3)
 	`
-	_, err := repo.db.ExecContext(ctx, queryCorrect, models.InvestigationResultCorrect, invID, pq.Array(ids))
+	_, err := repo.conn(ctx).ExecContext(ctx, queryCorrect, models.InvestigationResultCorrect, invID, pq.Array(ids))
 	if err != nil {
 		return fmt.Errorf("failed to update result to correct: %w", err)
 	}
@@ -154,7 +140,7 @@ func (repo *Repository) UpdateWinnersResult(ctx context.Context, invID uuid.UUID
 		SET result = $1, updated_at = now()
 		WHERE investigation_id = $2 AND user_id != ALL($3)
 	`
-	_, err = repo.db.ExecContext(ctx, queryIncorrect, models.InvestigationResultInCorrect, invID, pq.Array(ids))
+	_, err = repo.conn(ctx).ExecContext(ctx, queryIncorrect, models.InvestigationResultInCorrect, invID, pq.Array(ids))
 	if err != nil {
 		return fmt.Errorf("failed to update result to incorrect: %w", err)
 	}
@@ -162,43 +148,12 @@ func (repo *Repository) UpdateWinnersResult(ctx context.Context, invID uuid.UUID
 	return nil
 }
 
-func (repo *Repository) GetDisputesUsers(ctx context.Context, invID uuid.UUID) ([]models.User, error) {
-	query := `
-		SELECT u.id, u.username, u.chat_id, u.notification_enabled, u.rating
-		FROM investigations i
-		JOIN participants p ON p.dispute_id = i.dispute_id
-		JOIN users u ON u.id = p.user_id
-		WHERE i.id = $1
-		ORDER BY p.is_creator DESC, p.id
-	`
-
-	rows, err := repo.db.QueryContext(ctx, query, invID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute GetDisputesUsers query: %w", err)
-	}
-	defer rows.Close()
-
-	var users []models.User
-	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.ChatID, &user.NotificationEnabled, &user.Rating); err != nil {
-			return nil, fmt.Errorf("failed to scan user row: %w", err)
-		}
-		users = append(users, user)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows: %w", err)
-	}
-
-	return users, nil
-}
-
 func (repo *Repository) MarkJurorsSeen(ctx context.Context, actorUsername string, investigationIDs []uuid.UUID,
 ) error {
 	if len(investigationIDs) == 0 {
 		return nil
 	}
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := repo.conn(ctx).ExecContext(ctx, `
 		UPDATE jurors j
 		SET seen_at = now()
 		FROM users me
@@ -211,3 +166,30 @@ func (repo *Repository) MarkJurorsSeen(ctx context.Context, actorUsername string
 	}
 	return nil
 }
+
+// ListJurorBallots returns the votes cast in the investigation.
+func (repo *Repository) ListJurorBallots(ctx context.Context, invID uuid.UUID) ([]models.Ballot, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT vote
+		FROM jurors
+		WHERE investigation_id = $1 AND vote <> ''`,
+		invID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list juror ballots: %w", err)
+	}
+	defer rows.Close()
+
+	var ballots []models.Ballot
+	for rows.Next() {
+		var b models.Ballot
+		if err = rows.Scan(&b.Vote); err != nil {
+			return nil, fmt.Errorf("failed to scan juror ballot: %w", err)
+		}
+		ballots = append(ballots, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list juror ballots: %w", err)
+	}
+	return ballots, nil
+}
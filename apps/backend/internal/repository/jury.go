@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// ListJuryCandidates returns the users ready to investigate except the excluded ones, with the number of
+// investigation votes they cast since activeSince. Candidates are ordered by ID so a draw can be repeated.
+func (repo *Repository) ListJuryCandidates(ctx context.Context, excluded []uuid.UUID, activeSince time.Time,
+) ([]models.JuryCandidate, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT u.id, u.rating, COUNT(j.id)
+		FROM users u
+		LEFT JOIN jurors j ON j.user_id = u.id AND j.vote <> '' AND j.updated_at >= $2
+		WHERE u.investigation_readiness = TRUE
+		  AND NOT (u.id = ANY($1))
+		GROUP BY u.id, u.rating
+		ORDER BY u.id`,
+		pq.Array(excluded), activeSince,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jury candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []models.JuryCandidate
+	for rows.Next() {
+		var c models.JuryCandidate
+		if err = rows.Scan(&c.UserID, &c.Rating, &c.RecentVotes); err != nil {
+			return nil, fmt.Errorf("failed to scan jury candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jury candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+func (repo *Repository) InsertJuryDraw(ctx context.Context, draw models.JuryDraw) error {
+	candidates, err := json.Marshal(draw.Candidates)
+	if err != nil {
+		return fmt.Errorf("failed to encode jury candidates: %w", err)
+	}
+	_, err = repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO jury_draws (id, investigation_id, round, seed, size, candidates, selected, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		draw.ID,
+		draw.InvestigationID,
+		draw.Round,
+		draw.Seed,
+		draw.Size,
+		candidates,
+		pq.Array(draw.Selected),
+		draw.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert jury draw: %w", err)
+	}
+	return nil
+}
+
+// ListJuryDraws returns the selection rounds of the investigation, first round first.
+func (repo *Repository) ListJuryDraws(ctx context.Context, invID uuid.UUID) ([]models.JuryDraw, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT id, investigation_id, round, seed, size, candidates, selected, created_at
+		FROM jury_draws
+		WHERE investigation_id = $1
+		ORDER BY round`,
+		invID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jury draws: %w", err)
+	}
+	defer rows.Close()
+
+	var draws []models.JuryDraw
+	for rows.Next() {
+		var (
+			d          models.JuryDraw
+			candidates []byte
+		)
+		if err = rows.Scan(
+			&d.ID,
+			&d.InvestigationID,
+			&d.Round,
+			&d.Seed,
+			&d.Size,
+			&candidates,
+			pq.Array(&d.Selected),
+			&d.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan jury draw: %w", err)
+		}
+		if err = json.Unmarshal(candidates, &d.Candidates); err != nil {
+			return nil, fmt.Errorf("failed to decode jury candidates: %w", err)
+		}
+		draws = append(draws, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jury draws: %w", err)
+	}
+	return draws, nil
+}
+
+// EnrollJurors adds the users to the jury of the investigation.
+func (repo *Repository) EnrollJurors(ctx context.Context, invID uuid.UUID, userIDs []uuid.UUID) error {
+	for _, userID := range userIDs {
+		juror := models.NewJuror(invID, userID)
+		if _, err := repo.conn(ctx).ExecContext(ctx, `
+			INSERT INTO jurors (id, user_id, investigation_id, result, vote)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (investigation_id, user_id) DO NOTHING`,
+			juror.ID, juror.UserID, juror.InvestigationID, juror.Result, juror.Vote,
+		); err != nil {
+			return fmt.Errorf("failed to insert jurors: %w", err)
+		}
+	}
+	return nil
+}
+
+// DismissUnresponsiveJurors removes the jurors of a current investigation who neither opened it nor voted since
+// before cutoff and returns their user IDs. Jurors of a commit-reveal investigation are only replaced while
+// alternates can still commit.
+func (repo *Repository) DismissUnresponsiveJurors(ctx context.Context, invID uuid.UUID, cutoff time.Time,
+) ([]uuid.UUID, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		DELETE FROM jurors j
+		USING investigations i
+		WHERE i.id = j.investigation_id
+		  AND i.status = $3
+		  AND (i.commit_ends_at IS NULL OR i.commit_ends_at > now())
+		  AND j.investigation_id = $1
+		  AND j.vote = ''
+		  AND j.seen_at IS NULL
+		  AND j.updated_at < $2
+		RETURNING j.user_id`,
+		invID, cutoff, models.InvestigationStatusCurrent,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dismiss unresponsive jurors: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan dismissed juror: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to dismiss unresponsive jurors: %w", err)
+	}
+	return ids, nil
+}
+
+// RecuseJuror removes a juror who hasn't voted from the jury and records the recusal. It returns ErrAlreadyVoted
+// when the juror voted meanwhile.
+func (repo *Repository) RecuseJuror(ctx context.Context, jurorID uuid.UUID, recusal models.JurorRecusal) error {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+		DELETE FROM jurors
+		WHERE id = $1 AND investigation_id = $2 AND vote = ''`,
+		jurorID, recusal.InvestigationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete recused juror: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete recused juror: %w", err)
+	}
+	if n == 0 {
+		return ErrAlreadyVoted
+	}
+	if _, err = repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO juror_recusals (id, investigation_id, user_id, round, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		recusal.ID, recusal.InvestigationID, recusal.UserID, recusal.Round, recusal.Reason, recusal.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to insert juror recusal: %w", err)
+	}
+	return nil
+}
+
+// ListInvestigationsWithUnresponsiveJurors returns current investigations that have jurors who neither opened
+// them nor voted since before cutoff, skipping commit-reveal investigations past their commit phase.
+func (repo *Repository) ListInvestigationsWithUnresponsiveJurors(ctx context.Context, cutoff time.Time, limit int,
+) ([]models.Investigation, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT i.id, i.dispute_id
+		FROM investigations i
+		WHERE i.status = $1
+		  AND (i.commit_ends_at IS NULL OR i.commit_ends_at > now())
+		  AND EXISTS (
+			SELECT 1
+			FROM jurors j
+			WHERE j.investigation_id = i.id
+			  AND j.vote = ''
+			  AND j.seen_at IS NULL
+			  AND j.updated_at < $2
+		  )
+		ORDER BY i.created_at
+		LIMIT $3`,
+		models.InvestigationStatusCurrent, cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investigations with unresponsive jurors: %w", err)
+	}
+	defer rows.Close()
+
+	var investigations []models.Investigation
+	for rows.Next() {
+		var investigation models.Investigation
+		if err = rows.Scan(&investigation.ID, &investigation.DisputeID); err != nil {
+			return nil, fmt.Errorf("failed to scan investigation: %w", err)
+		}
+		investigations = append(investigations, investigation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list investigations with unresponsive jurors: %w", err)
+	}
+	return investigations, nil
+}
+
+// ListJuryVacancies returns current investigations whose jury has fewer jurors than the votes the investigation
+// waits for, skipping commit-reveal investigations past their commit phase.
+func (repo *Repository) ListJuryVacancies(ctx context.Context, limit int) ([]models.JuryVacancy, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT i.id, i.dispute_id, i.total - COUNT(j.id)
+		FROM investigations i
+		LEFT JOIN jurors j ON j.investigation_id = i.id
+		WHERE i.status = $1
+		  AND (i.commit_ends_at IS NULL OR i.commit_ends_at > now())
+		GROUP BY i.id
+		HAVING COUNT(j.id) < i.total
+		ORDER BY i.created_at
+		LIMIT $2`,
+		models.InvestigationStatusCurrent, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jury vacancies: %w", err)
+	}
+	defer rows.Close()
+
+	var vacancies []models.JuryVacancy
+	for rows.Next() {
+		var v models.JuryVacancy
+		if err = rows.Scan(&v.InvestigationID, &v.DisputeID, &v.Seats); err != nil {
+			return nil, fmt.Errorf("failed to scan jury vacancy: %w", err)
+		}
+		vacancies = append(vacancies, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jury vacancies: %w", err)
+	}
+	return vacancies, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestEnrollJurorsKeepsTotal(t *testing.T) {
+	var inserts, totals int
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			switch {
+			case strings.Contains(query, "INSERT INTO jurors"):
+				inserts++
+			case strings.Contains(query, "SET total"):
+				totals++
+			}
+			return driver.RowsAffected(1), nil
+		},
+	})
+
+	if err := repo.EnrollJurors(context.Background(), uuid.New(), []uuid.UUID{uuid.New(), uuid.New()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The total is the number of votes the investigation contract waits for, whoever sits on the jury.
+	if inserts != 2 || totals != 0 {
+		t.Fatalf("expected 2 juror inserts and no total update, got %d and %d", inserts, totals)
+	}
+}
+
+func TestRecuseJuror(t *testing.T) {
+	var deleted int64 = 1
+	var queries []string
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			queries = append(queries, query)
+			if strings.Contains(query, "DELETE FROM jurors") {
+				return driver.RowsAffected(deleted), nil
+			}
+			return driver.RowsAffected(1), nil
+		},
+	})
+	recusal := models.JurorRecusal{ID: uuid.New(), InvestigationID: uuid.New(), UserID: uuid.New(), Round: 1,
+		Reason: "I know the creator", CreatedAt: time.Now()}
+
+	if err := repo.RecuseJuror(context.Background(), uuid.New(), recusal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[1], "INSERT INTO juror_recusals") {
+		t.Fatalf("expected the juror deleted and the recusal recorded, got %v", queries)
+	}
+
+	deleted, queries = 0, nil
+	if err := repo.RecuseJuror(context.Background(), uuid.New(), recusal); !errors.Is(err, ErrAlreadyVoted) {
+		t.Fatalf("expected ErrAlreadyVoted, got %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("expected nothing recorded for a juror who voted, got %v", queries)
+	}
+}
+
+func TestListJuryDraws(t *testing.T) {
+	invID, candidate := uuid.New(), uuid.New()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"id", "investigation_id", "round", "seed", "size", "candidates", "selected", "created_at"},
+				[]driver.Value{uuid.NewString(), invID.String(), int64(1), int64(-7), int64(1),
+					[]byte(`[{"userID":"` + candidate.String() + `","rating":3,"recentVotes":1}]`),
+					"{" + candidate.String() + "}", time.Now()},
+			), nil
+		},
+	})
+
+	draws, err := repo.ListJuryDraws(context.Background(), invID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(draws) != 1 || draws[0].Seed != -7 || len(draws[0].Candidates) != 1 ||
+		draws[0].Candidates[0].Rating != 3 || len(draws[0].Selected) != 1 || draws[0].Selected[0] != candidate {
+		t.Fatalf("unexpected draws: %#v", draws)
+	}
+}
+
+func TestListJuryVacancies(t *testing.T) {
+	invID, disputeID := uuid.New(), uuid.New()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(query string, _ []driver.NamedValue) (driver.Rows, error) {
+			if !strings.Contains(query, "HAVING COUNT(j.id) < i.total") {
+				t.Fatalf("expected juries short of the total, got %s", query)
+			}
+			row := []driver.Value{invID.String(), disputeID.String(), int64(2)}
+			return newRows([]string{"id", "dispute_id", "seats"}, row), nil
+		},
+	})
+
+	vacancies, err := repo.ListJuryVacancies(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vacancies) != 1 || vacancies[0].InvestigationID != invID || vacancies[0].Seats != 2 {
+		t.Fatalf("unexpected vacancies: %+v", vacancies)
+	}
+}
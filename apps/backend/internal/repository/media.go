@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+// InsertMedia records media stored in the blob store. Media with the same hash is recorded once.
+func (repo *Repository) InsertMedia(ctx context.Context, media models.Media) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO media (hash, content_type, size, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (hash) DO NOTHING`,
+		media.Hash,
+		media.ContentType,
+		media.Size,
+		media.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert media: %w", err)
+	}
+	return nil
+}
+
+func (repo *Repository) GetMedia(ctx context.Context, hash string) (models.Media, error) {
+	var m models.Media
+	err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT hash, content_type, size, created_at
+		FROM media
+		WHERE hash = $1`,
+		hash,
+	).Scan(&m.Hash, &m.ContentType, &m.Size, &m.CreatedAt)
+	if err != nil {
+		return models.Media{}, fmt.Errorf("failed to get media: %w", handleNotFoundError(err))
+	}
+	return m, nil
+}
+
+// ListInlineImages returns up to limit dispute and evidence images still kept in the database. Empty images are
+// left out, there is nothing to move.
+func (repo *Repository) ListInlineImages(ctx context.Context, limit int) ([]models.InlineImage, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+	(SELECT 'dispute', id, image_data, image_type
+	FROM disputes
+	WHERE octet_length(image_data) > 0
+	LIMIT $1)
+	UNION ALL
+	(SELECT 'evidence', id, image_data, image_type
+	FROM evidences
+	WHERE octet_length(image_data) > 0
+	LIMIT $1)`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inline images: %w", err)
+	}
+	defer rows.Close()
+
+	var images []models.InlineImage
+	for rows.Next() {
+		var img models.InlineImage
+		if err := rows.Scan(&img.Kind, &img.ID, &img.Data, &img.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to scan inline image: %w", err)
+		}
+		images = append(images, img)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return images, nil
+}
+
+// MoveInlineImage points the dispute or evidence at its image in the blob store and drops the inline copy. An
+// evidence image also becomes the first attachment of the evidence.
+func (repo *Repository) MoveInlineImage(ctx context.Context, img models.InlineImage, hash string) error {
+	query := `UPDATE disputes SET image_hash = $2, image_data = NULL WHERE id = $1`
+	if img.Kind == models.InlineImageEvidence {
+		query = `
+		WITH moved AS (
+			UPDATE evidences SET image_hash = $2, image_data = NULL WHERE id = $1
+			RETURNING id, thumbnail_hash, created_at
+		)
+		INSERT INTO evidence_attachments (id, evidence_id, position, kind, media_hash, thumbnail_hash, created_at)
+		SELECT gen_random_uuid(), id, 0, 'image', $2, thumbnail_hash, created_at
+		FROM moved
+		ON CONFLICT (evidence_id, position) DO NOTHING`
+	}
+	if _, err := repo.conn(ctx).ExecContext(ctx, query, img.ID, hash); err != nil {
+		return fmt.Errorf("failed to move inline %s image: %w", img.Kind, err)
+	}
+	return nil
+}
+
+// CanViewMedia reports whether the user may view the media: it must belong to a dispute the user takes part in or
+// judges, or that is still open to accept, or to evidence the user may read. Evidence is readable by its own party
+// and, once released, by the other parties and the jury.
+func (repo *Repository) CanViewMedia(ctx context.Context, hash, actorUsername string) (bool, error) {
+	var ok bool
+	if err := repo.conn(ctx).QueryRowContext(ctx, fmt.Sprintf(`
+		WITH viewer AS (
+			SELECT id FROM users WHERE username = $2
+		), viewable_disputes AS (
+			SELECT p.dispute_id
+			FROM participants p
+			JOIN viewer v ON v.id = p.user_id
+			UNION
+			SELECT i.dispute_id
+			FROM jurors j
+			JOIN investigations i ON i.id = j.investigation_id
+			JOIN viewer v ON v.id = j.user_id
+		), evidence_media AS (
+			SELECT id AS evidence_id, image_hash AS hash FROM evidences
+			UNION ALL
+			SELECT id, thumbnail_hash FROM evidences
+			UNION ALL
+			SELECT evidence_id, media_hash FROM evidence_attachments
+			UNION ALL
+			SELECT evidence_id, thumbnail_hash FROM evidence_attachments
+		)
+		SELECT EXISTS (
+			SELECT 1
+			FROM disputes d
+			JOIN participants creator ON creator.dispute_id = d.id AND creator.is_creator = TRUE
+			WHERE $1 IN (d.image_hash, d.thumbnail_hash)
+			  AND (d.id IN (SELECT dispute_id FROM viewable_disputes) OR (%s))
+		) OR EXISTS (
+			SELECT 1
+			FROM evidence_media em
+			JOIN evidences e ON e.id = em.evidence_id
+			JOIN participants owner ON owner.id = e.participant_id
+			WHERE em.hash = $1
+			  AND (owner.user_id IN (SELECT id FROM viewer)
+			    OR (e.released_at IS NOT NULL AND owner.dispute_id IN (SELECT dispute_id FROM viewable_disputes)))
+		)`, openDisputeFilter),
+		hash, actorUsername,
+	).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check media access: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestGetMedia(t *testing.T) {
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"hash", "content_type", "size", "created_at"},
+				[]driver.Value{"ab12", "image/png", int64(3), now},
+			), nil
+		},
+	})
+
+	m, err := repo.GetMedia(context.Background(), "ab12")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Hash != "ab12" || m.ContentType != "image/png" || m.Size != 3 {
+		t.Fatalf("unexpected media: %#v", m)
+	}
+}
+
+func TestGetMediaNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"hash", "content_type", "size", "created_at"}), nil
+		},
+	})
+
+	if _, err := repo.GetMedia(context.Background(), "ab12"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListInlineImages(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"kind", "id", "image_data", "image_type"},
+				[]driver.Value{"dispute", "d1", []byte{1}, "image/png"},
+				[]driver.Value{"evidence", "e1", []byte{2}, nil},
+			), nil
+		},
+	})
+
+	images, err := repo.ListInlineImages(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 2 || images[0].Kind != models.InlineImageDispute || *images[0].ContentType != "image/png" ||
+		images[1].ID != "e1" || images[1].ContentType != nil {
+		t.Fatalf("unexpected images: %#v", images)
+	}
+}
+
+func TestMoveInlineImage(t *testing.T) {
+	var queries []string
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			queries = append(queries, query)
+			return driver.RowsAffected(1), nil
+		},
+	})
+
+	for _, img := range []models.InlineImage{
+		{Kind: models.InlineImageDispute, ID: "d1"},
+		{Kind: models.InlineImageEvidence, ID: "e1"},
+	} {
+		if err := repo.MoveInlineImage(context.Background(), img, "ab"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], "disputes") || !strings.Contains(queries[1], "evidences") ||
+		!strings.Contains(queries[1], "evidence_attachments") {
+		t.Fatalf("unexpected queries: %v", queries)
+	}
+}
+
+func TestCanViewMedia(t *testing.T) {
+	var (
+		query string
+		args  []driver.NamedValue
+	)
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(q string, a []driver.NamedValue) (driver.Rows, error) {
+			query, args = q, a
+			return newRows([]string{"exists"}, []driver.Value{true}), nil
+		},
+	})
+
+	ok, err := repo.CanViewMedia(context.Background(), "ab12", "alice")
+	if err != nil || !ok {
+		t.Fatalf("expected the media viewable, got %v %v", ok, err)
+	}
+	if len(args) != 2 || args[0].Value != "ab12" || args[1].Value != "alice" {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	if !strings.Contains(query, "e.released_at IS NOT NULL") || !strings.Contains(query, "d.visibility <> 'direct'") {
+		t.Fatalf("expected sealed evidence and direct disputes kept from other users, got %s", query)
+	}
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+const offerColumns = `id, dispute_id, version, proposer_id, amount_nano, deposit_nano, ends_at, description,
+	contract_address, status, created_at, decided_at`
+
+func (repo *Repository) InsertOffer(ctx context.Context, offer models.DisputeOffer) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO dispute_offers (`+offerColumns+`)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		offer.ID,
+		offer.DisputeID,
+		offer.Version,
+		offer.ProposerID,
+		offer.AmountNano,
+		offer.DepositNano,
+		offer.EndsAt,
+		offer.Description,
+		offer.ContractAddress,
+		offer.Status,
+		offer.CreatedAt,
+		offer.DecidedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert dispute offer: %w", err)
+	}
+	return nil
+}
+
+// GetLatestOffer returns the newest version of the dispute terms, whatever its status.
+func (repo *Repository) GetLatestOffer(ctx context.Context, disputeID uuid.UUID) (models.DisputeOffer, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+offerColumns+`
+		FROM dispute_offers
+		WHERE dispute_id = $1
+		ORDER BY version DESC
+		LIMIT 1`,
+		disputeID,
+	)
+	offer, err := scanOffer(row)
+	if err != nil {
+		return models.DisputeOffer{}, handleNotFoundError(err)
+	}
+	return offer, nil
+}
+
+func (repo *Repository) GetOffer(ctx context.Context, disputeID uuid.UUID, version int) (models.DisputeOffer, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+offerColumns+`
+		FROM dispute_offers
+		WHERE dispute_id = $1 AND version = $2`,
+		disputeID, version,
+	)
+	offer, err := scanOffer(row)
+	if err != nil {
+		return models.DisputeOffer{}, handleNotFoundError(err)
+	}
+	return offer, nil
+}
+
+// ListOffers returns every version of the dispute terms, oldest first.
+func (repo *Repository) ListOffers(ctx context.Context, disputeID uuid.UUID) ([]models.OfferCard, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT o.version, u.username, o.amount_nano, o.deposit_nano, o.ends_at, o.description,
+			o.contract_address, o.status, o.created_at, o.decided_at
+		FROM dispute_offers o
+		JOIN users u ON u.id = o.proposer_id
+		WHERE o.dispute_id = $1
+		ORDER BY o.version`,
+		disputeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispute offers: %w", err)
+	}
+	defer rows.Close()
+
+	var offers []models.OfferCard
+	for rows.Next() {
+		var o models.OfferCard
+		if err := rows.Scan(
+			&o.Version,
+			&o.Proposer,
+			&o.AmountNano,
+			&o.DepositNano,
+			&o.EndsAt,
+			&o.Description,
+			&o.ContractAddress,
+			&o.Status,
+			&o.CreatedAt,
+			&o.DecidedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dispute offer: %w", err)
+		}
+		offers = append(offers, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+	return offers, nil
+}
+
+// DecideOffer answers a pending offer with status; funded, when set, is the offer with the bet funded for it and
+// the deposit of that bet. It reports false when the offer is no longer pending.
+func (repo *Repository) DecideOffer(ctx context.Context, offerID uuid.UUID, status models.OfferStatus,
+	funded *models.DisputeOffer,
+) (bool, error) {
+	var contractAddress *string
+	var depositNano *int64
+	if funded != nil {
+		contractAddress, depositNano = funded.ContractAddress, &funded.DepositNano
+	}
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE dispute_offers
+		SET status = $2,
+			contract_address = COALESCE($3, contract_address),
+			deposit_nano = COALESCE($4, deposit_nano),
+			decided_at = now()
+		WHERE id = $1 AND status = 'pending'`,
+		offerID, status, contractAddress, depositNano,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to decide dispute offer: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// SupersedePendingOffers closes the pending offers of the dispute that a newer version replaces.
+func (repo *Repository) SupersedePendingOffers(ctx context.Context, disputeID uuid.UUID) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE dispute_offers
+		SET status = 'superseded', decided_at = now()
+		WHERE dispute_id = $1 AND status = 'pending'`,
+		disputeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to supersede dispute offers: %w", err)
+	}
+	return nil
+}
+
+func scanOffer(row rowScanner) (models.DisputeOffer, error) {
+	var o models.DisputeOffer
+	if err := row.Scan(
+		&o.ID,
+		&o.DisputeID,
+		&o.Version,
+		&o.ProposerID,
+		&o.AmountNano,
+		&o.DepositNano,
+		&o.EndsAt,
+		&o.Description,
+		&o.ContractAddress,
+		&o.Status,
+		&o.CreatedAt,
+		&o.DecidedAt,
+	); err != nil {
+		return models.DisputeOffer{}, fmt.Errorf("failed to scan dispute offer: %w", err)
+	}
+	return o, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestGetLatestOfferNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"id", "dispute_id", "version", "proposer_id", "amount_nano", "deposit_nano",
+				"ends_at", "description", "contract_address", "status", "created_at", "decided_at"}), nil
+		},
+	})
+
+	_, err := repo.GetLatestOffer(context.Background(), uuid.New())
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListOffers(t *testing.T) {
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"version", "username", "amount_nano", "deposit_nano", "ends_at", "description",
+				"contract_address", "status", "created_at", "decided_at"},
+				[]driver.Value{int64(1), "alice", int64(100), int64(20), now, "d", "addr", "accepted", now, now},
+				[]driver.Value{int64(2), "bob", int64(150), int64(20), now, "d", nil, "pending", now, nil},
+			), nil
+		},
+	})
+
+	offers, err := repo.ListOffers(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(offers) != 2 || offers[1].Proposer != "bob" || offers[1].Status != models.OfferStatusPending ||
+		offers[1].ContractAddress != nil || *offers[0].ContractAddress != "addr" {
+		t.Fatalf("unexpected offers: %+v", offers)
+	}
+}
+
+func TestDecideOfferNotPending(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
+			return driver.RowsAffected(0), nil
+		},
+	})
+
+	decided, err := repo.DecideOffer(context.Background(), uuid.New(), models.OfferStatusDeclined, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decided {
+		t.Fatal("expected an answered offer to stay as is")
+	}
+}
+
+func TestDecideOfferFunded(t *testing.T) {
+	var args []driver.NamedValue
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(_ string, a []driver.NamedValue) (driver.Result, error) {
+			args = a
+			return driver.RowsAffected(1), nil
+		},
+	})
+
+	funded := models.DisputeOffer{ContractAddress: new("bet-v2"), DepositNano: 50}
+	decided, err := repo.DecideOffer(context.Background(), uuid.New(), models.OfferStatusAccepted, &funded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decided || args[2].Value != "bet-v2" || args[3].Value != int64(50) {
+		t.Fatalf("expected the new bet and its deposit to be stored, got %+v", args)
+	}
+}
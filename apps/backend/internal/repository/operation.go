@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+// InsertProcessedOperation records op unless its message hash is already taken and reports whether it was inserted.
+func (repo *Repository) InsertProcessedOperation(ctx context.Context, op models.ProcessedOperation) (bool, error) {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO processed_operations (msg_hash, user_id, action, entity_id, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (msg_hash) DO NOTHING`,
+		op.MsgHash,
+		op.UserID,
+		op.Action,
+		op.EntityID,
+		op.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert processed operation: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (repo *Repository) GetProcessedOperation(ctx context.Context, msgHash string) (models.ProcessedOperation, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+	SELECT msg_hash, user_id, action, entity_id, created_at
+	FROM processed_operations
+	WHERE msg_hash = $1`,
+		msgHash,
+	)
+
+	var op models.ProcessedOperation
+	if err := row.Scan(&op.MsgHash, &op.UserID, &op.Action, &op.EntityID, &op.CreatedAt); err != nil {
+		return models.ProcessedOperation{}, fmt.Errorf("failed to get processed operation: %w", handleNotFoundError(err))
+	}
+	return op, nil
+}
+
+const pendingOperationColumns = `id, msg_hash, user_id, action, entity_id, boc, payload, status, error, attempts,
+	locked_until, created_at, updated_at`
+
+// InsertPendingOperation records op unless its message hash is already taken and reports whether it was inserted.
+func (repo *Repository) InsertPendingOperation(ctx context.Context, op models.PendingOperation) (bool, error) {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+	INSERT INTO pending_operations (id, msg_hash, user_id, action, entity_id, boc, payload, status, attempts,
+		created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (msg_hash) DO NOTHING`,
+		op.ID,
+		op.MsgHash,
+		op.UserID,
+		op.Action,
+		op.EntityID,
+		op.Boc,
+		op.Payload,
+		op.Status,
+		op.Attempts,
+		op.CreatedAt,
+		op.UpdatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert pending operation: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (repo *Repository) GetPendingOperation(ctx context.Context, id uuid.UUID) (models.PendingOperation, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+	SELECT `+pendingOperationColumns+`
+	FROM pending_operations
+	WHERE id = $1`,
+		id,
+	)
+	op, err := scanPendingOperation(row)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("failed to get pending operation: %w", handleNotFoundError(err))
+	}
+	return op, nil
+}
+
+func (repo *Repository) GetPendingOperationByHash(ctx context.Context, msgHash string,
+) (models.PendingOperation, error) {
+	row := repo.conn(ctx).QueryRowContext(ctx, `
+	SELECT `+pendingOperationColumns+`
+	FROM pending_operations
+	WHERE msg_hash = $1`,
+		msgHash,
+	)
+	op, err := scanPendingOperation(row)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("failed to get pending operation: %w", handleNotFoundError(err))
+	}
+	return op, nil
+}
+
+// ClaimPendingOperations leases up to limit pending operations until lockUntil and counts the attempt.
+// Operations leased by another confirmer are skipped until their lease expires.
+func (repo *Repository) ClaimPendingOperations(ctx context.Context, now, lockUntil time.Time, limit int,
+) ([]models.PendingOperation, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		UPDATE pending_operations o
+		SET locked_until = $2,
+		    attempts     = o.attempts + 1,
+		    updated_at   = $1
+		WHERE o.id IN (
+			SELECT po.id
+			FROM pending_operations po
+			WHERE po.status = $3
+			  AND (po.locked_until IS NULL OR po.locked_until < $1)
+			ORDER BY po.created_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+pendingOperationColumns,
+		now, lockUntil, models.OperationStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending operations: %w", err)
+	}
+	defer rows.Close()
+
+	var ops []models.PendingOperation
+	for rows.Next() {
+		op, err := scanPendingOperation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending operation: %w", err)
+		}
+		ops = append(ops, op)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pending operations: %w", err)
+	}
+	return ops, nil
+}
+
+// FinishPendingOperation settles a pending operation with status and releases its lease.
+// reason is kept for failures. Operations that are already settled are left as they are.
+func (repo *Repository) FinishPendingOperation(ctx context.Context, id uuid.UUID, status models.OperationStatus,
+	reason *string,
+) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	UPDATE pending_operations
+	SET status       = $2,
+	    error        = $3,
+	    locked_until = NULL,
+	    updated_at   = NOW()
+	WHERE id = $1
+	  AND status = $4`,
+		id, status, reason, models.OperationStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish pending operation: %w", err)
+	}
+	return nil
+}
+
+// ReleasePendingOperation drops the lease so the next confirmer run retries the operation right away.
+func (repo *Repository) ReleasePendingOperation(ctx context.Context, id uuid.UUID) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+	UPDATE pending_operations
+	SET locked_until = NULL,
+	    updated_at   = NOW()
+	WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release pending operation: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPendingOperation(row rowScanner) (models.PendingOperation, error) {
+	var op models.PendingOperation
+	err := row.Scan(
+		&op.ID,
+		&op.MsgHash,
+		&op.UserID,
+		&op.Action,
+		&op.EntityID,
+		&op.Boc,
+		&op.Payload,
+		&op.Status,
+		&op.Error,
+		&op.Attempts,
+		&op.LockedUntil,
+		&op.CreatedAt,
+		&op.UpdatedAt,
+	)
+	return op, err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestInsertProcessedOperation(t *testing.T) {
+	affected := int64(1)
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
+			return driver.RowsAffected(affected), nil
+		},
+	})
+	op := models.NewProcessedOperation("hash", uuid.New(), models.OperationActionVoteDispute, uuid.New().String())
+
+	inserted, err := repo.InsertProcessedOperation(context.Background(), op)
+	if err != nil || !inserted {
+		t.Fatalf("expected insert, got inserted=%v err=%v", inserted, err)
+	}
+
+	affected = 0
+	inserted, err = repo.InsertProcessedOperation(context.Background(), op)
+	if err != nil || inserted {
+		t.Fatalf("expected duplicate to be skipped, got inserted=%v err=%v", inserted, err)
+	}
+}
+
+func TestGetProcessedOperationNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"msg_hash", "user_id", "action", "entity_id", "created_at"}), nil
+		},
+	})
+
+	_, err := repo.GetProcessedOperation(context.Background(), "hash")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+var pendingOperationTestColumns = []string{"id", "msg_hash", "user_id", "action", "entity_id", "boc", "payload",
+	"status", "error", "attempts", "locked_until", "created_at", "updated_at"}
+
+func TestClaimPendingOperations(t *testing.T) {
+	opID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(_ string, args []driver.NamedValue) (driver.Rows, error) {
+			if args[2].Value != string(models.OperationStatusPending) {
+				t.Fatalf("unexpected status arg: %#v", args[2].Value)
+			}
+			return newRows(pendingOperationTestColumns,
+				[]driver.Value{opID.String(), "hash", userID.String(), "vote_dispute", "entity", "boc",
+					[]byte(`{"vote":true}`), "pending", nil, int64(2), now.Add(time.Minute), now, now},
+			), nil
+		},
+	})
+
+	ops, err := repo.ClaimPendingOperations(context.Background(), now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ops) != 1 || ops[0].ID != opID || ops[0].UserID != userID || ops[0].Attempts != 2 {
+		t.Fatalf("unexpected operations: %#v", ops)
+	}
+	if ops[0].Action != models.OperationActionVoteDispute || string(ops[0].Payload) != `{"vote":true}` ||
+		ops[0].Error != nil || ops[0].LockedUntil == nil {
+		t.Fatalf("unexpected operation fields: %#v", ops[0])
+	}
+}
+
+func TestGetPendingOperationNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(pendingOperationTestColumns), nil
+		},
+	})
+
+	_, err := repo.GetPendingOperation(context.Background(), uuid.New())
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -10,8 +10,9 @@ import (
 )
 
 func (repo *Repository) InsertParticipant(ctx context.Context, participant models.Participant) error {
-	if _, err := repo.db.ExecContext(ctx, `
-		INSERT INTO participants (id, user_id, dispute_id, is_creator, result, status, is_claimable, updated_at, seen_at)
+	if _, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO participants (id, user_id, dispute_id, is_creator, result, status, is_claimable, updated_at,
+		                          seen_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
 		participant.ID,
 		participant.UserID,
@@ -28,28 +29,66 @@ func (repo *Repository) InsertParticipant(ctx context.Context, participant model
 	return nil
 }
 
+// InsertOpponentParticipant adds the opponent of an open dispute. It reports false when another user has
+// already become the opponent.
+func (repo *Repository) InsertOpponentParticipant(ctx context.Context, participant models.Participant) (bool, error) {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO participants (id, user_id, dispute_id, is_creator, result, status, is_claimable, updated_at,
+		                          seen_at)
+		VALUES ($1, $2, $3, FALSE, $4, $5, $6, $7, $8)
+		ON CONFLICT (dispute_id) WHERE is_creator = FALSE DO NOTHING`,
+		participant.ID,
+		participant.UserID,
+		participant.DisputeID,
+		participant.Result,
+		participant.Status,
+		participant.IsClaimable,
+		participant.UpdatedAt,
+		participant.SeenAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert opponent participant: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
 func (repo *Repository) GetOpponentID(ctx context.Context, disputeID uuid.UUID, actorID uuid.UUID) (uuid.UUID, error) {
 	var opponentID uuid.UUID
-	if err := repo.db.QueryRowContext(ctx, `
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
 	SELECT user_id 
 	FROM participants
 	WHERE dispute_id = $1 AND user_id != $2`,
 		disputeID, actorID,
 	).Scan(&opponentID); err != nil {
-		return uuid.Nil, fmt.Errorf("failed to get opponent: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to get opponent: %w", handleNotFoundError(err))
 	}
 	return opponentID, nil
 }
 
 func (repo *Repository) GetParticipant(ctx context.Context, disputeID uuid.UUID, userID uuid.UUID,
 ) (models.Participant, error) {
-	var participant models.Participant
-	if err := repo.db.QueryRowContext(ctx, `
-	SELECT id, user_id, dispute_id, is_creator, status, result, is_win, is_claimable, updated_at, seen_at
+	participant, err := scanParticipant(repo.conn(ctx).QueryRowContext(ctx, `
+	SELECT `+participantColumns+`
 	FROM participants
 	WHERE dispute_id = $1 AND user_id = $2`,
 		disputeID, userID,
-	).Scan(
+	))
+	if err != nil {
+		return models.Participant{}, fmt.Errorf("failed to get participants: %w", handleNotFoundError(err))
+	}
+	return participant, nil
+}
+
+const participantColumns = `id, user_id, dispute_id, is_creator, status, result, is_win, is_claimable, updated_at,
+	seen_at`
+
+func scanParticipant(row rowScanner) (models.Participant, error) {
+	var participant models.Participant
+	err := row.Scan(
 		&participant.ID,
 		&participant.UserID,
 		&participant.DisputeID,
@@ -60,13 +99,24 @@ func (repo *Repository) GetParticipant(ctx context.Context, disputeID uuid.UUID,
 		&participant.IsClaimable,
 		&participant.UpdatedAt,
 		&participant.SeenAt,
-	); err != nil {
-		return models.Participant{}, fmt.Errorf("failed to get participants: %w", err)
-	}
-	return participant, nil
+	)
+	return participant, err
 }
 
+// UpdateParticipant applies opts and records opts.Event, if any, in the same transaction.
 func (repo *Repository) UpdateParticipant(ctx context.Context, opts models.ParticipantUpdateOpts) error {
+	if opts.Event != nil {
+		return repo.RunInTx(ctx, func(ctx context.Context) error {
+			if err := repo.updateParticipant(ctx, opts); err != nil {
+				return err
+			}
+			return repo.InsertDisputeEvent(ctx, *opts.Event)
+		})
+	}
+	return repo.updateParticipant(ctx, opts)
+}
+
+func (repo *Repository) updateParticipant(ctx context.Context, opts models.ParticipantUpdateOpts) error {
 	query := `
 		UPDATE participants
 		SET status = COALESCE($1, status),
@@ -76,11 +126,24 @@ func (repo *Repository) UpdateParticipant(ctx context.Context, opts models.Parti
 			seen_at = CASE WHEN $5 THEN now() ELSE seen_at END,
 			updated_at = now()
 		WHERE id = $6
+		  AND ($7::text IS NULL OR result = $7)
 	`
-	_, err := repo.db.ExecContext(ctx, query, opts.Status, opts.Result, opts.IsWin, opts.IsClaimable, opts.Seen, opts.ID)
+	res, err := repo.conn(ctx).ExecContext(ctx, query, opts.Status, opts.Result, opts.IsWin, opts.IsClaimable,
+		opts.Seen, opts.ID, opts.From)
 	if err != nil {
 		return fmt.Errorf("failed to update participants: %w", err)
 	}
+	if opts.From == nil {
+		return nil
+	}
+	// A concurrent request moved the participant on since it was read.
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: participant %s is no longer %s", models.ErrDisputeTransition, opts.ID, *opts.From)
+	}
 	return nil
 }
 
@@ -90,7 +153,7 @@ func (repo *Repository) MarkParticipantsSeen(ctx context.Context, actorUsername
 		return nil
 	}
 
-	if _, err := repo.db.ExecContext(ctx, `
+	if _, err := repo.conn(ctx).ExecContext(ctx, `
 		UPDATE participants AS self
 		SET seen_at = now()
 		FROM users me
@@ -103,3 +166,31 @@ func (repo *Repository) MarkParticipantsSeen(ctx context.Context, actorUsername
 	}
 	return nil
 }
+
+func (repo *Repository) ListParticipants(ctx context.Context, disputeID uuid.UUID) ([]models.Participant, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+	SELECT `+participantColumns+`
+	FROM participants
+	WHERE dispute_id = $1
+	ORDER BY is_creator DESC, id`,
+		disputeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list participants: %w", err)
+	}
+	defer rows.Close()
+
+	var participants []models.Participant
+	for rows.Next() {
+		participant, err := scanParticipant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan participant: %w", err)
+		}
+		participants = append(participants, participant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over participants: %w", err)
+	}
+
+	return participants, nil
+}
@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
 )
 
 type Repository struct {
@@ -12,6 +15,15 @@ type Repository struct {
 	logger log.Logger
 }
 
+// dbtx is the part of *sql.DB and *sql.Tx used by repository queries.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
 func New(db *sql.DB, logger log.Logger) (*Repository, error) {
 	if db == nil {
 		return nil, fmt.Errorf("db is nil")
@@ -25,6 +37,42 @@ func New(db *sql.DB, logger log.Logger) (*Repository, error) {
 	}, nil
 }
 
-func (repo *Repository) StartTx() (*sql.Tx, error) {
-	return repo.db.Begin()
+// RunInTx runs fn in a single database transaction: every repository call made with the ctx passed to fn joins it.
+// The transaction is committed when fn returns nil and rolled back otherwise. Nested calls reuse the outer transaction.
+func (repo *Repository) RunInTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				repo.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// conn returns the transaction started by RunInTx for ctx, or the pool when there is none.
+func (repo *Repository) conn(ctx context.Context) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return repo.db
 }
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestRunInTxCommits(t *testing.T) {
+	stub := &stubDB{
+		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
+			return driver.RowsAffected(1), nil
+		},
+	}
+	repo := newTestRepo(t, stub)
+
+	err := repo.RunInTx(context.Background(), func(ctx context.Context) error {
+		if err := repo.UpdateDisputeNextDeadline(ctx, uuid.New(), time.Now()); err != nil {
+			return err
+		}
+		// nested calls join the outer transaction
+		return repo.RunInTx(ctx, func(ctx context.Context) error {
+			return repo.UpdateDisputeNextDeadline(ctx, uuid.New(), time.Now())
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.begins != 1 || stub.commits != 1 || stub.rollbacks != 0 {
+		t.Fatalf("expected single committed tx, got begins=%d commits=%d rollbacks=%d",
+			stub.begins, stub.commits, stub.rollbacks)
+	}
+}
+
+func TestRunInTxRollsBackOnFailure(t *testing.T) {
+	execCalls := 0
+	stub := &stubDB{
+		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
+			execCalls++
+			if execCalls == 2 {
+				return nil, errors.New("participant insert failed")
+			}
+			return driver.RowsAffected(1), nil
+		},
+	}
+	repo := newTestRepo(t, stub)
+
+	disputeID := uuid.New()
+	err := repo.RunInTx(context.Background(), func(ctx context.Context) error {
+		if err := repo.InsertDispute(ctx, models.Dispute{ID: disputeID}); err != nil {
+			return err
+		}
+		return repo.InsertParticipant(ctx, models.NewParticipant(uuid.New(), disputeID, models.DisputesResultNew, false))
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if stub.begins != 1 || stub.commits != 0 || stub.rollbacks != 1 {
+		t.Fatalf("expected rolled back tx, got begins=%d commits=%d rollbacks=%d",
+			stub.begins, stub.commits, stub.rollbacks)
+	}
+}
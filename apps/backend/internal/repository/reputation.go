@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+// ListJurorJudgements returns the votes of the user in closed investigations with whether they agreed with the
+// outcome, decided when the investigation ended.
+func (repo *Repository) ListJurorJudgements(ctx context.Context, userID uuid.UUID) ([]models.Judgement, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT j.result = $2, i.ends_at
+		FROM jurors j
+		JOIN investigations i ON i.id = j.investigation_id
+		WHERE j.user_id = $1
+		  AND i.status = $4
+		  AND j.result IN ($2, $3)`,
+		userID, models.InvestigationResultCorrect, models.InvestigationResultInCorrect,
+		models.InvestigationStatusPassed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list juror judgements: %w", err)
+	}
+	defer rows.Close()
+
+	var judgements []models.Judgement
+	for rows.Next() {
+		var j models.Judgement
+		if err = rows.Scan(&j.Correct, &j.DecidedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan juror judgement: %w", err)
+		}
+		judgements = append(judgements, j)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list juror judgements: %w", err)
+	}
+	return judgements, nil
+}
+
+// CountJurorSeats returns how many closed investigations the user was drawn for, including the ones they were
+// dismissed from or didn't vote in.
+func (repo *Repository) CountJurorSeats(ctx context.Context, userID uuid.UUID) (int, error) {
+	var seats int
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM investigations i
+		WHERE i.status = $2
+		  AND (EXISTS (SELECT 1 FROM jury_draws d WHERE d.investigation_id = i.id AND $1 = ANY(d.selected))
+		    OR EXISTS (SELECT 1 FROM jurors j WHERE j.investigation_id = i.id AND j.user_id = $1))`,
+		userID, models.InvestigationStatusPassed,
+	).Scan(&seats); err != nil {
+		return 0, fmt.Errorf("failed to count juror seats: %w", err)
+	}
+	return seats, nil
+}
+
+// ListInvestigationJurorIDs returns everyone drawn for the investigation or sitting on its jury.
+func (repo *Repository) ListInvestigationJurorIDs(ctx context.Context, invID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT user_id FROM jurors WHERE investigation_id = $1
+		UNION
+		SELECT unnest(selected) FROM jury_draws WHERE investigation_id = $1`,
+		invID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investigation jurors: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan investigation juror: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list investigation jurors: %w", err)
+	}
+	return ids, nil
+}
+
+// ListUsersToRescore returns the users whose rating wasn't scored since before, least recently scored first.
+func (repo *Repository) ListUsersToRescore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT id
+		FROM users
+		WHERE rating_scored_at IS NULL OR rating_scored_at < $1
+		ORDER BY rating_scored_at NULLS FIRST, id
+		LIMIT $2`,
+		before, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users to rescore: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user to rescore: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users to rescore: %w", err)
+	}
+	return ids, nil
+}
+
+func (repo *Repository) UpdateUserRating(ctx context.Context, userID uuid.UUID, rating int, scoredAt time.Time,
+) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE users
+		SET rating = $1, rating_scored_at = $2
+		WHERE id = $3`,
+		rating, scoredAt, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user rating: %w", err)
+	}
+	return nil
+}
+
+func (repo *Repository) InsertRatingChange(ctx context.Context, change models.RatingChange) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO rating_history (id, user_id, investigation_id, rating, accuracy, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		change.ID, change.UserID, change.InvestigationID, change.Rating, change.Accuracy, change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert rating change: %w", err)
+	}
+	return nil
+}
+
+// ListRatingHistory returns the ratings the user had since the time, oldest first.
+func (repo *Repository) ListRatingHistory(ctx context.Context, userID uuid.UUID, since time.Time,
+) ([]models.RatingPoint, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT rating, accuracy, created_at
+		FROM rating_history
+		WHERE user_id = $1 AND created_at >= $2
+		ORDER BY created_at`,
+		userID, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rating history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []models.RatingPoint
+	for rows.Next() {
+		var p models.RatingPoint
+		if err = rows.Scan(&p.Rating, &p.Accuracy, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rating point: %w", err)
+		}
+		points = append(points, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list rating history: %w", err)
+	}
+	return points, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestListJurorJudgements(t *testing.T) {
+	now := time.Now()
+	var gotArgs []driver.NamedValue
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(_ string, args []driver.NamedValue) (driver.Rows, error) {
+			gotArgs = args
+			return newRows(
+				[]string{"correct", "decided_at"},
+				[]driver.Value{true, now},
+				[]driver.Value{false, now},
+			), nil
+		},
+	})
+
+	judgements, err := repo.ListJurorJudgements(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(judgements) != 2 || !judgements[0].Correct || judgements[1].Correct {
+		t.Fatalf("unexpected judgements: %+v", judgements)
+	}
+	if gotArgs[3].Value != string(models.InvestigationStatusPassed) {
+		t.Fatalf("expected closed investigations only, got %v", gotArgs[3].Value)
+	}
+}
+
+func TestListRatingHistory(t *testing.T) {
+	now := time.Now()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"rating", "accuracy", "created_at"},
+				[]driver.Value{int64(12), 0.8, now},
+				[]driver.Value{int64(30), 0.9, now},
+			), nil
+		},
+	})
+
+	points, err := repo.ListRatingHistory(context.Background(), uuid.New(), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 2 || points[1].Rating != 30 || points[1].Accuracy != 0.9 {
+		t.Fatalf("unexpected history: %+v", points)
+	}
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+// CanViewTransparencyReport reports whether the user is a participant of the dispute.
+func (repo *Repository) CanViewTransparencyReport(ctx context.Context, disputeID uuid.UUID, actorUsername string,
+) (bool, error) {
+	var ok bool
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM participants p
+			JOIN users u ON u.id = p.user_id
+			WHERE p.dispute_id = $1 AND u.username = $2
+		)`,
+		disputeID, actorUsername,
+	).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check transparency report access: %w", err)
+	}
+	return ok, nil
+}
+
+// GetTransparencyReport returns the vote counts and the verdict of the closed investigation of the dispute, or
+// ErrNotFound while there is none.
+func (repo *Repository) GetTransparencyReport(ctx context.Context, disputeID uuid.UUID,
+) (models.TransparencyReport, error) {
+	var (
+		report  models.TransparencyReport
+		verdict []byte
+	)
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, total, p1, p2, draw, verdict
+		FROM investigations
+		WHERE dispute_id = $1 AND status = $2`,
+		disputeID, models.InvestigationStatusPassed,
+	).Scan(&report.InvestigationID, &report.Total, &report.P1, &report.P2, &report.Draw, &verdict); err != nil {
+		return models.TransparencyReport{}, fmt.Errorf("failed to get transparency report: %w",
+			handleNotFoundError(err))
+	}
+	if verdict != nil {
+		report.Verdict = &models.Verdict{}
+		if err := json.Unmarshal(verdict, report.Verdict); err != nil {
+			return models.TransparencyReport{}, fmt.Errorf("failed to unmarshal verdict: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// ListTransparencyVotes returns the votes cast in the investigation without the jurors who cast them. Hidden
+// rationales are left out.
+func (repo *Repository) ListTransparencyVotes(ctx context.Context, invID uuid.UUID,
+) ([]models.TransparencyVote, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT id, vote, CASE WHEN rationale_hidden THEN NULL ELSE rationale END, rationale_hidden
+		FROM jurors
+		WHERE investigation_id = $1 AND vote <> ''
+		ORDER BY id`,
+		invID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transparency votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []models.TransparencyVote
+	for rows.Next() {
+		var v models.TransparencyVote
+		if err = rows.Scan(&v.ID, &v.Vote, &v.Rationale, &v.RationaleHidden); err != nil {
+			return nil, fmt.Errorf("failed to scan transparency vote: %w", err)
+		}
+		votes = append(votes, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list transparency votes: %w", err)
+	}
+	return votes, nil
+}
+
+// InsertRationaleFlag records the flag. A participant flagging the same rationale again keeps the first flag.
+func (repo *Repository) InsertRationaleFlag(ctx context.Context, flag models.RationaleFlag) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO rationale_flags (id, juror_id, reporter_id, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (juror_id, reporter_id) DO NOTHING`,
+		flag.ID, flag.JurorID, flag.ReporterID, flag.Reason, flag.Status, flag.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert rationale flag: %w", err)
+	}
+	return nil
+}
+
+func (repo *Repository) GetRationaleFlag(ctx context.Context, id uuid.UUID) (models.RationaleFlag, error) {
+	var flag models.RationaleFlag
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, juror_id, reporter_id, reason, status, created_at, resolved_at
+		FROM rationale_flags
+		WHERE id = $1`,
+		id,
+	).Scan(&flag.ID, &flag.JurorID, &flag.ReporterID, &flag.Reason, &flag.Status, &flag.CreatedAt,
+		&flag.ResolvedAt); err != nil {
+		return models.RationaleFlag{}, fmt.Errorf("failed to get rationale flag: %w", handleNotFoundError(err))
+	}
+	return flag, nil
+}
+
+// ListRationaleFlags returns the flags with the status, oldest first, with the rationales they report.
+func (repo *Repository) ListRationaleFlags(ctx context.Context, status models.RationaleFlagStatus,
+) ([]models.RationaleFlagCard, error) {
+	rows, err := repo.conn(ctx).QueryContext(ctx, `
+		SELECT f.id, f.juror_id, f.reporter_id, f.reason, f.status, f.created_at, f.resolved_at,
+			j.investigation_id, j.vote, COALESCE(j.rationale, ''), j.rationale_hidden
+		FROM rationale_flags f
+		JOIN jurors j ON j.id = f.juror_id
+		WHERE f.status = $1
+		ORDER BY f.created_at`,
+		status,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rationale flags: %w", err)
+	}
+	defer rows.Close()
+
+	var flags []models.RationaleFlagCard
+	for rows.Next() {
+		var f models.RationaleFlagCard
+		if err = rows.Scan(&f.ID, &f.JurorID, &f.ReporterID, &f.Reason, &f.Status, &f.CreatedAt, &f.ResolvedAt,
+			&f.InvestigationID, &f.Vote, &f.Rationale, &f.RationaleHidden); err != nil {
+			return nil, fmt.Errorf("failed to scan rationale flag: %w", err)
+		}
+		flags = append(flags, f)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list rationale flags: %w", err)
+	}
+	return flags, nil
+}
+
+// ResolveRationaleFlags resolves every open flag of the rationale of the juror with the status.
+func (repo *Repository) ResolveRationaleFlags(ctx context.Context, jurorID uuid.UUID,
+	status models.RationaleFlagStatus, resolvedAt time.Time,
+) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE rationale_flags
+		SET status = $1, resolved_at = $2
+		WHERE juror_id = $3 AND status = $4`,
+		status, resolvedAt, jurorID, models.RationaleFlagStatusOpen,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to resolve rationale flags: %w", err)
+	}
+	return nil
+}
+
+// HideRationale hides the rationale of the juror from the transparency report.
+func (repo *Repository) HideRationale(ctx context.Context, jurorID uuid.UUID) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		UPDATE jurors
+		SET rationale_hidden = TRUE, updated_at = now()
+		WHERE id = $1`,
+		jurorID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to hide rationale: %w", err)
+	}
+	return nil
+}
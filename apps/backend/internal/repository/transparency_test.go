@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGetTransparencyReport(t *testing.T) {
+	invID := uuid.New()
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"id", "total", "p1", "p2", "draw", "verdict"},
+				[]driver.Value{invID.String(), int64(3), int64(2), int64(1), int64(0),
+					[]byte(`{"vote":"p1","reason":"decided","round":1,"ballots":3}`)},
+			), nil
+		},
+	})
+
+	report, err := repo.GetTransparencyReport(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.InvestigationID != invID || report.P1 != 2 || report.Verdict == nil || report.Verdict.Vote != "p1" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestGetTransparencyReportNotFound(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows([]string{"id", "total", "p1", "p2", "draw", "verdict"}), nil
+		},
+	})
+
+	if _, err := repo.GetTransparencyReport(context.Background(), uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListTransparencyVotes(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"id", "vote", "rationale", "rationale_hidden"},
+				[]driver.Value{uuid.NewString(), "p1", "clear photo", false},
+				[]driver.Value{uuid.NewString(), "p2", nil, true},
+			), nil
+		},
+	})
+
+	votes, err := repo.ListTransparencyVotes(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(votes) != 2 || votes[0].Rationale == nil || *votes[0].Rationale != "clear photo" {
+		t.Fatalf("unexpected votes: %+v", votes)
+	}
+	if votes[1].Rationale != nil || !votes[1].RationaleHidden {
+		t.Fatalf("expected the hidden rationale left out, got %+v", votes[1])
+	}
+}
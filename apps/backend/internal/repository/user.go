@@ -13,7 +13,7 @@ import (
 
 func (repo *Repository) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
 	var user models.User
-	err := handleNotFoundError(repo.db.QueryRowContext(ctx, `
+	err := handleNotFoundError(repo.conn(ctx).QueryRowContext(ctx, `
 	SELECT id, username, photo_url, created_at, notification_enabled, dispute_readiness, investigation_readiness, 
 	minimum_dispute_amount_nano, rating, chat_id 
 	FROM users WHERE username = $1`, username).Scan(
@@ -36,7 +36,7 @@ func (repo *Repository) GetUserByUsername(ctx context.Context, username string)
 
 func (repo *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (models.User, error) {
 	var user models.User
-	err := handleNotFoundError(repo.db.QueryRowContext(ctx, `
+	err := handleNotFoundError(repo.conn(ctx).QueryRowContext(ctx, `
 	SELECT id, username, photo_url, created_at, notification_enabled, dispute_readiness, investigation_readiness, 
 	minimum_dispute_amount_nano, rating, chat_id
 	FROM users WHERE id = $1`, id).Scan(
@@ -59,7 +59,7 @@ func (repo *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (models.U
 
 func (repo *Repository) ExistByUsername(ctx context.Context, username string) (bool, error) {
 	var exists bool
-	err := repo.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)",
+	err := repo.conn(ctx).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)",
 		username).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check existence of user by username: %w", err)
@@ -69,7 +69,7 @@ func (repo *Repository) ExistByUsername(ctx context.Context, username string) (b
 
 func (repo *Repository) InsertUser(ctx context.Context, user models.User) error {
 	repo.logger.Info("creating user", zap.String("username", user.Username))
-	_, err := repo.db.ExecContext(ctx, `
+	_, err := repo.conn(ctx).ExecContext(ctx, `
 	INSERT INTO users (id, username, photo_url, chat_id, notification_enabled) 
 	VALUES ($1, $2, $3, $4, $5)`,
 		user.ID,
@@ -91,17 +91,15 @@ func (repo *Repository) UpdateUser(ctx context.Context, opts models.UserUpdateOp
 			notification_enabled = COALESCE($1, notification_enabled),
 			dispute_readiness = COALESCE($2, dispute_readiness),
 			investigation_readiness = COALESCE($3, investigation_readiness),
-			minimum_dispute_amount_nano = COALESCE($4, minimum_dispute_amount_nano),
-			rating = COALESCE($5, rating)
-		WHERE username = $6
+			minimum_dispute_amount_nano = COALESCE($4, minimum_dispute_amount_nano)
+		WHERE username = $5
 	`
 
-	_, err := repo.db.ExecContext(ctx, query,
+	_, err := repo.conn(ctx).ExecContext(ctx, query,
 		opts.NotificationEnabled,
 		opts.DisputeReadiness,
 		opts.InvestigationReadiness,
 		opts.MinimumDisputeAmountNano,
-		opts.Rating,
 		opts.Username,
 	)
 	if err != nil {
@@ -119,7 +117,7 @@ func (repo *Repository) UpdateChatID(ctx context.Context, chatID int64, username
 		WHERE username = $2
 	`
 
-	_, err := repo.db.ExecContext(ctx, query,
+	_, err := repo.conn(ctx).ExecContext(ctx, query,
 		chatID,
 		username,
 	)
@@ -136,7 +134,7 @@ func (repo *Repository) UpdateUserPhotoURL(ctx context.Context, username string,
 		WHERE username = $2 AND photo_url IS DISTINCT FROM $1
 	`
 
-	_, err := repo.db.ExecContext(ctx, query, photoUrl, username)
+	_, err := repo.conn(ctx).ExecContext(ctx, query, photoUrl, username)
 	if err != nil {
 		return fmt.Errorf("failed to update user photo url: %w", err)
 	}
@@ -146,7 +144,7 @@ func (repo *Repository) UpdateUserPhotoURL(ctx context.Context, username string,
 func (repo *Repository) GetTotalUsers(ctx context.Context) (int, error) {
 	var total int
 
-	if err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+	if err := repo.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&total); err != nil {
 		return 0, fmt.Errorf("failed to get total users: %w", err)
 	}
 	return total, nil
@@ -162,7 +160,7 @@ func (repo *Repository) GetUsers(ctx context.Context, ids []uuid.UUID) ([]models
 		WHERE id = ANY($1)
 	`
 
-	rows, err := repo.db.QueryContext(ctx, query, pq.Array(ids))
+	rows, err := repo.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch users: %w", err)
 	}
@@ -204,7 +202,7 @@ func (repo *Repository) GetTopUsers(ctx context.Context, limit int) ([]models.Us
 		LIMIT $1
 	`
 
-	rows, err := repo.db.QueryContext(ctx, query, limit)
+	rows, err := repo.conn(ctx).QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch top users: %w", err)
 	}
@@ -227,17 +225,3 @@ func (repo *Repository) GetTopUsers(ctx context.Context, limit int) ([]models.Us
 
 	return users, nil
 }
-
-func (repo *Repository) EarnWinnerRating(ctx context.Context, ids []uuid.UUID) error {
-	query := `
-		UPDATE users
-		SET rating = rating + 3
-		WHERE id = ANY($1)
-	`
-
-	_, err := repo.db.ExecContext(ctx, query, pq.Array(ids))
-	if err != nil {
-		return fmt.Errorf("failed to update winner ratings: %w", err)
-	}
-	return nil
-}
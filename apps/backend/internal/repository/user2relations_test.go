@@ -27,61 +27,41 @@ func TestGetOpponentID(t *testing.T) {
 	}
 }
 
-func TestUpdateParticipant(t *testing.T) {
-	execCalls := 0
+func TestInsertOpponentParticipantTaken(t *testing.T) {
 	repo := newTestRepo(t, &stubDB{
-		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
-			execCalls++
-			return driver.RowsAffected(1), nil
+		execFn: func(query string, _ []driver.NamedValue) (driver.Result, error) {
+			if !strings.Contains(query, "ON CONFLICT (dispute_id) WHERE is_creator = FALSE DO NOTHING") {
+				t.Fatalf("expected opponent conflict check in query: %s", query)
+			}
+			return driver.RowsAffected(0), nil
 		},
 	})
 
-	err := repo.UpdateParticipant(context.Background(), models.ParticipantUpdateOpts{ID: uuid.New()})
+	inserted, err := repo.InsertOpponentParticipant(context.Background(),
+		models.NewParticipant(uuid.New(), uuid.New(), models.DisputesResultNew, false))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if execCalls != 1 {
-		t.Fatalf("expected 1 exec, got %d", execCalls)
+	if inserted {
+		t.Fatal("expected no insert once the dispute has an opponent")
 	}
 }
 
-func TestBroadcastInvestigationSkipsDisputeUsers(t *testing.T) {
-	p1 := uuid.New()
-	p2 := uuid.New()
-	eligible := uuid.New()
+func TestUpdateParticipant(t *testing.T) {
 	execCalls := 0
-	queryChecked := false
 	repo := newTestRepo(t, &stubDB{
-		queryFn: func(query string, _ []driver.NamedValue) (driver.Rows, error) {
-			if !strings.Contains(query, "investigation_readiness = TRUE") {
-				t.Fatalf("expected investigation readiness filter in query: %s", query)
-			}
-			queryChecked = true
-			return newRows(
-				[]string{"id"},
-				[]driver.Value{p1.String()},
-				[]driver.Value{p2.String()},
-				[]driver.Value{eligible.String()},
-			), nil
-		},
 		execFn: func(string, []driver.NamedValue) (driver.Result, error) {
 			execCalls++
 			return driver.RowsAffected(1), nil
 		},
 	})
 
-	ids, err := repo.BroadcastInvestigation(context.Background(), models.NewJuror(uuid.New(), uuid.Nil), p1, p2)
+	err := repo.UpdateParticipant(context.Background(), models.ParticipantUpdateOpts{ID: uuid.New()})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(ids) != 1 || ids[0] != eligible {
-		t.Fatalf("unexpected ids: %#v", ids)
-	}
-	if !queryChecked {
-		t.Fatal("expected query check to run")
-	}
-	if execCalls != 2 {
-		t.Fatalf("expected 2 exec calls (insert + total update), got %d", execCalls)
+	if execCalls != 1 {
+		t.Fatalf("expected 1 exec, got %d", execCalls)
 	}
 }
 
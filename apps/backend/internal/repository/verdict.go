@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func (repo *Repository) GetVerdictPolicy(ctx context.Context) (models.VerdictPolicy, error) {
+	var policy models.VerdictPolicy
+	err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT quorum, require_rationale, commit_reveal, updated_at
+		FROM verdict_policy`,
+	).Scan(&policy.Quorum, &policy.RequireRationale, &policy.CommitReveal, &policy.UpdatedAt)
+	if err != nil {
+		return models.VerdictPolicy{}, fmt.Errorf("failed to get verdict policy: %w", handleNotFoundError(err))
+	}
+	return policy, nil
+}
+
+func (repo *Repository) UpdateVerdictPolicy(ctx context.Context, policy models.VerdictPolicy) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO verdict_policy (id, quorum, require_rationale, commit_reveal, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET quorum            = EXCLUDED.quorum,
+		    require_rationale = EXCLUDED.require_rationale,
+		    commit_reveal     = EXCLUDED.commit_reveal,
+		    updated_at        = EXCLUDED.updated_at`,
+		policy.Quorum, policy.RequireRationale, policy.CommitReveal, policy.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update verdict policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+func TestListJurorBallots(t *testing.T) {
+	repo := newTestRepo(t, &stubDB{
+		queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+			return newRows(
+				[]string{"vote"},
+				[]driver.Value{"p1"},
+				[]driver.Value{"p2"},
+			), nil
+		},
+	})
+
+	ballots, err := repo.ListJurorBallots(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ballots) != 2 || ballots[0] != (models.Ballot{Vote: "p1"}) || ballots[1].Vote != "p2" {
+		t.Fatalf("unexpected ballots: %+v", ballots)
+	}
+}
+
+func TestUpdateInvestigationVerdict(t *testing.T) {
+	var gotArgs []driver.NamedValue
+	repo := newTestRepo(t, &stubDB{
+		execFn: func(_ string, args []driver.NamedValue) (driver.Result, error) {
+			gotArgs = args
+			return driver.RowsAffected(1), nil
+		},
+	})
+
+	verdict := models.Verdict{Vote: "p1", Reason: models.VerdictReasonDecided, Round: 1, Ballots: 1, Required: 1,
+		Votes: map[string]int{"p1": 1}}
+	err := repo.UpdateInvestigation(context.Background(),
+		models.InvestigationUpdateOpts{ID: uuid.New(), Verdict: &verdict})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw, ok := gotArgs[8].Value.([]byte); !ok || len(raw) == 0 {
+		t.Fatalf("expected the verdict marshalled, got %#v", gotArgs[8].Value)
+	}
+}
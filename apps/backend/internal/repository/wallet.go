@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetUserWallet returns the raw address of the wallet bound to the user, or ErrNotFound while there is none.
+func (repo *Repository) GetUserWallet(ctx context.Context, userID uuid.UUID) (string, error) {
+	var wallet string
+	if err := repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT address
+		FROM user_wallets
+		WHERE user_id = $1`,
+		userID,
+	).Scan(&wallet); err != nil {
+		return "", fmt.Errorf("failed to get user wallet: %w", handleNotFoundError(err))
+	}
+	return wallet, nil
+}
+
+// BindUserWallet binds the wallet to a user who has none yet and reports whether the user ends up bound to it: it
+// doesn't when the user is bound to another wallet or the wallet to another user.
+func (repo *Repository) BindUserWallet(ctx context.Context, userID uuid.UUID, wallet string) (bool, error) {
+	res, err := repo.conn(ctx).ExecContext(ctx, `
+		INSERT INTO user_wallets (user_id, address)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		userID, wallet,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to bind user wallet: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to bind user wallet: %w", err)
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	// A concurrent request may have bound the same wallet first.
+	var bound bool
+	if err = repo.conn(ctx).QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_wallets WHERE user_id = $1 AND address = $2
+		)`,
+		userID, wallet,
+	).Scan(&bound); err != nil {
+		return false, fmt.Errorf("failed to check user wallet: %w", err)
+	}
+	return bound, nil
+}
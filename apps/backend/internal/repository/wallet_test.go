@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBindUserWallet(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		inserted int64
+		exists   bool
+		want     bool
+	}{
+		{name: "binds a new wallet", inserted: 1, want: true},
+		{name: "keeps a wallet a concurrent request bound", exists: true, want: true},
+		{name: "refuses a wallet bound elsewhere"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			queried := false
+			repo := newTestRepo(t, &stubDB{
+				execFn: func(string, []driver.NamedValue) (driver.Result, error) {
+					return driver.RowsAffected(tc.inserted), nil
+				},
+				queryFn: func(string, []driver.NamedValue) (driver.Rows, error) {
+					queried = true
+					return newRows([]string{"exists"}, []driver.Value{tc.exists}), nil
+				},
+			})
+
+			bound, err := repo.BindUserWallet(context.Background(), uuid.New(), "0:aa")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if bound != tc.want || queried != (tc.inserted == 0) {
+				t.Fatalf("expected bound=%v, got %v (queried %v)", tc.want, bound, queried)
+			}
+		})
+	}
+}
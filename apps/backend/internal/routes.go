@@ -7,11 +7,10 @@ import (
 
 func (s Server) RegisterRoutes(repo *repository.Repository) {
 	s.router.Static("/swagger", "./swagger")
-
 	apiRouter := s.router.Group("/api/v1", api.Middleware())
 
 	auth := apiRouter.Group("/auth")
-	auth.POST("/telegram", api.TelegramAuth(repo, s.logger))
+	auth.POST("/telegram", api.TelegramAuth(repo, s.logger, s.msgService))
 
 	changes := apiRouter.Group("/changes")
 	changes.GET("", api.ListChanges(repo, s.logger))
@@ -20,26 +19,61 @@ func (s Server) RegisterRoutes(repo *repository.Repository) {
 	users.GET("/me", api.GetMe(repo, s.logger))
 	users.PATCH("", api.UpdateUser(repo, s.logger))
 	users.GET("/top", api.GetTop(repo, s.logger))
+	users.GET("/me/reputation", api.GetMyReputation(repo, s.logger))
+	users.GET("/:username/reputation", api.GetUserReputation(repo, s.logger))
+	users.GET("/juror-blocks", api.ListJurorBlocks(repo, s.logger))
+	users.POST("/juror-blocks", api.BlockJuror(repo, s.logger))
+	users.POST("/juror-blocks/remove", api.UnblockJuror(repo, s.logger))
 
 	disputes := apiRouter.Group("/disputes")
 	disputes.GET("", api.ListDisputes(repo, s.logger, s.msgService))
+	disputes.GET("/open", api.ListOpenDisputes(repo, s.logger, s.msgService))
+	disputes.GET("/open/:id", api.GetOpenDispute(repo, s.logger, s.msgService))
 	disputes.POST("/mark-seen", api.MarkDisputesSeen(repo, s.logger, s.msgService))
 	disputes.POST("/precheck", api.PrecheckDispute(repo, s.logger, s.msgService))
-	disputes.POST("", api.CreateDispute(repo, s.logger, s.msgService, s.txMonitor))
+	disputes.POST("", api.CreateDispute(repo, s.logger, s.msgService, s.txMonitor, s.betMaster, s.blobs))
 	disputes.GET("/:id", api.GetDispute(repo, s.logger, s.msgService))
+	disputes.GET("/:id/history", api.GetDisputeHistory(repo, s.logger, s.msgService))
+	disputes.GET("/:id/transparency-report", api.GetTransparencyReport(repo, s.logger))
+	disputes.POST("/:id/transparency-report/votes/:voteID/flag", api.FlagRationale(repo, s.logger))
 	disputes.GET("/:id/evidence", api.GetDisputeForEvidence(repo, s.logger, s.msgService))
 	disputes.POST("/:id/accept", api.AcceptDispute(repo, s.logger, s.msgService, s.txMonitor))
 	disputes.POST("/:id/reject", api.RejectDispute(repo, s.logger, s.msgService))
 	disputes.POST("/:id/claim", api.ClaimDispute(repo, s.logger, s.msgService, s.txMonitor))
 	disputes.POST("/:id/vote", api.VoteDispute(repo, s.logger, s.msgService, s.txMonitor))
-	disputes.POST("/:id/evidence", api.ProvideEvidence(repo, s.logger, s.msgService, s.txMonitor))
+	disputes.GET("/:id/offers", api.ListOffers(repo, s.logger, s.msgService))
+	disputes.POST("/:id/offers", api.ProposeOffer(repo, s.logger, s.msgService, s.txMonitor, s.betMaster))
+	disputes.POST("/:id/offers/:version/accept", api.AcceptOffer(repo, s.logger, s.msgService, s.txMonitor, s.betMaster))
+	disputes.POST("/:id/offers/:version/decline", api.DeclineOffer(repo, s.logger, s.msgService))
+	disputes.POST("/:id/evidence", api.ProvideEvidence(repo, s.logger, s.msgService, s.txMonitor, s.blobs))
+
+	invitations := apiRouter.Group("/invitations")
+	invitations.POST("/:token/redeem", api.RedeemInvitation(repo, s.logger, s.msgService))
 
 	evidence := apiRouter.Group("/evidence")
 	evidence.GET("", api.GetEvidencesByDispute(repo, s.logger, s.msgService))
+	evidence.GET("/:id/verification", api.VerifyEvidence(repo, s.logger, s.msgService))
 
 	investigation := apiRouter.Group("/investigations")
 	investigation.GET("", api.ListInvestigations(repo, s.logger, s.msgService))
 	investigation.POST("/mark-seen", api.MarkInvestigationsSeen(repo, s.logger, s.msgService))
 	investigation.GET("/:id", api.GetInvestigation(repo, s.logger, s.msgService))
+	investigation.POST("/:id/commit", api.CommitInvestigationVote(repo, s.logger, s.msgService))
 	investigation.POST("/:id/vote", api.VoteInvestigation(repo, s.logger, s.msgService, s.txMonitor))
+	investigation.POST("/:id/recuse", api.RecuseInvestigation(repo, s.logger, s.msgService))
+
+	admin := apiRouter.Group("/admin", api.AdminOnly())
+	admin.GET("/investigations/:id/exclusions", api.GetJuryExclusions(repo, s.logger))
+	admin.GET("/jury/conflict-policy", api.GetConflictPolicy(repo, s.logger))
+	admin.POST("/jury/conflict-policy", api.UpdateConflictPolicy(repo, s.logger))
+	admin.GET("/jury/verdict-policy", api.GetVerdictPolicy(repo, s.logger))
+	admin.POST("/jury/verdict-policy", api.UpdateVerdictPolicy(repo, s.logger))
+	admin.GET("/rationale-flags", api.ListRationaleFlags(repo, s.logger))
+	admin.POST("/rationale-flags/:id/resolve", api.ResolveRationaleFlag(repo, s.logger))
+
+	media := apiRouter.Group("/media")
+	media.GET("/:hash", api.GetMedia(repo, s.logger, s.blobs))
+
+	operations := apiRouter.Group("/operations")
+	operations.GET("/:id", api.GetOperation(repo, s.logger, s.msgService))
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/kisnikita/safe-disputes/backend/internal/integrations/blob"
 	"github.com/kisnikita/safe-disputes/backend/internal/integrations/ton"
 	"github.com/kisnikita/safe-disputes/backend/internal/services"
 
@@ -22,9 +23,13 @@ type Server struct {
 	srv        *http.Server
 	msgService services.MessageService
 	txMonitor  ton.TonAPIMonitor
+	betMaster  string
+	blobs      blob.Store
 }
 
-func NewServer(logger log.Logger, msgService services.MessageService, txMonitor ton.TonAPIMonitor) *Server {
+func NewServer(logger log.Logger, msgService services.MessageService, txMonitor ton.TonAPIMonitor,
+	betMaster string, blobs blob.Store,
+) *Server {
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -44,6 +49,8 @@ func NewServer(logger log.Logger, msgService services.MessageService, txMonitor
 		},
 		msgService: msgService,
 		txMonitor:  txMonitor,
+		betMaster:  betMaster,
+		blobs:      blobs,
 	}
 }
 
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultOperationBatchSize    = 20
+	defaultOperationLockTimeout  = 2 * time.Minute
+	defaultOperationCheckTimeout = 30 * time.Second
+	defaultOperationMaxAge       = time.Hour
+	defaultOperationMaxAttempts  = 10
+)
+
+type PendingOperationClaimer interface {
+	ClaimPendingOperations(ctx context.Context, now, lockUntil time.Time, limit int) ([]models.PendingOperation, error)
+	FinishPendingOperation(ctx context.Context, id uuid.UUID, status models.OperationStatus, reason *string) error
+	ReleasePendingOperation(ctx context.Context, id uuid.UUID) error
+}
+
+type PendingOperationFinder interface {
+	GetPendingOperation(ctx context.Context, id uuid.UUID) (models.PendingOperation, error)
+}
+
+type OperationApplier interface {
+	ApplyOperation(ctx context.Context, op models.PendingOperation) error
+}
+
+// OperationService confirms pending operations in the background and reports their progress to their owners.
+type OperationService struct {
+	logger log.Logger
+
+	opClaimer  PendingOperationClaimer
+	opFinder   PendingOperationFinder
+	userFinder UserFinder
+	appliers   map[models.OperationAction]OperationApplier
+	txMonitor  TransactionMonitor
+	txRunner   TxRunner
+	msgSender  MessageSender
+
+	batchSize    int
+	lockTimeout  time.Duration
+	checkTimeout time.Duration
+	maxAge       time.Duration
+	maxAttempts  int
+	now          func() time.Time
+}
+
+func NewOperationService(repo *repository.Repository, log log.Logger, msgSender MessageSender,
+) (OperationService, error) {
+	if repo == nil {
+		return OperationService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return OperationService{}, fmt.Errorf("logger is nil")
+	}
+	disputeSrv, err := NewDisputeService(repo, log, msgSender)
+	if err != nil {
+		return OperationService{}, fmt.Errorf("failed to create dispute service: %w", err)
+	}
+	evidenceSrv, err := NewEvidenceService(repo, log, msgSender)
+	if err != nil {
+		return OperationService{}, fmt.Errorf("failed to create evidence service: %w", err)
+	}
+	investigationSrv, err := NewInvestigationService(repo, log, msgSender)
+	if err != nil {
+		return OperationService{}, fmt.Errorf("failed to create investigation service: %w", err)
+	}
+	offerSrv, err := NewOfferService(repo, log, msgSender)
+	if err != nil {
+		return OperationService{}, fmt.Errorf("failed to create offer service: %w", err)
+	}
+
+	return OperationService{
+		logger:     log,
+		opClaimer:  repo,
+		opFinder:   repo,
+		userFinder: repo,
+		appliers: map[models.OperationAction]OperationApplier{
+			models.OperationActionCreateDispute:     disputeSrv,
+			models.OperationActionAcceptDispute:     disputeSrv,
+			models.OperationActionClaimDispute:      disputeSrv,
+			models.OperationActionVoteDispute:       disputeSrv,
+			models.OperationActionProposeOffer:      offerSrv,
+			models.OperationActionAcceptOffer:       offerSrv,
+			models.OperationActionProvideEvidence:   evidenceSrv,
+			models.OperationActionVoteInvestigation: investigationSrv,
+		},
+		txRunner:     repo,
+		msgSender:    msgSender,
+		batchSize:    defaultOperationBatchSize,
+		lockTimeout:  defaultOperationLockTimeout,
+		checkTimeout: defaultOperationCheckTimeout,
+		maxAge:       defaultOperationMaxAge,
+		maxAttempts:  defaultOperationMaxAttempts,
+		now:          time.Now,
+	}, nil
+}
+
+func (s OperationService) WithTransactionMonitor(txMonitor TransactionMonitor) OperationService {
+	s.txMonitor = txMonitor
+	return s
+}
+
+// WithApplier makes applier handle the given actions, e.g. an investigation service with a custom verdict rule.
+func (s OperationService) WithApplier(applier OperationApplier, actions ...models.OperationAction) OperationService {
+	s.appliers = maps.Clone(s.appliers)
+	for _, action := range actions {
+		s.appliers[action] = applier
+	}
+	return s
+}
+
+// GetOperation returns the progress of an operation submitted by actorUsername.
+// Operations of other users are reported as not found.
+func (s OperationService) GetOperation(ctx context.Context, operationID, actorUsername string,
+) (models.OperationDetails, error) {
+	id, err := uuid.Parse(operationID)
+	if err != nil {
+		return models.OperationDetails{}, fmt.Errorf("%w: invalid operation ID format: %v", ErrValidation, err)
+	}
+	actor, err := s.userFinder.GetUserByUsername(ctx, actorUsername)
+	if err != nil {
+		return models.OperationDetails{}, fmt.Errorf("failed to get actor user: %w", err)
+	}
+
+	op, err := s.opFinder.GetPendingOperation(ctx, id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.OperationDetails{}, fmt.Errorf("%w: %s", ErrOperationNotFound, id)
+	case err != nil:
+		return models.OperationDetails{}, fmt.Errorf("failed to get pending operation: %w", err)
+	}
+	if op.UserID != actor.ID {
+		return models.OperationDetails{}, fmt.Errorf("%w: %s", ErrOperationNotFound, id)
+	}
+	return op.Details(), nil
+}
+
+// Run confirms pending operations every interval until ctx is cancelled.
+func (s OperationService) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, s.logger, "pending-operations", interval, s.ProcessPendingOperations)
+}
+
+// ProcessPendingOperations checks the transactions of pending operations and applies the confirmed ones.
+// Operations are leased with locked_until, so several instances never handle the same operation at once,
+// and operations left pending by a stopped instance are picked up once their lease expires.
+func (s OperationService) ProcessPendingOperations(ctx context.Context) error {
+	if s.txMonitor == nil {
+		return fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
+	}
+	now := s.now()
+	ops, err := s.opClaimer.ClaimPendingOperations(ctx, now, now.Add(s.lockTimeout), s.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim pending operations: %w", err)
+	}
+
+	// Waiting for the chain dominates, so operations of a batch are confirmed concurrently.
+	var wg sync.WaitGroup
+	for _, op := range ops {
+		wg.Go(func() {
+			s.confirm(ctx, op, now)
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s OperationService) confirm(ctx context.Context, op models.PendingOperation, now time.Time) {
+	logger := s.logger.With(zap.String("operation_id", op.ID.String()), zap.String("action", string(op.Action)))
+
+	applier, ok := s.appliers[op.Action]
+	if !ok {
+		s.fail(ctx, logger, op, fmt.Errorf("%w: unsupported operation %s", ErrValidation, op.Action))
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, s.checkTimeout)
+	err := s.txMonitor.WaitForSuccess(checkCtx, op.Boc)
+	cancel()
+	switch {
+	case errors.Is(err, ErrTxFailed), errors.Is(err, ErrInvalidBOC):
+		s.fail(ctx, logger, op, err)
+		return
+	case err != nil && now.Sub(op.CreatedAt) > s.maxAge:
+		s.fail(ctx, logger, op, err)
+		return
+	case err != nil:
+		logger.Info("transaction is not confirmed yet", zap.Error(err))
+		s.release(ctx, logger, op)
+		return
+	}
+
+	err = inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		if err := applier.ApplyOperation(ctx, op); err != nil {
+			return err
+		}
+		return s.opClaimer.FinishPendingOperation(ctx, op.ID, models.OperationStatusSucceeded, nil)
+	})
+	switch {
+	case err == nil:
+		logger.Info("operation applied")
+	case isPermanentOperationError(err), op.Attempts >= s.maxAttempts:
+		s.fail(ctx, logger, op, err)
+	default:
+		logger.Error("failed to apply operation, will retry", zap.Int("attempts", op.Attempts), zap.Error(err))
+		s.release(ctx, logger, op)
+	}
+}
+
+// isPermanentOperationError reports whether retrying the operation cannot succeed.
+func isPermanentOperationError(err error) bool {
+	return errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, repository.ErrNotFound) ||
+		errors.Is(err, ErrIdempotencyConflict) ||
+		errors.Is(err, ErrDisputeTaken)
+}
+
+func (s OperationService) fail(ctx context.Context, logger log.Logger, op models.PendingOperation, reason error) {
+	logger.Error("operation failed", zap.Error(reason))
+	if err := s.opClaimer.FinishPendingOperation(ctx, op.ID, models.OperationStatusFailed,
+		new(reason.Error())); err != nil {
+		logger.Error("failed to mark operation failed", zap.Error(err))
+	}
+}
+
+func (s OperationService) release(ctx context.Context, logger log.Logger, op models.PendingOperation) {
+	if err := s.opClaimer.ReleasePendingOperation(ctx, op.ID); err != nil {
+		logger.Error("failed to release operation", zap.Error(err))
+	}
+}
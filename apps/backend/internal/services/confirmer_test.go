@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeOperationClaimer struct {
+	claimed  []models.PendingOperation
+	stored   map[uuid.UUID]models.PendingOperation
+	statuses map[uuid.UUID]models.OperationStatus
+	reasons  map[uuid.UUID]string
+	released []uuid.UUID
+}
+
+func (f *fakeOperationClaimer) ClaimPendingOperations(context.Context, time.Time, time.Time, int,
+) ([]models.PendingOperation, error) {
+	return f.claimed, nil
+}
+
+func (f *fakeOperationClaimer) FinishPendingOperation(_ context.Context, id uuid.UUID, status models.OperationStatus,
+	reason *string,
+) error {
+	f.statuses[id] = status
+	if reason != nil {
+		f.reasons[id] = *reason
+	}
+	return nil
+}
+
+func (f *fakeOperationClaimer) ReleasePendingOperation(_ context.Context, id uuid.UUID) error {
+	f.released = append(f.released, id)
+	return nil
+}
+
+func (f *fakeOperationClaimer) GetPendingOperation(_ context.Context, id uuid.UUID) (models.PendingOperation, error) {
+	op, ok := f.stored[id]
+	if !ok {
+		return models.PendingOperation{}, repository.ErrNotFound
+	}
+	return op, nil
+}
+
+type fakeOperationApplier struct {
+	err     error
+	applied []uuid.UUID
+}
+
+func (f *fakeOperationApplier) ApplyOperation(_ context.Context, op models.PendingOperation) error {
+	f.applied = append(f.applied, op.ID)
+	return f.err
+}
+
+func TestOperationServiceProcessPendingOperations(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	newService := func(op models.PendingOperation, monitorErr, applyErr error,
+	) (OperationService, *fakeOperationClaimer, *fakeOperationApplier, *fakeTxRunner) {
+		claimer := &fakeOperationClaimer{
+			claimed:  []models.PendingOperation{op},
+			statuses: map[uuid.UUID]models.OperationStatus{},
+			reasons:  map[uuid.UUID]string{},
+		}
+		applier := &fakeOperationApplier{err: applyErr}
+		txRunner := &fakeTxRunner{}
+		return OperationService{
+			logger:       noopLogger{},
+			opClaimer:    claimer,
+			appliers:     map[models.OperationAction]OperationApplier{models.OperationActionAcceptDispute: applier},
+			txMonitor:    &fakeTxMonitor{err: monitorErr},
+			txRunner:     txRunner,
+			msgSender:    &fakeMessageSender{},
+			batchSize:    defaultOperationBatchSize,
+			lockTimeout:  defaultOperationLockTimeout,
+			checkTimeout: time.Second,
+			maxAge:       time.Hour,
+			maxAttempts:  3,
+			now:          func() time.Time { return now },
+		}, claimer, applier, txRunner
+	}
+	newOp := func(createdAt time.Time, attempts int) models.PendingOperation {
+		op := models.NewPendingOperation("hash", uuid.New(), models.OperationActionAcceptDispute, uuid.NewString(),
+			"boc", nil)
+		op.CreatedAt = createdAt
+		op.Attempts = attempts
+		return op
+	}
+
+	t.Run("confirmed operation is applied and settled in one unit of work", func(t *testing.T) {
+		op := newOp(now.Add(-time.Minute), 1)
+		svc, claimer, applier, txRunner := newService(op, nil, nil)
+
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(applier.applied) != 1 || claimer.statuses[op.ID] != models.OperationStatusSucceeded {
+			t.Fatalf("expected applied and succeeded, got applied=%v status=%q", applier.applied,
+				claimer.statuses[op.ID])
+		}
+		if txRunner.commits != 1 {
+			t.Fatalf("expected one commit, got %d", txRunner.commits)
+		}
+	})
+
+	t.Run("failed transaction fails the operation", func(t *testing.T) {
+		op := newOp(now.Add(-time.Minute), 1)
+		svc, claimer, applier, _ := newService(op, ErrTxFailed, nil)
+
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(applier.applied) != 0 || claimer.statuses[op.ID] != models.OperationStatusFailed {
+			t.Fatalf("expected failed without apply, got applied=%v status=%q", applier.applied,
+				claimer.statuses[op.ID])
+		}
+		if claimer.reasons[op.ID] == "" {
+			t.Fatal("expected failure reason to be stored")
+		}
+	})
+
+	t.Run("unfinalized transaction is retried later", func(t *testing.T) {
+		op := newOp(now.Add(-time.Minute), 1)
+		svc, claimer, applier, _ := newService(op, ErrTxNotFinalized, nil)
+
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(applier.applied) != 0 || len(claimer.statuses) != 0 || len(claimer.released) != 1 {
+			t.Fatalf("expected release only, got applied=%v statuses=%v released=%v", applier.applied,
+				claimer.statuses, claimer.released)
+		}
+	})
+
+	t.Run("operation unconfirmed past max age fails", func(t *testing.T) {
+		op := newOp(now.Add(-2*time.Hour), 5)
+		svc, claimer, _, _ := newService(op, ErrTxNotFinalized, nil)
+
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if claimer.statuses[op.ID] != models.OperationStatusFailed {
+			t.Fatalf("expected failed, got %q", claimer.statuses[op.ID])
+		}
+	})
+
+	t.Run("validation error fails at once", func(t *testing.T) {
+		op := newOp(now.Add(-time.Minute), 1)
+		svc, claimer, _, txRunner := newService(op, nil, ErrValidation)
+
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if claimer.statuses[op.ID] != models.OperationStatusFailed || txRunner.rollbacks != 1 {
+			t.Fatalf("expected rolled back failure, got status=%q rollbacks=%d", claimer.statuses[op.ID],
+				txRunner.rollbacks)
+		}
+	})
+
+	t.Run("transient apply error is retried until max attempts", func(t *testing.T) {
+		op := newOp(now.Add(-time.Minute), 1)
+		svc, claimer, _, _ := newService(op, nil, errors.New("db is down"))
+
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(claimer.statuses) != 0 || len(claimer.released) != 1 {
+			t.Fatalf("expected retry, got statuses=%v released=%v", claimer.statuses, claimer.released)
+		}
+
+		op = newOp(now.Add(-time.Minute), 3)
+		svc, claimer, _, _ = newService(op, nil, errors.New("db is down"))
+		if err := svc.ProcessPendingOperations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if claimer.statuses[op.ID] != models.OperationStatusFailed {
+			t.Fatalf("expected failed after max attempts, got %q", claimer.statuses[op.ID])
+		}
+	})
+}
+
+func TestOperationServiceGetOperation(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	bob := models.User{ID: uuid.New(), Username: "bob"}
+	op := models.NewPendingOperation("hash", alice.ID, models.OperationActionClaimDispute, uuid.NewString(), "boc", nil)
+	svc := OperationService{
+		logger:     noopLogger{},
+		opFinder:   &fakeOperationClaimer{stored: map[uuid.UUID]models.PendingOperation{op.ID: op}},
+		userFinder: &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": alice, "bob": bob}},
+	}
+
+	details, err := svc.GetOperation(context.Background(), op.ID.String(), "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if details.ID != op.ID || details.Status != models.OperationStatusPending {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+
+	if _, err = svc.GetOperation(context.Background(), op.ID.String(), "bob"); !errors.Is(err, ErrOperationNotFound) {
+		t.Fatalf("expected ErrOperationNotFound for another user, got %v", err)
+	}
+	if _, err = svc.GetOperation(context.Background(), uuid.NewString(), "alice"); !errors.Is(err, ErrOperationNotFound) {
+		t.Fatalf("expected ErrOperationNotFound, got %v", err)
+	}
+	if _, err = svc.GetOperation(context.Background(), "nope", "alice"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+type ConflictPolicyStore interface {
+	GetConflictPolicy(ctx context.Context) (models.ConflictPolicy, error)
+	UpdateConflictPolicy(ctx context.Context, policy models.ConflictPolicy) error
+}
+
+type JuryExclusionLister interface {
+	ListJuryExclusions(ctx context.Context, invID uuid.UUID) ([]models.JuryExclusionCard, error)
+}
+
+type JurorBlockStore interface {
+	InsertJurorBlock(ctx context.Context, block models.JurorBlock) error
+	DeleteJurorBlock(ctx context.Context, userID, blockedUserID uuid.UUID) error
+	ListJurorBlocks(ctx context.Context, userID uuid.UUID) ([]models.JurorBlockCard, error)
+}
+
+// ConflictService manages the conflict-of-interest policy of jury selection, the jurors users block from their
+// disputes and the report of the exclusions applied to each investigation.
+type ConflictService struct {
+	logger log.Logger
+
+	policyStore     ConflictPolicyStore
+	exclusionLister JuryExclusionLister
+	blockStore      JurorBlockStore
+	userFinder      UserFinder
+
+	now func() time.Time
+}
+
+func NewConflictService(repo *repository.Repository, log log.Logger) (ConflictService, error) {
+	if repo == nil {
+		return ConflictService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return ConflictService{}, fmt.Errorf("logger is nil")
+	}
+	return ConflictService{
+		logger: log,
+
+		policyStore:     repo,
+		exclusionLister: repo,
+		blockStore:      repo,
+		userFinder:      repo,
+
+		now: time.Now,
+	}, nil
+}
+
+func (s ConflictService) GetConflictPolicy(ctx context.Context) (models.ConflictPolicy, error) {
+	policy, err := s.policyStore.GetConflictPolicy(ctx)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.DefaultConflictPolicy, nil
+	case err != nil:
+		return models.ConflictPolicy{}, err
+	}
+	return policy, nil
+}
+
+func (s ConflictService) UpdateConflictPolicy(ctx context.Context, policy models.ConflictPolicy,
+) (models.ConflictPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return models.ConflictPolicy{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	policy.UpdatedAt = s.now()
+	if err := s.policyStore.UpdateConflictPolicy(ctx, policy); err != nil {
+		return models.ConflictPolicy{}, err
+	}
+	s.logger.Info("jury conflict policy updated", zap.Int("dispute_window_days", policy.DisputeWindowDays),
+		zap.Int("co_juror_window_days", policy.CoJurorWindowDays), zap.Int("co_juror_limit", policy.CoJurorLimit))
+	return policy, nil
+}
+
+// ListJuryExclusions returns who was kept off the jury of the investigation and why.
+func (s ConflictService) ListJuryExclusions(ctx context.Context, investigationID string,
+) ([]models.JuryExclusionCard, error) {
+	invID, err := uuid.Parse(investigationID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid investigation ID format: %s", ErrValidation, err)
+	}
+	return s.exclusionLister.ListJuryExclusions(ctx, invID)
+}
+
+// BlockJuror keeps the user off the juries of the actor's disputes and the actor off the juries of theirs.
+func (s ConflictService) BlockJuror(ctx context.Context, actorUsername, username string) error {
+	actor, blocked, err := s.getBlockPair(ctx, actorUsername, username)
+	if err != nil {
+		return err
+	}
+	return s.blockStore.InsertJurorBlock(ctx, models.JurorBlock{
+		UserID:        actor.ID,
+		BlockedUserID: blocked.ID,
+		CreatedAt:     s.now(),
+	})
+}
+
+func (s ConflictService) UnblockJuror(ctx context.Context, actorUsername, username string) error {
+	actor, blocked, err := s.getBlockPair(ctx, actorUsername, username)
+	if err != nil {
+		return err
+	}
+	return s.blockStore.DeleteJurorBlock(ctx, actor.ID, blocked.ID)
+}
+
+func (s ConflictService) ListJurorBlocks(ctx context.Context, actorUsername string) ([]models.JurorBlockCard, error) {
+	actor, err := s.getUser(ctx, actorUsername)
+	if err != nil {
+		return nil, err
+	}
+	return s.blockStore.ListJurorBlocks(ctx, actor.ID)
+}
+
+func (s ConflictService) getBlockPair(ctx context.Context, actorUsername, username string,
+) (models.User, models.User, error) {
+	if actorUsername == username {
+		return models.User{}, models.User{}, fmt.Errorf("%w: users can't block themselves", ErrValidation)
+	}
+	actor, err := s.getUser(ctx, actorUsername)
+	if err != nil {
+		return models.User{}, models.User{}, err
+	}
+	blocked, err := s.getUser(ctx, username)
+	if err != nil {
+		return models.User{}, models.User{}, err
+	}
+	return actor, blocked, nil
+}
+
+func (s ConflictService) getUser(ctx context.Context, username string) (models.User, error) {
+	user, err := s.userFinder.GetUserByUsername(ctx, username)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.User{}, ErrUserNotFound
+	case err != nil:
+		return models.User{}, fmt.Errorf("failed to get user by username: %w", err)
+	}
+	return user, nil
+}
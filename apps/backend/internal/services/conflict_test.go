@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeConflictRepo struct {
+	fakeUserRepo
+
+	users   map[string]models.User
+	policy  *models.ConflictPolicy
+	blocks  []models.JurorBlock
+	deleted [][2]uuid.UUID
+}
+
+func (f *fakeConflictRepo) GetUserByUsername(_ context.Context, username string) (models.User, error) {
+	user, ok := f.users[username]
+	if !ok {
+		return models.User{}, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeConflictRepo) GetConflictPolicy(context.Context) (models.ConflictPolicy, error) {
+	if f.policy == nil {
+		return models.ConflictPolicy{}, repository.ErrNotFound
+	}
+	return *f.policy, nil
+}
+
+func (f *fakeConflictRepo) UpdateConflictPolicy(_ context.Context, policy models.ConflictPolicy) error {
+	f.policy = &policy
+	return nil
+}
+
+func (f *fakeConflictRepo) InsertJurorBlock(_ context.Context, block models.JurorBlock) error {
+	f.blocks = append(f.blocks, block)
+	return nil
+}
+
+func (f *fakeConflictRepo) DeleteJurorBlock(_ context.Context, userID, blockedUserID uuid.UUID) error {
+	f.deleted = append(f.deleted, [2]uuid.UUID{userID, blockedUserID})
+	return nil
+}
+
+func (f *fakeConflictRepo) ListJurorBlocks(context.Context, uuid.UUID) ([]models.JurorBlockCard, error) {
+	return nil, nil
+}
+
+func TestConflictService(t *testing.T) {
+	alice, bob := models.User{ID: uuid.New(), Username: "alice"}, models.User{ID: uuid.New(), Username: "bob"}
+	newService := func() (ConflictService, *fakeConflictRepo) {
+		repo := &fakeConflictRepo{users: map[string]models.User{"alice": alice, "bob": bob}}
+		return ConflictService{
+			logger:      noopLogger{},
+			policyStore: repo,
+			blockStore:  repo,
+			userFinder:  repo,
+			now:         time.Now,
+		}, repo
+	}
+
+	t.Run("falls back to the default policy", func(t *testing.T) {
+		svc, _ := newService()
+		policy, err := svc.GetConflictPolicy(context.Background())
+		if err != nil || policy != models.DefaultConflictPolicy {
+			t.Fatalf("expected the default policy, got %+v, %v", policy, err)
+		}
+	})
+
+	t.Run("rejects an invalid policy", func(t *testing.T) {
+		svc, repo := newService()
+		_, err := svc.UpdateConflictPolicy(context.Background(), models.ConflictPolicy{DisputeWindowDays: 30})
+		if !errors.Is(err, ErrValidation) || repo.policy != nil {
+			t.Fatalf("expected a validation error without update, got %v", err)
+		}
+	})
+
+	t.Run("updates the policy", func(t *testing.T) {
+		svc, repo := newService()
+		policy := models.ConflictPolicy{DisputeWindowDays: 30, CoJurorWindowDays: 30, CoJurorLimit: 2}
+		updated, err := svc.UpdateConflictPolicy(context.Background(), policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.policy == nil || repo.policy.CoJurorLimit != 2 || updated.UpdatedAt.IsZero() {
+			t.Fatalf("expected the policy stored, got %+v", repo.policy)
+		}
+	})
+
+	t.Run("blocks and unblocks a juror", func(t *testing.T) {
+		svc, repo := newService()
+		if err := svc.BlockJuror(context.Background(), "alice", "bob"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := svc.UnblockJuror(context.Background(), "alice", "bob"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.blocks) != 1 || repo.blocks[0].UserID != alice.ID || repo.blocks[0].BlockedUserID != bob.ID {
+			t.Fatalf("unexpected blocks: %+v", repo.blocks)
+		}
+		if len(repo.deleted) != 1 || repo.deleted[0] != [2]uuid.UUID{alice.ID, bob.ID} {
+			t.Fatalf("unexpected unblocks: %+v", repo.deleted)
+		}
+	})
+
+	t.Run("rejects self and unknown users", func(t *testing.T) {
+		svc, repo := newService()
+		if err := svc.BlockJuror(context.Background(), "alice", "alice"); !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+		if err := svc.BlockJuror(context.Background(), "alice", "carol"); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("expected ErrUserNotFound, got %v", err)
+		}
+		if len(repo.blocks) != 0 {
+			t.Fatalf("expected no blocks, got %+v", repo.blocks)
+		}
+	})
+}
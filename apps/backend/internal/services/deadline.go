@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDeadlineBatchSize   = 50
+	defaultDeadlineLockTimeout = 2 * time.Minute
+	evidenceWindow             = 24 * time.Hour
+)
+
+// Participant results of the stages in which a dispute waits for someone to act before next_deadline.
+var (
+	offerResults = []models.Result{
+		models.DisputesResultNew,
+		models.DisputesResultSent,
+		models.DisputesResultCountered,
+		models.DisputesResultOffered,
+	}
+	votingResults   = []models.Result{models.DisputesResultProcessed, models.DisputesResultAnswered}
+	evidenceResults = []models.Result{models.DisputesResultEvidence, models.DisputesResultEvidenceAnswered}
+)
+
+// deadlineStages are claimed by the worker: a dispute is only picked up when all its participants are in one of
+// them, so settled disputes and disputes with nothing to enforce are left alone.
+var deadlineStages = [][]models.Result{offerResults, votingResults, evidenceResults}
+
+type OverdueDisputeClaimer interface {
+	ClaimOverdueDisputes(ctx context.Context, now, lockUntil time.Time, stages [][]models.Result, limit int,
+	) ([]uuid.UUID, error)
+	ReleaseDisputeDeadline(ctx context.Context, disputeID uuid.UUID) error
+}
+
+type ParticipantLister interface {
+	ListParticipants(ctx context.Context, disputeID uuid.UUID) ([]models.Participant, error)
+}
+
+type InvestigationOpener interface {
+	OpenInvestigation(ctx context.Context, disputeID uuid.UUID, participantIDs ...uuid.UUID) error
+}
+
+type deadlineStage int
+
+const (
+	deadlineStageNone deadlineStage = iota
+	deadlineStageOffer
+	deadlineStageVoting
+	deadlineStageEvidence
+)
+
+type DeadlineService struct {
+	logger log.Logger
+
+	disputeClaimer      OverdueDisputeClaimer
+	disputeFinder       DisputeFinder
+	disputeCreator      DisputeCreator
+	participantLister   ParticipantLister
+	participantUpdater  ParticipantUpdater
+	userFinder          UserFinder
+	investigationOpener InvestigationOpener
+	msgSender           MessageSender
+	txRunner            TxRunner
+
+	batchSize   int
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+func NewDeadlineService(repo *repository.Repository, log log.Logger, msgSender MessageSender) (DeadlineService, error) {
+	if repo == nil {
+		return DeadlineService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return DeadlineService{}, fmt.Errorf("logger is nil")
+	}
+	evidenceSrv, err := NewEvidenceService(repo, log, msgSender)
+	if err != nil {
+		return DeadlineService{}, fmt.Errorf("failed to create evidence service: %w", err)
+	}
+
+	return DeadlineService{
+		logger:              log,
+		disputeClaimer:      repo,
+		disputeFinder:       repo,
+		disputeCreator:      repo,
+		participantLister:   repo,
+		participantUpdater:  transitionUpdater{repo},
+		userFinder:          repo,
+		investigationOpener: evidenceSrv,
+		msgSender:           msgSender,
+		txRunner:            repo,
+		batchSize:           defaultDeadlineBatchSize,
+		lockTimeout:         defaultDeadlineLockTimeout,
+		now:                 time.Now,
+	}, nil
+}
+
+// WithInvestigationOpener sets what opens the investigation of a dispute whose evidence deadline passed.
+func (s DeadlineService) WithInvestigationOpener(opener InvestigationOpener) DeadlineService {
+	s.investigationOpener = opener
+	return s
+}
+
+// Run processes overdue disputes every interval until ctx is cancelled.
+func (s DeadlineService) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, s.logger, "dispute-deadlines", interval, s.ProcessOverdueDisputes)
+}
+
+// ProcessOverdueDisputes settles or escalates disputes whose next_deadline has passed.
+// Disputes are leased with deadline_locked_until, so several instances never handle the same dispute at once.
+func (s DeadlineService) ProcessOverdueDisputes(ctx context.Context) error {
+	now := s.now()
+	ids, err := s.disputeClaimer.ClaimOverdueDisputes(ctx, now, now.Add(s.lockTimeout), deadlineStages, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim overdue disputes: %w", err)
+	}
+
+	for _, id := range ids {
+		// A failed dispute keeps its lease, so it is retried once the lease runs out.
+		err = inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+			if err := s.processOverdueDispute(ctx, id, now); err != nil {
+				return err
+			}
+			return s.disputeClaimer.ReleaseDisputeDeadline(ctx, id)
+		})
+		if err != nil {
+			s.logger.Error("failed to process overdue dispute", zap.String("dispute_id", id.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s DeadlineService) processOverdueDispute(ctx context.Context, disputeID uuid.UUID, now time.Time) error {
+	participants, err := s.participantLister.ListParticipants(ctx, disputeID)
+	if err != nil {
+		return fmt.Errorf("failed to list participants: %w", err)
+	}
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, disputeID)
+	if err != nil {
+		return fmt.Errorf("failed to get dispute: %w", err)
+	}
+	if isUnacceptedOpenDispute(participants) {
+		return s.expireOffer(ctx, dispute, participants...)
+	}
+	if len(participants) != 2 {
+		return fmt.Errorf("expected 2 participants, got %d", len(participants))
+	}
+
+	p1, p2 := participants[0], participants[1]
+	switch disputeStage(p1, p2) {
+	case deadlineStageOffer:
+		return s.expireOffer(ctx, dispute, p1, p2)
+	case deadlineStageVoting:
+		return s.finalizeVotes(ctx, dispute, p1, p2, now)
+	case deadlineStageEvidence:
+		return s.escalateEvidence(ctx, dispute, p1, p2)
+	default:
+		s.logger.Info("overdue dispute has nothing to enforce", zap.String("dispute_id", disputeID.String()),
+			zap.String("p1_result", string(p1.Result)), zap.String("p2_result", string(p2.Result)))
+		return nil
+	}
+}
+
+func disputeStage(participants ...models.Participant) deadlineStage {
+	inStage := func(results ...models.Result) bool {
+		for _, p := range participants {
+			if !containsResult(results, p.Result) {
+				return false
+			}
+		}
+		return true
+	}
+	allNew := true
+	for _, p := range participants {
+		allNew = allNew && p.Status == models.DisputesStatusNew
+	}
+	switch {
+	case allNew && inStage(offerResults...):
+		return deadlineStageOffer
+	case inStage(votingResults...):
+		return deadlineStageVoting
+	case inStage(evidenceResults...):
+		return deadlineStageEvidence
+	default:
+		return deadlineStageNone
+	}
+}
+
+// isUnacceptedOpenDispute reports whether participants are the creator alone, still waiting for someone to accept.
+func isUnacceptedOpenDispute(participants []models.Participant) bool {
+	return len(participants) == 1 && participants[0].IsCreator &&
+		participants[0].Status == models.DisputesStatusNew && participants[0].Result == models.DisputesResultSent
+}
+
+func containsResult(results []models.Result, result models.Result) bool {
+	for _, r := range results {
+		if r == result {
+			return true
+		}
+	}
+	return false
+}
+
+// votedWin mirrors the Bet contract Finalize: a participant who did not vote is treated as voting "lose".
+func votedWin(p models.Participant) bool {
+	return p.Result == models.DisputesResultAnswered && p.IsWin
+}
+
+func (s DeadlineService) expireOffer(ctx context.Context, dispute models.Dispute, participants ...models.Participant,
+) error {
+	for _, p := range participants {
+		opts, err := transition(p, models.DisputeActionExpire, models.ActorSystem, models.DisputesResultRejected)
+		if err != nil {
+			return err
+		}
+		opts.Status = new(models.DisputesStatusPassed)
+		opts.IsClaimable = new(p.IsCreator)
+		if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+			return fmt.Errorf("failed to expire participant: %w", err)
+		}
+	}
+
+	for _, p := range participants {
+		format := "Срок принятия пари %s истёк."
+		if p.IsCreator {
+			format = "Пари %s не было принято до дедлайна. Вы можете вернуть вашу ставку и депозит!"
+		}
+		if err := notifyUser(ctx, s.userFinder, s.msgSender, p.UserID, fmt.Sprintf(format, dispute.Title)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s DeadlineService) finalizeVotes(ctx context.Context, dispute models.Dispute, p1, p2 models.Participant,
+	now time.Time,
+) error {
+	p1Win, p2Win := votedWin(p1), votedWin(p2)
+
+	if p1Win && p2Win {
+		for _, p := range []models.Participant{p1, p2} {
+			opts, err := transition(p, models.DisputeActionRequestEvidence, models.ActorSystem,
+				models.DisputesResultEvidence)
+			if err != nil {
+				return err
+			}
+			opts.IsWin = new(true)
+			if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+				return fmt.Errorf("failed to move participant to evidence: %w", err)
+			}
+		}
+		if err := s.disputeCreator.UpdateDisputeNextDeadline(ctx, dispute.ID, now.Add(evidenceWindow)); err != nil {
+			return fmt.Errorf("failed to set next deadline for evidence stage: %w", err)
+		}
+		for _, p := range []models.Participant{p1, p2} {
+			msg := fmt.Sprintf("Ваше пари %s требует доказательств. Внесите их в течении 24 часов.", dispute.Title)
+			if err := notifyUser(ctx, s.userFinder, s.msgSender, p.UserID, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !p1Win && !p2Win {
+		for _, p := range []models.Participant{p1, p2} {
+			opts, err := transition(p, models.DisputeActionSettle, models.ActorSystem, models.DisputesResultDraw)
+			if err != nil {
+				return err
+			}
+			opts.Status = new(models.DisputesStatusPassed)
+			opts.IsClaimable = new(true)
+			if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+				return fmt.Errorf("failed to settle draw: %w", err)
+			}
+		}
+		for _, p := range []models.Participant{p1, p2} {
+			msg := fmt.Sprintf(
+				"Срок голосования по пари %s истёк, пари завершилось вничью. Вы можете вернуть свою ставку и депозит!",
+				dispute.Title)
+			if err := notifyUser(ctx, s.userFinder, s.msgSender, p.UserID, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	winner, loser := p1, p2
+	if p2Win {
+		winner, loser = p2, p1
+	}
+	loserOpts, err := transition(loser, models.DisputeActionSettle, models.ActorSystem, models.DisputesResultLose)
+	if err != nil {
+		return err
+	}
+	loserOpts.Status = new(models.DisputesStatusPassed)
+	loserOpts.IsClaimable = new(true)
+	if err := s.participantUpdater.UpdateParticipant(ctx, loserOpts); err != nil {
+		return fmt.Errorf("failed to settle loser: %w", err)
+	}
+	winnerOpts, err := transition(winner, models.DisputeActionSettle, models.ActorSystem, models.DisputesResultWin)
+	if err != nil {
+		return err
+	}
+	winnerOpts.Status = new(models.DisputesStatusPassed)
+	winnerOpts.IsWin = new(true)
+	winnerOpts.IsClaimable = new(true)
+	if err := s.participantUpdater.UpdateParticipant(ctx, winnerOpts); err != nil {
+		return fmt.Errorf("failed to settle winner: %w", err)
+	}
+
+	if err := notifyUser(ctx, s.userFinder, s.msgSender, winner.UserID, fmt.Sprintf(
+		"Срок голосования по пари %s истёк, пари завершилось победой. Вы можете забрать свою награду!", dispute.Title),
+	); err != nil {
+		return err
+	}
+	return notifyUser(ctx, s.userFinder, s.msgSender, loser.UserID, fmt.Sprintf(
+		"Срок голосования по пари %s истёк, пари завершилось поражением. Вы можете вернуть ваш депозит!", dispute.Title))
+}
+
+func (s DeadlineService) escalateEvidence(ctx context.Context, dispute models.Dispute,
+	participants ...models.Participant,
+) error {
+	userIDs := make([]uuid.UUID, 0, len(participants))
+	for _, p := range participants {
+		opts, err := transition(p, models.DisputeActionInvestigate, models.ActorSystem, models.DisputesResultInspected)
+		if err != nil {
+			return err
+		}
+		if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+			return fmt.Errorf("failed to escalate participant: %w", err)
+		}
+		userIDs = append(userIDs, p.UserID)
+	}
+
+	if err := s.investigationOpener.OpenInvestigation(ctx, dispute.ID, userIDs...); err != nil {
+		return fmt.Errorf("failed to open investigation: %w", err)
+	}
+
+	for _, p := range participants {
+		msg := fmt.Sprintf("Срок подачи доказательств по пари %s истёк. Пари передано на расследование.", dispute.Title)
+		if err := notifyUser(ctx, s.userFinder, s.msgSender, p.UserID, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
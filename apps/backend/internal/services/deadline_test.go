@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+type fakeDeadlineRepo struct {
+	fakeDisputeRepo
+
+	claimed      []uuid.UUID
+	claimErr     error
+	claimStages  [][]models.Result
+	claimLockTTL time.Duration
+	released     []uuid.UUID
+	participants map[uuid.UUID][]models.Participant
+
+	openedInvestigations [][]uuid.UUID
+}
+
+func (f *fakeDeadlineRepo) ClaimOverdueDisputes(_ context.Context, now, lockUntil time.Time,
+	stages [][]models.Result, _ int,
+) ([]uuid.UUID, error) {
+	f.claimStages = stages
+	f.claimLockTTL = lockUntil.Sub(now)
+	return f.claimed, f.claimErr
+}
+func (f *fakeDeadlineRepo) ReleaseDisputeDeadline(_ context.Context, disputeID uuid.UUID) error {
+	f.released = append(f.released, disputeID)
+	return nil
+}
+func (f *fakeDeadlineRepo) ListParticipants(_ context.Context, disputeID uuid.UUID) ([]models.Participant, error) {
+	return f.participants[disputeID], nil
+}
+func (f *fakeDeadlineRepo) OpenInvestigation(_ context.Context, _ uuid.UUID, participantIDs ...uuid.UUID) error {
+	f.openedInvestigations = append(f.openedInvestigations, participantIDs)
+	return nil
+}
+
+func newTestDeadlineService(repo *fakeDeadlineRepo, sender *fakeMessageSender, now time.Time) DeadlineService {
+	return DeadlineService{
+		logger:              noopLogger{},
+		disputeClaimer:      repo,
+		disputeFinder:       repo,
+		disputeCreator:      repo,
+		participantLister:   repo,
+		participantUpdater:  repo,
+		userFinder:          repo,
+		investigationOpener: repo,
+		msgSender:           sender,
+		batchSize:           defaultDeadlineBatchSize,
+		lockTimeout:         defaultDeadlineLockTimeout,
+		now:                 func() time.Time { return now },
+	}
+}
+
+func TestDeadlineServiceProcessOverdueDisputes(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	creator := models.User{ID: uuid.New(), NotificationEnabled: true, ChatID: 1}
+	opponent := models.User{ID: uuid.New(), NotificationEnabled: true, ChatID: 2}
+
+	newRepo := func(p1, p2 models.Participant) (*fakeDeadlineRepo, uuid.UUID) {
+		disputeID := uuid.New()
+		p1.ID, p1.UserID, p1.IsCreator = uuid.New(), creator.ID, true
+		p2.ID, p2.UserID = uuid.New(), opponent.ID
+		return &fakeDeadlineRepo{
+			fakeDisputeRepo: fakeDisputeRepo{
+				usersByID: map[uuid.UUID]models.User{creator.ID: creator, opponent.ID: opponent},
+				dispute:   models.Dispute{ID: disputeID, Title: "D"},
+			},
+			claimed:      []uuid.UUID{disputeID},
+			participants: map[uuid.UUID][]models.Participant{disputeID: {p1, p2}},
+		}, disputeID
+	}
+
+	t.Run("unaccepted offer is rejected and refundable for creator", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusNew, Result: models.DisputesResultSent},
+			models.Participant{Status: models.DisputesStatusNew, Result: models.DisputesResultNew},
+		)
+		sender := &fakeMessageSender{}
+		svc := newTestDeadlineService(repo, sender, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.updatedDP) != 2 {
+			t.Fatalf("expected 2 updates, got %d", len(repo.updatedDP))
+		}
+		for i, wantClaimable := range []bool{true, false} {
+			upd := repo.updatedDP[i]
+			if *upd.Result != models.DisputesResultRejected || *upd.Status != models.DisputesStatusPassed {
+				t.Fatalf("unexpected update %d: %+v", i, upd)
+			}
+			if *upd.IsClaimable != wantClaimable {
+				t.Fatalf("update %d: expected claimable %v", i, wantClaimable)
+			}
+		}
+		if sender.calls != 2 {
+			t.Fatalf("expected 2 messages, got %d", sender.calls)
+		}
+		if repo.claimLockTTL != defaultDeadlineLockTimeout {
+			t.Fatalf("expected lock ttl %v, got %v", defaultDeadlineLockTimeout, repo.claimLockTTL)
+		}
+		if len(repo.claimStages) != 3 || !slices.Equal(repo.claimStages[0], offerResults) {
+			t.Fatalf("expected the offer, voting and evidence stages to be claimed, got %v", repo.claimStages)
+		}
+		if !slices.Equal(repo.released, repo.claimed) {
+			t.Fatalf("expected the lease to be released, got %v", repo.released)
+		}
+	})
+
+	t.Run("failed dispute keeps its lease", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusNew, Result: models.DisputesResultSent},
+			models.Participant{Status: models.DisputesStatusNew, Result: models.DisputesResultNew},
+		)
+		repo.failUpdateAt = 2
+		svc := newTestDeadlineService(repo, &fakeMessageSender{}, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.released) != 0 {
+			t.Fatalf("expected the lease to be kept for a retry, got %v", repo.released)
+		}
+	})
+
+	t.Run("open dispute nobody accepted is refundable for creator", func(t *testing.T) {
+		repo, disputeID := newRepo(
+			models.Participant{Status: models.DisputesStatusNew, Result: models.DisputesResultSent},
+			models.Participant{},
+		)
+		repo.participants[disputeID] = repo.participants[disputeID][:1]
+		sender := &fakeMessageSender{}
+		svc := newTestDeadlineService(repo, sender, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.updatedDP) != 1 || !*repo.updatedDP[0].IsClaimable ||
+			*repo.updatedDP[0].Result != models.DisputesResultRejected {
+			t.Fatalf("expected creator to be rejected and claimable, got %+v", repo.updatedDP)
+		}
+		if sender.calls != 1 || sender.chatIDs[0] != creator.ChatID {
+			t.Fatalf("expected creator notification, got calls=%d chats=%v", sender.calls, sender.chatIDs)
+		}
+	})
+
+	t.Run("single win vote wins when opponent is silent", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultProcessed},
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultAnswered, IsWin: true},
+		)
+		sender := &fakeMessageSender{}
+		svc := newTestDeadlineService(repo, sender, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.updatedDP) != 2 {
+			t.Fatalf("expected 2 updates, got %d", len(repo.updatedDP))
+		}
+		loser, winner := repo.updatedDP[0], repo.updatedDP[1]
+		if *loser.Result != models.DisputesResultLose || *winner.Result != models.DisputesResultWin {
+			t.Fatalf("unexpected results: loser=%s winner=%s", *loser.Result, *winner.Result)
+		}
+		if winner.ID != repo.participants[repo.claimed[0]][1].ID {
+			t.Fatal("expected opponent to win")
+		}
+		if !strings.Contains(sender.messages[0], "победой") || !strings.Contains(sender.messages[1], "поражением") {
+			t.Fatalf("unexpected messages: %v", sender.messages)
+		}
+	})
+
+	t.Run("no win votes end in draw", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultProcessed},
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultAnswered},
+		)
+		svc := newTestDeadlineService(repo, &fakeMessageSender{}, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, upd := range repo.updatedDP {
+			if *upd.Result != models.DisputesResultDraw || !*upd.IsClaimable {
+				t.Fatalf("expected claimable draw, got %+v", upd)
+			}
+		}
+	})
+
+	t.Run("two win votes move to evidence stage", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultAnswered, IsWin: true},
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultAnswered, IsWin: true},
+		)
+		svc := newTestDeadlineService(repo, &fakeMessageSender{}, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.updatedDeadlines) != 1 || !repo.updatedDeadlines[0].Equal(now.Add(evidenceWindow)) {
+			t.Fatalf("unexpected deadlines: %v", repo.updatedDeadlines)
+		}
+		for _, upd := range repo.updatedDP {
+			if *upd.Result != models.DisputesResultEvidence || upd.Status != nil {
+				t.Fatalf("expected evidence result, got %+v", upd)
+			}
+		}
+	})
+
+	t.Run("missing evidence escalates to investigation", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultEvidenceAnswered},
+			models.Participant{Status: models.DisputesStatusCurrent, Result: models.DisputesResultEvidence},
+		)
+		svc := newTestDeadlineService(repo, &fakeMessageSender{}, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.openedInvestigations) != 1 {
+			t.Fatalf("expected 1 investigation, got %d", len(repo.openedInvestigations))
+		}
+		if !slices.Equal(repo.openedInvestigations[0], []uuid.UUID{creator.ID, opponent.ID}) {
+			t.Fatalf("unexpected investigation parties: %v", repo.openedInvestigations[0])
+		}
+		for _, upd := range repo.updatedDP {
+			if *upd.Result != models.DisputesResultInspected {
+				t.Fatalf("expected inspected result, got %+v", upd)
+			}
+		}
+	})
+
+	t.Run("finished dispute is left untouched", func(t *testing.T) {
+		repo, _ := newRepo(
+			models.Participant{Status: models.DisputesStatusPassed, Result: models.DisputesResultWin},
+			models.Participant{Status: models.DisputesStatusPassed, Result: models.DisputesResultLose},
+		)
+		sender := &fakeMessageSender{}
+		svc := newTestDeadlineService(repo, sender, now)
+
+		if err := svc.ProcessOverdueDisputes(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.updatedDP) != 0 || sender.calls != 0 {
+			t.Fatalf("expected no changes, got %d updates and %d messages", len(repo.updatedDP), sender.calls)
+		}
+	})
+}
+
+func TestDeadlineServiceProcessOverdueDisputesClaimError(t *testing.T) {
+	repo := &fakeDeadlineRepo{claimErr: errors.New("db down")}
+	svc := newTestDeadlineService(repo, &fakeMessageSender{}, time.Now())
+
+	if err := svc.ProcessOverdueDisputes(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+}
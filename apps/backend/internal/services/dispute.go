@@ -30,6 +30,17 @@ type DisputeCreator interface {
 
 type ParticipantCreator interface {
 	InsertParticipant(ctx context.Context, participant models.Participant) error
+	InsertOpponentParticipant(ctx context.Context, participant models.Participant) (bool, error)
+}
+
+type InvitationCreator interface {
+	InsertInvitation(ctx context.Context, inv models.Invitation) error
+}
+
+type OpenDisputeFinder interface {
+	ListOpenDisputes(ctx context.Context, actorUsername string, opts models.OpenDisputeListOpts,
+	) ([]models.OpenDisputeCard, error)
+	GetOpenDispute(ctx context.Context, disputeID uuid.UUID) (models.OpenDisputeCard, error)
 }
 
 type OpponentGetter interface {
@@ -44,6 +55,15 @@ type ParticipantUpdater interface {
 	UpdateParticipant(ctx context.Context, opts models.ParticipantUpdateOpts) error
 }
 
+type DisputeEventRecorder interface {
+	InsertDisputeEvent(ctx context.Context, event models.DisputeEvent) error
+}
+
+type DisputeHistoryFinder interface {
+	CanViewDisputeHistory(ctx context.Context, disputeID uuid.UUID, actorUsername string) (bool, error)
+	ListDisputeEvents(ctx context.Context, disputeID uuid.UUID) ([]models.DisputeEventCard, error)
+}
+
 type ParticipantSeener interface {
 	MarkParticipantsSeen(ctx context.Context, actorUsername string, disputeIDs []uuid.UUID) error
 }
@@ -54,6 +74,15 @@ type MessageSender interface {
 
 type TransactionMonitor interface {
 	WaitForSuccess(ctx context.Context, boc string) error
+	MessageHash(boc string) (string, error)
+	// VerifyMessage checks that the wallet message in boc sends the contract call described by want.
+	VerifyMessage(ctx context.Context, boc string, want models.ExpectedMessage) error
+	// SenderWallet returns the raw address of the wallet the message in boc is signed for.
+	SenderWallet(boc string) (string, error)
+	// CallGetter returns the positive integer the getter of contract returns.
+	CallGetter(ctx context.Context, contract, getter string) (int64, error)
+	// CallAddressGetter returns the address the getter of contract returns.
+	CallAddressGetter(ctx context.Context, contract, getter string) (string, error)
 }
 
 type DisputeService struct {
@@ -62,14 +91,25 @@ type DisputeService struct {
 	disputeCreator     DisputeCreator
 	disputeFinder      DisputeFinder
 	disputeReadFinder  DisputeReadFinder
+	openDisputeFinder  OpenDisputeFinder
 	participantCreator ParticipantCreator
+	invitationCreator  InvitationCreator
+	offerCreator       OfferCreator
 	participantGetter  ParticipantGetter
 	participantUpdater ParticipantUpdater
 	participantSeener  ParticipantSeener
 	opponentGetter     OpponentGetter
+	eventRecorder      DisputeEventRecorder
+	historyFinder      DisputeHistoryFinder
 	userFinder         UserFinder
 	msgSender          MessageSender
 	txMonitor          TransactionMonitor
+	txRunner           TxRunner
+	opRecorder         OperationRecorder
+	opStore            PendingOperationStore
+	walletStore        UserWalletStore
+	mediaStorer        MediaStorer
+	betMaster          string
 }
 
 func NewDisputeService(repo *repository.Repository, log log.Logger, msgSender MessageSender) (DisputeService, error) {
@@ -84,13 +124,22 @@ func NewDisputeService(repo *repository.Repository, log log.Logger, msgSender Me
 		disputeCreator:     repo,
 		disputeFinder:      repo,
 		disputeReadFinder:  repo,
+		openDisputeFinder:  repo,
 		participantCreator: repo,
+		invitationCreator:  repo,
+		offerCreator:       repo,
 		participantGetter:  repo,
-		participantUpdater: repo,
+		participantUpdater: transitionUpdater{repo},
 		participantSeener:  repo,
 		opponentGetter:     repo,
+		eventRecorder:      repo,
+		historyFinder:      repo,
 		userFinder:         repo,
 		msgSender:          msgSender,
+		txRunner:           repo,
+		opRecorder:         repo,
+		opStore:            repo,
+		walletStore:        repo,
 	}, nil
 }
 
@@ -99,23 +148,113 @@ func (s DisputeService) WithTransactionMonitor(txMonitor TransactionMonitor) Dis
 	return s
 }
 
-func (s DisputeService) ensureTxSuccess(ctx context.Context, boc string) error {
-	if s.txMonitor == nil {
-		return fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
-	}
-	return s.txMonitor.WaitForSuccess(ctx, boc)
+// WithMediaStore sets where uploaded dispute images are stored.
+func (s DisputeService) WithMediaStore(media MediaStorer) DisputeService {
+	s.mediaStorer = media
+	return s
 }
 
-func (s DisputeService) CreateDispute(ctx context.Context, req models.CreateDisputeReq, creatorUsername string) error {
-	if err := s.ensureTxSuccess(ctx, req.Boc); err != nil {
-		return err
+// WithBetMaster sets the address of the BetMaster contract that creation messages must be sent to.
+func (s DisputeService) WithBetMaster(address string) DisputeService {
+	s.betMaster = address
+	return s
+}
+
+func (s DisputeService) operations() operationRunner {
+	return operationRunner{
+		txMonitor:  s.txMonitor,
+		txRunner:   s.txRunner,
+		recorder:   s.opRecorder,
+		store:      s.opStore,
+		wallets:    s.walletStore,
+		userFinder: s.userFinder,
+		msgSender:  s.msgSender,
+		logger:     s.logger,
 	}
+}
+
+// disputeVotePayload is what VoteDispute stores with its pending operation.
+type disputeVotePayload struct {
+	Vote bool `json:"vote"`
+}
+
+// disputeAcceptPayload is what AcceptDispute stores with its pending operation.
+type disputeAcceptPayload struct {
+	// TermsVersion is the version of the dispute terms the acceptor funded.
+	TermsVersion int `json:"termsVersion,omitempty"`
+}
 
-	opponent, err := s.userFinder.GetUserByUsername(ctx, req.Opponent)
+// CreateDispute records the creation as a pending operation; the dispute is stored once the deploy is confirmed.
+// Request fields are validated upfront, so an invalid dispute is rejected before the operation is recorded.
+func (s DisputeService) CreateDispute(ctx context.Context, req models.CreateDisputeReq, creatorUsername string,
+) (models.PendingOperation, error) {
+	dispute, err := models.NewDispute(req)
 	if err != nil {
-		return fmt.Errorf("failed to check if opponent exists: %w", ErrUserNotFound)
+		return models.PendingOperation{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	if s.betMaster == "" {
+		return models.PendingOperation{}, fmt.Errorf("bet master address is not configured")
+	}
+	// The master deploys the bet with the whole value and the deadline of the CreateBet message.
+	want := models.CreateBetMessage(s.betMaster, dispute.AmountNano+dispute.DepositNano, dispute.EndsAt)
+	// The image goes to the blob store upfront so the pending operation only carries its hash.
+	if len(req.ImageData) > 0 {
+		img, err := storeImage(ctx, s.mediaStorer, req.ImageData)
+		if err != nil {
+			return models.PendingOperation{}, err
+		}
+		req.SetImage(img)
 	}
+	return s.operations().submit(ctx, creatorUsername, models.OperationActionCreateDispute, req.ContractAddress,
+		req.Boc, want, req, func(ctx context.Context) error {
+			return s.createDispute(ctx, req, creatorUsername)
+		})
+}
+
+// ApplyOperation applies a confirmed operation recorded by CreateDispute, AcceptDispute, ClaimDispute or VoteDispute.
+func (s DisputeService) ApplyOperation(ctx context.Context, op models.PendingOperation) error {
+	return s.operations().apply(ctx, op, func(ctx context.Context, username string) error {
+		switch op.Action {
+		case models.OperationActionCreateDispute:
+			var req models.CreateDisputeReq
+			if err := decodePayload(op, &req); err != nil {
+				return err
+			}
+			return s.createDispute(ctx, req, username)
+		case models.OperationActionAcceptDispute:
+			var payload disputeAcceptPayload
+			// Accepts recorded before negotiation carry no payload.
+			if len(op.Payload) > 0 {
+				if err := decodePayload(op, &payload); err != nil {
+					return err
+				}
+			}
+			return s.acceptDispute(ctx, op.EntityID, username, payload)
+		case models.OperationActionClaimDispute:
+			return s.claimDispute(ctx, op.EntityID, username)
+		case models.OperationActionVoteDispute:
+			var payload disputeVotePayload
+			if err := decodePayload(op, &payload); err != nil {
+				return err
+			}
+			if payload.Vote {
+				return s.winDispute(ctx, op.EntityID, username)
+			}
+			return s.loseDispute(ctx, op.EntityID, username)
+		default:
+			return fmt.Errorf("%w: unsupported dispute operation %s", ErrValidation, op.Action)
+		}
+	})
+}
 
+func (s DisputeService) createDispute(ctx context.Context, req models.CreateDisputeReq, creatorUsername string) error {
+	if len(req.ImageData) > 0 {
+		imageHash, err := storeLegacyImage(ctx, s.mediaStorer, req.ImageData, req.ImageType)
+		if err != nil {
+			return err
+		}
+		req.ImageHash = imageHash
+	}
 	dispute, err := models.NewDispute(req)
 	switch {
 	case errors.Is(err, models.ErrDisputeValidation):
@@ -123,13 +262,30 @@ func (s DisputeService) CreateDispute(ctx context.Context, req models.CreateDisp
 	case err != nil:
 		return fmt.Errorf("failed to build dispute model %w", err)
 	}
+
+	// An opponent who hasn't registered yet is invited and joins the dispute on their first visit.
+	var opponent models.User
+	invite := false
+	if !dispute.IsOpen() {
+		opponent, err = s.userFinder.GetUserByUsername(ctx, models.NormalizeUsername(req.Opponent))
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			invite = true
+		case err != nil:
+			return fmt.Errorf("failed to check if opponent exists: %w", err)
+		}
+	}
+
 	if err = s.disputeCreator.InsertDispute(ctx, dispute); err != nil {
 		return fmt.Errorf("failed to create dispute: %w", err)
 	}
 
-	participantOpponent := models.NewParticipant(opponent.ID, dispute.ID, models.DisputesResultNew, false)
-	if err = s.participantCreator.InsertParticipant(ctx, participantOpponent); err != nil {
-		return fmt.Errorf("failed to create participants for opponent: %w", err)
+	// The opponent of an open dispute joins when accepting it.
+	if !dispute.IsOpen() && !invite {
+		participantOpponent := models.NewParticipant(opponent.ID, dispute.ID, models.DisputesResultNew, false)
+		if err = s.participantCreator.InsertParticipant(ctx, participantOpponent); err != nil {
+			return fmt.Errorf("failed to create participants for opponent: %w", err)
+		}
 	}
 
 	creator, err := s.userFinder.GetUserByUsername(ctx, creatorUsername)
@@ -140,9 +296,27 @@ func (s DisputeService) CreateDispute(ctx context.Context, req models.CreateDisp
 	if err = s.participantCreator.InsertParticipant(ctx, participantCreator); err != nil {
 		return fmt.Errorf("failed to create participants for creator: %w", err)
 	}
+	err = s.eventRecorder.InsertDisputeEvent(ctx, participantCreator.JoinEvent(models.DisputeActionCreate))
+	if err != nil {
+		return err
+	}
+	if err = s.offerCreator.InsertOffer(ctx, models.InitialOffer(dispute, creator.ID)); err != nil {
+		return fmt.Errorf("failed to record dispute terms: %w", err)
+	}
 
-	if opponent.NotificationEnabled {
-		if err = s.msgSender.SendMessage(opponent.ChatID,
+	if invite {
+		invitation, err := models.NewInvitation(dispute.ID, creator.ID, req.Opponent, dispute.EndsAt)
+		if err != nil {
+			return err
+		}
+		if err = s.invitationCreator.InsertInvitation(ctx, invitation); err != nil {
+			return fmt.Errorf("failed to invite opponent: %w", err)
+		}
+		return nil
+	}
+
+	if !dispute.IsOpen() && opponent.NotificationEnabled {
+		if err = sendMessage(ctx, s.msgSender, opponent.ChatID,
 			fmt.Sprintf("Пользователь %s вызвает вас на пари %s", creator.Username, dispute.Title)); err != nil {
 			return err
 		}
@@ -157,13 +331,17 @@ func (s DisputeService) PrecheckCreateDispute(ctx context.Context, opponent stri
 		return fmt.Errorf("invalid data for disute precheck")
 	}
 
-	if actorUsername == opponent {
+	if actorUsername == models.NormalizeUsername(opponent) {
 		return fmt.Errorf("creator and opponent must be different: %w", ErrSelfOpponent)
 	}
 
-	opponentUser, err := s.userFinder.GetUserByUsername(ctx, opponent)
-	if err != nil {
-		return fmt.Errorf("failed to check if opponent exists: %w", ErrUserNotFound)
+	opponentUser, err := s.userFinder.GetUserByUsername(ctx, models.NormalizeUsername(opponent))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// Unregistered opponents are invited and have no dispute settings yet.
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check if opponent exists: %w", err)
 	}
 
 	if !opponentUser.DisputeReadiness {
@@ -191,6 +369,35 @@ func (s DisputeService) ListDisputes(ctx context.Context, opts models.DisputeLis
 	return disputes, nil
 }
 
+// ListOpenDisputes returns public challenges that the actor can accept.
+func (s DisputeService) ListOpenDisputes(ctx context.Context, opts models.OpenDisputeListOpts, actorUsername string,
+) ([]models.OpenDisputeCard, error) {
+	disputes, err := s.openDisputeFinder.ListOpenDisputes(ctx, actorUsername, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open disputes: %w", err)
+	}
+	if len(disputes) == 0 {
+		return []models.OpenDisputeCard{}, nil
+	}
+	return disputes, nil
+}
+
+// GetOpenDispute returns a public or link-shared challenge while nobody has accepted it.
+func (s DisputeService) GetOpenDispute(ctx context.Context, disputeID string) (models.OpenDisputeCard, error) {
+	disputeUUID, err := uuid.Parse(disputeID)
+	if err != nil {
+		return models.OpenDisputeCard{}, fmt.Errorf("%w: invalid dispute ID format: %v", ErrValidation, err)
+	}
+	dispute, err := s.openDisputeFinder.GetOpenDispute(ctx, disputeUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.OpenDisputeCard{}, ErrOpenDisputeNotFound
+	}
+	if err != nil {
+		return models.OpenDisputeCard{}, fmt.Errorf("failed to get open dispute: %w", err)
+	}
+	return dispute, nil
+}
+
 func (s DisputeService) MarkDisputesSeen(ctx context.Context, actorUsername string, disputeIDs []string,
 ) error {
 	ids := make([]uuid.UUID, 0, len(disputeIDs))
@@ -223,11 +430,53 @@ func (s DisputeService) GetDispute(ctx context.Context, disputeID string, actorU
 	return dispute, nil
 }
 
-func (s DisputeService) AcceptDispute(ctx context.Context, disputeID string, acceptorUsername string, boc string) error {
-	if err := s.ensureTxSuccess(ctx, boc); err != nil {
-		return err
+// GetDisputeHistory returns the dispute events, oldest first. Only participants of the dispute and jurors of its
+// investigation see them; for anyone else the dispute doesn't exist.
+func (s DisputeService) GetDisputeHistory(ctx context.Context, disputeID string, actorUsername string,
+) ([]models.DisputeEventCard, error) {
+	disputeUUID, err := uuid.Parse(disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid dispute ID format: %s", ErrValidation, err)
 	}
 
+	ok, err := s.historyFinder.CanViewDisputeHistory(ctx, disputeUUID, actorUsername)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDisputeNotFound
+	}
+	events, err := s.historyFinder.ListDisputeEvents(ctx, disputeUUID)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// AcceptDispute accepts a challenge or joins an open dispute.
+func (s DisputeService) AcceptDispute(ctx context.Context, disputeID string, acceptorUsername string, boc string,
+) (models.PendingOperation, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	// The bet takes exactly the stake and the deposit it was funded with. The master derives both from the value
+	// it was sent, so they are read from the bet rather than from the terms.
+	want := models.ExpectedMessage{
+		Destination: dispute.ContractAddress,
+		Opcodes:     []uint32{models.OpAccept},
+		ValueGetter: models.StakeWithDepositGetter,
+	}
+	payload := disputeAcceptPayload{TermsVersion: dispute.TermsVersion}
+	return s.operations().submit(ctx, acceptorUsername, models.OperationActionAcceptDispute, disputeID, boc, want,
+		payload, func(ctx context.Context) error {
+			return s.acceptDispute(ctx, disputeID, acceptorUsername, payload)
+		})
+}
+
+func (s DisputeService) acceptDispute(ctx context.Context, disputeID string, acceptorUsername string,
+	payload disputeAcceptPayload,
+) error {
 	acceptor, err := s.userFinder.GetUserByUsername(ctx, acceptorUsername)
 	if err != nil {
 		return fmt.Errorf("failed to get acceptor user: %w", err)
@@ -238,8 +487,23 @@ func (s DisputeService) AcceptDispute(ctx context.Context, disputeID string, acc
 		return fmt.Errorf("invalid dispute ID format: %w", err)
 	}
 
-	participantAccepter, err := s.participantGetter.GetParticipant(ctx, disputeUUID, acceptor.ID)
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, disputeUUID)
 	if err != nil {
+		return fmt.Errorf("failed to get dispute: %w", err)
+	}
+	// Accepts recorded before negotiation carry no terms version.
+	if payload.TermsVersion != 0 && payload.TermsVersion != dispute.TermsVersion {
+		return fmt.Errorf("%w: dispute terms changed from version %d to %d", ErrValidation,
+			payload.TermsVersion, dispute.TermsVersion)
+	}
+
+	participantAccepter, err := s.participantGetter.GetParticipant(ctx, disputeUUID, acceptor.ID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		if participantAccepter, err = s.joinOpenDispute(ctx, dispute, acceptor.ID); err != nil {
+			return err
+		}
+	case err != nil:
 		return err
 	}
 
@@ -247,11 +511,12 @@ func (s DisputeService) AcceptDispute(ctx context.Context, disputeID string, acc
 		return fmt.Errorf("user2duspite %s is not in new status", participantAccepter.ID)
 	}
 
-	opts := models.ParticipantUpdateOpts{
-		ID:     participantAccepter.ID,
-		Status: new(models.DisputesStatusCurrent),
-		Result: new(models.DisputesResultProcessed),
+	opts, err := transition(participantAccepter, models.DisputeActionAccept, models.ActorSelf,
+		models.DisputesResultProcessed)
+	if err != nil {
+		return err
 	}
+	opts.Status = new(models.DisputesStatusCurrent)
 	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 		return fmt.Errorf("failed to update acceptor dispute status: %w", err)
 	}
@@ -265,7 +530,12 @@ func (s DisputeService) AcceptDispute(ctx context.Context, disputeID string, acc
 	if err != nil {
 		return err
 	}
-	opts.ID = participantOpponent.ID
+	opts, err = transition(participantOpponent, models.DisputeActionAccept, models.ActorPeer,
+		models.DisputesResultProcessed)
+	if err != nil {
+		return err
+	}
+	opts.Status = new(models.DisputesStatusCurrent)
 	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 		return fmt.Errorf("failed to update opponent dispute status: %w", err)
 	}
@@ -277,19 +547,41 @@ func (s DisputeService) AcceptDispute(ctx context.Context, disputeID string, acc
 	}
 
 	if opponent.NotificationEnabled {
-		dispute, err := s.disputeFinder.GetDisputeByID(ctx, disputeUUID)
-		if err != nil {
-			return fmt.Errorf("failed to get dispute: %w", err)
-		}
 		msg := fmt.Sprintf("Ваше пари %s было принято пользователем %s", dispute.Title, acceptor.Username)
-		if err = s.msgSender.SendMessage(opponent.ChatID, msg); err != nil {
+		if err = sendMessage(ctx, s.msgSender, opponent.ChatID, msg); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// joinOpenDispute makes the user the opponent of an open dispute. Only the first user to accept joins;
+// the Bet contract likewise takes the first Accept only.
+func (s DisputeService) joinOpenDispute(ctx context.Context, dispute models.Dispute, userID uuid.UUID,
+) (models.Participant, error) {
+	if !dispute.IsOpen() {
+		return models.Participant{}, fmt.Errorf("%w: user is not a participant of dispute %s", ErrValidation, dispute.ID)
+	}
+
+	participant := models.NewParticipant(userID, dispute.ID, models.DisputesResultNew, false)
+	inserted, err := s.participantCreator.InsertOpponentParticipant(ctx, participant)
+	if err != nil {
+		return models.Participant{}, fmt.Errorf("failed to join open dispute: %w", err)
+	}
+	if !inserted {
+		return models.Participant{}, ErrDisputeTaken
+	}
+	return participant, nil
+}
+
 func (s DisputeService) RejectDispute(ctx context.Context, disputeID string, rejectorUsername string) error {
+	ctx = repository.WithEventSource(ctx, rejectorUsername, "")
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		return s.rejectDispute(ctx, disputeID, rejectorUsername)
+	})
+}
+
+func (s DisputeService) rejectDispute(ctx context.Context, disputeID string, rejectorUsername string) error {
 	rejector, err := s.userFinder.GetUserByUsername(ctx, rejectorUsername)
 	if err != nil {
 		return fmt.Errorf("failed to get rejector user: %w", err)
@@ -310,7 +602,10 @@ func (s DisputeService) RejectDispute(ctx context.Context, disputeID string, rej
 	}
 
 	opID, err := s.opponentGetter.GetOpponentID(ctx, disputeUUID, rejector.ID)
-	if err != nil {
+	switch {
+	case errors.Is(err, repository.ErrNotFound) && participantRejector.IsCreator:
+		return s.withdrawOpenDispute(ctx, participantRejector)
+	case err != nil:
 		return fmt.Errorf("failed to get opponent ID: %w", err)
 	}
 	participantOpponent, err := s.participantGetter.GetParticipant(ctx, disputeUUID, opID)
@@ -319,35 +614,36 @@ func (s DisputeService) RejectDispute(ctx context.Context, disputeID string, rej
 	}
 
 	creatorID := participantRejector.UserID
-	if participantRejector.Result != models.DisputesResultSent {
+	if !participantRejector.IsCreator {
 		creatorID = participantOpponent.UserID
 	}
 
-	switch {
-	// Cancellation is only valid before acceptance, while one side has "sent" and the other has "new".
-	case participantRejector.Result == models.DisputesResultNew && participantOpponent.Result == models.DisputesResultSent:
-		// ok
-	case participantRejector.Result == models.DisputesResultSent && participantOpponent.Result == models.DisputesResultNew:
-		//ok
-	default:
+	// Cancellation is only valid before acceptance, while the sides are still negotiating the terms.
+	if !models.Negotiating(participantRejector, participantOpponent) {
 		return fmt.Errorf("%w: bad status: rejector: %s, opponent: %s",
 			ErrValidation, participantRejector.Result, participantOpponent.Result)
 	}
 
-	opts := models.ParticipantUpdateOpts{
-		ID:     participantRejector.ID,
-		Status: new(models.DisputesStatusPassed),
-		Result: new(models.DisputesResultRejected),
-		// claimable only for creator
-		IsClaimable: new(participantRejector.UserID == creatorID),
-		// remove mark for rejector who didn't create bet
-		Seen: new(participantRejector.UserID != creatorID),
+	opts, err := transition(participantRejector, models.DisputeActionReject, models.ActorSelf,
+		models.DisputesResultRejected)
+	if err != nil {
+		return err
 	}
+	opts.Status = new(models.DisputesStatusPassed)
+	// claimable only for creator
+	opts.IsClaimable = new(participantRejector.UserID == creatorID)
+	// remove mark for rejector who didn't create bet
+	opts.Seen = new(participantRejector.UserID != creatorID)
 	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 		return fmt.Errorf("failed to update rejector dispute status: %w", err)
 	}
 
-	opts.ID = participantOpponent.ID
+	opts, err = transition(participantOpponent, models.DisputeActionReject, models.ActorPeer,
+		models.DisputesResultRejected)
+	if err != nil {
+		return err
+	}
+	opts.Status = new(models.DisputesStatusPassed)
 	opts.IsClaimable = new(participantOpponent.UserID == creatorID)
 	// always mark reject for opponent
 	opts.Seen = new(false)
@@ -370,18 +666,44 @@ func (s DisputeService) RejectDispute(ctx context.Context, disputeID string, rej
 		if opponent.ID == creatorID {
 			format = "Пользователь %s отклонил ваш вызов на пари %s. Вы можете вернуть вашу ставку и депозит!"
 		}
-		if err = s.msgSender.SendMessage(opponent.ChatID, fmt.Sprintf(format, rejector.Username, dispute.Title)); err != nil {
+		if err = sendMessage(ctx, s.msgSender, opponent.ChatID, fmt.Sprintf(format, rejector.Username, dispute.Title)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (s DisputeService) ClaimDispute(ctx context.Context, disputeID string, claimerUsername string, boc string) error {
-	if err := s.ensureTxSuccess(ctx, boc); err != nil {
+// withdrawOpenDispute cancels an open dispute that nobody has accepted; the creator can then take the stake back.
+func (s DisputeService) withdrawOpenDispute(ctx context.Context, creator models.Participant) error {
+	opts, err := transition(creator, models.DisputeActionReject, models.ActorSelf, models.DisputesResultRejected)
+	if err != nil {
 		return err
 	}
+	opts.Status = new(models.DisputesStatusPassed)
+	opts.IsClaimable = new(true)
+	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+		return fmt.Errorf("failed to update creator dispute status: %w", err)
+	}
+	return nil
+}
+
+func (s DisputeService) ClaimDispute(ctx context.Context, disputeID string, claimerUsername string, boc string,
+) (models.PendingOperation, error) {
+	// A participant whose result was rejected gets the stake back with Cancel instead of Claim. A dispute the
+	// deadline worker settled is still open on the bet until someone sends Finalize, so the claim may carry it.
+	want, err := s.contractCall(ctx, disputeID, models.OpClaim, models.OpCancel)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	want.Preceding = []models.ExpectedMessage{{Opcodes: []uint32{models.OpFinalize}}}
+	want.PrecedingOptional = true
+	return s.operations().submit(ctx, claimerUsername, models.OperationActionClaimDispute, disputeID, boc, want, nil,
+		func(ctx context.Context) error {
+			return s.claimDispute(ctx, disputeID, claimerUsername)
+		})
+}
 
+func (s DisputeService) claimDispute(ctx context.Context, disputeID string, claimerUsername string) error {
 	claimer, err := s.userFinder.GetUserByUsername(ctx, claimerUsername)
 	if err != nil {
 		return fmt.Errorf("failed to get claimer user: %w", err)
@@ -401,11 +723,13 @@ func (s DisputeService) ClaimDispute(ctx context.Context, disputeID string, clai
 		return fmt.Errorf("%w: participant can't claime", ErrValidation)
 	}
 
-	opts := models.ParticipantUpdateOpts{
-		ID:          participantClaimer.ID,
-		IsClaimable: new(false),
-		Seen:        new(true),
+	opts, err := transition(participantClaimer, models.DisputeActionClaim, models.ActorSelf,
+		participantClaimer.Result)
+	if err != nil {
+		return err
 	}
+	opts.IsClaimable = new(false)
+	opts.Seen = new(true)
 	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 		return fmt.Errorf("failed to update claimer dispute status: %w", err)
 	}
@@ -413,15 +737,70 @@ func (s DisputeService) ClaimDispute(ctx context.Context, disputeID string, clai
 }
 
 func (s DisputeService) VoteDispute(ctx context.Context, disputeID string, voterUsername string, vote bool, boc string,
-) error {
-	if err := s.ensureTxSuccess(ctx, boc); err != nil {
-		return err
+) (models.PendingOperation, error) {
+	result := models.VoteResultLose
+	if vote {
+		result = models.VoteResultWin
 	}
+	want, err := s.contractCall(ctx, disputeID, models.OpVoteResult)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	want.Fields = []models.MessageField{{Name: "result", Bits: 8, Value: &result}}
+	return s.operations().submit(ctx, voterUsername, models.OperationActionVoteDispute, disputeID, boc, want,
+		disputeVotePayload{Vote: vote}, func(ctx context.Context) error {
+			if vote {
+				return s.winDispute(ctx, disputeID, voterUsername)
+			}
+			return s.loseDispute(ctx, disputeID, voterUsername)
+		})
+}
 
-	if vote {
-		return s.winDispute(ctx, disputeID, voterUsername)
+// contractCall returns the message that calls the bet contract of the dispute with one of opcodes.
+func (s DisputeService) contractCall(ctx context.Context, disputeID string, opcodes ...uint32,
+) (models.ExpectedMessage, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return models.ExpectedMessage{}, err
+	}
+	return models.ExpectedMessage{Destination: dispute.ContractAddress, Opcodes: opcodes}, nil
+}
+
+// transition returns the update that moves p to result to, or ErrValidation when the dispute state machine
+// doesn't allow it.
+func transition(p models.Participant, action models.DisputeAction, actor models.Actor, to models.Result,
+) (models.ParticipantUpdateOpts, error) {
+	opts, err := p.Transition(action, actor, to)
+	if err != nil {
+		return models.ParticipantUpdateOpts{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	return opts, nil
+}
+
+// transitionUpdater reports an update that lost the race against a concurrent request moving the same
+// participant as ErrValidation, the same as transition does for a move the state machine doesn't allow.
+type transitionUpdater struct {
+	ParticipantUpdater
+}
+
+func (u transitionUpdater) UpdateParticipant(ctx context.Context, opts models.ParticipantUpdateOpts) error {
+	err := u.ParticipantUpdater.UpdateParticipant(ctx, opts)
+	if errors.Is(err, models.ErrDisputeTransition) {
+		return fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+	return err
+}
+
+func (s DisputeService) getDispute(ctx context.Context, disputeID string) (models.Dispute, error) {
+	disputeUUID, err := uuid.Parse(disputeID)
+	if err != nil {
+		return models.Dispute{}, fmt.Errorf("%w: invalid dispute ID format: %v", ErrValidation, err)
+	}
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, disputeUUID)
+	if err != nil {
+		return models.Dispute{}, fmt.Errorf("failed to get dispute: %w", err)
 	}
-	return s.loseDispute(ctx, disputeID, voterUsername)
+	return dispute, nil
 }
 
 func (s DisputeService) winDispute(ctx context.Context, disputeID string, winnerUsername string) error {
@@ -447,14 +826,16 @@ func (s DisputeService) winDispute(ctx context.Context, disputeID string, winner
 		return err
 	}
 
-	var opts models.ParticipantUpdateOpts
 	// first win
 	if participantOpponent.Result == models.DisputesResultProcessed {
-		opts.ID = participantWinner.ID
+		opts, err := transition(participantWinner, models.DisputeActionVote, models.ActorSelf,
+			models.DisputesResultAnswered)
+		if err != nil {
+			return err
+		}
 		opts.IsWin = new(true)
-		opts.Result = new(models.DisputesResultAnswered)
 		opts.Seen = new(true)
-		err := s.participantUpdater.UpdateParticipant(ctx, opts)
+		err = s.participantUpdater.UpdateParticipant(ctx, opts)
 		if err != nil {
 			return fmt.Errorf("failed to update voter dispute status: %w", err)
 		}
@@ -466,8 +847,11 @@ func (s DisputeService) winDispute(ctx context.Context, disputeID string, winner
 
 	// -- win --
 	if !participantOpponent.IsWin {
-		opts.ID = participantOpponent.ID
-		opts.Result = new(models.DisputesResultLose)
+		opts, err := transition(participantOpponent, models.DisputeActionSettle, models.ActorPeer,
+			models.DisputesResultLose)
+		if err != nil {
+			return err
+		}
 		opts.Status = new(models.DisputesStatusPassed)
 		opts.IsClaimable = new(true)
 		if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
@@ -475,8 +859,13 @@ func (s DisputeService) winDispute(ctx context.Context, disputeID string, winner
 		}
 		format = "Ваше пари %s c пользователем %s завершилось поражением. Вы можете вернуть ваш депозит!"
 
-		opts.ID = participantWinner.ID
-		opts.Result = new(models.DisputesResultWin)
+		opts, err = transition(participantWinner, models.DisputeActionSettle, models.ActorSelf,
+			models.DisputesResultWin)
+		if err != nil {
+			return err
+		}
+		opts.Status = new(models.DisputesStatusPassed)
+		opts.IsClaimable = new(true)
 		opts.IsWin = new(true)
 		if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update voter dispute status: %w", err)
@@ -485,15 +874,23 @@ func (s DisputeService) winDispute(ctx context.Context, disputeID string, winner
 
 	// -- investigation --
 	if participantOpponent.IsWin {
-		opts.ID = participantOpponent.ID
+		opts, err := transition(participantOpponent, models.DisputeActionRequestEvidence, models.ActorPeer,
+			models.DisputesResultEvidence)
+		if err != nil {
+			return err
+		}
 		opts.IsWin = new(true)
-		opts.Result = new(models.DisputesResultEvidence)
 		if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update opponent dispute status: %w", err)
 		}
 		format = "Ваше пари %s c пользователем %s требует доказательств. Внесите их в течении 24 часов."
 
-		opts.ID = participantWinner.ID
+		opts, err = transition(participantWinner, models.DisputeActionRequestEvidence, models.ActorSelf,
+			models.DisputesResultEvidence)
+		if err != nil {
+			return err
+		}
+		opts.IsWin = new(true)
 		opts.Seen = new(true)
 		if err := s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update voter dispute status: %w", err)
@@ -514,7 +911,7 @@ func (s DisputeService) winDispute(ctx context.Context, disputeID string, winner
 		if err != nil {
 			return fmt.Errorf("failed to get dispute: %w", err)
 		}
-		return s.msgSender.SendMessage(opponent.ChatID, fmt.Sprintf(format, dispute.Title, winner.Username))
+		return sendMessage(ctx, s.msgSender, opponent.ChatID, fmt.Sprintf(format, dispute.Title, winner.Username))
 	}
 	return nil
 }
@@ -542,11 +939,13 @@ func (s DisputeService) loseDispute(ctx context.Context, disputeID string, loser
 		return fmt.Errorf("failed to get opponent participant: %w", err)
 	}
 
-	var opts models.ParticipantUpdateOpts
 	// first lose
 	if participantOpponent.Result == models.DisputesResultProcessed {
-		opts.ID = participantLoser.ID
-		opts.Result = new(models.DisputesResultAnswered)
+		opts, err := transition(participantLoser, models.DisputeActionVote, models.ActorSelf,
+			models.DisputesResultAnswered)
+		if err != nil {
+			return err
+		}
 		opts.Seen = new(true)
 		if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update submitter participant: %w", err)
@@ -559,15 +958,23 @@ func (s DisputeService) loseDispute(ctx context.Context, disputeID string, loser
 
 	// lose
 	if participantOpponent.IsWin {
-		opts.ID = participantLoser.ID
-		opts.Result = new(models.DisputesResultLose)
+		opts, err := transition(participantLoser, models.DisputeActionSettle, models.ActorSelf,
+			models.DisputesResultLose)
+		if err != nil {
+			return err
+		}
 		opts.Status = new(models.DisputesStatusPassed)
 		opts.IsClaimable = new(true)
 		if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update submitter participant: %w", err)
 		}
-		opts.ID = participantOpponent.ID
-		opts.Result = new(models.DisputesResultWin)
+		opts, err = transition(participantOpponent, models.DisputeActionSettle, models.ActorPeer,
+			models.DisputesResultWin)
+		if err != nil {
+			return err
+		}
+		opts.Status = new(models.DisputesStatusPassed)
+		opts.IsClaimable = new(true)
 		if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update opponent participant: %w", err)
 		}
@@ -576,13 +983,21 @@ func (s DisputeService) loseDispute(ctx context.Context, disputeID string, loser
 
 	// draw
 	if !participantOpponent.IsWin {
-		opts.ID = participantLoser.ID
-		opts.Result = new(models.DisputesResultDraw)
+		opts, err := transition(participantLoser, models.DisputeActionSettle, models.ActorSelf,
+			models.DisputesResultDraw)
+		if err != nil {
+			return err
+		}
 		opts.Status = new(models.DisputesStatusPassed)
 		if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update submitter participant: %w", err)
 		}
-		opts.ID = participantOpponent.ID
+		opts, err = transition(participantOpponent, models.DisputeActionSettle, models.ActorPeer,
+			models.DisputesResultDraw)
+		if err != nil {
+			return err
+		}
+		opts.Status = new(models.DisputesStatusPassed)
 		if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
 			return fmt.Errorf("failed to update opponent participant: %w", err)
 		}
@@ -600,7 +1015,7 @@ func (s DisputeService) loseDispute(ctx context.Context, disputeID string, loser
 		if err != nil {
 			return fmt.Errorf("failed to get dispute: %w", err)
 		}
-		return s.msgSender.SendMessage(opponent.ChatID, fmt.Sprintf(format, dispute.Title, loser.Username))
+		return sendMessage(ctx, s.msgSender, opponent.ChatID, fmt.Sprintf(format, dispute.Title, loser.Username))
 	}
 	return nil
 }
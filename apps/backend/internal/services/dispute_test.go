@@ -3,12 +3,15 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
 )
 
 type fakeDisputeRepo struct {
@@ -16,19 +19,36 @@ type fakeDisputeRepo struct {
 	usersByID         map[uuid.UUID]models.User
 	participantByUser map[uuid.UUID]models.Participant
 	dispute           models.Dispute
+	participants      []models.Participant
 	opponentID        uuid.UUID
 
+	insertDPErr        error
+	opponentTaken      bool
+	// failUpdateAt fails the participant update with that 1-based number.
+	failUpdateAt int
+
 	insertDisputeCalls int
 	insertDPCalls      int
 	insertedDP         []models.Participant
+	invitations        []models.Invitation
+	offers             []models.DisputeOffer
+	events             []models.DisputeEvent
+	canViewHistory     bool
 	updatedDP          []models.ParticipantUpdateOpts
 	updatedDeadlines   []time.Time
 }
 
+const testBetMaster = "EQC9siZ7Ss9MZVqU1ywih597rSaekr7gKvWxYmuJNkj2q7Il"
+
 type fakeTxMonitor struct {
-	err   error
-	calls int
-	boc   string
+	err       error
+	calls     int
+	boc       string
+	verifyErr error
+	want      models.ExpectedMessage
+	// getters and addresses map "contract.getter" to what CallGetter and CallAddressGetter return.
+	getters   map[string]int64
+	addresses map[string]string
 }
 
 func (f *fakeTxMonitor) WaitForSuccess(_ context.Context, boc string) error {
@@ -37,6 +57,35 @@ func (f *fakeTxMonitor) WaitForSuccess(_ context.Context, boc string) error {
 	return f.err
 }
 
+func (f *fakeTxMonitor) MessageHash(boc string) (string, error) {
+	return "hash-" + boc, nil
+}
+
+func (f *fakeTxMonitor) VerifyMessage(_ context.Context, _ string, want models.ExpectedMessage) error {
+	f.want = want
+	return f.verifyErr
+}
+
+func (f *fakeTxMonitor) SenderWallet(boc string) (string, error) {
+	return "wallet-" + boc, nil
+}
+
+func (f *fakeTxMonitor) CallGetter(_ context.Context, contract, getter string) (int64, error) {
+	value, ok := f.getters[contract+"."+getter]
+	if !ok {
+		return 0, fmt.Errorf("%w: no getter %s of %s", ErrValidation, getter, contract)
+	}
+	return value, nil
+}
+
+func (f *fakeTxMonitor) CallAddressGetter(_ context.Context, contract, getter string) (string, error) {
+	addr, ok := f.addresses[contract+"."+getter]
+	if !ok {
+		return "", fmt.Errorf("%w: no getter %s of %s", ErrValidation, getter, contract)
+	}
+	return addr, nil
+}
+
 func (f *fakeDisputeRepo) GetDisputeByID(context.Context, uuid.UUID) (models.Dispute, error) {
 	return f.dispute, nil
 }
@@ -53,8 +102,9 @@ func (f *fakeDisputeRepo) GetDisputeDetailsByID(context.Context, uuid.UUID, stri
 func (f *fakeDisputeRepo) GetDisputeForEvidence(context.Context, uuid.UUID) (models.Dispute, error) {
 	return f.dispute, nil
 }
-func (f *fakeDisputeRepo) InsertDispute(context.Context, models.Dispute) error {
+func (f *fakeDisputeRepo) InsertDispute(_ context.Context, dispute models.Dispute) error {
 	f.insertDisputeCalls++
+	f.dispute = dispute
 	return nil
 }
 func (f *fakeDisputeRepo) UpdateDisputeNextDeadline(_ context.Context, _ uuid.UUID, nextDeadline time.Time) error {
@@ -63,20 +113,68 @@ func (f *fakeDisputeRepo) UpdateDisputeNextDeadline(_ context.Context, _ uuid.UU
 }
 func (f *fakeDisputeRepo) InsertParticipant(_ context.Context, participant models.Participant) error {
 	f.insertDPCalls++
+	if f.insertDPErr != nil {
+		return f.insertDPErr
+	}
+	f.insertedDP = append(f.insertedDP, participant)
+	return nil
+}
+func (f *fakeDisputeRepo) InsertOpponentParticipant(_ context.Context, participant models.Participant) (bool, error) {
+	f.insertDPCalls++
+	if f.opponentTaken {
+		return false, nil
+	}
 	f.insertedDP = append(f.insertedDP, participant)
+	if f.participantByUser == nil {
+		f.participantByUser = map[uuid.UUID]models.Participant{}
+	}
+	f.participantByUser[participant.UserID] = participant
+	return true, nil
+}
+func (f *fakeDisputeRepo) InsertInvitation(_ context.Context, inv models.Invitation) error {
+	f.invitations = append(f.invitations, inv)
 	return nil
 }
+func (f *fakeDisputeRepo) InsertOffer(_ context.Context, offer models.DisputeOffer) error {
+	f.offers = append(f.offers, offer)
+	return nil
+}
+func (f *fakeDisputeRepo) InsertDisputeEvent(_ context.Context, event models.DisputeEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+func (f *fakeDisputeRepo) CanViewDisputeHistory(context.Context, uuid.UUID, string) (bool, error) {
+	return f.canViewHistory, nil
+}
+func (f *fakeDisputeRepo) ListDisputeEvents(context.Context, uuid.UUID) ([]models.DisputeEventCard, error) {
+	cards := make([]models.DisputeEventCard, 0, len(f.events))
+	for _, e := range f.events {
+		cards = append(cards, models.DisputeEventCard{Action: e.Action, Actor: e.Actor, FromResult: e.FromResult,
+			ToResult: e.ToResult, CreatedAt: e.CreatedAt})
+	}
+	return cards, nil
+}
+
 func (f *fakeDisputeRepo) GetOpponentID(context.Context, uuid.UUID, uuid.UUID) (uuid.UUID, error) {
+	if f.opponentID == uuid.Nil {
+		return uuid.Nil, repository.ErrNotFound
+	}
 	return f.opponentID, nil
 }
 func (f *fakeDisputeRepo) GetParticipant(_ context.Context, _ uuid.UUID, userID uuid.UUID) (models.Participant, error) {
 	participant, ok := f.participantByUser[userID]
 	if !ok {
-		return models.Participant{}, errors.New("participant not found")
+		return models.Participant{}, repository.ErrNotFound
 	}
 	return participant, nil
 }
+func (f *fakeDisputeRepo) ListParticipants(context.Context, uuid.UUID) ([]models.Participant, error) {
+	return f.participants, nil
+}
 func (f *fakeDisputeRepo) UpdateParticipant(_ context.Context, opts models.ParticipantUpdateOpts) error {
+	if len(f.updatedDP)+1 == f.failUpdateAt {
+		return errors.New("update failed")
+	}
 	f.updatedDP = append(f.updatedDP, opts)
 	return nil
 }
@@ -90,7 +188,7 @@ func (f *fakeDisputeRepo) GetUserByID(_ context.Context, id uuid.UUID) (models.U
 func (f *fakeDisputeRepo) GetUserByUsername(_ context.Context, username string) (models.User, error) {
 	u, ok := f.usersByUsername[username]
 	if !ok {
-		return models.User{}, errors.New("user not found")
+		return models.User{}, repository.ErrNotFound
 	}
 	return u, nil
 }
@@ -119,22 +217,26 @@ func TestDisputeServiceCreateDispute(t *testing.T) {
 	}
 	sender := &fakeMessageSender{}
 	txMonitor := &fakeTxMonitor{}
+	endsAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
 	svc := DisputeService{
 		logger:             noopLogger{},
 		disputeCreator:     repo,
 		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
 		userFinder:         repo,
+		betMaster:          testBetMaster,
 		msgSender:          sender,
 		txMonitor:          txMonitor,
 	}
 
-	err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
+	_, err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
 		Title:           "test",
 		Description:     "desc",
 		Opponent:        "bob",
 		AmountNano:      "100000000000",
 		DepositNano:     "20000000000",
-		EndsAt:          time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+		EndsAt:          endsAt.Format(time.RFC3339),
 		ContractAddress: "addr",
 		Boc:             "boc",
 	}, "alice")
@@ -144,17 +246,74 @@ func TestDisputeServiceCreateDispute(t *testing.T) {
 	if txMonitor.calls != 1 || txMonitor.boc != "boc" {
 		t.Fatalf("expected tx monitor call with boc, got calls=%d boc=%q", txMonitor.calls, txMonitor.boc)
 	}
+	want := txMonitor.want
+	if want.Destination != testBetMaster || want.ValueNano != 120000000000 ||
+		len(want.Opcodes) != 1 || want.Opcodes[0] != models.OpCreateBet {
+		t.Fatalf("unexpected expected message: %+v", want)
+	}
+	if len(want.Fields) != 2 || want.Fields[1].Value == nil || int64(*want.Fields[1].Value) != endsAt.Unix() {
+		t.Fatalf("expected resultDeadline %d, got %+v", endsAt.Unix(), want.Fields)
+	}
 	if repo.insertDisputeCalls != 1 {
 		t.Fatalf("expected 1 dispute insert, got %d", repo.insertDisputeCalls)
 	}
 	if repo.insertDPCalls != 2 {
 		t.Fatalf("expected 2 participant inserts, got %d", repo.insertDPCalls)
 	}
+	if len(repo.events) != 1 || repo.events[0].Action != models.DisputeActionCreate ||
+		*repo.events[0].ActorID != creator.ID || *repo.events[0].ToResult != models.DisputesResultSent {
+		t.Fatalf("expected create event by the creator, got %+v", repo.events)
+	}
 	if sender.calls != 1 || sender.chatIDs[0] != 777 {
 		t.Fatalf("expected message to chat 777, got calls=%d chats=%v", sender.calls, sender.chatIDs)
 	}
 }
 
+func TestDisputeServiceCreateDisputeRollsBackOnParticipantFailure(t *testing.T) {
+	opponent := models.User{ID: uuid.New(), Username: "bob", NotificationEnabled: true, ChatID: 777}
+	repo := &fakeDisputeRepo{
+		usersByUsername: map[string]models.User{
+			"alice": {ID: uuid.New(), Username: "alice"},
+			"bob":   opponent,
+		},
+		insertDPErr: errors.New("insert failed"),
+	}
+	sender := &fakeMessageSender{}
+	txRunner := &fakeTxRunner{}
+	svc := DisputeService{
+		logger:             noopLogger{},
+		disputeCreator:     repo,
+		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
+		userFinder:         repo,
+		betMaster:          testBetMaster,
+		msgSender:          sender,
+		txMonitor:          &fakeTxMonitor{},
+		txRunner:           txRunner,
+	}
+
+	_, err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
+		Title:           "test",
+		Description:     "desc",
+		Opponent:        "bob",
+		AmountNano:      "100000000000",
+		DepositNano:     "20000000000",
+		EndsAt:          time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+		ContractAddress: "addr",
+		Boc:             "boc",
+	}, "alice")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if txRunner.rollbacks != 1 || txRunner.commits != 0 {
+		t.Fatalf("expected rollback only, got commits=%d rollbacks=%d", txRunner.commits, txRunner.rollbacks)
+	}
+	if sender.calls != 0 {
+		t.Fatalf("expected no messages after rollback, got %d", sender.calls)
+	}
+}
+
 func TestDisputeServiceCreateDisputeIgnoresOpponentSettings(t *testing.T) {
 	repo := &fakeDisputeRepo{
 		usersByUsername: map[string]models.User{
@@ -166,12 +325,15 @@ func TestDisputeServiceCreateDisputeIgnoresOpponentSettings(t *testing.T) {
 		logger:             noopLogger{},
 		disputeCreator:     repo,
 		userFinder:         repo,
+		betMaster:          testBetMaster,
 		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
 		msgSender:          &fakeMessageSender{},
 		txMonitor:          &fakeTxMonitor{},
 	}
 
-	err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
+	_, err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
 		Title:           "t",
 		Description:     "d",
 		Opponent:        "bob",
@@ -197,12 +359,15 @@ func TestDisputeServiceCreateDisputeTxFailed(t *testing.T) {
 		logger:             noopLogger{},
 		disputeCreator:     repo,
 		userFinder:         repo,
+		betMaster:          testBetMaster,
 		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
 		msgSender:          &fakeMessageSender{},
 		txMonitor:          &fakeTxMonitor{err: ErrTxFailed},
 	}
 
-	err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
+	_, err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
 		Title:           "t",
 		Description:     "d",
 		Opponent:        "bob",
@@ -220,6 +385,88 @@ func TestDisputeServiceCreateDisputeTxFailed(t *testing.T) {
 	}
 }
 
+func TestDisputeServiceCreateOpenDispute(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": creator}}
+	sender := &fakeMessageSender{}
+	svc := DisputeService{
+		logger:             noopLogger{},
+		disputeCreator:     repo,
+		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
+		userFinder:         repo,
+		betMaster:          testBetMaster,
+		msgSender:          sender,
+		txMonitor:          &fakeTxMonitor{},
+	}
+
+	_, err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
+		Title:           "t",
+		Description:     "d",
+		AmountNano:      "100000000000",
+		DepositNano:     "20000000000",
+		EndsAt:          time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+		ContractAddress: "addr",
+		Boc:             "boc",
+	}, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.insertedDP) != 1 || !repo.insertedDP[0].IsCreator || repo.insertedDP[0].UserID != creator.ID {
+		t.Fatalf("expected only the creator participant, got %+v", repo.insertedDP)
+	}
+	if sender.calls != 0 {
+		t.Fatalf("expected no messages for open dispute, got %d", sender.calls)
+	}
+}
+
+func TestDisputeServiceCreateDisputeInvitesUnregisteredOpponent(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": creator}}
+	sender := &fakeMessageSender{}
+	endsAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	svc := DisputeService{
+		logger:             noopLogger{},
+		disputeCreator:     repo,
+		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
+		invitationCreator:  repo,
+		userFinder:         repo,
+		betMaster:          testBetMaster,
+		msgSender:          sender,
+		txMonitor:          &fakeTxMonitor{},
+	}
+
+	_, err := svc.CreateDispute(context.Background(), models.CreateDisputeReq{
+		Title:           "t",
+		Description:     "d",
+		Opponent:        "@carol",
+		AmountNano:      "100000000000",
+		DepositNano:     "20000000000",
+		EndsAt:          endsAt.Format(time.RFC3339),
+		ContractAddress: "addr",
+		Boc:             "boc",
+	}, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.insertedDP) != 1 || !repo.insertedDP[0].IsCreator {
+		t.Fatalf("expected only the creator participant, got %+v", repo.insertedDP)
+	}
+	if len(repo.invitations) != 1 {
+		t.Fatalf("expected 1 invitation, got %d", len(repo.invitations))
+	}
+	inv := repo.invitations[0]
+	if inv.Username != "carol" || inv.CreatorID != creator.ID || inv.Token == "" || !inv.ExpiresAt.Equal(endsAt) {
+		t.Fatalf("unexpected invitation: %+v", inv)
+	}
+	if sender.calls != 0 {
+		t.Fatalf("expected no messages, got %d", sender.calls)
+	}
+}
+
 func TestDisputeServicePrecheckCreateDispute(t *testing.T) {
 	repo := &fakeDisputeRepo{
 		usersByUsername: map[string]models.User{
@@ -233,6 +480,14 @@ func TestDisputeServicePrecheckCreateDispute(t *testing.T) {
 	}
 }
 
+func TestDisputeServicePrecheckCreateDisputeUnregisteredOpponent(t *testing.T) {
+	svc := DisputeService{logger: noopLogger{}, userFinder: &fakeDisputeRepo{}}
+
+	if err := svc.PrecheckCreateDispute(context.Background(), "carol", 100*models.NanoPerTON, "alice"); err != nil {
+		t.Fatalf("expected unregistered opponent to pass precheck, got %v", err)
+	}
+}
+
 func TestDisputeServicePrecheckCreateDisputeSelfOpponent(t *testing.T) {
 	svc := DisputeService{logger: noopLogger{}, userFinder: &fakeDisputeRepo{}}
 
@@ -251,6 +506,33 @@ func TestDisputeServiceGetDisputeInvalidID(t *testing.T) {
 	}
 }
 
+func TestDisputeServiceGetDisputeHistory(t *testing.T) {
+	disputeID := uuid.New()
+	p := models.Participant{ID: uuid.New(), DisputeID: disputeID, Result: models.DisputesResultNew}
+	repo := &fakeDisputeRepo{events: []models.DisputeEvent{p.Event(models.DisputeActionExpire, models.ActorSystem,
+		models.DisputesResultRejected)}}
+	svc := DisputeService{logger: noopLogger{}, historyFinder: repo}
+
+	t.Run("hidden from outsiders", func(t *testing.T) {
+		if _, err := svc.GetDisputeHistory(context.Background(), disputeID.String(), "mallory"); !errors.Is(err,
+			ErrDisputeNotFound) {
+			t.Fatalf("expected ErrDisputeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("shown to participants and jurors", func(t *testing.T) {
+		repo.canViewHistory = true
+		events, err := svc.GetDisputeHistory(context.Background(), disputeID.String(), "alice")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Action != models.DisputeActionExpire ||
+			*events[0].ToResult != models.DisputesResultRejected {
+			t.Fatalf("unexpected history: %+v", events)
+		}
+	})
+}
+
 func TestDisputeServiceWinDispute(t *testing.T) {
 	voter := models.User{ID: uuid.New(), Username: "alice"}
 	opponent := models.User{ID: uuid.New(), Username: "bob", NotificationEnabled: true, ChatID: 888}
@@ -261,7 +543,7 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 			usersByUsername: map[string]models.User{"alice": voter},
 			usersByID:       map[uuid.UUID]models.User{opponent.ID: opponent},
 			participantByUser: map[uuid.UUID]models.Participant{
-				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent},
+				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent, Result: models.DisputesResultProcessed},
 				opponent.ID: {ID: uuid.New(), Result: models.DisputesResultProcessed},
 			},
 			dispute:    models.Dispute{ID: disputeID, Title: "D1"},
@@ -271,7 +553,7 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 		svc := DisputeService{logger: noopLogger{}, userFinder: repo, participantGetter: repo,
 			participantUpdater: repo, opponentGetter: repo, disputeFinder: repo, msgSender: sender, txMonitor: &fakeTxMonitor{}}
 
-		err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc")
+		_, err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -283,12 +565,36 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 		}
 	})
 
+	t.Run("second vote is rejected", func(t *testing.T) {
+		repo := &fakeDisputeRepo{
+			usersByUsername: map[string]models.User{"alice": voter},
+			usersByID:       map[uuid.UUID]models.User{opponent.ID: opponent},
+			participantByUser: map[uuid.UUID]models.Participant{
+				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent, Result: models.DisputesResultAnswered},
+				opponent.ID: {ID: uuid.New(), Result: models.DisputesResultProcessed},
+			},
+			dispute:    models.Dispute{ID: disputeID, Title: "D1"},
+			opponentID: opponent.ID,
+		}
+		svc := DisputeService{logger: noopLogger{}, userFinder: repo, participantGetter: repo,
+			participantUpdater: repo, opponentGetter: repo, disputeFinder: repo, msgSender: &fakeMessageSender{},
+			txMonitor: &fakeTxMonitor{}}
+
+		_, err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", false, "boc")
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected ErrValidation, got %v", err)
+		}
+		if len(repo.updatedDP) != 0 {
+			t.Fatalf("expected no updates, got %d", len(repo.updatedDP))
+		}
+	})
+
 	t.Run("win branch updates both and notifies opponent", func(t *testing.T) {
 		repo := &fakeDisputeRepo{
 			usersByUsername: map[string]models.User{"alice": voter},
 			usersByID:       map[uuid.UUID]models.User{opponent.ID: opponent},
 			participantByUser: map[uuid.UUID]models.Participant{
-				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent},
+				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent, Result: models.DisputesResultProcessed},
 				opponent.ID: {ID: uuid.New(), IsWin: false, Result: models.DisputesResultAnswered},
 			},
 			dispute:    models.Dispute{ID: disputeID, Title: "D2"},
@@ -298,7 +604,7 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 		svc := DisputeService{logger: noopLogger{}, userFinder: repo, participantGetter: repo,
 			participantUpdater: repo, opponentGetter: repo, disputeFinder: repo, msgSender: sender, txMonitor: &fakeTxMonitor{}}
 
-		err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc")
+		_, err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -318,7 +624,7 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 			usersByUsername: map[string]models.User{"alice": voter},
 			usersByID:       map[uuid.UUID]models.User{opponent.ID: opponent},
 			participantByUser: map[uuid.UUID]models.Participant{
-				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent},
+				voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent, Result: models.DisputesResultProcessed},
 				opponent.ID: {ID: uuid.New(), IsWin: true, Result: models.DisputesResultAnswered},
 			},
 			dispute:    models.Dispute{ID: disputeID, Title: "D3"},
@@ -337,7 +643,7 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 			txMonitor:          &fakeTxMonitor{},
 		}
 
-		err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc")
+		_, err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -350,6 +656,39 @@ func TestDisputeServiceWinDispute(t *testing.T) {
 	})
 }
 
+func TestDisputeServiceVoteDisputeRollsBackOnSettleFailure(t *testing.T) {
+	voter := models.User{ID: uuid.New(), Username: "alice"}
+	opponent := models.User{ID: uuid.New(), Username: "bob", NotificationEnabled: true, ChatID: 888}
+	disputeID := uuid.New()
+	repo := &fakeDisputeRepo{
+		usersByUsername: map[string]models.User{"alice": voter},
+		usersByID:       map[uuid.UUID]models.User{opponent.ID: opponent},
+		participantByUser: map[uuid.UUID]models.Participant{
+			voter.ID:    {ID: uuid.New(), Status: models.DisputesStatusCurrent, Result: models.DisputesResultProcessed},
+			opponent.ID: {ID: uuid.New(), Result: models.DisputesResultAnswered},
+		},
+		dispute:    models.Dispute{ID: disputeID, Title: "D2"},
+		opponentID: opponent.ID,
+		// The voter is settled, the opponent fails.
+		failUpdateAt: 2,
+	}
+	sender := &fakeMessageSender{}
+	txRunner := &fakeTxRunner{}
+	svc := DisputeService{logger: noopLogger{}, userFinder: repo, participantGetter: repo, participantUpdater: repo,
+		opponentGetter: repo, disputeFinder: repo, msgSender: sender, txMonitor: &fakeTxMonitor{}, txRunner: txRunner}
+
+	if _, err := svc.VoteDispute(context.Background(), disputeID.String(), "alice", true, "boc"); err == nil {
+		t.Fatal("expected error")
+	}
+	if txRunner.rollbacks != 1 || txRunner.commits != 0 || len(repo.updatedDP) != 1 {
+		t.Fatalf("expected the settled voter rolled back, got commits=%d rollbacks=%d updates=%d", txRunner.commits,
+			txRunner.rollbacks, len(repo.updatedDP))
+	}
+	if sender.calls != 0 {
+		t.Fatalf("expected no messages after rollback, got %d", sender.calls)
+	}
+}
+
 func TestDisputeServiceRejectDispute(t *testing.T) {
 	creator := models.User{ID: uuid.New(), Username: "alice", NotificationEnabled: true, ChatID: 111}
 	opponent := models.User{ID: uuid.New(), Username: "bob", NotificationEnabled: true, ChatID: 999}
@@ -369,10 +708,11 @@ func TestDisputeServiceRejectDispute(t *testing.T) {
 						Result: models.DisputesResultNew,
 					},
 					creator.ID: {
-						ID:     creatorParticipantID,
-						UserID: creator.ID,
-						Status: models.DisputesStatusNew,
-						Result: models.DisputesResultSent,
+						ID:        creatorParticipantID,
+						UserID:    creator.ID,
+						Status:    models.DisputesStatusNew,
+						Result:    models.DisputesResultSent,
+						IsCreator: true,
 					},
 				},
 				dispute:    models.Dispute{ID: disputeID, Title: "R1"},
@@ -423,10 +763,11 @@ func TestDisputeServiceRejectDispute(t *testing.T) {
 				usersByID:       map[uuid.UUID]models.User{opponent.ID: opponent},
 				participantByUser: map[uuid.UUID]models.Participant{
 					creator.ID: {
-						ID:     creatorParticipantID,
-						UserID: creator.ID,
-						Status: models.DisputesStatusNew,
-						Result: models.DisputesResultSent,
+						ID:        creatorParticipantID,
+						UserID:    creator.ID,
+						Status:    models.DisputesStatusNew,
+						Result:    models.DisputesResultSent,
+						IsCreator: true,
 					},
 					opponent.ID: {
 						ID:     opponentParticipantID,
@@ -476,6 +817,153 @@ func TestDisputeServiceRejectDispute(t *testing.T) {
 	})
 }
 
+func TestDisputeServiceAcceptOpenDispute(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice", NotificationEnabled: true, ChatID: 111}
+	acceptor := models.User{ID: uuid.New(), Username: "carol"}
+	disputeID := uuid.New()
+	newRepo := func(visibility models.DisputeVisibility) *fakeDisputeRepo {
+		return &fakeDisputeRepo{
+			usersByUsername: map[string]models.User{"carol": acceptor},
+			usersByID:       map[uuid.UUID]models.User{creator.ID: creator},
+			participantByUser: map[uuid.UUID]models.Participant{
+				creator.ID: {
+					ID:        uuid.New(),
+					UserID:    creator.ID,
+					IsCreator: true,
+					Status:    models.DisputesStatusNew,
+					Result:    models.DisputesResultSent,
+				},
+			},
+			dispute:    models.Dispute{ID: disputeID, Title: "O1", Visibility: visibility},
+			opponentID: creator.ID,
+		}
+	}
+	newService := func(repo *fakeDisputeRepo, sender *fakeMessageSender) DisputeService {
+		return DisputeService{
+			logger:             noopLogger{},
+			userFinder:         repo,
+			disputeFinder:      repo,
+			participantCreator: repo,
+			offerCreator:       repo,
+			eventRecorder:      repo,
+			participantGetter:  repo,
+			participantUpdater: repo,
+			opponentGetter:     repo,
+			msgSender:          sender,
+		}
+	}
+
+	t.Run("first acceptor becomes the opponent", func(t *testing.T) {
+		repo := newRepo(models.DisputeVisibilityPublic)
+		sender := &fakeMessageSender{}
+
+		if err := newService(repo, sender).acceptDispute(context.Background(), disputeID.String(), "carol", disputeAcceptPayload{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.insertedDP) != 1 || repo.insertedDP[0].UserID != acceptor.ID || repo.insertedDP[0].IsCreator {
+			t.Fatalf("expected acceptor to join as opponent, got %+v", repo.insertedDP)
+		}
+		if len(repo.updatedDP) != 2 {
+			t.Fatalf("expected both participants to move to current, got %d updates", len(repo.updatedDP))
+		}
+		if sender.calls != 1 || sender.chatIDs[0] != creator.ChatID {
+			t.Fatalf("expected creator notification, got calls=%d chats=%v", sender.calls, sender.chatIDs)
+		}
+	})
+
+	t.Run("failed creator update rolls back the join", func(t *testing.T) {
+		repo := newRepo(models.DisputeVisibilityPublic)
+		repo.failUpdateAt = 1
+		sender := &fakeMessageSender{}
+		txRunner := &fakeTxRunner{}
+		svc := newService(repo, sender)
+
+		err := inTx(context.Background(), txRunner, sender, noopLogger{}, func(ctx context.Context) error {
+			return svc.acceptDispute(ctx, disputeID.String(), "carol", disputeAcceptPayload{})
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if txRunner.rollbacks != 1 || txRunner.commits != 0 || len(repo.insertedDP) != 1 {
+			t.Fatalf("expected the inserted opponent rolled back, got commits=%d rollbacks=%d", txRunner.commits,
+				txRunner.rollbacks)
+		}
+		if sender.calls != 0 {
+			t.Fatalf("expected no messages after rollback, got %d", sender.calls)
+		}
+	})
+
+	t.Run("second acceptor loses the race", func(t *testing.T) {
+		repo := newRepo(models.DisputeVisibilityLink)
+		repo.opponentTaken = true
+
+		err := newService(repo, &fakeMessageSender{}).acceptDispute(context.Background(), disputeID.String(), "carol", disputeAcceptPayload{})
+		if !errors.Is(err, ErrDisputeTaken) {
+			t.Fatalf("expected ErrDisputeTaken, got %v", err)
+		}
+		if len(repo.updatedDP) != 0 {
+			t.Fatalf("expected no updates, got %d", len(repo.updatedDP))
+		}
+	})
+
+	t.Run("direct dispute can't be accepted by a stranger", func(t *testing.T) {
+		repo := newRepo(models.DisputeVisibilityDirect)
+
+		err := newService(repo, &fakeMessageSender{}).acceptDispute(context.Background(), disputeID.String(), "carol", disputeAcceptPayload{})
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected ErrValidation, got %v", err)
+		}
+		if repo.insertDPCalls != 0 {
+			t.Fatalf("expected no participant insert, got %d", repo.insertDPCalls)
+		}
+	})
+}
+
+func TestDisputeServiceWithdrawOpenDispute(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	creatorParticipantID := uuid.New()
+	repo := &fakeDisputeRepo{
+		usersByUsername: map[string]models.User{"alice": creator},
+		participantByUser: map[uuid.UUID]models.Participant{
+			creator.ID: {
+				ID:        creatorParticipantID,
+				UserID:    creator.ID,
+				IsCreator: true,
+				Status:    models.DisputesStatusNew,
+				Result:    models.DisputesResultSent,
+			},
+		},
+		dispute: models.Dispute{ID: uuid.New(), Visibility: models.DisputeVisibilityPublic},
+	}
+	svc := DisputeService{
+		logger:             noopLogger{},
+		userFinder:         repo,
+		participantGetter:  repo,
+		participantUpdater: repo,
+		opponentGetter:     repo,
+		disputeFinder:      repo,
+		msgSender:          &fakeMessageSender{},
+	}
+
+	if err := svc.RejectDispute(context.Background(), repo.dispute.ID.String(), "alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.updatedDP) != 1 || repo.updatedDP[0].ID != creatorParticipantID {
+		t.Fatalf("expected only the creator to be updated, got %+v", repo.updatedDP)
+	}
+	if opts := repo.updatedDP[0]; opts.IsClaimable == nil || !*opts.IsClaimable ||
+		opts.Result == nil || *opts.Result != models.DisputesResultRejected {
+		t.Fatalf("expected creator to be rejected and claimable, got %+v", opts)
+	}
+}
+
+// staleParticipantUpdater behaves like a participant moved on by a concurrent request after it was read.
+type staleParticipantUpdater struct{}
+
+func (staleParticipantUpdater) UpdateParticipant(_ context.Context, opts models.ParticipantUpdateOpts) error {
+	return fmt.Errorf("%w: participant %s is no longer %s", models.ErrDisputeTransition, opts.ID, *opts.From)
+}
+
 func TestDisputeServiceRefundDispute(t *testing.T) {
 	refunder := models.User{ID: uuid.New(), Username: "alice"}
 	disputeID := uuid.New()
@@ -496,12 +984,13 @@ func TestDisputeServiceRefundDispute(t *testing.T) {
 		svc := DisputeService{
 			logger:             noopLogger{},
 			userFinder:         repo,
+			disputeFinder:      repo,
 			participantGetter:  repo,
 			participantUpdater: repo,
 			txMonitor:          txMonitor,
 		}
 
-		err := svc.ClaimDispute(context.Background(), disputeID.String(), refunder.Username, "boc")
+		_, err := svc.ClaimDispute(context.Background(), disputeID.String(), refunder.Username, "boc")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -519,6 +1008,53 @@ func TestDisputeServiceRefundDispute(t *testing.T) {
 		}
 	})
 
+	t.Run("claim loses the race to a concurrent claim", func(t *testing.T) {
+		repo := &fakeDisputeRepo{
+			usersByUsername: map[string]models.User{"alice": refunder},
+			participantByUser: map[uuid.UUID]models.Participant{
+				refunder.ID: {ID: uuid.New(), Result: models.DisputesResultWin, IsClaimable: true},
+			},
+		}
+		svc := DisputeService{
+			logger:             noopLogger{},
+			userFinder:         repo,
+			disputeFinder:      repo,
+			participantGetter:  repo,
+			participantUpdater: transitionUpdater{staleParticipantUpdater{}},
+			txMonitor:          &fakeTxMonitor{},
+		}
+
+		_, err := svc.ClaimDispute(context.Background(), disputeID.String(), refunder.Username, "boc")
+		if !errors.Is(err, ErrValidation) || !errors.Is(err, models.ErrDisputeTransition) {
+			t.Fatalf("expected ErrValidation, got %v", err)
+		}
+	})
+
+	t.Run("can't claim before settle", func(t *testing.T) {
+		repo := &fakeDisputeRepo{
+			usersByUsername: map[string]models.User{"alice": refunder},
+			participantByUser: map[uuid.UUID]models.Participant{
+				refunder.ID: {ID: uuid.New(), Result: models.DisputesResultAnswered, IsClaimable: true},
+			},
+		}
+		svc := DisputeService{
+			logger:             noopLogger{},
+			userFinder:         repo,
+			disputeFinder:      repo,
+			participantGetter:  repo,
+			participantUpdater: repo,
+			txMonitor:          &fakeTxMonitor{},
+		}
+
+		_, err := svc.ClaimDispute(context.Background(), disputeID.String(), refunder.Username, "boc")
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected ErrValidation, got %v", err)
+		}
+		if len(repo.updatedDP) != 0 {
+			t.Fatalf("expected no participant updates, got %d", len(repo.updatedDP))
+		}
+	})
+
 	t.Run("non claimable participant cannot refund", func(t *testing.T) {
 		repo := &fakeDisputeRepo{
 			usersByUsername: map[string]models.User{"alice": refunder},
@@ -533,12 +1069,13 @@ func TestDisputeServiceRefundDispute(t *testing.T) {
 		svc := DisputeService{
 			logger:             noopLogger{},
 			userFinder:         repo,
+			disputeFinder:      repo,
 			participantGetter:  repo,
 			participantUpdater: repo,
 			txMonitor:          &fakeTxMonitor{},
 		}
 
-		err := svc.ClaimDispute(context.Background(), disputeID.String(), refunder.Username, "boc")
+		_, err := svc.ClaimDispute(context.Background(), disputeID.String(), refunder.Username, "boc")
 		if !errors.Is(err, ErrValidation) {
 			t.Fatalf("expected ErrValidation, got %v", err)
 		}
@@ -547,3 +1084,43 @@ func TestDisputeServiceRefundDispute(t *testing.T) {
 		}
 	})
 }
+
+func TestDisputeServiceClaimDisputeSettledByDeadline(t *testing.T) {
+	winner := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &fakeDisputeRepo{
+		usersByUsername: map[string]models.User{"alice": winner},
+		participantByUser: map[uuid.UUID]models.Participant{
+			winner.ID: {
+				ID:          uuid.New(),
+				Status:      models.DisputesStatusPassed,
+				Result:      models.DisputesResultWin,
+				IsWin:       true,
+				IsClaimable: true,
+			},
+		},
+		dispute: models.Dispute{ID: uuid.New(), ContractAddress: "bet"},
+	}
+	txMonitor := &fakeTxMonitor{}
+	svc := DisputeService{
+		logger:             noopLogger{},
+		userFinder:         repo,
+		disputeFinder:      repo,
+		participantGetter:  repo,
+		participantUpdater: repo,
+		txMonitor:          txMonitor,
+	}
+
+	_, err := svc.ClaimDispute(context.Background(), repo.dispute.ID.String(), winner.Username, "boc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The deadline worker settles the dispute in the database only; the bet still waits for Finalize.
+	if want := txMonitor.want; !slices.Contains(want.Opcodes, models.OpClaim) ||
+		!want.PrecedingOptional || len(want.Preceding) != 1 ||
+		!slices.Equal(want.Preceding[0].Opcodes, []uint32{models.OpFinalize}) {
+		t.Fatalf("expected Claim that may follow Finalize, got %+v", want)
+	}
+	if len(repo.updatedDP) != 1 || *repo.updatedDP[0].IsClaimable {
+		t.Fatalf("expected the payout to be taken, got %+v", repo.updatedDP)
+	}
+}
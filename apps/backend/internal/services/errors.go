@@ -8,6 +8,14 @@ import (
 var (
 	ErrNotFound             = errors.New("not found")
 	ErrUserNotFound         = fmt.Errorf("user %w", ErrNotFound)
+	ErrOperationNotFound    = fmt.Errorf("operation %w", ErrNotFound)
+	ErrDisputeNotFound      = fmt.Errorf("dispute %w", ErrNotFound)
+	ErrOpenDisputeNotFound  = fmt.Errorf("open dispute %w", ErrNotFound)
+	ErrInvitationNotFound   = fmt.Errorf("invitation %w", ErrNotFound)
+	ErrReportNotFound       = fmt.Errorf("transparency report %w", ErrNotFound)
+	ErrFlagNotFound         = fmt.Errorf("rationale flag %w", ErrNotFound)
+	ErrMediaNotFound        = fmt.Errorf("media %w", ErrNotFound)
+	ErrEvidenceNotFound     = fmt.Errorf("evidence %w", ErrNotFound)
 	ErrMinimalAmount        = errors.New("amount is less than opponent's minimum disputes amount")
 	ErrUnready              = errors.New("not ready for disputes")
 	ErrSelfOpponent         = errors.New("creator and opponent must be different")
@@ -16,4 +24,20 @@ var (
 	ErrTxNotFinalized       = errors.New("transaction not finalized in time")
 	ErrTxMonitorUnavailable = errors.New("transaction monitor unavailable")
 	ErrValidation			= errors.New("failed to validate")
+	ErrIdempotencyConflict  = errors.New("transaction was already used for another operation")
+	ErrDisputeTaken         = errors.New("dispute was already accepted by another user")
+	ErrUnsupportedMedia     = errors.New("unsupported media type")
+	ErrMalformedMedia       = errors.New("malformed media")
+	ErrMediaTooLarge        = errors.New("media is too large")
 )
+
+// MessageMismatchError reports a signed message that does not carry the contract call its operation expects.
+type MessageMismatchError struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+func (e *MessageMismatchError) Error() string {
+	return fmt.Sprintf("message %s mismatch: expected %s, got %s", e.Field, e.Expected, e.Actual)
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
@@ -22,8 +24,22 @@ type EvidenceGetter interface {
 	GetEvidences(ctx context.Context, disputeID uuid.UUID) ([]models.Evidence, error)
 }
 
-type EvidenceBroadcaster interface {
-	BroadcastInvestigation(ctx context.Context, u2i models.Juror, p1, p2 uuid.UUID) ([]uuid.UUID, error)
+type EvidenceFinder interface {
+	GetEvidence(ctx context.Context, evidenceID uuid.UUID) (models.Evidence, error)
+}
+
+// EvidenceViewer tells whether a user is a participant of a dispute or a juror of its investigation.
+type EvidenceViewer interface {
+	CanViewDisputeHistory(ctx context.Context, disputeID uuid.UUID, actorUsername string) (bool, error)
+}
+
+// EvidenceReleaser unseals the evidence of a dispute once its investigation opens.
+type EvidenceReleaser interface {
+	ReleaseEvidences(ctx context.Context, disputeID uuid.UUID, releasedAt time.Time) error
+}
+
+type EvidenceAttachmentLister interface {
+	ListEvidenceAttachments(ctx context.Context, disputeID uuid.UUID) ([]models.EvidenceAttachment, error)
 }
 
 type EvidenceService struct {
@@ -32,15 +48,26 @@ type EvidenceService struct {
 	evidenceCreator      EvidenceCreator
 	evidenceChecker      EvidenceChecker
 	evidenceGetter       EvidenceGetter
+	attachmentLister     EvidenceAttachmentLister
+	evidenceFinder       EvidenceFinder
+	evidenceViewer       EvidenceViewer
+	evidenceReleaser     EvidenceReleaser
 	userFinder           UserFinder
 	participantUpdater   ParticipantUpdater
 	participantGetter    ParticipantGetter
 	opponentGetter       OpponentGetter
 	investigationCreator InvestigationCreator
-	evidenceBroadcaster  EvidenceBroadcaster
+	jurySelector         JurySelector
+	verdictPolicyFinder  VerdictPolicyFinder
 	disputesFinder       DisputeFinder
+	eventRecorder        DisputeEventRecorder
 	msgSender            MessageSender
 	txMonitor            TransactionMonitor
+	txRunner             TxRunner
+	opRecorder           OperationRecorder
+	opStore              PendingOperationStore
+	walletStore          UserWalletStore
+	mediaStorer          MediaStorer
 }
 
 func NewEvidenceService(repo *repository.Repository, log log.Logger, msgSender MessageSender) (EvidenceService, error) {
@@ -50,20 +77,34 @@ func NewEvidenceService(repo *repository.Repository, log log.Logger, msgSender M
 	if log == nil {
 		return EvidenceService{}, fmt.Errorf("logger is nil")
 	}
+	jurySrv, err := NewJuryService(repo, log, msgSender)
+	if err != nil {
+		return EvidenceService{}, fmt.Errorf("failed to create jury service: %w", err)
+	}
 
 	return EvidenceService{
 		logger:               log,
 		evidenceCreator:      repo,
 		evidenceChecker:      repo,
 		evidenceGetter:       repo,
+		attachmentLister:     repo,
+		evidenceFinder:       repo,
+		evidenceViewer:       repo,
+		evidenceReleaser:     repo,
 		userFinder:           repo,
-		participantUpdater:   repo,
+		participantUpdater:   transitionUpdater{repo},
 		participantGetter:    repo,
 		opponentGetter:       repo,
 		investigationCreator: repo,
-		evidenceBroadcaster:  repo,
+		jurySelector:         jurySrv,
+		verdictPolicyFinder:  repo,
 		disputesFinder:       repo,
+		eventRecorder:        repo,
 		msgSender:            msgSender,
+		txRunner:             repo,
+		opRecorder:           repo,
+		opStore:              repo,
+		walletStore:          repo,
 	}, nil
 }
 
@@ -72,18 +113,80 @@ func (s EvidenceService) WithTransactionMonitor(txMonitor TransactionMonitor) Ev
 	return s
 }
 
-func (s EvidenceService) ProvideEvidence(ctx context.Context, opts models.EvidenceOpts) error {
-	if s.txMonitor == nil {
-		return fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
+// WithMediaStore sets where evidence attachments are stored.
+func (s EvidenceService) WithMediaStore(media MediaStorer) EvidenceService {
+	s.mediaStorer = media
+	return s
+}
+
+func (s EvidenceService) operations() operationRunner {
+	return operationRunner{
+		txMonitor:  s.txMonitor,
+		txRunner:   s.txRunner,
+		recorder:   s.opRecorder,
+		store:      s.opStore,
+		wallets:    s.walletStore,
+		userFinder: s.userFinder,
+		msgSender:  s.msgSender,
+		logger:     s.logger,
+	}
+}
+
+func (s EvidenceService) ProvideEvidence(ctx context.Context, opts models.EvidenceOpts) (models.PendingOperation, error) {
+	disputeUUID, err := uuid.Parse(opts.DisputeID)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("%w: invalid dispute ID format: %v", ErrValidation, err)
+	}
+	dispute, err := s.disputesFinder.GetDisputeByID(ctx, disputeUUID)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	// Attachments go to the blob store upfront so the pending operation only carries their hashes.
+	if opts.Attachments, err = storeAttachments(ctx, s.mediaStorer, opts.Uploads); err != nil {
+		return models.PendingOperation{}, err
+	}
+	opts.Uploads = nil
+	if opts.ContentHash, err = opts.Digest(); err != nil {
+		return models.PendingOperation{}, fmt.Errorf("%w: %s", ErrValidation, err)
 	}
-	if err := s.txMonitor.WaitForSuccess(ctx, opts.Boc); err != nil {
+	digest, err := models.DigestInt(opts.ContentHash)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	// Evidence goes to the investigation the bet deployed, committing the digest of exactly what is stored here.
+	want := models.ProvideEvidenceMessage(dispute.ContractAddress, digest)
+	return s.operations().submit(ctx, opts.Username, models.OperationActionProvideEvidence, opts.DisputeID, opts.Boc,
+		want, opts, func(ctx context.Context) error {
+			return s.provideEvidence(ctx, opts)
+		})
+}
+
+// ApplyOperation applies a confirmed operation recorded by ProvideEvidence.
+func (s EvidenceService) ApplyOperation(ctx context.Context, op models.PendingOperation) error {
+	if op.Action != models.OperationActionProvideEvidence {
+		return fmt.Errorf("%w: unsupported evidence operation %s", ErrValidation, op.Action)
+	}
+	var opts models.EvidenceOpts
+	if err := decodePayload(op, &opts); err != nil {
 		return err
 	}
+	return s.operations().apply(ctx, op, func(ctx context.Context, username string) error {
+		opts.Username = username
+		return s.provideEvidence(ctx, opts)
+	})
+}
 
+func (s EvidenceService) provideEvidence(ctx context.Context, opts models.EvidenceOpts) error {
 	disputeUUID, err := uuid.Parse(opts.DisputeID)
 	if err != nil {
 		return fmt.Errorf("invalid dispute ID format: %w", err)
 	}
+	if len(opts.ImageData) > 0 {
+		if opts.ImageHash, err = storeLegacyImage(ctx, s.mediaStorer, opts.ImageData, opts.ImageType); err != nil {
+			return err
+		}
+	}
+	opts.AttachLegacyImage()
 
 	provider, err := s.userFinder.GetUserByUsername(ctx, opts.Username)
 	if err != nil {
@@ -100,18 +203,19 @@ func (s EvidenceService) ProvideEvidence(ctx context.Context, opts models.Eviden
 		return fmt.Errorf("failed to check if first evidence: %w", err)
 	}
 
-	evidence := models.NewEvidence(participantProvider.ID, opts.Description, opts.ImageData, opts.ImageType)
+	evidence := models.NewEvidence(participantProvider.ID, opts)
 	if err := s.evidenceCreator.InsertEvidence(ctx, evidence); err != nil {
 		return fmt.Errorf("failed to insert first evidence: %w", err)
 	}
 
 	// --- FIRST EVIDENCE ---
 	if isFirst {
-		optsDP := models.ParticipantUpdateOpts{
-			ID:     participantProvider.ID,
-			Result: new(models.DisputesResultEvidenceAnswered),
-			Seen: new(true),
+		optsDP, err := transition(participantProvider, models.DisputeActionProvideEvidence, models.ActorSelf,
+			models.DisputesResultEvidenceAnswered)
+		if err != nil {
+			return err
 		}
+		optsDP.Seen = new(true)
 		if err := s.participantUpdater.UpdateParticipant(ctx, optsDP); err != nil {
 			return fmt.Errorf("failed to update participants result: %w", err)
 		}
@@ -119,11 +223,12 @@ func (s EvidenceService) ProvideEvidence(ctx context.Context, opts models.Eviden
 	}
 
 	// --- SECOND EVIDENCE ---
-	updOpts := models.ParticipantUpdateOpts{
-		ID:     participantProvider.ID,
-		Result: new(models.DisputesResultInspected),
-		Seen: new(true),
+	updOpts, err := transition(participantProvider, models.DisputeActionInvestigate, models.ActorSelf,
+		models.DisputesResultInspected)
+	if err != nil {
+		return err
 	}
+	updOpts.Seen = new(true)
 	if err := s.participantUpdater.UpdateParticipant(ctx, updOpts); err != nil {
 		return fmt.Errorf("failed to update participants result: %w", err)
 	}
@@ -136,59 +241,181 @@ func (s EvidenceService) ProvideEvidence(ctx context.Context, opts models.Eviden
 	if err != nil {
 		return fmt.Errorf("failed to get opponent participants: %w", err)
 	}
-	updOpts.ID = participantOpponent.ID
+	updOpts, err = transition(participantOpponent, models.DisputeActionInvestigate, models.ActorPeer,
+		models.DisputesResultInspected)
+	if err != nil {
+		return err
+	}
 	updOpts.Seen = new(false)
 	if err := s.participantUpdater.UpdateParticipant(ctx, updOpts); err != nil {
 		return fmt.Errorf("failed to update participants result: %w", err)
 	}
 
-	// --- CREATE INVESTIGATION ---
-	dispute, err := s.disputesFinder.GetDisputeByID(ctx, disputeUUID)
+	return s.openInvestigation(ctx, disputeUUID, provider.ID, opID)
+}
+
+// OpenInvestigation creates the investigation for a dispute and draws its jury out of everyone but the participants.
+func (s EvidenceService) OpenInvestigation(ctx context.Context, disputeID uuid.UUID, participantIDs ...uuid.UUID,
+) error {
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		return s.openInvestigation(ctx, disputeID, participantIDs...)
+	})
+}
+
+func (s EvidenceService) openInvestigation(ctx context.Context, disputeID uuid.UUID, participantIDs ...uuid.UUID,
+) error {
+	dispute, err := s.disputesFinder.GetDisputeByID(ctx, disputeID)
 	if err != nil {
 		return fmt.Errorf("failed to get dispute by ID: %w", err)
 	}
 
-	investigation := models.NewInvestigation(disputeUUID, dispute.Title)
+	requiredVotes, err := s.requiredVotes(ctx, dispute)
+	if err != nil {
+		return err
+	}
+	policy, err := currentVerdictPolicy(ctx, s.verdictPolicyFinder)
+	if err != nil {
+		return fmt.Errorf("failed to get verdict policy: %w", err)
+	}
+	investigation := models.NewInvestigation(disputeID, dispute.Title, requiredVotes, policy)
 	err = s.investigationCreator.InsertInvestigation(ctx, investigation)
 	if err != nil {
 		return fmt.Errorf("failed to insert opts: %w", err)
 	}
-
-	u2i := models.NewJuror(investigation.ID, uuid.Nil)
-	userIDs, err := s.evidenceBroadcaster.BroadcastInvestigation(ctx, u2i, provider.ID, opID)
-	if err != nil {
-		return fmt.Errorf("failed to broadcast investigation: %w", err)
+	// Every party has submitted or the evidence deadline passed: the parties and the jury see all evidence now.
+	if err = s.evidenceReleaser.ReleaseEvidences(ctx, disputeID, time.Now()); err != nil {
+		return fmt.Errorf("failed to release evidences: %w", err)
 	}
-	if len(userIDs) == 0 {
-		return nil
+	event := models.NewDisputeEvent(disputeID, models.DisputeActionOpenInvestigation, models.ActorSystem)
+	if err = s.eventRecorder.InsertDisputeEvent(ctx, event); err != nil {
+		return err
 	}
 
-	users, err := s.userFinder.GetUsers(ctx, userIDs)
+	// The parties never sit on the jury of their own dispute.
+	err = s.jurySelector.SelectJury(ctx, investigation, investigation.Total, participantIDs)
 	if err != nil {
-		return fmt.Errorf("failed to get users: %w", err)
+		return fmt.Errorf("failed to select jury: %w", err)
 	}
+	return nil
+}
 
-	for _, u := range users {
-		if u.NotificationEnabled {
-			msg := "Вам доступно новое расследование!"
-			if err = s.msgSender.SendMessage(u.ChatID, msg); err != nil {
-				return err
-			}
-		}
+// requiredVotes reads how many juror votes the investigation contract deployed by the bet of the dispute waits
+// for. It resolves only once that many jurors voted, so the jury has as many seats.
+func (s EvidenceService) requiredVotes(ctx context.Context, dispute models.Dispute) (int, error) {
+	if s.txMonitor == nil {
+		return 0, fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
 	}
-	return nil
+	investigation, err := s.txMonitor.CallAddressGetter(ctx, dispute.ContractAddress,
+		models.InvestigationAddressGetter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get investigation address: %w", err)
+	}
+	required, err := s.txMonitor.CallGetter(ctx, investigation, models.RequiredVotesCountGetter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get required votes count: %w", err)
+	}
+	return int(required), nil
 }
 
-func (s EvidenceService) GetEvidences(ctx context.Context, disputeID string) ([]models.Evidence, error) {
+// GetEvidences returns the evidence of a dispute to its participants and the jurors of its investigation; for anyone
+// else the dispute doesn't exist. Evidence still sealed is left out for everyone but its own party.
+func (s EvidenceService) GetEvidences(ctx context.Context, disputeID, actorUsername string,
+) ([]models.Evidence, error) {
 	disputeUUID, err := uuid.Parse(disputeID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid dispute ID format: %w", err)
 	}
+	viewerID, ok, err := s.evidenceViewerID(ctx, disputeUUID, actorUsername)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDisputeNotFound
+	}
 
-	evidences, err := s.evidenceGetter.GetEvidences(ctx, disputeUUID)
+	all, err := s.evidenceGetter.GetEvidences(ctx, disputeUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get evidences: %w", err)
 	}
+	evidences := make([]models.Evidence, 0, len(all))
+	for _, e := range all {
+		if !e.SealedFor(viewerID) {
+			evidences = append(evidences, e)
+		}
+	}
+	attachments, err := s.attachmentLister.ListEvidenceAttachments(ctx, disputeUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evidence attachments: %w", err)
+	}
 
+	byEvidence := make(map[uuid.UUID][]models.EvidenceAttachment, len(evidences))
+	for _, a := range attachments {
+		a.SetURLs()
+		byEvidence[a.EvidenceID] = append(byEvidence[a.EvidenceID], a)
+	}
+	for i := range evidences {
+		evidences[i].Attachments = byEvidence[evidences[i].ID]
+	}
 	return evidences, nil
 }
+
+// VerifyEvidence recomputes the digest of the evidence for a participant of its dispute or a juror of its
+// investigation, and compares it with the hash committed on-chain.
+func (s EvidenceService) VerifyEvidence(ctx context.Context, evidenceID, actorUsername string,
+) (models.EvidenceVerification, error) {
+	evidenceUUID, err := uuid.Parse(evidenceID)
+	if err != nil {
+		return models.EvidenceVerification{}, fmt.Errorf("%w: invalid evidence ID format: %s", ErrValidation, err)
+	}
+	evidence, err := s.evidenceFinder.GetEvidence(ctx, evidenceUUID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.EvidenceVerification{}, ErrEvidenceNotFound
+	case err != nil:
+		return models.EvidenceVerification{}, fmt.Errorf("failed to get evidence: %w", err)
+	}
+	viewerID, ok, err := s.evidenceViewerID(ctx, evidence.DisputeID, actorUsername)
+	if err != nil {
+		return models.EvidenceVerification{}, err
+	}
+	if !ok || evidence.SealedFor(viewerID) {
+		return models.EvidenceVerification{}, ErrEvidenceNotFound
+	}
+
+	attachments, err := s.attachmentLister.ListEvidenceAttachments(ctx, evidence.DisputeID)
+	if err != nil {
+		return models.EvidenceVerification{}, fmt.Errorf("failed to list evidence attachments: %w", err)
+	}
+	for _, a := range attachments {
+		if a.EvidenceID == evidence.ID {
+			a.SetURLs()
+			evidence.Attachments = append(evidence.Attachments, a)
+		}
+	}
+	return models.NewEvidenceVerification(evidence), nil
+}
+
+// evidenceViewerID tells whether the user may view the evidence of the dispute, and as whom: their participant ID,
+// or uuid.Nil for a juror of its investigation.
+func (s EvidenceService) evidenceViewerID(ctx context.Context, disputeID uuid.UUID, actorUsername string,
+) (uuid.UUID, bool, error) {
+	ok, err := s.evidenceViewer.CanViewDisputeHistory(ctx, disputeID, actorUsername)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to check evidence access: %w", err)
+	}
+	if !ok {
+		return uuid.Nil, false, nil
+	}
+	user, err := s.userFinder.GetUserByUsername(ctx, actorUsername)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to get user by username: %w", err)
+	}
+	participant, err := s.participantGetter.GetParticipant(ctx, disputeID, user.ID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return uuid.Nil, true, nil
+	case err != nil:
+		return uuid.Nil, false, fmt.Errorf("failed to get participant: %w", err)
+	}
+	return participant.ID, true, nil
+}
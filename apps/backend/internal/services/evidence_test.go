@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
 )
 
 type fakeEvidenceDeps struct {
@@ -16,25 +19,59 @@ type fakeEvidenceDeps struct {
 	totalUsers   int
 	dispute      models.Dispute
 	usersByIDs   []models.User
-	broadcastIDs []uuid.UUID
 	isFirst      bool
+	participants []models.Participant
+	excluded     []uuid.UUID
+	jurySize     int
+	opened       models.Investigation
+	evidences    []models.Evidence
+	attachments  []models.EvidenceAttachment
+	canView      bool
+	released     []uuid.UUID
+	juryErr      error
 
 	insertEvidenceCalls      int
 	insertInvestigationCalls int
 	updatedDP               []models.ParticipantUpdateOpts
+	events                  []models.DisputeEvent
 }
 
-func (f *fakeEvidenceDeps) InsertEvidence(context.Context, models.Evidence) error {
+func (f *fakeEvidenceDeps) InsertDisputeEvent(_ context.Context, event models.DisputeEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeEvidenceDeps) InsertEvidence(_ context.Context, evidence models.Evidence) error {
 	f.insertEvidenceCalls++
+	f.evidences = append(f.evidences, evidence)
 	return nil
 }
 func (f *fakeEvidenceDeps) IsFirstEvidence(context.Context, string) (bool, error) { return f.isFirst, nil }
 func (f *fakeEvidenceDeps) GetEvidences(context.Context, uuid.UUID) ([]models.Evidence, error) {
-	return nil, nil
+	return f.evidences, nil
+}
+func (f *fakeEvidenceDeps) GetEvidence(_ context.Context, evidenceID uuid.UUID) (models.Evidence, error) {
+	for _, e := range f.evidences {
+		if e.ID == evidenceID {
+			return e, nil
+		}
+	}
+	return models.Evidence{}, repository.ErrNotFound
+}
+func (f *fakeEvidenceDeps) CanViewDisputeHistory(context.Context, uuid.UUID, string) (bool, error) {
+	return f.canView, nil
+}
+func (f *fakeEvidenceDeps) ReleaseEvidences(_ context.Context, disputeID uuid.UUID, _ time.Time) error {
+	f.released = append(f.released, disputeID)
+	return nil
+}
+func (f *fakeEvidenceDeps) ListEvidenceAttachments(context.Context, uuid.UUID) ([]models.EvidenceAttachment, error) {
+	return f.attachments, nil
 }
-func (f *fakeEvidenceDeps) BroadcastInvestigation(context.Context, models.Juror, uuid.UUID, uuid.UUID,
-) ([]uuid.UUID, error) {
-	return f.broadcastIDs, nil
+func (f *fakeEvidenceDeps) SelectJury(_ context.Context, _ models.Investigation, size int, excluded []uuid.UUID,
+) error {
+	f.jurySize, f.excluded = size, excluded
+	return f.juryErr
 }
 func (f *fakeEvidenceDeps) GetUserByID(context.Context, uuid.UUID) (models.User, error) { return models.User{}, nil }
 func (f *fakeEvidenceDeps) GetUserByUsername(context.Context, string) (models.User, error) {
@@ -47,7 +84,6 @@ func (f *fakeEvidenceDeps) GetUsers(context.Context, []uuid.UUID) ([]models.User
 }
 func (f *fakeEvidenceDeps) GetTopUsers(context.Context, int) ([]models.User, error) { return nil, nil }
 func (f *fakeEvidenceDeps) UpdateUser(context.Context, models.UserUpdateOpts) error  { return nil }
-func (f *fakeEvidenceDeps) EarnWinnerRating(context.Context, []uuid.UUID) error      { return nil }
 func (f *fakeEvidenceDeps) UpdateParticipant(_ context.Context, opts models.ParticipantUpdateOpts) error {
 	f.updatedDP = append(f.updatedDP, opts)
 	return nil
@@ -59,13 +95,20 @@ func (f *fakeEvidenceDeps) GetParticipant(_ context.Context, _ uuid.UUID, userID
 	}
 	return f.participantOpponent, nil
 }
+func (f *fakeEvidenceDeps) ListParticipants(context.Context, uuid.UUID) ([]models.Participant, error) {
+	return f.participants, nil
+}
 func (f *fakeEvidenceDeps) GetOpponentID(context.Context, uuid.UUID, uuid.UUID) (uuid.UUID, error) {
 	return f.opponentID, nil
 }
-func (f *fakeEvidenceDeps) InsertInvestigation(context.Context, models.Investigation) error {
+func (f *fakeEvidenceDeps) InsertInvestigation(_ context.Context, investigation models.Investigation) error {
 	f.insertInvestigationCalls++
+	f.opened = investigation
 	return nil
 }
+func (f *fakeEvidenceDeps) GetVerdictPolicy(context.Context) (models.VerdictPolicy, error) {
+	return models.VerdictPolicy{}, repository.ErrNotFound
+}
 func (f *fakeEvidenceDeps) GetDisputeByID(context.Context, uuid.UUID) (models.Dispute, error) {
 	return f.dispute, nil
 }
@@ -81,10 +124,11 @@ func TestEvidenceServiceProvideEvidenceFirst(t *testing.T) {
 	deps := &fakeEvidenceDeps{
 		isFirst:    true,
 		user:       models.User{ID: userID, Username: "alice"},
-		participantSelf:    models.Participant{ID: uuid.New()},
-		dispute:    models.Dispute{ID: uuid.New(), Title: "D1"},
+		participantSelf:    models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidence},
+		dispute:    models.Dispute{ID: uuid.New(), Title: "D1", ContractAddress: "bet"},
 		totalUsers: 10,
 	}
+	txMonitor := &fakeTxMonitor{}
 	svc := EvidenceService{
 		logger:          noopLogger{},
 		evidenceCreator: deps,
@@ -92,13 +136,18 @@ func TestEvidenceServiceProvideEvidenceFirst(t *testing.T) {
 		userFinder:      deps,
 		participantUpdater:      deps,
 		participantGetter:       deps,
-		txMonitor:               &fakeTxMonitor{},
+		disputesFinder:          deps,
+		txMonitor:               txMonitor,
 	}
 
-	err := svc.ProvideEvidence(context.Background(), models.EvidenceOpts{DisputeID: uuid.NewString(), Username: "alice", Boc: "boc"})
+	_, err := svc.ProvideEvidence(context.Background(), models.EvidenceOpts{DisputeID: uuid.NewString(), Username: "alice", Boc: "boc"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if want := txMonitor.want; want.Destination != "bet" || want.DestinationGetter != models.InvestigationAddressGetter ||
+		len(want.Opcodes) != 1 || want.Opcodes[0] != models.OpProvideEvidence {
+		t.Fatalf("unexpected expected message: %+v", want)
+	}
 	if deps.insertEvidenceCalls != 1 {
 		t.Fatalf("expected 1 insert evidence, got %d", deps.insertEvidenceCalls)
 	}
@@ -110,22 +159,25 @@ func TestEvidenceServiceProvideEvidenceFirst(t *testing.T) {
 	}
 }
 
+// jurySizeMonitor is a tx monitor of a bet whose investigation waits for the votes of required jurors.
+func jurySizeMonitor(bet string, required int64) *fakeTxMonitor {
+	return &fakeTxMonitor{
+		addresses: map[string]string{bet + "." + models.InvestigationAddressGetter: "investigation"},
+		getters:   map[string]int64{"investigation." + models.RequiredVotesCountGetter: required},
+	}
+}
+
 func TestEvidenceServiceProvideEvidenceSecond(t *testing.T) {
 	userID := uuid.New()
 	opID := uuid.New()
 	deps := &fakeEvidenceDeps{
 		isFirst:      false,
 		user:         models.User{ID: userID, Username: "alice"},
-		participantSelf:      models.Participant{ID: uuid.New()},
-		participantOpponent:  models.Participant{ID: uuid.New()},
+		participantSelf:      models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidence},
+		participantOpponent:  models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidenceAnswered},
 		opponentID:   opID,
 		totalUsers:   12,
-		dispute:      models.Dispute{ID: uuid.New(), Title: "D2"},
-		broadcastIDs: []uuid.UUID{uuid.New(), uuid.New()},
-		usersByIDs: []models.User{
-			{ID: uuid.New(), NotificationEnabled: true, ChatID: 101},
-			{ID: uuid.New(), NotificationEnabled: false, ChatID: 202},
-		},
+		dispute:      models.Dispute{ID: uuid.New(), Title: "D2", ContractAddress: "bet"},
 	}
 	sender := &fakeMessageSender{}
 	svc := EvidenceService{
@@ -137,13 +189,16 @@ func TestEvidenceServiceProvideEvidenceSecond(t *testing.T) {
 		participantGetter:            deps,
 		opponentGetter:       deps,
 		investigationCreator: deps,
-		evidenceBroadcaster:  deps,
+		evidenceReleaser:     deps,
+		eventRecorder:        deps,
+		jurySelector:         deps,
+		verdictPolicyFinder:  deps,
 		disputesFinder:       deps,
 		msgSender:            sender,
-		txMonitor:            &fakeTxMonitor{},
+		txMonitor:            jurySizeMonitor("bet", 3),
 	}
 
-	err := svc.ProvideEvidence(context.Background(), models.EvidenceOpts{DisputeID: uuid.NewString(), Username: "alice", Boc: "boc"})
+	_, err := svc.ProvideEvidence(context.Background(), models.EvidenceOpts{DisputeID: uuid.NewString(), Username: "alice", Boc: "boc"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -156,16 +211,163 @@ func TestEvidenceServiceProvideEvidenceSecond(t *testing.T) {
 	if deps.insertInvestigationCalls != 1 {
 		t.Fatalf("expected 1 investigation insert, got %d", deps.insertInvestigationCalls)
 	}
-	if sender.calls != 1 {
-		t.Fatalf("expected 1 notification, got %d", sender.calls)
+	if deps.opened.VotingMode != models.VotingModeOpen || deps.opened.CommitEndsAt != nil {
+		t.Fatalf("expected open voting by default, got %+v", deps.opened)
+	}
+	if deps.jurySize != 3 || len(deps.excluded) != 2 {
+		t.Fatalf("expected a jury of 3 without both parties, got %d excluding %v", deps.jurySize, deps.excluded)
+	}
+	if len(deps.released) != 1 {
+		t.Fatalf("expected the evidence of the dispute released, got %v", deps.released)
+	}
+}
+
+func TestEvidenceServiceProvideEvidenceRollsBackOnJuryFailure(t *testing.T) {
+	deps := &fakeEvidenceDeps{
+		user:                models.User{ID: uuid.New(), Username: "alice"},
+		participantSelf:     models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidence},
+		participantOpponent: models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidenceAnswered},
+		opponentID:          uuid.New(),
+		dispute:             models.Dispute{ID: uuid.New(), Title: "D2", ContractAddress: "bet"},
+		juryErr:             errors.New("not enough users"),
+	}
+	sender := &fakeMessageSender{}
+	txRunner := &fakeTxRunner{}
+	svc := EvidenceService{
+		logger:               noopLogger{},
+		evidenceCreator:      deps,
+		evidenceChecker:      deps,
+		userFinder:           deps,
+		participantUpdater:   deps,
+		participantGetter:    deps,
+		opponentGetter:       deps,
+		investigationCreator: deps,
+		evidenceReleaser:     deps,
+		eventRecorder:        deps,
+		jurySelector:         deps,
+		verdictPolicyFinder:  deps,
+		disputesFinder:       deps,
+		msgSender:            sender,
+		txMonitor:            jurySizeMonitor("bet", 1),
+		txRunner:             txRunner,
+	}
+
+	opts := models.EvidenceOpts{DisputeID: deps.dispute.ID.String(), Username: "alice", Boc: "boc"}
+	if _, err := svc.ProvideEvidence(context.Background(), opts); err == nil {
+		t.Fatal("expected error")
+	}
+	// The evidence, both participant moves and the investigation are undone together.
+	if txRunner.rollbacks != 1 || txRunner.commits != 0 || deps.insertInvestigationCalls != 1 {
+		t.Fatalf("expected rollback only, got commits=%d rollbacks=%d", txRunner.commits, txRunner.rollbacks)
+	}
+	if sender.calls != 0 {
+		t.Fatalf("expected no messages after rollback, got %d", sender.calls)
 	}
 }
 
 func TestEvidenceServiceGetEvidencesInvalidID(t *testing.T) {
 	svc := EvidenceService{logger: noopLogger{}, evidenceGetter: &fakeEvidenceDeps{}}
 
-	_, err := svc.GetEvidences(context.Background(), "bad-id")
+	_, err := svc.GetEvidences(context.Background(), "bad-id", "alice")
 	if err == nil {
 		t.Fatal("expected error")
 	}
 }
+
+func TestEvidenceServiceVerifyEvidence(t *testing.T) {
+	upload := models.MediaHash([]byte("%PDF-1.7"))
+	opts := models.EvidenceOpts{
+		Description: "paid",
+		Attachments: []models.StoredAttachment{{Kind: models.AttachmentKindPDF, Hash: upload, UploadHash: upload}},
+	}
+	digest, err := opts.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts.ContentHash = digest
+	evidence := models.NewEvidence(uuid.New(), opts)
+	evidence.DisputeID = uuid.New()
+	stored := evidence
+	stored.Attachments = nil
+	sealed := models.Evidence{ID: uuid.New(), DisputeID: evidence.DisputeID, ParticipantID: uuid.New()}
+	stored.ReleasedAt = new(time.Now())
+	deps := &fakeEvidenceDeps{
+		evidences:   []models.Evidence{stored, sealed},
+		attachments: append(evidence.Attachments, models.EvidenceAttachment{EvidenceID: uuid.New(), MediaHash: "ab12"}),
+	}
+	svc := EvidenceService{
+		logger:            noopLogger{},
+		evidenceFinder:    deps,
+		evidenceViewer:    deps,
+		attachmentLister:  deps,
+		userFinder:        deps,
+		participantGetter: deps,
+	}
+
+	_, err = svc.VerifyEvidence(context.Background(), evidence.ID.String(), "mallory")
+	if !errors.Is(err, ErrEvidenceNotFound) {
+		t.Fatalf("expected ErrEvidenceNotFound for outsiders, got %v", err)
+	}
+	deps.canView = true
+	_, err = svc.VerifyEvidence(context.Background(), uuid.NewString(), "juror")
+	if !errors.Is(err, ErrEvidenceNotFound) {
+		t.Fatalf("expected ErrEvidenceNotFound, got %v", err)
+	}
+	_, err = svc.VerifyEvidence(context.Background(), sealed.ID.String(), "bob")
+	if !errors.Is(err, ErrEvidenceNotFound) {
+		t.Fatalf("expected ErrEvidenceNotFound for sealed evidence, got %v", err)
+	}
+	v, err := svc.VerifyEvidence(context.Background(), evidence.ID.String(), "juror")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !v.Verified || len(v.Attachments) != 1 || v.Attachments[0].URL != models.MediaURL(upload) {
+		t.Fatalf("expected the evidence verified with its own attachment, got %+v", v)
+	}
+}
+
+func TestEvidenceServiceGetEvidencesSealed(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	self := models.Participant{ID: uuid.New(), UserID: alice.ID}
+	opponent := models.Participant{ID: uuid.New()}
+	own := models.Evidence{ID: uuid.New(), ParticipantID: self.ID}
+	theirs := models.Evidence{ID: uuid.New(), ParticipantID: opponent.ID}
+	deps := &fakeEvidenceDeps{
+		user:                alice,
+		participantSelf:     self,
+		participantOpponent: opponent,
+		evidences:           []models.Evidence{own, theirs},
+	}
+	svc := EvidenceService{
+		logger:            noopLogger{},
+		evidenceGetter:    deps,
+		evidenceViewer:    deps,
+		attachmentLister:  deps,
+		userFinder:        deps,
+		participantGetter: deps,
+	}
+	disputeID := uuid.NewString()
+
+	if _, err := svc.GetEvidences(context.Background(), disputeID, "mallory"); !errors.Is(err, ErrDisputeNotFound) {
+		t.Fatalf("expected ErrDisputeNotFound for outsiders, got %v", err)
+	}
+	deps.canView = true
+	evidences, err := svc.GetEvidences(context.Background(), disputeID, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(evidences) != 1 || evidences[0].ID != own.ID {
+		t.Fatalf("expected only the own evidence before release, got %+v", evidences)
+	}
+
+	for i := range deps.evidences {
+		deps.evidences[i].ReleasedAt = new(time.Now())
+	}
+	evidences, err = svc.GetEvidences(context.Background(), disputeID, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(evidences) != 2 {
+		t.Fatalf("expected every evidence after release, got %+v", evidences)
+	}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/kisnikita/safe-disputes/backend/pkg/log"
 	"go.uber.org/zap"
 )
@@ -29,3 +31,18 @@ func (f *fakeMessageSender) SendMessage(chatID int64, text string) error {
 	f.messages = append(f.messages, text)
 	return f.err
 }
+
+// fakeTxRunner records how units of work end; the fakes behind it have no real rollback.
+type fakeTxRunner struct {
+	commits   int
+	rollbacks int
+}
+
+func (f *fakeTxRunner) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		f.rollbacks++
+		return err
+	}
+	f.commits++
+	return nil
+}
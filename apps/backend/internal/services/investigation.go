@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
@@ -11,6 +13,11 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	defaultInvestigationBatchSize   = 50
+	defaultInvestigationLockTimeout = 2 * time.Minute
+)
+
 type InvestigationCreator interface {
 	InsertInvestigation(ctx context.Context, investigation models.Investigation) error
 }
@@ -32,10 +39,25 @@ type InvestigationDeleter interface {
 	DeleteUsersWithoutVote(ctx context.Context, invID uuid.UUID) error
 }
 
+type InvestigationExpirer interface {
+	ClaimExpiredInvestigations(ctx context.Context, now, lockUntil time.Time, limit int) ([]models.Investigation, error)
+}
+
 type JurorFinder interface {
 	GetJuror(ctx context.Context, invID, userID uuid.UUID) (models.Juror, error)
 	GetWinnersIDs(ctx context.Context, invID uuid.UUID, winner string) ([]uuid.UUID, error)
-	GetDisputesUsers(ctx context.Context, invID uuid.UUID) ([]models.User, error)
+}
+
+// InvestigationLocker locks an investigation until the transaction ends, so that its votes are counted and it is
+// finalized one transaction at a time.
+type InvestigationLocker interface {
+	LockInvestigation(ctx context.Context, invID uuid.UUID) (models.Investigation, error)
+}
+
+// InvestigationVoteCounter stores one vote per juror and counts the investigation votes from the stored ones.
+type InvestigationVoteCounter interface {
+	CastJurorVote(ctx context.Context, jurorID uuid.UUID, vote string, rationale *string) error
+	RecountInvestigationVotes(ctx context.Context, invID uuid.UUID) (models.VoteCounts, error)
 }
 
 type JurorUpdater interface {
@@ -43,6 +65,26 @@ type JurorUpdater interface {
 	UpdateWinnersResult(ctx context.Context, invID uuid.UUID, ids []uuid.UUID) error
 }
 
+// JurorCommitCounter counts the vote commitments of a commit-reveal investigation.
+type JurorCommitCounter interface {
+	CountJurorCommitments(ctx context.Context, invID uuid.UUID) (int, error)
+}
+
+// JurorBallotLister returns the votes cast in an investigation for its verdict policy to count.
+type JurorBallotLister interface {
+	ListJurorBallots(ctx context.Context, invID uuid.UUID) ([]models.Ballot, error)
+}
+
+// JuryReplacer draws alternates for jurors who recused.
+type JuryReplacer interface {
+	ReplaceJurors(ctx context.Context, investigation models.Investigation, size int) ([]uuid.UUID, error)
+}
+
+// JurorRecuser removes a juror who recused from the jury and records why.
+type JurorRecuser interface {
+	RecuseJuror(ctx context.Context, jurorID uuid.UUID, recusal models.JurorRecusal) error
+}
+
 type JurorSeener interface {
 	MarkJurorsSeen(ctx context.Context, actorUsername string, investigationIDs []uuid.UUID) error
 }
@@ -54,16 +96,29 @@ type InvestigationService struct {
 	investigationReadFinder InvestigationReadFinder
 	investigationUpdater    InvestigationUpdater
 	investigationDeleter    InvestigationDeleter
+	investigationExpirer    InvestigationExpirer
+	investigationLocker     InvestigationLocker
+	voteCounter             InvestigationVoteCounter
 	userFinder              UserFinder
 	userUpdater             UserUpdater
 	participantUpdater      ParticipantUpdater
-	participantGetter       ParticipantGetter
+	participantLister       ParticipantLister
 	jurorFinder             JurorFinder
 	jurorUpdater            JurorUpdater
 	jurorSeener             JurorSeener
+	jurorCommitCounter      JurorCommitCounter
+	ballotLister            JurorBallotLister
+	juryReplacer            JuryReplacer
+	jurorRecuser            JurorRecuser
+	reputationScorer        ReputationScorer
 	disputeFinder           DisputeFinder
+	eventRecorder           DisputeEventRecorder
 	msgSender               MessageSender
 	txMonitor               TransactionMonitor
+	txRunner                TxRunner
+	opRecorder              OperationRecorder
+	opStore                 PendingOperationStore
+	walletStore             UserWalletStore
 }
 
 func NewInvestigationService(repo *repository.Repository, log log.Logger, msgSender MessageSender,
@@ -74,6 +129,14 @@ func NewInvestigationService(repo *repository.Repository, log log.Logger, msgSen
 	if log == nil {
 		return InvestigationService{}, fmt.Errorf("logger is nil")
 	}
+	jurySrv, err := NewJuryService(repo, log, msgSender)
+	if err != nil {
+		return InvestigationService{}, fmt.Errorf("failed to create jury service: %w", err)
+	}
+	reputationSrv, err := NewReputationService(repo, log)
+	if err != nil {
+		return InvestigationService{}, fmt.Errorf("failed to create reputation service: %w", err)
+	}
 
 	return InvestigationService{
 		logger:                  log,
@@ -82,15 +145,28 @@ func NewInvestigationService(repo *repository.Repository, log log.Logger, msgSen
 		investigationReadFinder: repo,
 		investigationUpdater:    repo,
 		investigationDeleter:    repo,
+		investigationExpirer:    repo,
+		investigationLocker:     repo,
+		voteCounter:             repo,
 		userFinder:              repo,
 		userUpdater:             repo,
-		participantUpdater:      repo,
-		participantGetter:       repo,
+		participantUpdater:      transitionUpdater{repo},
+		participantLister:       repo,
 		jurorFinder:             repo,
 		jurorUpdater:            repo,
 		jurorSeener:             repo,
+		jurorCommitCounter:      repo,
+		ballotLister:            repo,
+		juryReplacer:            jurySrv,
+		jurorRecuser:            repo,
+		reputationScorer:        reputationSrv,
 		disputeFinder:           repo,
+		eventRecorder:           repo,
 		msgSender:               msgSender,
+		txRunner:                repo,
+		opRecorder:              repo,
+		opStore:                 repo,
+		walletStore:             repo,
 	}, nil
 }
 
@@ -99,6 +175,19 @@ func (s InvestigationService) WithTransactionMonitor(txMonitor TransactionMonito
 	return s
 }
 
+func (s InvestigationService) operations() operationRunner {
+	return operationRunner{
+		txMonitor:  s.txMonitor,
+		txRunner:   s.txRunner,
+		recorder:   s.opRecorder,
+		store:      s.opStore,
+		wallets:    s.walletStore,
+		userFinder: s.userFinder,
+		msgSender:  s.msgSender,
+		logger:     s.logger,
+	}
+}
+
 func (s InvestigationService) ListInvestigation(ctx context.Context, opts models.InvestigationListOpts,
 	actorUsername string,
 ) ([]models.InvestigationCard, error) {
@@ -127,222 +216,516 @@ func (s InvestigationService) GetInvestigation(ctx context.Context, id, actorUse
 		return models.InvestigationDetails{}, fmt.Errorf("failed to get investigation: %w", err)
 	}
 
+	investigation.Phase = investigation.PhaseAt(time.Now())
+	if investigation.Phase == models.VotingPhaseCommit {
+		investigation.HideTallies()
+	}
+
 	return investigation, nil
 }
 
-func (s InvestigationService) VoteInvestigation(ctx context.Context, investigationID, username, vote, boc string) error {
-	if s.txMonitor == nil {
-		return fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
+// CommitVote records the hash of the juror's vote and salt in the commit phase of a commit-reveal investigation.
+// A juror may change the commitment until the phase closes. The reveal phase opens early once every juror
+// committed.
+func (s InvestigationService) CommitVote(ctx context.Context, investigationID, username, commitment string) error {
+	invUUID, err := uuid.Parse(investigationID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid investigation ID format: %v", ErrValidation, err)
 	}
-	if err := s.txMonitor.WaitForSuccess(ctx, boc); err != nil {
-		return err
+	commitment, err = models.ParseVoteCommitment(commitment)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
 	}
-
 	user, err := s.userFinder.GetUserByUsername(ctx, username)
 	if err != nil {
 		return fmt.Errorf("failed to get user by username: %w", err)
 	}
 
-	invUUID, err := uuid.Parse(investigationID)
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		investigation, err := s.investigationFinder.GetInvestigation(ctx, invUUID, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get investigation: %w", err)
+		}
+		now := time.Now()
+		if phase := investigation.PhaseAt(now); phase != models.VotingPhaseCommit {
+			return fmt.Errorf("%w: investigation doesn't take commitments in the %s phase", ErrValidation, phase)
+		}
+		juror, err := s.jurorFinder.GetJuror(ctx, invUUID, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get juror: %w", err)
+		}
+		opts := models.JurorUpdateOpts{ID: juror.ID, Commitment: &commitment, SeenAt: new(true)}
+		if err = s.jurorUpdater.UpdateJuror(ctx, opts); err != nil {
+			return fmt.Errorf("failed to update juror: %w", err)
+		}
+
+		commits, err := s.jurorCommitCounter.CountJurorCommitments(ctx, invUUID)
+		if err != nil {
+			return err
+		}
+		s.logger.Info("investigation vote committed", zap.String("investigation_id", investigationID),
+			zap.String("username", username), zap.Int("commits", commits), zap.Int("total", investigation.Total))
+		if commits < investigation.Total {
+			return nil
+		}
+		endsAt := now.Add(models.InvestigationRevealDuration)
+		if endsAt.After(investigation.EndsAt) {
+			endsAt = investigation.EndsAt
+		}
+		invUpdateOpts := models.InvestigationUpdateOpts{ID: invUUID, CommitEndsAt: &now, EndsAt: &endsAt}
+		if err = s.investigationUpdater.UpdateInvestigation(ctx, invUpdateOpts); err != nil {
+			return fmt.Errorf("failed to open reveal phase: %w", err)
+		}
+		return nil
+	})
+}
+
+// checkVote makes sure the juror can cast the vote now: any time in an open investigation, and only in the reveal
+// phase with the vote and salt committed to in a commit-reveal one.
+func checkVote(investigation models.Investigation, juror models.Juror, vote, salt string, now time.Time) error {
+	switch phase := investigation.PhaseAt(now); phase {
+	case models.VotingPhaseOpen:
+		return nil
+	case models.VotingPhaseReveal:
+	default:
+		return fmt.Errorf("%w: investigation doesn't take votes in the %s phase", ErrValidation, phase)
+	}
+	if juror.Commitment == nil {
+		return fmt.Errorf("%w: juror didn't commit a vote", ErrValidation)
+	}
+	if models.VoteCommitment(vote, salt) != *juror.Commitment {
+		return fmt.Errorf("%w: vote doesn't match the commitment", ErrValidation)
+	}
+	return nil
+}
+
+// checkRationale returns the trimmed rationale of a vote, nil when there is none, and rejects a missing one when the
+// verdict policy of the investigation requires it.
+func checkRationale(investigation models.Investigation, rationale string) (*string, error) {
+	parsed, err := models.ParseRationale(rationale)
 	if err != nil {
-		return fmt.Errorf("invalid investigation ID format: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
 	}
+	if parsed == nil && investigation.VerdictPolicy.RequireRationale {
+		return nil, fmt.Errorf("%w: the investigation requires a rationale with the vote", ErrValidation)
+	}
+	return parsed, nil
+}
 
-	juror, err := s.jurorFinder.GetJuror(ctx, invUUID, user.ID)
+// investigationVotePayload is what VoteInvestigation stores with its pending operation.
+type investigationVotePayload struct {
+	Vote      string `json:"vote"`
+	Salt      string `json:"salt,omitempty"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// VoteInvestigation casts the juror's vote, or reveals it in the reveal phase of a commit-reveal investigation
+// together with the salt it was committed with. The rationale explains the vote to the parties once the
+// investigation closes.
+func (s InvestigationService) VoteInvestigation(ctx context.Context, investigationID, username, vote, salt,
+	rationale, boc string,
+) (models.PendingOperation, error) {
+	want, err := s.voteMessage(ctx, investigationID, username, vote, salt, rationale)
 	if err != nil {
-		return fmt.Errorf("failed to get investigation: %w", err)
+		return models.PendingOperation{}, err
 	}
+	payload := investigationVotePayload{Vote: vote, Salt: salt, Rationale: rationale}
+	return s.operations().submit(ctx, username, models.OperationActionVoteInvestigation, investigationID, boc, want,
+		payload, func(ctx context.Context) error {
+			return s.voteInvestigation(ctx, investigationID, username, vote, salt, rationale)
+		})
+}
 
-	opts := models.JurorUpdateOpts{
-		ID:     juror.ID,
-		Vote:   &vote,
-		Result: new(models.InvestigationResultSent),
-		SeenAt: new(true),
+// voteMessage returns the JurorVote message a juror sends to the investigation deployed by the dispute's bet.
+// A vote the investigation can't take is rejected before the juror signs anything.
+func (s InvestigationService) voteMessage(ctx context.Context, investigationID, username, vote, salt,
+	rationale string,
+) (models.ExpectedMessage, error) {
+	invUUID, err := uuid.Parse(investigationID)
+	if err != nil {
+		return models.ExpectedMessage{}, fmt.Errorf("%w: invalid investigation ID format: %v", ErrValidation, err)
 	}
-	err = s.jurorUpdater.UpdateJuror(ctx, opts)
+	user, err := s.userFinder.GetUserByUsername(ctx, username)
+	if err != nil {
+		return models.ExpectedMessage{}, fmt.Errorf("failed to get user by username: %w", err)
+	}
+	investigation, err := s.investigationFinder.GetInvestigation(ctx, invUUID, user.ID)
+	if err != nil {
+		return models.ExpectedMessage{}, fmt.Errorf("failed to get investigation: %w", err)
+	}
+	juror, err := s.jurorFinder.GetJuror(ctx, invUUID, user.ID)
 	if err != nil {
-		return fmt.Errorf("failed to update jurors: %w", err)
+		return models.ExpectedMessage{}, fmt.Errorf("failed to get juror: %w", err)
+	}
+	if err = checkVote(investigation, juror, vote, salt, time.Now()); err != nil {
+		return models.ExpectedMessage{}, err
 	}
-	rating := user.Rating + 1
-	usrUpdOpts := models.UserUpdateOpts{
-		Username: user.Username, Rating: &rating,
+	if _, err = checkRationale(investigation, rationale); err != nil {
+		return models.ExpectedMessage{}, err
 	}
-	err = s.userUpdater.UpdateUser(ctx, usrUpdOpts)
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, investigation.DisputeID)
 	if err != nil {
-		return fmt.Errorf("failed to update user rating: %w", err)
+		return models.ExpectedMessage{}, fmt.Errorf("failed to get dispute: %w", err)
 	}
 
-	investigation, err := s.investigationFinder.GetInvestigation(ctx, invUUID, user.ID)
+	option, err := jurorVoteOption(vote)
 	if err != nil {
-		return fmt.Errorf("failed to get investigation: %w", err)
+		return models.ExpectedMessage{}, err
 	}
+	return models.ExpectedMessage{
+		Destination:       dispute.ContractAddress,
+		DestinationGetter: models.InvestigationAddressGetter,
+		Opcodes:           []uint32{models.OpJurorVote},
+		Fields:            []models.MessageField{{Name: "option", Bits: 8, Value: &option}},
+	}, nil
+}
 
+// jurorVoteOption returns the option of the JurorVote message for the vote: p1, p2 or draw.
+func jurorVoteOption(vote string) (uint64, error) {
 	switch vote {
 	case "p1":
-		investigation.P1 += 1
+		return models.JurorVoteP1, nil
 	case "p2":
-		investigation.P2 += 1
-	default:
-		investigation.Draw += 1
+		return models.JurorVoteP2, nil
+	case "draw":
+		return models.JurorVoteDraw, nil
 	}
+	return 0, fmt.Errorf("%w: vote must be p1, p2 or draw, got %q", ErrValidation, vote)
+}
 
-	invUpdateOpts := models.InvestigationUpdateOpts{
-		ID:   investigation.ID,
-		P1:   &investigation.P1,
-		P2:   &investigation.P2,
-		Draw: &investigation.Draw,
+// ApplyOperation applies a confirmed operation recorded by VoteInvestigation.
+func (s InvestigationService) ApplyOperation(ctx context.Context, op models.PendingOperation) error {
+	if op.Action != models.OperationActionVoteInvestigation {
+		return fmt.Errorf("%w: unsupported investigation operation %s", ErrValidation, op.Action)
 	}
-	if err = s.investigationUpdater.UpdateInvestigation(ctx, invUpdateOpts); err != nil {
-		return fmt.Errorf("failed to update investigation: %w", err)
-	}
-	if investigation.P1+investigation.P2+investigation.Draw != investigation.Total {
-		return nil
+	var payload investigationVotePayload
+	if err := decodePayload(op, &payload); err != nil {
+		return err
 	}
+	return s.operations().apply(ctx, op, func(ctx context.Context, username string) error {
+		return s.voteInvestigation(ctx, op.EntityID, username, payload.Vote, payload.Salt, payload.Rationale)
+	})
+}
 
-	s.logger.Info("investigation vote added", zap.String("investigation_id", investigationID), zap.String("username", username))
-
-	invUpdateOpts.Status = new(models.InvestigationStatusPassed)
-	if err = s.investigationUpdater.UpdateInvestigation(ctx, invUpdateOpts); err != nil {
-		return fmt.Errorf("failed to update investigation: %w", err)
+// voteInvestigation counts the vote of a juror. The investigation stays locked for the rest of the transaction, so
+// concurrent votes are counted one at a time from the votes stored per juror and only the last one finalizes it.
+// Like the Investigation contract, it is decided once it has as many votes as its total.
+func (s InvestigationService) voteInvestigation(ctx context.Context, investigationID, username, vote, salt,
+	rationale string,
+) error {
+	user, err := s.userFinder.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user by username: %w", err)
 	}
 
-	if err = s.investigationDeleter.DeleteUsersWithoutVote(ctx, investigation.ID); err != nil {
-		return fmt.Errorf("failed to delete users without vote: %w", err)
+	invUUID, err := uuid.Parse(investigationID)
+	if err != nil {
+		return fmt.Errorf("invalid investigation ID format: %w", err)
 	}
 
-	var res string
-	if investigation.P1 > investigation.P2 && investigation.P1 > investigation.Draw {
-		res = "p1"
-	} else if investigation.P2 > investigation.P1 && investigation.P2 > investigation.Draw {
-		res = "p2"
-	} else {
-		res = "draw"
+	investigation, err := s.investigationLocker.LockInvestigation(ctx, invUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get investigation: %w", err)
 	}
-
-	winnerIDs, err := s.jurorFinder.GetWinnersIDs(ctx, investigation.ID, res)
+	juror, err := s.jurorFinder.GetJuror(ctx, invUUID, user.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get winners IDs: %w", err)
+		return fmt.Errorf("failed to get juror: %w", err)
 	}
-	if err = s.userUpdater.EarnWinnerRating(ctx, winnerIDs); err != nil {
-		return fmt.Errorf("failed to update winner rating: %w", err)
+	if investigation.Status == models.InvestigationStatusPassed {
+		return fmt.Errorf("%w: investigation is already closed", ErrValidation)
 	}
-	if err = s.jurorUpdater.UpdateWinnersResult(ctx, investigation.ID, winnerIDs); err != nil {
-		return fmt.Errorf("failed to update winners result: %w", err)
+	if err = checkVote(investigation, juror, vote, salt, time.Now()); err != nil {
+		return err
 	}
-	users, err := s.jurorFinder.GetDisputesUsers(ctx, investigation.ID)
+	parsedRationale, err := checkRationale(investigation, rationale)
 	if err != nil {
-		return fmt.Errorf("failed to get users from investigation: %w", err)
+		return err
+	}
+	if _, err = jurorVoteOption(vote); err != nil {
+		return err
 	}
 
-	participantP1, err := s.participantGetter.GetParticipant(ctx, investigation.DisputeID, users[0].ID)
-	if err != nil {
-		return fmt.Errorf("failed to get participants for user1: %w", err)
+	err = s.voteCounter.CastJurorVote(ctx, juror.ID, vote, parsedRationale)
+	switch {
+	case errors.Is(err, repository.ErrAlreadyVoted):
+		return fmt.Errorf("%w: juror already voted", ErrValidation)
+	case err != nil:
+		return fmt.Errorf("failed to cast juror vote: %w", err)
 	}
-	participantP2, err := s.participantGetter.GetParticipant(ctx, investigation.DisputeID, users[1].ID)
+	counts, err := s.voteCounter.RecountInvestigationVotes(ctx, investigation.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get participants for user2: %w", err)
+		return err
+	}
+	investigation.P1, investigation.P2, investigation.Draw = counts.P1, counts.P2, counts.Draw
+	s.logger.Info("investigation vote added", zap.String("investigation_id", investigationID),
+		zap.String("username", username), zap.Int("votes", counts.Total), zap.Int("total", investigation.Total))
+	if counts.Total < investigation.Total {
+		return nil
 	}
 
-	dispute, err := s.disputeFinder.GetDisputeByID(ctx, investigation.DisputeID)
+	if err = s.finalizeInvestigation(ctx, investigation); err != nil {
+		return err
+	}
+
+	s.logger.Info("investigation finalized by the last vote", zap.String("investigation_id", investigationID))
+	return nil
+}
+
+// RecuseJuror takes a juror who hasn't voted off the jury of the investigation and draws an alternate for the seat,
+// so the investigation doesn't wait for a vote that won't come. Without an alternate the seat stays vacant until the
+// jury service fills it. Recusals count as missed seats for the juror's reputation.
+func (s InvestigationService) RecuseJuror(ctx context.Context, investigationID, username, reason string) error {
+	invUUID, err := uuid.Parse(investigationID)
 	if err != nil {
-		return fmt.Errorf("failed to get dispute by ID: %w", err)
+		return fmt.Errorf("%w: invalid investigation ID format: %v", ErrValidation, err)
+	}
+	user, err := s.userFinder.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user by username: %w", err)
 	}
-	if res == "draw" {
-		participantUpdateOpts := models.ParticipantUpdateOpts{
-			ID:          participantP1.ID,
-			Status:      new(models.DisputesStatusPassed),
-			Result:      new(models.DisputesResultDraw),
-			IsClaimable: new(true),
+
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		investigation, err := s.investigationLocker.LockInvestigation(ctx, invUUID)
+		if err != nil {
+			return fmt.Errorf("failed to get investigation: %w", err)
 		}
-		if err = s.participantUpdater.UpdateParticipant(ctx, participantUpdateOpts); err != nil {
-			return fmt.Errorf("failed to update participants: %w", err)
+		juror, err := s.jurorFinder.GetJuror(ctx, invUUID, user.ID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return fmt.Errorf("%w: user isn't a juror of the investigation", ErrValidation)
+		case err != nil:
+			return fmt.Errorf("failed to get juror: %w", err)
 		}
-		participantUpdateOpts.ID = participantP2.ID
-		if err = s.participantUpdater.UpdateParticipant(ctx, participantUpdateOpts); err != nil {
-			return fmt.Errorf("failed to update participants: %w", err)
+		now := time.Now()
+		phase := investigation.PhaseAt(now)
+		if phase == models.VotingPhaseClosed {
+			return fmt.Errorf("%w: investigation is already closed", ErrValidation)
 		}
-		if users[0].NotificationEnabled {
-			msg := fmt.Sprintf("Расследование %s завершилось ничьей, вы можете забрать свою ставку!", dispute.Title)
-			if err = s.msgSender.SendMessage(users[0].ChatID, msg); err != nil {
-				return fmt.Errorf("failed to send message to user: %w", err)
-			}
+		if juror.Vote != "" {
+			return fmt.Errorf("%w: juror already voted", ErrValidation)
 		}
-		if users[1].NotificationEnabled {
-			msg := fmt.Sprintf("Расследование %s завершилось ничьей, вы можете забрать свою ставку!", dispute.Title)
-			if err = s.msgSender.SendMessage(users[1].ChatID, msg); err != nil {
-				return fmt.Errorf("failed to send message to user: %w", err)
+		recusal, err := models.NewJurorRecusal(investigation, user.ID, reason, now)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		err = s.jurorRecuser.RecuseJuror(ctx, juror.ID, recusal)
+		switch {
+		case errors.Is(err, repository.ErrAlreadyVoted):
+			return fmt.Errorf("%w: juror already voted", ErrValidation)
+		case err != nil:
+			return fmt.Errorf("failed to recuse juror: %w", err)
+		}
+		var alternates []uuid.UUID
+		if phase != models.VotingPhaseReveal {
+			// An alternate drawn in the reveal phase couldn't commit a vote anymore.
+			if alternates, err = s.juryReplacer.ReplaceJurors(ctx, investigation, 1); err != nil {
+				return fmt.Errorf("failed to replace juror: %w", err)
 			}
 		}
+		s.logger.Info("juror recused", zap.String("investigation_id", investigationID),
+			zap.String("username", username), zap.Int("alternates", len(alternates)))
 		return nil
+	})
+}
+
+// ExtendExpiredInvestigations gives investigations whose EndsAt has passed without all the votes they need another
+// round. The Investigation contract only resolves once it has as many votes as it requires, so an investigation is
+// never closed off-chain: jurors who did not vote or reveal their commitment lose their seat, the jury service draws
+// alternates for it and the voting starts over.
+func (s InvestigationService) ExtendExpiredInvestigations(ctx context.Context) error {
+	now := time.Now()
+	investigations, err := s.investigationExpirer.ClaimExpiredInvestigations(ctx, now,
+		now.Add(defaultInvestigationLockTimeout), defaultInvestigationBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim expired investigations: %w", err)
 	}
 
-	if res == "p1" {
-		participantUpdateOpts := models.ParticipantUpdateOpts{
-			ID:          participantP1.ID,
-			Status:      new(models.DisputesStatusPassed),
-			Result:      new(models.DisputesResultWin),
-			IsClaimable: new(true),
-		}
-		if err = s.participantUpdater.UpdateParticipant(ctx, participantUpdateOpts); err != nil {
-			return fmt.Errorf("failed to update participants: %w", err)
+	for _, investigation := range investigations {
+		err = inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+			return s.extendExpiredInvestigation(ctx, investigation, now)
+		})
+		if err != nil {
+			s.logger.Error("failed to extend expired investigation",
+				zap.String("investigation_id", investigation.ID.String()), zap.Error(err))
 		}
+	}
+	return nil
+}
 
-		participantUpdateOpts.ID = participantP2.ID
-		participantUpdateOpts.Result = new(models.DisputesResultLose)
-		participantUpdateOpts.IsClaimable = new(false)
-		if err = s.participantUpdater.UpdateParticipant(ctx, participantUpdateOpts); err != nil {
-			return fmt.Errorf("failed to update participants: %w", err)
-		}
-		if users[0].NotificationEnabled {
-			msg := fmt.Sprintf("Расследование %s завершилось победой, вы можете забрать свою ставку!", dispute.Title)
-			if err = s.msgSender.SendMessage(users[0].ChatID, msg); err != nil {
-				return fmt.Errorf("failed to send message to user: %w", err)
-			}
-		}
+// RunExpiry extends expired investigations every interval until ctx is cancelled.
+func (s InvestigationService) RunExpiry(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, s.logger, "investigation-expiry", interval, s.ExtendExpiredInvestigations)
+}
+
+func (s InvestigationService) extendExpiredInvestigation(ctx context.Context, investigation models.Investigation,
+	now time.Time,
+) error {
+	investigation, err := s.investigationLocker.LockInvestigation(ctx, investigation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to lock investigation: %w", err)
+	}
+	if investigation.Status == models.InvestigationStatusPassed || investigation.EndsAt.After(now) {
+		// The last vote closed the investigation or it was extended since it was claimed.
 		return nil
 	}
+	if err = s.investigationDeleter.DeleteUsersWithoutVote(ctx, investigation.ID); err != nil {
+		return fmt.Errorf("failed to delete users without vote: %w", err)
+	}
+
+	endsAt := now.Add(models.InvestigationDuration)
+	opts := models.InvestigationUpdateOpts{ID: investigation.ID, EndsAt: &endsAt}
+	if investigation.VotingMode == models.VotingModeCommitReveal {
+		// Both phases start over so the alternates can commit; the votes already revealed stay.
+		opts.CommitEndsAt = new(now.Add(models.InvestigationCommitDuration))
+	}
+	if err = s.investigationUpdater.UpdateInvestigation(ctx, opts); err != nil {
+		return fmt.Errorf("failed to extend investigation: %w", err)
+	}
+	s.logger.Info("expired investigation extended", zap.String("investigation_id", investigation.ID.String()),
+		zap.Int("votes", investigation.P1+investigation.P2+investigation.Draw), zap.Int("total", investigation.Total),
+		zap.Time("ends_at", endsAt))
+	return nil
+}
+
+// verdict counts the juror ballots under the verdict policy of the investigation and returns which participants
+// the winning vote makes win. The creator is p1. A hung jury makes nobody win.
+func (s InvestigationService) verdict(ctx context.Context, investigation models.Investigation,
+	participants []models.Participant,
+) (models.Verdict, func(models.Participant) bool, error) {
+	ballots, err := s.ballotLister.ListJurorBallots(ctx, investigation.ID)
+	if err != nil {
+		return models.Verdict{}, nil, fmt.Errorf("failed to list juror ballots: %w", err)
+	}
+	verdict := investigation.VerdictPolicy.Decide(ballots, investigation.Total)
+	verdict.Round = investigation.Round
+	if verdict.Hung() {
+		return verdict, func(models.Participant) bool { return false }, nil
+	}
+
+	if len(participants) != 2 {
+		return models.Verdict{}, nil, fmt.Errorf("expected 2 participants, got %d", len(participants))
+	}
+	return verdict, func(p models.Participant) bool {
+		return (verdict.Vote == "p1" && p.ID == participants[0].ID) ||
+			(verdict.Vote == "p2" && p.ID == participants[1].ID)
+	}, nil
+}
+
+// finalizeInvestigation decides the investigation with the votes cast: the investigation closes, jurors who picked
+// the verdict are rewarded and every dispute participant is settled. A hung jury rewards no juror and settles the
+// dispute as a draw, like the Bet contract does with an undecided investigation.
+func (s InvestigationService) finalizeInvestigation(ctx context.Context, investigation models.Investigation) error {
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, investigation.DisputeID)
+	if err != nil {
+		return fmt.Errorf("failed to get dispute by ID: %w", err)
+	}
+	participants, err := s.participantLister.ListParticipants(ctx, investigation.DisputeID)
+	if err != nil {
+		return fmt.Errorf("failed to list dispute participants: %w", err)
+	}
+	verdict, won, err := s.verdict(ctx, investigation, participants)
+	if err != nil {
+		return err
+	}
+	invUpdateOpts := models.InvestigationUpdateOpts{
+		ID:      investigation.ID,
+		P1:      &investigation.P1,
+		P2:      &investigation.P2,
+		Draw:    &investigation.Draw,
+		Total:   &investigation.Total,
+		Status:  new(models.InvestigationStatusPassed),
+		Verdict: &verdict,
+	}
+	if err := s.investigationUpdater.UpdateInvestigation(ctx, invUpdateOpts); err != nil {
+		return fmt.Errorf("failed to update investigation: %w", err)
+	}
+	event := models.NewDisputeEvent(investigation.DisputeID, models.DisputeActionCloseInvestigation, models.ActorSystem)
+	if err := s.eventRecorder.InsertDisputeEvent(ctx, event); err != nil {
+		return err
+	}
 
-	// res == p2
-	participantUpdateOpts := models.ParticipantUpdateOpts{
-		ID:          participantP2.ID,
-		Status:      new(models.DisputesStatusPassed),
-		Result:      new(models.DisputesResultWin),
-		IsClaimable: new(true),
+	if err := s.investigationDeleter.DeleteUsersWithoutVote(ctx, investigation.ID); err != nil {
+		return fmt.Errorf("failed to delete users without vote: %w", err)
 	}
-	if err = s.participantUpdater.UpdateParticipant(ctx, participantUpdateOpts); err != nil {
-		return fmt.Errorf("failed to update participants: %w", err)
+
+	// The contract pays the jurors only for a decisive verdict, so nobody picked the verdict of a hung jury.
+	var winnerIDs []uuid.UUID
+	if !verdict.Hung() {
+		if winnerIDs, err = s.jurorFinder.GetWinnersIDs(ctx, investigation.ID, verdict.Vote); err != nil {
+			return fmt.Errorf("failed to get winners IDs: %w", err)
+		}
+	}
+	if err = s.jurorUpdater.UpdateWinnersResult(ctx, investigation.ID, winnerIDs); err != nil {
+		return fmt.Errorf("failed to update winners result: %w", err)
+	}
+	if err = s.reputationScorer.RescoreJurors(ctx, investigation.ID); err != nil {
+		return fmt.Errorf("failed to rescore jurors: %w", err)
 	}
 
-	participantUpdateOpts.ID = participantP1.ID
-	participantUpdateOpts.Result = new(models.DisputesResultLose)
-	participantUpdateOpts.IsClaimable = new(false)
-	if err = s.participantUpdater.UpdateParticipant(ctx, participantUpdateOpts); err != nil {
-		return fmt.Errorf("failed to update participants: %w", err)
+	// The deposit of a participant who loses the investigation pays the jurors.
+	winners, err := settleParticipants(ctx, s.participantUpdater, participants, won)
+	if err != nil {
+		return err
 	}
-	if users[1].NotificationEnabled {
-		msg := fmt.Sprintf("Расследование %s завершилось победой, вы можете забрать свою ставку!", dispute.Title)
-		if err = s.msgSender.SendMessage(users[1].ChatID, msg); err != nil {
-			return fmt.Errorf("failed to send message to user: %w", err)
+	format := "Расследование %s завершилось победой, вы можете забрать свою ставку!"
+	notified := winners
+	if len(winners) == 0 {
+		format = "Расследование %s завершилось ничьей, вы можете забрать свою ставку!"
+		notified = participants
+	}
+	for _, p := range notified {
+		if err = notifyUser(ctx, s.userFinder, s.msgSender, p.UserID, fmt.Sprintf(format, dispute.Title)); err != nil {
+			return err
 		}
 	}
 
 	for _, id := range winnerIDs {
-		u, err := s.userFinder.GetUserByID(ctx, id)
-		if err != nil {
-			return fmt.Errorf("failed to get user by ID: %w", err)
-		}
-		if u.NotificationEnabled {
-			msg := fmt.Sprintf("Вы верно рассмотрели расследование %s выиграли расследование", dispute.Title)
-			if err = s.msgSender.SendMessage(u.ChatID, msg); err != nil {
-				return fmt.Errorf("failed to send message to user: %w", err)
-			}
+		msg := fmt.Sprintf("Вы верно рассмотрели расследование %s выиграли расследование", dispute.Title)
+		if err = notifyUser(ctx, s.userFinder, s.msgSender, id, msg); err != nil {
+			return err
 		}
 	}
 
-	s.logger.Info("vote added to investigation", zap.String("investigation_id", investigationID), zap.String("username", username))
 	return nil
 }
 
+// settleParticipants closes the dispute for both participants: the one won picks wins and the other loses. When
+// nobody wins, both get a draw and take the stake back. The deposit of the loser pays the jurors, so only the winner
+// can claim.
+func settleParticipants(ctx context.Context, updater ParticipantUpdater, participants []models.Participant,
+	won func(models.Participant) bool,
+) (winners []models.Participant, err error) {
+	for _, p := range participants {
+		if won(p) {
+			winners = append(winners, p)
+		}
+	}
+
+	for _, p := range participants {
+		result, isWin := models.DisputesResultLose, won(p)
+		switch {
+		case len(winners) == 0:
+			result = models.DisputesResultDraw
+		case isWin:
+			result = models.DisputesResultWin
+		}
+		opts, err := transition(p, models.DisputeActionSettle, models.ActorSystem, result)
+		if err != nil {
+			return nil, err
+		}
+		opts.Status = new(models.DisputesStatusPassed)
+		opts.IsClaimable = new(result != models.DisputesResultLose)
+		if isWin {
+			opts.IsWin = new(true)
+		}
+		if err = updater.UpdateParticipant(ctx, opts); err != nil {
+			return nil, fmt.Errorf("failed to settle participant: %w", err)
+		}
+	}
+	return winners, nil
+}
+
 func (s InvestigationService) MarkInvestigationsSeen(ctx context.Context, actorUsername string, investigationIDs []string,
 ) error {
 	ids := make([]uuid.UUID, 0, len(investigationIDs))
@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
 )
 
 type fakeInvestigationDeps struct {
@@ -13,6 +16,8 @@ type fakeInvestigationDeps struct {
 	participant      models.Juror
 	investigation    models.Investigation
 	winners          []uuid.UUID
+	winnerVote       string
+	rewarded         []uuid.UUID
 	disputeUsers     []models.User
 	participantByUser        map[uuid.UUID]models.Participant
 	dispute          models.Dispute
@@ -26,8 +31,44 @@ type fakeInvestigationDeps struct {
 	updatedInv      []models.InvestigationUpdateOpts
 	updatedDP      []models.ParticipantUpdateOpts
 	deleteNoVoteCnt int
-	earnWinnerCnt   int
+	rescoreCnt      int
 	updateWinnerCnt int
+	events          []models.DisputeEvent
+	commits         int
+	ballots         []models.Ballot
+	cast            []string
+	castErr         error
+	rationales      []*string
+	recusals        []models.JurorRecusal
+	alternates      int
+	replacedBy      int
+}
+
+func (f *fakeInvestigationDeps) ListJurorBallots(context.Context, uuid.UUID) ([]models.Ballot, error) {
+	return f.ballots, nil
+}
+
+func (f *fakeInvestigationDeps) RecuseJuror(_ context.Context, _ uuid.UUID, recusal models.JurorRecusal) error {
+	f.recusals = append(f.recusals, recusal)
+	return nil
+}
+
+// ReplaceJurors draws up to size of the available alternates onto the jury.
+func (f *fakeInvestigationDeps) ReplaceJurors(_ context.Context, _ models.Investigation, size int,
+) ([]uuid.UUID, error) {
+	drawn := min(size, f.alternates)
+	f.alternates -= drawn
+	f.replacedBy += drawn
+	return make([]uuid.UUID, drawn), nil
+}
+
+func (f *fakeInvestigationDeps) CountJurorCommitments(context.Context, uuid.UUID) (int, error) {
+	return f.commits, nil
+}
+
+func (f *fakeInvestigationDeps) InsertDisputeEvent(_ context.Context, event models.DisputeEvent) error {
+	f.events = append(f.events, event)
+	return nil
 }
 
 func (f *fakeInvestigationDeps) InsertInvestigation(context.Context, models.Investigation) error {
@@ -63,21 +104,57 @@ func (f *fakeInvestigationDeps) DeleteUsersWithoutVote(context.Context, uuid.UUI
 func (f *fakeInvestigationDeps) GetJuror(context.Context, uuid.UUID, uuid.UUID) (models.Juror, error) {
 	return f.participant, nil
 }
-func (f *fakeInvestigationDeps) GetWinnersIDs(context.Context, uuid.UUID, string) ([]uuid.UUID, error) {
+func (f *fakeInvestigationDeps) GetWinnersIDs(_ context.Context, _ uuid.UUID, vote string) ([]uuid.UUID, error) {
+	f.winnerVote = vote
 	return f.winners, nil
 }
-func (f *fakeInvestigationDeps) GetDisputesUsers(context.Context, uuid.UUID) ([]models.User, error) {
-	return f.disputeUsers, nil
+func (f *fakeInvestigationDeps) LockInvestigation(context.Context, uuid.UUID) (models.Investigation, error) {
+	return f.investigation, nil
+}
+func (f *fakeInvestigationDeps) CastJurorVote(_ context.Context, _ uuid.UUID, vote string, rationale *string) error {
+	if f.castErr != nil {
+		return f.castErr
+	}
+	f.cast = append(f.cast, vote)
+	f.rationales = append(f.rationales, rationale)
+	return nil
+}
+
+// RecountInvestigationVotes counts the ballots, which hold every stored vote including the ones just cast.
+func (f *fakeInvestigationDeps) RecountInvestigationVotes(context.Context, uuid.UUID) (models.VoteCounts, error) {
+	return countBallots(f.ballots), nil
+}
+
+func countBallots(ballots []models.Ballot) models.VoteCounts {
+	counts := models.VoteCounts{Total: len(ballots)}
+	for _, b := range ballots {
+		switch b.Vote {
+		case "p1":
+			counts.P1++
+		case "p2":
+			counts.P2++
+		case "draw":
+			counts.Draw++
+		}
+	}
+	return counts
 }
+
 func (f *fakeInvestigationDeps) UpdateJuror(_ context.Context, opts models.JurorUpdateOpts) error {
 	f.updatedParticipants = append(f.updatedParticipants, opts)
 	return nil
 }
-func (f *fakeInvestigationDeps) UpdateWinnersResult(context.Context, uuid.UUID, []uuid.UUID) error {
+func (f *fakeInvestigationDeps) UpdateWinnersResult(_ context.Context, _ uuid.UUID, ids []uuid.UUID) error {
 	f.updateWinnerCnt++
+	f.rewarded = ids
 	return nil
 }
-func (f *fakeInvestigationDeps) GetUserByID(context.Context, uuid.UUID) (models.User, error) {
+func (f *fakeInvestigationDeps) GetUserByID(_ context.Context, id uuid.UUID) (models.User, error) {
+	for _, u := range f.disputeUsers {
+		if u.ID == id {
+			return u, nil
+		}
+	}
 	return models.User{}, nil
 }
 func (f *fakeInvestigationDeps) GetUserByUsername(context.Context, string) (models.User, error) {
@@ -97,17 +174,22 @@ func (f *fakeInvestigationDeps) UpdateUser(context.Context, models.UserUpdateOpt
 func (f *fakeInvestigationDeps) UpdateUserPhotoURL(context.Context, string, *string) error {
 	return nil
 }
-func (f *fakeInvestigationDeps) EarnWinnerRating(context.Context, []uuid.UUID) error {
-	f.earnWinnerCnt++
+func (f *fakeInvestigationDeps) RescoreJurors(context.Context, uuid.UUID) error {
+	f.rescoreCnt++
 	return nil
 }
 func (f *fakeInvestigationDeps) UpdateParticipant(_ context.Context, opts models.ParticipantUpdateOpts) error {
 	f.updatedDP = append(f.updatedDP, opts)
 	return nil
 }
-func (f *fakeInvestigationDeps) GetParticipant(_ context.Context, _ uuid.UUID, userID uuid.UUID,
-) (models.Participant, error) {
-	return f.participantByUser[userID], nil
+func (f *fakeInvestigationDeps) ListParticipants(context.Context, uuid.UUID) ([]models.Participant, error) {
+	participants := make([]models.Participant, 0, len(f.disputeUsers))
+	for _, u := range f.disputeUsers {
+		p := f.participantByUser[u.ID]
+		p.UserID = u.ID
+		participants = append(participants, p)
+	}
+	return participants, nil
 }
 func (f *fakeInvestigationDeps) GetDisputeByID(context.Context, uuid.UUID) (models.Dispute, error) {
 	return f.dispute, nil
@@ -156,7 +238,9 @@ func TestInvestigationServiceVoteInvestigationNonFinal(t *testing.T) {
 		user:          models.User{ID: userID, Username: "alice", Rating: 3},
 		participant:   models.Juror{ID: uuid.New()},
 		investigation: models.Investigation{ID: invID, DisputeID: uuid.New(), Total: 3, P1: 1, P2: 0, Draw: 0},
+		dispute:       models.Dispute{ContractAddress: "bet"},
 	}
+	txMonitor := &fakeTxMonitor{}
 	svc := InvestigationService{
 		logger:               noopLogger{},
 		userFinder:           deps,
@@ -164,25 +248,169 @@ func TestInvestigationServiceVoteInvestigationNonFinal(t *testing.T) {
 		jurorUpdater: deps,
 		userUpdater:          deps,
 		investigationFinder:  deps,
+		investigationLocker:  deps,
+		voteCounter:          deps,
 		investigationUpdater: deps,
-		txMonitor:            &fakeTxMonitor{},
+		disputeFinder:        deps,
+		eventRecorder:        deps,
+		txMonitor:            txMonitor,
 	}
 
-	err := svc.VoteInvestigation(context.Background(), invID.String(), "alice", "p2", "boc")
+	_, err := svc.VoteInvestigation(context.Background(), invID.String(), "alice", "p2", "", "", "boc")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(deps.updatedParticipants) != 1 {
-		t.Fatalf("expected 1 participant update, got %d", len(deps.updatedParticipants))
+	want := txMonitor.want
+	if want.Destination != "bet" || want.DestinationGetter != models.InvestigationAddressGetter ||
+		len(want.Fields) != 1 || *want.Fields[0].Value != models.JurorVoteP2 {
+		t.Fatalf("unexpected expected message: %+v", want)
+	}
+	if len(deps.cast) != 1 || deps.cast[0] != "p2" {
+		t.Fatalf("expected the p2 vote cast, got %v", deps.cast)
 	}
-	if len(deps.updatedInv) != 1 {
-		t.Fatalf("expected 1 investigation update, got %d", len(deps.updatedInv))
+	if len(deps.updatedInv) != 0 {
+		t.Fatalf("expected the investigation to stay open, got %+v", deps.updatedInv)
 	}
 	if deps.deleteNoVoteCnt != 0 {
 		t.Fatalf("expected no delete users without vote")
 	}
 }
 
+func TestInvestigationServiceVoteRationale(t *testing.T) {
+	newService := func() (InvestigationService, *fakeInvestigationDeps) {
+		policy := models.DefaultVerdictPolicy
+		policy.RequireRationale = true
+		deps := &fakeInvestigationDeps{
+			user:        models.User{ID: uuid.New(), Username: "alice"},
+			participant: models.Juror{ID: uuid.New()},
+			investigation: models.Investigation{ID: uuid.New(), DisputeID: uuid.New(), Total: 3,
+				VerdictPolicy: policy},
+		}
+		return InvestigationService{
+			logger:              noopLogger{},
+			userFinder:          deps,
+			jurorFinder:         deps,
+			investigationFinder: deps,
+			investigationLocker: deps,
+			voteCounter:         deps,
+			disputeFinder:       deps,
+			txMonitor:           &fakeTxMonitor{},
+		}, deps
+	}
+
+	svc, deps := newService()
+	_, err := svc.VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice", "p1", "", " ",
+		"boc")
+	if !errors.Is(err, ErrValidation) || len(deps.cast) != 0 {
+		t.Fatalf("expected ErrValidation without a rationale, got %v", err)
+	}
+
+	svc, deps = newService()
+	_, err = svc.VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice", "p1", "",
+		" the receipt is forged ", "boc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deps.rationales) != 1 || *deps.rationales[0] != "the receipt is forged" {
+		t.Fatalf("expected the trimmed rationale stored, got %v", deps.rationales)
+	}
+}
+
+func TestInvestigationServiceRecuseJuror(t *testing.T) {
+	newService := func(deps *fakeInvestigationDeps) InvestigationService {
+		deps.user = models.User{ID: uuid.New(), Username: "alice"}
+		deps.dispute = models.Dispute{ID: deps.investigation.DisputeID, Title: "INV"}
+		return InvestigationService{
+			logger:               noopLogger{},
+			userFinder:           deps,
+			jurorFinder:          deps,
+			jurorUpdater:         deps,
+			jurorCommitCounter:   deps,
+			jurorRecuser:         deps,
+			juryReplacer:         deps,
+			ballotLister:         deps,
+			reputationScorer:     deps,
+			investigationLocker:  deps,
+			voteCounter:          deps,
+			investigationUpdater: deps,
+			investigationDeleter: deps,
+			participantLister:    deps,
+			participantUpdater:   deps,
+			disputeFinder:        deps,
+			eventRecorder:        deps,
+			msgSender:            &fakeMessageSender{},
+		}
+	}
+	newInvestigation := func(total int) models.Investigation {
+		return models.Investigation{ID: uuid.New(), DisputeID: uuid.New(), Total: total, Round: 1,
+			Status: models.InvestigationStatusCurrent, EndsAt: time.Now().Add(time.Hour),
+			VerdictPolicy: models.DefaultVerdictPolicy}
+	}
+
+	t.Run("draws an alternate for the seat", func(t *testing.T) {
+		deps := &fakeInvestigationDeps{investigation: newInvestigation(3), participant: models.Juror{ID: uuid.New()},
+			ballots: []models.Ballot{{Vote: "p1"}}, alternates: 2}
+		err := newService(deps).RecuseJuror(context.Background(), deps.investigation.ID.String(), "alice",
+			" I know the creator ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.recusals) != 1 || deps.recusals[0].Reason != "I know the creator" || deps.recusals[0].Round != 1 {
+			t.Fatalf("expected the recusal recorded, got %+v", deps.recusals)
+		}
+		if deps.replacedBy != 1 || deps.investigation.Total != 3 || len(deps.updatedInv) != 0 {
+			t.Fatalf("expected one alternate and the investigation still open, got %d alternates, total %d",
+				deps.replacedBy, deps.investigation.Total)
+		}
+	})
+
+	t.Run("leaves the seat vacant without an alternate", func(t *testing.T) {
+		deps := &fakeInvestigationDeps{investigation: newInvestigation(2), participant: models.Juror{ID: uuid.New()},
+			ballots: []models.Ballot{{Vote: "draw"}}}
+		err := newService(deps).RecuseJuror(context.Background(), deps.investigation.ID.String(), "alice", "busy")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if deps.replacedBy != 0 || len(deps.updatedInv) != 0 || len(deps.updatedDP) != 0 {
+			t.Fatalf("expected the investigation to wait for the vote it needs, got %+v", deps.updatedInv)
+		}
+	})
+
+	t.Run("doesn't draw alternates in the reveal phase", func(t *testing.T) {
+		inv := newInvestigation(3)
+		inv.VotingMode = models.VotingModeCommitReveal
+		inv.CommitEndsAt = new(time.Now().Add(-time.Minute))
+		deps := &fakeInvestigationDeps{investigation: inv, participant: models.Juror{ID: uuid.New()}, alternates: 1,
+			commits: 2}
+		err := newService(deps).RecuseJuror(context.Background(), deps.investigation.ID.String(), "alice", "busy")
+		if err != nil || len(deps.recusals) != 1 || deps.replacedBy != 0 {
+			t.Fatalf("expected the juror recused without an alternate, got %v and %d", err, deps.replacedBy)
+		}
+	})
+
+	for name, deps := range map[string]*fakeInvestigationDeps{
+		"rejects a juror who voted": {investigation: newInvestigation(3),
+			participant: models.Juror{ID: uuid.New(), Vote: "p1"}, alternates: 1},
+		"rejects a closed investigation": {investigation: models.Investigation{ID: uuid.New(), Total: 3,
+			Status: models.InvestigationStatusPassed}, participant: models.Juror{ID: uuid.New()}, alternates: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := newService(deps).RecuseJuror(context.Background(), deps.investigation.ID.String(), "alice", "busy")
+			if !errors.Is(err, ErrValidation) || len(deps.recusals) != 0 || deps.replacedBy != 0 {
+				t.Fatalf("expected ErrValidation without a recusal, got %v", err)
+			}
+		})
+	}
+
+	t.Run("requires a reason", func(t *testing.T) {
+		deps := &fakeInvestigationDeps{investigation: newInvestigation(3), participant: models.Juror{ID: uuid.New()}}
+		err := newService(deps).RecuseJuror(context.Background(), deps.investigation.ID.String(), "alice", "  ")
+		if !errors.Is(err, ErrValidation) || len(deps.recusals) != 0 {
+			t.Fatalf("expected ErrValidation without a recusal, got %v", err)
+		}
+	})
+}
+
 func TestInvestigationServiceVoteInvestigationDrawFinal(t *testing.T) {
 	user1 := models.User{ID: uuid.New(), Username: "u1", NotificationEnabled: true, ChatID: 101}
 	user2 := models.User{ID: uuid.New(), Username: "u2", NotificationEnabled: true, ChatID: 202}
@@ -192,13 +420,14 @@ func TestInvestigationServiceVoteInvestigationDrawFinal(t *testing.T) {
 		user:          user1,
 		participant:   models.Juror{ID: uuid.New()},
 		investigation: models.Investigation{ID: invID, DisputeID: disputeID, Total: 1, P1: 0, P2: 0, Draw: 0},
-		winners:       []uuid.UUID{user1.ID},
+		winners:       []uuid.UUID{uuid.New()},
 		disputeUsers:  []models.User{user1, user2},
 		participantByUser: map[uuid.UUID]models.Participant{
-			user1.ID: {ID: uuid.New()},
-			user2.ID: {ID: uuid.New()},
+			user1.ID: {ID: uuid.New(), Result: models.DisputesResultInspected},
+			user2.ID: {ID: uuid.New(), Result: models.DisputesResultInspected},
 		},
 		dispute: models.Dispute{ID: disputeID, Title: "INV"},
+		ballots: []models.Ballot{{Vote: "draw"}},
 	}
 	sender := &fakeMessageSender{}
 	svc := InvestigationService{
@@ -207,22 +436,27 @@ func TestInvestigationServiceVoteInvestigationDrawFinal(t *testing.T) {
 		userUpdater:          deps,
 		jurorFinder:  deps,
 		jurorUpdater: deps,
+		ballotLister:         deps,
+		reputationScorer:     deps,
 		investigationFinder:  deps,
+		investigationLocker:  deps,
+		voteCounter:          deps,
 		investigationUpdater: deps,
 		investigationDeleter: deps,
-		participantGetter:            deps,
+		participantLister:            deps,
 		participantUpdater:           deps,
 		disputeFinder:        deps,
+		eventRecorder:        deps,
 		msgSender:            sender,
 		txMonitor:            &fakeTxMonitor{},
 	}
 
-	err := svc.VoteInvestigation(context.Background(), invID.String(), user1.Username, "draw", "boc")
+	_, err := svc.VoteInvestigation(context.Background(), invID.String(), user1.Username, "draw", "", "", "boc")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(deps.updatedInv) != 2 {
-		t.Fatalf("expected 2 investigation updates, got %d", len(deps.updatedInv))
+	if len(deps.updatedInv) != 1 || *deps.updatedInv[0].Draw != 1 {
+		t.Fatalf("expected the investigation closed with the draw vote, got %+v", deps.updatedInv)
 	}
 	if deps.deleteNoVoteCnt != 1 {
 		t.Fatalf("expected delete users without vote once, got %d", deps.deleteNoVoteCnt)
@@ -233,4 +467,373 @@ func TestInvestigationServiceVoteInvestigationDrawFinal(t *testing.T) {
 	if sender.calls != 2 {
 		t.Fatalf("expected 2 messages for draw, got %d", sender.calls)
 	}
+	if deps.rescoreCnt != 1 {
+		t.Fatalf("expected the jurors rescored once, got %d", deps.rescoreCnt)
+	}
+}
+
+type fakeInvestigationExpirer struct {
+	*fakeInvestigationDeps
+	expired []models.Investigation
+}
+
+func (f fakeInvestigationExpirer) ClaimExpiredInvestigations(context.Context, time.Time, time.Time, int,
+) ([]models.Investigation, error) {
+	return f.expired, nil
+}
+
+func TestInvestigationServiceExtendExpiredInvestigations(t *testing.T) {
+	disputeID := uuid.New()
+
+	newService := func(inv models.Investigation) (InvestigationService, *fakeInvestigationDeps, *fakeMessageSender) {
+		deps := &fakeInvestigationDeps{
+			dispute:       models.Dispute{ID: disputeID, Title: "INV"},
+			investigation: inv,
+		}
+		sender := &fakeMessageSender{}
+		svc := InvestigationService{
+			logger:               noopLogger{},
+			userFinder:           deps,
+			jurorFinder:          deps,
+			jurorUpdater:         deps,
+			ballotLister:         deps,
+			investigationUpdater: deps,
+			investigationDeleter: deps,
+			investigationExpirer: fakeInvestigationExpirer{fakeInvestigationDeps: deps, expired: []models.Investigation{inv}},
+			investigationLocker:  deps,
+			voteCounter:          deps,
+			participantLister:    deps,
+			participantUpdater:   deps,
+			disputeFinder:        deps,
+			eventRecorder:        deps,
+			msgSender:            sender,
+		}
+		return svc, deps, sender
+	}
+
+	t.Run("partial votes wait for alternates", func(t *testing.T) {
+		inv := models.Investigation{ID: uuid.New(), DisputeID: disputeID, Total: 5, P1: 2, P2: 1}
+		svc, deps, sender := newService(inv)
+		deps.ballots = []models.Ballot{{Vote: "p1"}, {Vote: "p1"}, {Vote: "p2"}}
+
+		if err := svc.ExtendExpiredInvestigations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.updatedInv) != 1 {
+			t.Fatalf("expected 1 investigation update, got %d", len(deps.updatedInv))
+		}
+		upd := deps.updatedInv[0]
+		if upd.EndsAt == nil || upd.Status != nil || upd.Total != nil || upd.CommitEndsAt != nil {
+			t.Fatalf("expected only ends_at update, got %+v", upd)
+		}
+		if deps.deleteNoVoteCnt != 1 {
+			t.Fatalf("expected delete users without vote once, got %d", deps.deleteNoVoteCnt)
+		}
+		if len(deps.updatedDP) != 0 || sender.calls != 0 {
+			t.Fatal("expected the dispute to stay unsettled")
+		}
+	})
+
+	t.Run("commit-reveal starts both phases over", func(t *testing.T) {
+		inv := models.Investigation{ID: uuid.New(), DisputeID: disputeID, Total: 4,
+			VotingMode: models.VotingModeCommitReveal}
+		svc, deps, _ := newService(inv)
+
+		if err := svc.ExtendExpiredInvestigations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.updatedInv) != 1 || deps.updatedInv[0].CommitEndsAt == nil {
+			t.Fatalf("expected the commit phase to reopen, got %+v", deps.updatedInv)
+		}
+		if !deps.updatedInv[0].CommitEndsAt.Before(*deps.updatedInv[0].EndsAt) {
+			t.Fatal("expected the commit phase to end before the reveal phase")
+		}
+	})
+
+	t.Run("skips an investigation the last vote closed", func(t *testing.T) {
+		inv := models.Investigation{ID: uuid.New(), DisputeID: disputeID, Total: 2, P1: 2,
+			Status: models.InvestigationStatusPassed}
+		svc, deps, sender := newService(inv)
+
+		if err := svc.ExtendExpiredInvestigations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.updatedInv) != 0 || deps.deleteNoVoteCnt != 0 || sender.calls != 0 {
+			t.Fatal("expected the closed investigation to be left alone")
+		}
+	})
+}
+
+func TestInvestigationServiceHungJury(t *testing.T) {
+	user1 := models.User{ID: uuid.New(), Username: "u1"}
+	user2 := models.User{ID: uuid.New(), Username: "u2"}
+	disputeID := uuid.New()
+	newService := func(policy models.VerdictPolicy, round int) (InvestigationService, *fakeInvestigationDeps) {
+		deps := &fakeInvestigationDeps{
+			user:        user1,
+			participant: models.Juror{ID: uuid.New()},
+			investigation: models.Investigation{ID: uuid.New(), DisputeID: disputeID, Total: 2, P1: 1,
+				VerdictPolicy: policy, Round: round},
+			disputeUsers: []models.User{user1, user2},
+			participantByUser: map[uuid.UUID]models.Participant{
+				user1.ID: {ID: uuid.New(), Result: models.DisputesResultInspected},
+				user2.ID: {ID: uuid.New(), Result: models.DisputesResultInspected},
+			},
+			dispute: models.Dispute{ID: disputeID, Title: "INV"},
+			ballots: []models.Ballot{{Vote: "p1"}, {Vote: "p2"}},
+		}
+		return InvestigationService{
+			logger:               noopLogger{},
+			userFinder:           deps,
+			userUpdater:          deps,
+			jurorFinder:          deps,
+			jurorUpdater:         deps,
+			ballotLister:         deps,
+			reputationScorer:     deps,
+			investigationFinder:  deps,
+			investigationLocker:  deps,
+			voteCounter:          deps,
+			investigationUpdater: deps,
+			investigationDeleter: deps,
+			participantLister:    deps,
+			participantUpdater:   deps,
+			disputeFinder:        deps,
+			eventRecorder:        deps,
+			msgSender:            &fakeMessageSender{},
+			txMonitor:            &fakeTxMonitor{},
+		}, deps
+	}
+	vote := func(t *testing.T, svc InvestigationService, deps *fakeInvestigationDeps) {
+		t.Helper()
+		_, err := svc.VoteInvestigation(context.Background(), deps.investigation.ID.String(), user1.Username, "p2",
+			"", "", "boc")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	lastUpdate := func(deps *fakeInvestigationDeps) models.InvestigationUpdateOpts {
+		return deps.updatedInv[len(deps.updatedInv)-1]
+	}
+
+	t.Run("tie settles as a draw without paying jurors", func(t *testing.T) {
+		svc, deps := newService(models.DefaultVerdictPolicy, 1)
+		deps.winners = []uuid.UUID{uuid.New()}
+		vote(t, svc, deps)
+		upd := lastUpdate(deps)
+		if *upd.Status != models.InvestigationStatusPassed || upd.Verdict == nil ||
+			upd.Verdict.Reason != models.VerdictReasonTie || upd.Verdict.Round != 1 {
+			t.Fatalf("expected a closed tie, got %+v", upd)
+		}
+		for _, dp := range deps.updatedDP {
+			if *dp.Result != models.DisputesResultDraw {
+				t.Fatalf("expected draw, got %+v", dp)
+			}
+		}
+		if deps.winnerVote != "" || len(deps.rewarded) != 0 {
+			t.Fatalf("expected no juror rewarded, got vote %q and %v", deps.winnerVote, deps.rewarded)
+		}
+	})
+
+	t.Run("majority short of the contract threshold is hung", func(t *testing.T) {
+		svc, deps := newService(models.DefaultVerdictPolicy, 1)
+		deps.investigation.Total = 4
+		deps.ballots = []models.Ballot{{Vote: "p1"}, {Vote: "p2"}, {Vote: "p2"}, {Vote: "draw"}}
+		vote(t, svc, deps)
+		if upd := lastUpdate(deps); upd.Verdict.Reason != models.VerdictReasonBelowThreshold {
+			t.Fatalf("expected the verdict below the threshold, got %+v", upd.Verdict)
+		}
+	})
+
+	t.Run("decisive draw pays the draw voters", func(t *testing.T) {
+		svc, deps := newService(models.DefaultVerdictPolicy, 1)
+		deps.winners = []uuid.UUID{uuid.New(), uuid.New()}
+		deps.ballots = []models.Ballot{{Vote: "draw"}, {Vote: "draw"}}
+		vote(t, svc, deps)
+		if upd := lastUpdate(deps); upd.Verdict.Vote != "draw" || upd.Verdict.Hung() {
+			t.Fatalf("expected a decisive draw, got %+v", upd.Verdict)
+		}
+		if deps.winnerVote != "draw" || len(deps.rewarded) != 2 {
+			t.Fatalf("expected the draw voters rewarded, got vote %q and %v", deps.winnerVote, deps.rewarded)
+		}
+		for _, dp := range deps.updatedDP {
+			if *dp.Result != models.DisputesResultDraw {
+				t.Fatalf("expected draw, got %+v", dp)
+			}
+		}
+	})
+}
+
+func TestInvestigationServiceVoteInvestigationClosed(t *testing.T) {
+	deps := &fakeInvestigationDeps{
+		user:          models.User{ID: uuid.New(), Username: "alice"},
+		participant:   models.Juror{ID: uuid.New()},
+		investigation: models.Investigation{ID: uuid.New(), Status: models.InvestigationStatusPassed},
+	}
+	svc := InvestigationService{
+		logger:              noopLogger{},
+		userFinder:          deps,
+		jurorFinder:         deps,
+		jurorUpdater:        deps,
+		investigationFinder: deps,
+		investigationLocker: deps,
+		voteCounter:         deps,
+		disputeFinder:       deps,
+		eventRecorder:       deps,
+		txMonitor:           &fakeTxMonitor{},
+	}
+
+	_, err := svc.VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice", "p1", "", "", "boc")
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if len(deps.cast) != 0 {
+		t.Fatal("expected no vote cast")
+	}
+}
+
+// A juror's second vote, which may race the first one, is turned away by the jurors row guard in CastJurorVote;
+// the service must report it without recounting or finalizing.
+func TestInvestigationServiceVoteInvestigationAlreadyVoted(t *testing.T) {
+	deps := &fakeInvestigationDeps{
+		user:          models.User{ID: uuid.New(), Username: "alice"},
+		participant:   models.Juror{ID: uuid.New()},
+		investigation: models.Investigation{ID: uuid.New(), DisputeID: uuid.New(), Total: 1},
+		dispute:       models.Dispute{ContractAddress: "bet"},
+		castErr:       repository.ErrAlreadyVoted,
+		ballots:       []models.Ballot{{Vote: "p1"}},
+	}
+	svc := InvestigationService{
+		logger:               noopLogger{},
+		userFinder:           deps,
+		jurorFinder:          deps,
+		jurorUpdater:         deps,
+		investigationFinder:  deps,
+		investigationLocker:  deps,
+		voteCounter:          deps,
+		investigationUpdater: deps,
+		disputeFinder:        deps,
+		eventRecorder:        deps,
+		txMonitor:            &fakeTxMonitor{},
+	}
+
+	_, err := svc.VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice", "p1", "", "", "boc")
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if len(deps.updatedInv) != 0 || deps.rescoreCnt != 0 {
+		t.Fatalf("expected the investigation left alone, got %+v", deps.updatedInv)
+	}
+}
+
+func TestInvestigationServiceCommitReveal(t *testing.T) {
+	newDeps := func(commitEndsAt time.Time, commitment *string) *fakeInvestigationDeps {
+		return &fakeInvestigationDeps{
+			user:        models.User{ID: uuid.New(), Username: "alice"},
+			participant: models.Juror{ID: uuid.New(), Commitment: commitment},
+			investigation: models.Investigation{ID: uuid.New(), DisputeID: uuid.New(), Total: 3,
+				EndsAt: commitEndsAt.Add(models.InvestigationRevealDuration), VotingMode: models.VotingModeCommitReveal,
+				CommitEndsAt: &commitEndsAt},
+			dispute: models.Dispute{ContractAddress: "bet"},
+		}
+	}
+	newService := func(deps *fakeInvestigationDeps) InvestigationService {
+		return InvestigationService{
+			logger:               noopLogger{},
+			userFinder:           deps,
+			userUpdater:          deps,
+			jurorFinder:          deps,
+			jurorUpdater:         deps,
+			jurorCommitCounter:   deps,
+			investigationFinder:  deps,
+			investigationLocker:  deps,
+			voteCounter:          deps,
+			investigationUpdater: deps,
+			disputeFinder:        deps,
+			eventRecorder:        deps,
+			txMonitor:            &fakeTxMonitor{},
+		}
+	}
+	commitment := models.VoteCommitment("p1", "pepper")
+
+	t.Run("commits in the commit phase", func(t *testing.T) {
+		deps := newDeps(time.Now().Add(time.Hour), nil)
+		deps.commits = 1
+		err := newService(deps).CommitVote(context.Background(), deps.investigation.ID.String(), "alice", commitment)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.updatedParticipants) != 1 || *deps.updatedParticipants[0].Commitment != commitment {
+			t.Fatalf("expected the commitment stored, got %+v", deps.updatedParticipants)
+		}
+		if len(deps.updatedInv) != 0 {
+			t.Fatalf("expected the commit phase to stay open, got %+v", deps.updatedInv)
+		}
+	})
+
+	t.Run("opens the reveal phase once everyone committed", func(t *testing.T) {
+		deps := newDeps(time.Now().Add(time.Hour), nil)
+		deps.commits = 3
+		err := newService(deps).CommitVote(context.Background(), deps.investigation.ID.String(), "alice", commitment)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.updatedInv) != 1 || deps.updatedInv[0].CommitEndsAt == nil ||
+			deps.updatedInv[0].CommitEndsAt.After(time.Now()) {
+			t.Fatalf("expected the commit phase closed, got %+v", deps.updatedInv)
+		}
+	})
+
+	t.Run("rejects commitments after the commit phase", func(t *testing.T) {
+		deps := newDeps(time.Now().Add(-time.Minute), nil)
+		err := newService(deps).CommitVote(context.Background(), deps.investigation.ID.String(), "alice", commitment)
+		if !errors.Is(err, ErrValidation) || len(deps.updatedParticipants) != 0 {
+			t.Fatalf("expected ErrValidation without update, got %v", err)
+		}
+	})
+
+	t.Run("rejects votes in the commit phase", func(t *testing.T) {
+		deps := newDeps(time.Now().Add(time.Hour), &commitment)
+		_, err := newService(deps).VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice",
+			"p1", "pepper", "", "boc")
+		if !errors.Is(err, ErrValidation) || len(deps.cast) != 0 {
+			t.Fatalf("expected ErrValidation without a vote, got %v", err)
+		}
+	})
+
+	t.Run("rejects a reveal that doesn't match the commitment", func(t *testing.T) {
+		deps := newDeps(time.Now().Add(-time.Minute), &commitment)
+		_, err := newService(deps).VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice",
+			"p2", "pepper", "", "boc")
+		if !errors.Is(err, ErrValidation) || len(deps.cast) != 0 {
+			t.Fatalf("expected ErrValidation without a vote, got %v", err)
+		}
+	})
+
+	t.Run("counts a matching reveal", func(t *testing.T) {
+		deps := newDeps(time.Now().Add(-time.Minute), &commitment)
+		_, err := newService(deps).VoteInvestigation(context.Background(), deps.investigation.ID.String(), "alice",
+			"p1", "pepper", "", "boc")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.cast) != 1 || len(deps.updatedInv) != 0 {
+			t.Fatalf("expected the vote counted without closing, got %v %+v", deps.cast, deps.updatedInv)
+		}
+	})
+
+	t.Run("hides tallies in the commit phase", func(t *testing.T) {
+		commitEndsAt := time.Now().Add(time.Hour)
+		deps := &fakeInvestigationDeps{getResult: models.InvestigationDetails{
+			DisputeID: uuid.NewString(), P1: 2, Draw: 1, VotingMode: models.VotingModeCommitReveal,
+			Status: models.InvestigationStatusCurrent, CommitEndsAt: &commitEndsAt,
+		}}
+		svc := InvestigationService{logger: noopLogger{}, investigationReadFinder: deps, disputeFinder: deps}
+		details, err := svc.GetInvestigation(context.Background(), uuid.NewString(), "alice")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if details.Phase != models.VotingPhaseCommit || details.P1 != 0 || details.Draw != 0 {
+			t.Fatalf("expected hidden tallies in the commit phase, got %+v", details)
+		}
+	})
 }
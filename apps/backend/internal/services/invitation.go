@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type InvitationClaimer interface {
+	ExpireInvitations(ctx context.Context, username string, now time.Time) error
+	ListPendingInvitations(ctx context.Context, username string, userID uuid.UUID, now time.Time,
+	) ([]models.Invitation, error)
+	ClaimInvitation(ctx context.Context, id, userID uuid.UUID, now time.Time) (models.Invitation, error)
+	RejectInvitation(ctx context.Context, id uuid.UUID) error
+	ClaimInvitationByToken(ctx context.Context, token string, userID uuid.UUID, now time.Time,
+	) (models.Invitation, error)
+}
+
+// InvitationService attaches disputes to opponents who were invited before they registered.
+type InvitationService struct {
+	logger log.Logger
+
+	invitationClaimer  InvitationClaimer
+	participantCreator ParticipantCreator
+	participantGetter  ParticipantGetter
+	disputeFinder      DisputeFinder
+	userFinder         UserFinder
+	msgSender          MessageSender
+	txRunner           TxRunner
+	now                func() time.Time
+}
+
+func NewInvitationService(repo *repository.Repository, log log.Logger, msgSender MessageSender,
+) (InvitationService, error) {
+	if repo == nil {
+		return InvitationService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return InvitationService{}, fmt.Errorf("logger is nil")
+	}
+	return InvitationService{
+		logger:             log,
+		invitationClaimer:  repo,
+		participantCreator: repo,
+		participantGetter:  repo,
+		disputeFinder:      repo,
+		userFinder:         repo,
+		msgSender:          msgSender,
+		txRunner:           repo,
+		now:                time.Now,
+	}, nil
+}
+
+// AttachInvitations makes the user the opponent of every pending dispute they were invited to by username.
+// Each invitation is attached in its own unit of work, so one that fails doesn't hold back the others.
+// Invitations the dispute can no longer take the user for are rejected, overdue ones are expired; other
+// failures leave the invitation pending for the next login and are returned.
+func (s InvitationService) AttachInvitations(ctx context.Context, username string) error {
+	user, err := s.userFinder.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user by username: %w", err)
+	}
+	now := s.now()
+	if err = s.invitationClaimer.ExpireInvitations(ctx, username, now); err != nil {
+		return err
+	}
+	invitations, err := s.invitationClaimer.ListPendingInvitations(ctx, username, user.ID, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pending := range invitations {
+		err = inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+			inv, err := s.invitationClaimer.ClaimInvitation(ctx, pending.ID, user.ID, now)
+			if err != nil {
+				return err
+			}
+			return s.attach(ctx, inv, user)
+		})
+		switch {
+		case err == nil, errors.Is(err, repository.ErrNotFound):
+			// Claimed by a concurrent login or redeemed by token in the meantime.
+		case errors.Is(err, ErrDisputeTaken), errors.Is(err, ErrValidation):
+			s.logger.Info("invitation rejected", zap.String("invitation_id", pending.ID.String()), zap.Error(err))
+			if err = s.invitationClaimer.RejectInvitation(ctx, pending.ID); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			errs = append(errs, fmt.Errorf("failed to attach invitation %s: %w", pending.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RedeemInvitation makes the user the opponent of the dispute the invite token was generated for.
+func (s InvitationService) RedeemInvitation(ctx context.Context, token string, username string) error {
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		user, err := s.userFinder.GetUserByUsername(ctx, username)
+		if err != nil {
+			return fmt.Errorf("failed to get user by username: %w", err)
+		}
+		inv, err := s.invitationClaimer.ClaimInvitationByToken(ctx, token, user.ID, s.now())
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrInvitationNotFound
+		case err != nil:
+			return err
+		}
+		return s.attach(ctx, inv, user)
+	})
+}
+
+func (s InvitationService) attach(ctx context.Context, inv models.Invitation, user models.User) error {
+	_, err := s.participantGetter.GetParticipant(ctx, inv.DisputeID, user.ID)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: user already takes part in the dispute", ErrValidation)
+	case !errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	participant := models.NewParticipant(user.ID, inv.DisputeID, models.DisputesResultNew, false)
+	inserted, err := s.participantCreator.InsertOpponentParticipant(ctx, participant)
+	if err != nil {
+		return fmt.Errorf("failed to attach invitation: %w", err)
+	}
+	if !inserted {
+		return ErrDisputeTaken
+	}
+	s.logger.Info("invitation attached", zap.String("dispute_id", inv.DisputeID.String()),
+		zap.String("username", user.Username))
+
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, inv.DisputeID)
+	if err != nil {
+		return fmt.Errorf("failed to get dispute: %w", err)
+	}
+	creator, err := s.userFinder.GetUserByID(ctx, inv.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to get dispute creator: %w", err)
+	}
+
+	if user.NotificationEnabled {
+		if err = sendMessage(ctx, s.msgSender, user.ChatID,
+			fmt.Sprintf("Пользователь %s вызвает вас на пари %s", creator.Username, dispute.Title)); err != nil {
+			return err
+		}
+	}
+	if creator.NotificationEnabled {
+		if err = sendMessage(ctx, s.msgSender, creator.ChatID,
+			fmt.Sprintf("Пользователь %s присоединился к пари %s", user.Username, dispute.Title)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
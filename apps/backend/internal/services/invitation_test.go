@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeInvitationRepo struct {
+	fakeDisputeRepo
+
+	pending       []models.Invitation
+	takenDisputes map[uuid.UUID]bool
+	claimedBy     uuid.UUID
+	claimedToken  string
+	expiredFor    string
+	rejected      []uuid.UUID
+}
+
+func (f *fakeInvitationRepo) ExpireInvitations(_ context.Context, username string, _ time.Time) error {
+	f.expiredFor = username
+	return nil
+}
+
+func (f *fakeInvitationRepo) ListPendingInvitations(_ context.Context, username string, _ uuid.UUID, _ time.Time,
+) ([]models.Invitation, error) {
+	var pending []models.Invitation
+	for _, inv := range f.pending {
+		if inv.Username == username {
+			pending = append(pending, inv)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeInvitationRepo) ClaimInvitation(_ context.Context, id, userID uuid.UUID, _ time.Time,
+) (models.Invitation, error) {
+	f.claimedBy = userID
+	for _, inv := range f.pending {
+		if inv.ID == id {
+			return inv, nil
+		}
+	}
+	return models.Invitation{}, repository.ErrNotFound
+}
+
+func (f *fakeInvitationRepo) RejectInvitation(_ context.Context, id uuid.UUID) error {
+	f.rejected = append(f.rejected, id)
+	return nil
+}
+
+func (f *fakeInvitationRepo) InsertOpponentParticipant(ctx context.Context, participant models.Participant,
+) (bool, error) {
+	if f.takenDisputes[participant.DisputeID] {
+		return false, nil
+	}
+	return f.fakeDisputeRepo.InsertOpponentParticipant(ctx, participant)
+}
+
+func (f *fakeInvitationRepo) ClaimInvitationByToken(_ context.Context, token string, userID uuid.UUID, _ time.Time,
+) (models.Invitation, error) {
+	f.claimedBy = userID
+	f.claimedToken = token
+	for _, inv := range f.pending {
+		if inv.Token == token {
+			return inv, nil
+		}
+	}
+	return models.Invitation{}, repository.ErrNotFound
+}
+
+func newTestInvitationService(repo *fakeInvitationRepo, sender *fakeMessageSender) InvitationService {
+	return InvitationService{
+		logger:             noopLogger{},
+		invitationClaimer:  repo,
+		participantCreator: repo,
+		participantGetter:  repo,
+		disputeFinder:      repo,
+		userFinder:         repo,
+		msgSender:          sender,
+		now:                time.Now,
+	}
+}
+
+func TestInvitationServiceAttachInvitations(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice", NotificationEnabled: true, ChatID: 111}
+	invitee := models.User{ID: uuid.New(), Username: "carol", NotificationEnabled: true, ChatID: 333}
+	disputeID := uuid.New()
+	repo := &fakeInvitationRepo{
+		fakeDisputeRepo: fakeDisputeRepo{
+			usersByUsername: map[string]models.User{"carol": invitee},
+			usersByID:       map[uuid.UUID]models.User{creator.ID: creator},
+			dispute:         models.Dispute{ID: disputeID, Title: "I1"},
+		},
+		pending: []models.Invitation{{ID: uuid.New(), DisputeID: disputeID, CreatorID: creator.ID, Username: "carol"}},
+	}
+	sender := &fakeMessageSender{}
+
+	if err := newTestInvitationService(repo, sender).AttachInvitations(context.Background(), "carol"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.claimedBy != invitee.ID || repo.expiredFor != "carol" {
+		t.Fatalf("expected invitations claimed by %s, got %s", invitee.ID, repo.claimedBy)
+	}
+	if len(repo.insertedDP) != 1 || repo.insertedDP[0].UserID != invitee.ID || repo.insertedDP[0].DisputeID != disputeID ||
+		repo.insertedDP[0].IsCreator || repo.insertedDP[0].Result != models.DisputesResultNew {
+		t.Fatalf("expected invitee to join as opponent, got %+v", repo.insertedDP)
+	}
+	if sender.calls != 2 || sender.chatIDs[0] != invitee.ChatID || sender.chatIDs[1] != creator.ChatID {
+		t.Fatalf("expected invitee and creator notifications, got calls=%d chats=%v", sender.calls, sender.chatIDs)
+	}
+}
+
+func TestInvitationServiceAttachInvitationsRejectsOnlyTheFailingOne(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	invitee := models.User{ID: uuid.New(), Username: "carol"}
+	taken := models.Invitation{ID: uuid.New(), DisputeID: uuid.New(), CreatorID: creator.ID, Username: "carol"}
+	open := models.Invitation{ID: uuid.New(), DisputeID: uuid.New(), CreatorID: creator.ID, Username: "carol"}
+	repo := &fakeInvitationRepo{
+		fakeDisputeRepo: fakeDisputeRepo{
+			usersByUsername: map[string]models.User{"carol": invitee},
+			usersByID:       map[uuid.UUID]models.User{creator.ID: creator},
+		},
+		pending:       []models.Invitation{taken, open},
+		takenDisputes: map[uuid.UUID]bool{taken.DisputeID: true},
+	}
+	service := newTestInvitationService(repo, &fakeMessageSender{})
+	runner := &fakeTxRunner{}
+	service.txRunner = runner
+
+	if err := service.AttachInvitations(context.Background(), "carol"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.insertedDP) != 1 || repo.insertedDP[0].DisputeID != open.DisputeID {
+		t.Fatalf("expected invitee to join only the open dispute, got %+v", repo.insertedDP)
+	}
+	if len(repo.rejected) != 1 || repo.rejected[0] != taken.ID {
+		t.Fatalf("expected taken invitation to be rejected, got %v", repo.rejected)
+	}
+	if runner.commits != 1 || runner.rollbacks != 1 {
+		t.Fatalf("expected one unit of work per invitation, got commits=%d rollbacks=%d",
+			runner.commits, runner.rollbacks)
+	}
+}
+
+func TestInvitationServiceRedeemInvitation(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	redeemer := models.User{ID: uuid.New(), Username: "dave"}
+	disputeID := uuid.New()
+	newRepo := func() *fakeInvitationRepo {
+		return &fakeInvitationRepo{
+			fakeDisputeRepo: fakeDisputeRepo{
+				usersByUsername: map[string]models.User{"dave": redeemer},
+				usersByID:       map[uuid.UUID]models.User{creator.ID: creator},
+				dispute:         models.Dispute{ID: disputeID, Title: "I2"},
+			},
+			pending: []models.Invitation{{
+				ID: uuid.New(), DisputeID: disputeID, CreatorID: creator.ID, Username: "carol", Token: "tok",
+			}},
+		}
+	}
+
+	t.Run("token holder joins whatever username was invited", func(t *testing.T) {
+		repo := newRepo()
+
+		err := newTestInvitationService(repo, &fakeMessageSender{}).RedeemInvitation(context.Background(), "tok", "dave")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.insertedDP) != 1 || repo.insertedDP[0].UserID != redeemer.ID {
+			t.Fatalf("expected redeemer to join, got %+v", repo.insertedDP)
+		}
+	})
+
+	t.Run("unknown or expired token", func(t *testing.T) {
+		repo := newRepo()
+
+		err := newTestInvitationService(repo, &fakeMessageSender{}).RedeemInvitation(context.Background(), "nope", "dave")
+		if !errors.Is(err, ErrInvitationNotFound) {
+			t.Fatalf("expected ErrInvitationNotFound, got %v", err)
+		}
+		if len(repo.insertedDP) != 0 {
+			t.Fatalf("expected no participants, got %d", len(repo.insertedDP))
+		}
+	})
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+const defaultJuryBatchSize = 50
+
+type JuryCandidateFinder interface {
+	ListJuryCandidates(ctx context.Context, excluded []uuid.UUID, activeSince time.Time) ([]models.JuryCandidate, error)
+}
+
+type JuryDrawStore interface {
+	InsertJuryDraw(ctx context.Context, draw models.JuryDraw) error
+	ListJuryDraws(ctx context.Context, invID uuid.UUID) ([]models.JuryDraw, error)
+}
+
+type JurorEnroller interface {
+	EnrollJurors(ctx context.Context, invID uuid.UUID, userIDs []uuid.UUID) error
+	DismissUnresponsiveJurors(ctx context.Context, invID uuid.UUID, cutoff time.Time) ([]uuid.UUID, error)
+	ListInvestigationsWithUnresponsiveJurors(ctx context.Context, cutoff time.Time, limit int,
+	) ([]models.Investigation, error)
+	ListJuryVacancies(ctx context.Context, limit int) ([]models.JuryVacancy, error)
+}
+
+type JuryConflictFinder interface {
+	GetConflictPolicy(ctx context.Context) (models.ConflictPolicy, error)
+	ListJuryConflicts(ctx context.Context, parties []uuid.UUID, policy models.ConflictPolicy, now time.Time,
+	) ([]models.JuryExclusion, error)
+	InsertJuryExclusions(ctx context.Context, exclusions []models.JuryExclusion) error
+}
+
+// JurySelector draws the jury of a new investigation.
+type JurySelector interface {
+	SelectJury(ctx context.Context, investigation models.Investigation, size int, parties []uuid.UUID) error
+}
+
+// JuryService draws rating-weighted juries of as many jurors as the investigation contract waits for votes, instead
+// of inviting everyone ready to investigate, and replaces jurors who don't respond with alternates. A jury short of
+// candidates keeps recruiting until every seat is taken. Users with a conflict of interest with the parties are kept
+// off the jury. Every draw is recorded with its seed and candidate pool, every exclusion with its reason.
+type JuryService struct {
+	logger log.Logger
+
+	candidateFinder   JuryCandidateFinder
+	conflictFinder    JuryConflictFinder
+	drawStore         JuryDrawStore
+	jurorEnroller     JurorEnroller
+	participantLister ParticipantLister
+	userFinder        UserFinder
+	msgSender         MessageSender
+	txRunner          TxRunner
+
+	batchSize int
+	seed      func() int64
+	now       func() time.Time
+}
+
+func NewJuryService(repo *repository.Repository, log log.Logger, msgSender MessageSender) (JuryService, error) {
+	if repo == nil {
+		return JuryService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return JuryService{}, fmt.Errorf("logger is nil")
+	}
+
+	return JuryService{
+		logger:            log,
+		candidateFinder:   repo,
+		conflictFinder:    repo,
+		drawStore:         repo,
+		jurorEnroller:     repo,
+		participantLister: repo,
+		userFinder:        repo,
+		msgSender:         msgSender,
+		txRunner:          repo,
+		batchSize:         defaultJuryBatchSize,
+		seed:              randomSeed,
+		now:               time.Now,
+	}, nil
+}
+
+// SelectJury draws size jurors for the investigation out of everyone ready to investigate except the parties of
+// the dispute and the users with a conflict of interest with them.
+func (s JuryService) SelectJury(ctx context.Context, investigation models.Investigation, size int,
+	parties []uuid.UUID,
+) error {
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		_, err := s.draw(ctx, investigation.ID, 1, size, parties, nil)
+		return err
+	})
+}
+
+// Run replaces unresponsive jurors and fills vacant seats every interval until ctx is cancelled.
+func (s JuryService) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, s.logger, "jury-alternates", interval, func(ctx context.Context) error {
+		if err := s.ReplaceUnresponsiveJurors(ctx); err != nil {
+			return err
+		}
+		return s.FillVacancies(ctx)
+	})
+}
+
+// FillVacancies draws jurors for the investigations whose jury has fewer jurors than the votes the investigation
+// contract waits for, e.g. because there weren't enough candidates when it opened.
+func (s JuryService) FillVacancies(ctx context.Context) error {
+	vacancies, err := s.jurorEnroller.ListJuryVacancies(ctx, s.batchSize)
+	if err != nil {
+		return err
+	}
+	for _, v := range vacancies {
+		err = inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+			investigation := models.Investigation{ID: v.InvestigationID, DisputeID: v.DisputeID}
+			added, err := s.redraw(ctx, investigation, v.Seats)
+			if err != nil {
+				return err
+			}
+			if len(added) > 0 {
+				s.logger.Info("vacant jury seats filled", zap.String("investigation_id", v.InvestigationID.String()),
+					zap.Int("seats", v.Seats), zap.Int("jurors", len(added)))
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.Error("failed to fill vacant jury seats",
+				zap.String("investigation_id", v.InvestigationID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// ReplaceUnresponsiveJurors dismisses jurors who neither opened nor voted on their investigation within
+// models.JuryResponseTimeout and draws as many alternates out of the users not drawn for it yet.
+func (s JuryService) ReplaceUnresponsiveJurors(ctx context.Context) error {
+	cutoff := s.now().Add(-models.JuryResponseTimeout)
+	investigations, err := s.jurorEnroller.ListInvestigationsWithUnresponsiveJurors(ctx, cutoff, s.batchSize)
+	if err != nil {
+		return err
+	}
+	for _, investigation := range investigations {
+		err = inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+			return s.replaceUnresponsiveJurors(ctx, investigation, cutoff)
+		})
+		if err != nil {
+			s.logger.Error("failed to replace unresponsive jurors",
+				zap.String("investigation_id", investigation.ID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s JuryService) replaceUnresponsiveJurors(ctx context.Context, investigation models.Investigation,
+	cutoff time.Time,
+) error {
+	dismissed, err := s.jurorEnroller.DismissUnresponsiveJurors(ctx, investigation.ID, cutoff)
+	if err != nil {
+		return err
+	}
+	if len(dismissed) == 0 {
+		// Another instance got to them first.
+		return nil
+	}
+
+	alternates, err := s.redraw(ctx, investigation, len(dismissed))
+	if err != nil {
+		return err
+	}
+	s.logger.Info("unresponsive jurors replaced", zap.String("investigation_id", investigation.ID.String()),
+		zap.Int("dismissed", len(dismissed)), zap.Int("alternates", len(alternates)))
+	return nil
+}
+
+// ReplaceJurors draws size alternates for jurors who recused from the investigation out of the users not drawn
+// for it yet. Fewer alternates are drawn when there aren't enough candidates left.
+func (s JuryService) ReplaceJurors(ctx context.Context, investigation models.Investigation, size int,
+) ([]uuid.UUID, error) {
+	var added []uuid.UUID
+	err := inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		var err error
+		added, err = s.redraw(ctx, investigation, size)
+		return err
+	})
+	return added, err
+}
+
+// redraw runs another selection round for the investigation without the parties and the users drawn before.
+func (s JuryService) redraw(ctx context.Context, investigation models.Investigation, size int) ([]uuid.UUID, error) {
+	participants, err := s.participantLister.ListParticipants(ctx, investigation.DisputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispute participants: %w", err)
+	}
+	parties := make([]uuid.UUID, 0, len(participants))
+	for _, p := range participants {
+		parties = append(parties, p.UserID)
+	}
+	draws, err := s.drawStore.ListJuryDraws(ctx, investigation.ID)
+	if err != nil {
+		return nil, err
+	}
+	var drawn []uuid.UUID
+	for _, d := range draws {
+		drawn = append(drawn, d.Selected...)
+	}
+	return s.draw(ctx, investigation.ID, len(draws)+1, size, parties, drawn)
+}
+
+// draw runs a selection round without the parties, the users conflicting with them and the excluded users,
+// enrolls the drawn users and lets them know.
+func (s JuryService) draw(ctx context.Context, invID uuid.UUID, round, size int, parties, excluded []uuid.UUID,
+) ([]uuid.UUID, error) {
+	conflicted, err := s.excludeConflicts(ctx, invID, parties)
+	if err != nil {
+		return nil, err
+	}
+	excluded = append(slices.Clone(excluded), conflicted...)
+
+	candidates, err := s.candidateFinder.ListJuryCandidates(ctx, excluded, s.now().Add(-models.JuryActivityWindow))
+	if err != nil {
+		return nil, err
+	}
+	draw := models.NewJuryDraw(invID, round, s.seed(), size, candidates)
+	if len(draw.Selected) == 0 && round > 1 {
+		// A jury waiting for candidates is redrawn every run; rounds that found nobody aren't worth keeping.
+		return nil, nil
+	}
+	if err = s.drawStore.InsertJuryDraw(ctx, draw); err != nil {
+		return nil, err
+	}
+	if len(draw.Selected) == 0 {
+		return nil, nil
+	}
+	if err = s.jurorEnroller.EnrollJurors(ctx, invID, draw.Selected); err != nil {
+		return nil, err
+	}
+
+	users, err := s.userFinder.GetUsers(ctx, draw.Selected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, u := range users {
+		if u.NotificationEnabled {
+			if err = sendMessage(ctx, s.msgSender, u.ChatID, "Вам доступно новое расследование!"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return draw.Selected, nil
+}
+
+// excludeConflicts records the parties and the users conflicting with them as excluded from the jury of the
+// investigation and returns their IDs.
+func (s JuryService) excludeConflicts(ctx context.Context, invID uuid.UUID, parties []uuid.UUID,
+) ([]uuid.UUID, error) {
+	policy, err := s.conflictFinder.GetConflictPolicy(ctx)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		policy = models.DefaultConflictPolicy
+	case err != nil:
+		return nil, err
+	}
+	conflicts, err := s.conflictFinder.ListJuryConflicts(ctx, parties, policy, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]models.JuryExclusion, 0, len(parties)+len(conflicts))
+	excluded := make([]uuid.UUID, 0, len(parties)+len(conflicts))
+	for _, party := range parties {
+		exclusions = append(exclusions, models.NewJuryExclusion(invID, party, party, models.ConflictReasonParty))
+		excluded = append(excluded, party)
+	}
+	for _, c := range conflicts {
+		exclusions = append(exclusions, models.NewJuryExclusion(invID, c.UserID, c.PartyID, c.Reason))
+		excluded = append(excluded, c.UserID)
+	}
+	if err = s.conflictFinder.InsertJuryExclusions(ctx, exclusions); err != nil {
+		return nil, err
+	}
+	return excluded, nil
+}
+
+func randomSeed() int64 {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+)
+
+type fakeJuryRepo struct {
+	*fakeDisputeRepo
+
+	candidates   []models.JuryCandidate
+	draws        []models.JuryDraw
+	jurors       map[uuid.UUID][]uuid.UUID
+	unresponsive map[uuid.UUID][]uuid.UUID
+	required     map[uuid.UUID]int
+	excluded     []uuid.UUID
+	conflicts    []models.JuryExclusion
+	exclusions   []models.JuryExclusion
+}
+
+func (f *fakeJuryRepo) GetConflictPolicy(context.Context) (models.ConflictPolicy, error) {
+	return models.DefaultConflictPolicy, nil
+}
+
+func (f *fakeJuryRepo) ListJuryConflicts(context.Context, []uuid.UUID, models.ConflictPolicy, time.Time,
+) ([]models.JuryExclusion, error) {
+	return f.conflicts, nil
+}
+
+func (f *fakeJuryRepo) InsertJuryExclusions(_ context.Context, exclusions []models.JuryExclusion) error {
+	f.exclusions = append(f.exclusions, exclusions...)
+	return nil
+}
+
+func (f *fakeJuryRepo) ListJuryCandidates(_ context.Context, excluded []uuid.UUID, _ time.Time,
+) ([]models.JuryCandidate, error) {
+	f.excluded = excluded
+	var candidates []models.JuryCandidate
+	for _, c := range f.candidates {
+		if !slices.Contains(excluded, c.UserID) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+func (f *fakeJuryRepo) InsertJuryDraw(_ context.Context, draw models.JuryDraw) error {
+	f.draws = append(f.draws, draw)
+	return nil
+}
+
+func (f *fakeJuryRepo) ListJuryDraws(context.Context, uuid.UUID) ([]models.JuryDraw, error) {
+	return f.draws, nil
+}
+
+func (f *fakeJuryRepo) EnrollJurors(_ context.Context, invID uuid.UUID, userIDs []uuid.UUID) error {
+	f.jurors[invID] = append(f.jurors[invID], userIDs...)
+	return nil
+}
+
+func (f *fakeJuryRepo) DismissUnresponsiveJurors(_ context.Context, invID uuid.UUID, _ time.Time,
+) ([]uuid.UUID, error) {
+	dismissed := f.unresponsive[invID]
+	delete(f.unresponsive, invID)
+	f.jurors[invID] = slices.DeleteFunc(f.jurors[invID], func(id uuid.UUID) bool {
+		return slices.Contains(dismissed, id)
+	})
+	return dismissed, nil
+}
+
+func (f *fakeJuryRepo) ListInvestigationsWithUnresponsiveJurors(context.Context, time.Time, int,
+) ([]models.Investigation, error) {
+	var investigations []models.Investigation
+	for id := range f.unresponsive {
+		investigations = append(investigations, models.Investigation{ID: id, DisputeID: f.dispute.ID})
+	}
+	return investigations, nil
+}
+
+func (f *fakeJuryRepo) ListJuryVacancies(context.Context, int) ([]models.JuryVacancy, error) {
+	var vacancies []models.JuryVacancy
+	for id, required := range f.required {
+		if seats := required - len(f.jurors[id]); seats > 0 {
+			vacancy := models.JuryVacancy{InvestigationID: id, DisputeID: f.dispute.ID, Seats: seats}
+			vacancies = append(vacancies, vacancy)
+		}
+	}
+	return vacancies, nil
+}
+
+func (f *fakeJuryRepo) GetUsers(_ context.Context, ids []uuid.UUID) ([]models.User, error) {
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, models.User{ID: id, NotificationEnabled: true, ChatID: 1})
+	}
+	return users, nil
+}
+
+func TestJuryService(t *testing.T) {
+	p1, p2 := uuid.New(), uuid.New()
+	newRepo := func() *fakeJuryRepo {
+		repo := &fakeJuryRepo{
+			fakeDisputeRepo: &fakeDisputeRepo{
+				dispute:      models.Dispute{ID: uuid.New()},
+				participants: []models.Participant{{UserID: p1}, {UserID: p2}},
+			},
+			jurors:       map[uuid.UUID][]uuid.UUID{},
+			unresponsive: map[uuid.UUID][]uuid.UUID{},
+			required:     map[uuid.UUID]int{},
+		}
+		for _, id := range []uuid.UUID{p1, p2} {
+			repo.candidates = append(repo.candidates, models.JuryCandidate{UserID: id, Rating: 100})
+		}
+		for range 8 {
+			repo.candidates = append(repo.candidates, models.JuryCandidate{UserID: uuid.New()})
+		}
+		return repo
+	}
+	newService := func(repo *fakeJuryRepo, sender *fakeMessageSender) JuryService {
+		return JuryService{
+			logger:            noopLogger{},
+			candidateFinder:   repo,
+			conflictFinder:    repo,
+			drawStore:         repo,
+			jurorEnroller:     repo,
+			participantLister: repo,
+			userFinder:        repo,
+			msgSender:         sender,
+			batchSize:         defaultJuryBatchSize,
+			seed:              func() int64 { return 7 },
+			now:               time.Now,
+		}
+	}
+
+	t.Run("draws a bounded panel without the parties", func(t *testing.T) {
+		repo := newRepo()
+		sender := &fakeMessageSender{}
+		inv := models.Investigation{ID: uuid.New(), DisputeID: repo.dispute.ID}
+
+		if err := newService(repo, sender).SelectJury(context.Background(), inv, 3, []uuid.UUID{p1, p2}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		jury := repo.jurors[inv.ID]
+		if len(jury) != 3 || slices.Contains(jury, p1) || slices.Contains(jury, p2) {
+			t.Fatalf("expected 3 jurors without the parties, got %v", jury)
+		}
+		if len(repo.draws) != 1 || repo.draws[0].Seed != 7 || repo.draws[0].Round != 1 {
+			t.Fatalf("expected the draw to be recorded, got %+v", repo.draws)
+		}
+		if replay := models.DrawJury(repo.draws[0].Candidates, 3, 7); !slices.Equal(replay, jury) {
+			t.Fatalf("expected the recorded draw to be reproducible, got %v and %v", replay, jury)
+		}
+		if sender.calls != 3 {
+			t.Fatalf("expected 3 notifications, got %d", sender.calls)
+		}
+	})
+
+	t.Run("keeps conflicted users off the jury", func(t *testing.T) {
+		repo := newRepo()
+		partner, blocked := repo.candidates[2].UserID, repo.candidates[3].UserID
+		repo.conflicts = []models.JuryExclusion{
+			{UserID: partner, PartyID: p1, Reason: models.ConflictReasonDisputePartner},
+			{UserID: blocked, PartyID: p2, Reason: models.ConflictReasonBlocked},
+		}
+		inv := models.Investigation{ID: uuid.New(), DisputeID: repo.dispute.ID}
+
+		svc := newService(repo, &fakeMessageSender{})
+		if err := svc.SelectJury(context.Background(), inv, 6, []uuid.UUID{p1, p2}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		jury := repo.jurors[inv.ID]
+		if len(jury) != 6 || slices.Contains(jury, partner) || slices.Contains(jury, blocked) {
+			t.Fatalf("expected 6 jurors without conflicted users, got %v", jury)
+		}
+		if len(repo.exclusions) != 4 {
+			t.Fatalf("expected the parties and 2 conflicts recorded, got %+v", repo.exclusions)
+		}
+		for _, e := range repo.exclusions {
+			if e.InvestigationID != inv.ID {
+				t.Fatalf("expected exclusions of investigation %s, got %+v", inv.ID, e)
+			}
+		}
+		if repo.exclusions[0].Reason != models.ConflictReasonParty || repo.exclusions[0].UserID != p1 {
+			t.Fatalf("expected the parties recorded first, got %+v", repo.exclusions[0])
+		}
+	})
+
+	t.Run("tops up unresponsive jurors with alternates", func(t *testing.T) {
+		repo := newRepo()
+		svc := newService(repo, &fakeMessageSender{})
+		inv := models.Investigation{ID: uuid.New(), DisputeID: repo.dispute.ID}
+		if err := svc.SelectJury(context.Background(), inv, 3, []uuid.UUID{p1, p2}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		first := slices.Clone(repo.jurors[inv.ID])
+		repo.unresponsive[inv.ID] = first[:2]
+
+		if err := svc.ReplaceUnresponsiveJurors(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		jury := repo.jurors[inv.ID]
+		if len(jury) != 3 || len(repo.draws) != 2 || repo.draws[1].Round != 2 {
+			t.Fatalf("expected 2 alternates in a second round, got jury %v and %d draws", jury, len(repo.draws))
+		}
+		for _, id := range []uuid.UUID{p1, p2, first[0], first[1], first[2]} {
+			if !slices.Contains(repo.excluded, id) {
+				t.Fatalf("expected parties and earlier jurors excluded, got %v", repo.excluded)
+			}
+		}
+	})
+
+	t.Run("replaces a recused juror with someone not drawn before", func(t *testing.T) {
+		repo := newRepo()
+		svc := newService(repo, &fakeMessageSender{})
+		inv := models.Investigation{ID: uuid.New(), DisputeID: repo.dispute.ID}
+		if err := svc.SelectJury(context.Background(), inv, 3, []uuid.UUID{p1, p2}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		recused := repo.jurors[inv.ID][0]
+		repo.jurors[inv.ID] = slices.Clone(repo.jurors[inv.ID][1:])
+
+		alternates, err := svc.ReplaceJurors(context.Background(), inv, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(alternates) != 1 || alternates[0] == recused || len(repo.jurors[inv.ID]) != 3 {
+			t.Fatalf("expected an alternate other than the recused juror, got %v", alternates)
+		}
+	})
+	t.Run("keeps recruiting until every seat is taken", func(t *testing.T) {
+		repo := newRepo()
+		svc := newService(repo, &fakeMessageSender{})
+		inv := models.Investigation{ID: uuid.New(), DisputeID: repo.dispute.ID, Total: 12}
+		repo.required[inv.ID] = inv.Total
+		if err := svc.SelectJury(context.Background(), inv, inv.Total, []uuid.UUID{p1, p2}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.jurors[inv.ID]) != 8 {
+			t.Fatalf("expected every candidate drawn, got %v", repo.jurors[inv.ID])
+		}
+
+		if err := svc.FillVacancies(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.draws) != 1 {
+			t.Fatalf("expected a round that found nobody not to be recorded, got %d draws", len(repo.draws))
+		}
+
+		late := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+		for _, id := range late {
+			repo.candidates = append(repo.candidates, models.JuryCandidate{UserID: id})
+		}
+		if err := svc.FillVacancies(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if jury := repo.jurors[inv.ID]; len(jury) != 12 || len(repo.draws) != 2 || repo.draws[1].Size != 4 {
+			t.Fatalf("expected the 4 vacant seats filled in a second round, got %d jurors and draws %+v", len(jury),
+				repo.draws)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"go.uber.org/zap"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+const defaultMediaBatchSize = 20
+
+// BlobStore keeps blobs by key. Get returns an error wrapping fs.ErrNotExist for a missing key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type MediaRecorder interface {
+	InsertMedia(ctx context.Context, media models.Media) error
+	GetMedia(ctx context.Context, hash string) (models.Media, error)
+}
+
+// MediaViewer tells whether a user may view media, by the disputes and evidence it belongs to.
+type MediaViewer interface {
+	CanViewMedia(ctx context.Context, hash, actorUsername string) (bool, error)
+}
+
+type InlineImageMover interface {
+	ListInlineImages(ctx context.Context, limit int) ([]models.InlineImage, error)
+	MoveInlineImage(ctx context.Context, img models.InlineImage, hash string) error
+}
+
+// MediaStorer stores uploaded media; disputes and evidence keep only its hash.
+type MediaStorer interface {
+	StoreImage(ctx context.Context, data []byte) (models.StoredImage, error)
+	StoreMedia(ctx context.Context, data []byte, contentType string) (models.Media, error)
+	StoreAttachment(ctx context.Context, data []byte) (models.StoredAttachment, error)
+}
+
+// MediaService keeps dispute and evidence images in the blob store, addressed by the SHA-256 of their content.
+type MediaService struct {
+	logger log.Logger
+
+	blobs         BlobStore
+	mediaRecorder MediaRecorder
+	mediaViewer   MediaViewer
+	imageMover    InlineImageMover
+
+	batchSize int
+}
+
+func NewMediaService(repo *repository.Repository, log log.Logger, blobs BlobStore) (MediaService, error) {
+	if repo == nil {
+		return MediaService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return MediaService{}, fmt.Errorf("logger is nil")
+	}
+	if blobs == nil {
+		return MediaService{}, fmt.Errorf("blob store is nil")
+	}
+	return MediaService{
+		logger:        log,
+		blobs:         blobs,
+		mediaRecorder: repo,
+		mediaViewer:   repo,
+		imageMover:    repo,
+		batchSize:     defaultMediaBatchSize,
+	}, nil
+}
+
+// StoreMedia puts data into the blob store before recording it, so recorded media can always be served. Storing
+// the same content again is a no-op.
+func (s MediaService) StoreMedia(ctx context.Context, data []byte, contentType string) (models.Media, error) {
+	media, err := models.NewMedia(data, contentType)
+	if err != nil {
+		return models.Media{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	if err = s.blobs.Put(ctx, media.Hash, data, media.ContentType); err != nil {
+		return models.Media{}, fmt.Errorf("failed to put media: %w", err)
+	}
+	if err = s.mediaRecorder.InsertMedia(ctx, media); err != nil {
+		return models.Media{}, fmt.Errorf("failed to record media: %w", err)
+	}
+	return media, nil
+}
+
+// StoreImage validates an uploaded image by its content, re-encodes it without metadata and stores it with its
+// thumbnail.
+func (s MediaService) StoreImage(ctx context.Context, data []byte) (models.StoredImage, error) {
+	img, err := models.ProcessImage(data)
+	switch {
+	case errors.Is(err, models.ErrUnsupportedImage):
+		return models.StoredImage{}, fmt.Errorf("%w: %w", ErrUnsupportedMedia, err)
+	case errors.Is(err, models.ErrImageTooLarge):
+		return models.StoredImage{}, fmt.Errorf("%w: %w", ErrMediaTooLarge, err)
+	case errors.Is(err, models.ErrMalformedImage):
+		return models.StoredImage{}, fmt.Errorf("%w: %w", ErrMalformedMedia, err)
+	case err != nil:
+		return models.StoredImage{}, fmt.Errorf("failed to process image: %w", err)
+	}
+	media, err := s.StoreMedia(ctx, img.Data, img.ContentType)
+	if err != nil {
+		return models.StoredImage{}, err
+	}
+	thumbnail, err := s.StoreMedia(ctx, img.Thumbnail, models.ThumbnailContentType)
+	if err != nil {
+		return models.StoredImage{}, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+	return models.StoredImage{Hash: media.Hash, ThumbnailHash: thumbnail.Hash, ContentType: img.ContentType}, nil
+}
+
+// StoreAttachment stores an evidence attachment, telling its kind by its content. Images are processed like by
+// StoreImage; videos, PDFs and text are stored as uploaded. Either way the hash of the upload is kept for the
+// evidence digest.
+func (s MediaService) StoreAttachment(ctx context.Context, data []byte) (models.StoredAttachment, error) {
+	kind, contentType, err := models.ClassifyAttachment(data)
+	switch {
+	case errors.Is(err, models.ErrUnsupportedAttachment):
+		return models.StoredAttachment{}, fmt.Errorf("%w: %w", ErrUnsupportedMedia, err)
+	case errors.Is(err, models.ErrAttachmentTooLarge):
+		return models.StoredAttachment{}, fmt.Errorf("%w: %w", ErrMediaTooLarge, err)
+	case errors.Is(err, models.ErrAttachmentValidation):
+		return models.StoredAttachment{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	case err != nil:
+		return models.StoredAttachment{}, fmt.Errorf("failed to classify attachment: %w", err)
+	}
+	if kind == models.AttachmentKindImage {
+		img, err := s.StoreImage(ctx, data)
+		if err != nil {
+			return models.StoredAttachment{}, err
+		}
+		return models.StoredAttachment{
+			Kind:          kind,
+			Hash:          img.Hash,
+			UploadHash:    models.MediaHash(data),
+			ThumbnailHash: &img.ThumbnailHash,
+		}, nil
+	}
+	media, err := s.StoreMedia(ctx, data, contentType)
+	if err != nil {
+		return models.StoredAttachment{}, err
+	}
+	return models.StoredAttachment{Kind: kind, Hash: media.Hash, UploadHash: media.Hash}, nil
+}
+
+// GetMedia returns the media with the hash and its content. Media the user may not view doesn't exist for them.
+func (s MediaService) GetMedia(ctx context.Context, hash, actorUsername string) (models.Media, []byte, error) {
+	hash, err := models.ParseMediaHash(hash)
+	if err != nil {
+		return models.Media{}, nil, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	ok, err := s.mediaViewer.CanViewMedia(ctx, hash, actorUsername)
+	if err != nil {
+		return models.Media{}, nil, err
+	}
+	if !ok {
+		return models.Media{}, nil, ErrMediaNotFound
+	}
+	media, err := s.mediaRecorder.GetMedia(ctx, hash)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.Media{}, nil, ErrMediaNotFound
+	case err != nil:
+		return models.Media{}, nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	data, err := s.blobs.Get(ctx, hash)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return models.Media{}, nil, ErrMediaNotFound
+	case err != nil:
+		return models.Media{}, nil, fmt.Errorf("failed to get media content: %w", err)
+	}
+	return media, data, nil
+}
+
+// MoveInlineImages moves dispute and evidence images still kept in the database into the blob store. It runs
+// until none are left; an image that fails to move stops the run and is retried on the next one.
+func (s MediaService) MoveInlineImages(ctx context.Context) error {
+	moved := 0
+	for {
+		images, err := s.imageMover.ListInlineImages(ctx, s.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list inline images: %w", err)
+		}
+		if len(images) == 0 {
+			if moved > 0 {
+				s.logger.Info("inline images moved to the blob store", zap.Int("count", moved))
+			}
+			return nil
+		}
+		for _, img := range images {
+			var contentType string
+			if img.ContentType != nil {
+				contentType = *img.ContentType
+			}
+			media, err := s.StoreMedia(ctx, img.Data, contentType)
+			if err != nil {
+				return fmt.Errorf("failed to store %s %s image: %w", img.Kind, img.ID, err)
+			}
+			if err = s.imageMover.MoveInlineImage(ctx, img, media.Hash); err != nil {
+				return err
+			}
+			moved++
+		}
+	}
+}
+
+// storeImage processes an uploaded image and moves it into the blob store with its thumbnail.
+func storeImage(ctx context.Context, storer MediaStorer, data []byte) (models.StoredImage, error) {
+	if storer == nil {
+		return models.StoredImage{}, fmt.Errorf("media store is not configured")
+	}
+	return storer.StoreImage(ctx, data)
+}
+
+// storeAttachments moves the files uploaded with evidence into the blob store, in order. Captions are checked
+// before anything is stored.
+func storeAttachments(ctx context.Context, storer MediaStorer, uploads []models.AttachmentUpload,
+) ([]models.StoredAttachment, error) {
+	if len(uploads) > models.MaxEvidenceAttachments {
+		return nil, fmt.Errorf("%w: at most %d attachments are allowed", ErrValidation, models.MaxEvidenceAttachments)
+	}
+	captions := make([]*string, len(uploads))
+	for i, upload := range uploads {
+		caption, err := models.ParseCaption(upload.Caption)
+		if err != nil {
+			return nil, fmt.Errorf("%w: attachment %d: %s", ErrValidation, i+1, err)
+		}
+		captions[i] = caption
+	}
+	if len(uploads) > 0 && storer == nil {
+		return nil, fmt.Errorf("media store is not configured")
+	}
+
+	attachments := make([]models.StoredAttachment, 0, len(uploads))
+	for i, upload := range uploads {
+		a, err := storer.StoreAttachment(ctx, upload.Data)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %d: %w", i+1, err)
+		}
+		a.Caption = captions[i]
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+// storeLegacyImage stores an image carried by an operation recorded before the blob store, as it was uploaded:
+// the upload was accepted then, so the operation must not fail on it now.
+func storeLegacyImage(ctx context.Context, storer MediaStorer, data []byte, contentType string) (*string, error) {
+	if storer == nil {
+		return nil, fmt.Errorf("media store is not configured")
+	}
+	media, err := storer.StoreMedia(ctx, data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	return &media.Hash, nil
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeMediaDeps struct {
+	blobs  map[string][]byte
+	media  map[string]models.Media
+	inline []models.InlineImage
+	moved  map[string]string
+	putErr error
+	// viewers maps media hashes to the users that may view them.
+	viewers map[string][]string
+}
+
+func newFakeMediaDeps() *fakeMediaDeps {
+	return &fakeMediaDeps{blobs: map[string][]byte{}, media: map[string]models.Media{}, moved: map[string]string{}}
+}
+
+func (f *fakeMediaDeps) Put(_ context.Context, key string, data []byte, _ string) error {
+	if f.putErr != nil {
+		return f.putErr
+	}
+	f.blobs[key] = data
+	return nil
+}
+func (f *fakeMediaDeps) Get(_ context.Context, key string) ([]byte, error) {
+	data, ok := f.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("blob %s: %w", key, fs.ErrNotExist)
+	}
+	return data, nil
+}
+func (f *fakeMediaDeps) InsertMedia(_ context.Context, media models.Media) error {
+	if _, ok := f.media[media.Hash]; !ok {
+		f.media[media.Hash] = media
+	}
+	return nil
+}
+func (f *fakeMediaDeps) GetMedia(_ context.Context, hash string) (models.Media, error) {
+	media, ok := f.media[hash]
+	if !ok {
+		return models.Media{}, repository.ErrNotFound
+	}
+	return media, nil
+}
+func (f *fakeMediaDeps) CanViewMedia(_ context.Context, hash, actorUsername string) (bool, error) {
+	return slices.Contains(f.viewers[hash], actorUsername), nil
+}
+func (f *fakeMediaDeps) ListInlineImages(_ context.Context, limit int) ([]models.InlineImage, error) {
+	return f.inline[:min(limit, len(f.inline))], nil
+}
+func (f *fakeMediaDeps) MoveInlineImage(_ context.Context, img models.InlineImage, hash string) error {
+	f.moved[img.ID] = hash
+	f.inline = f.inline[1:]
+	return nil
+}
+
+func newTestMediaService(deps *fakeMediaDeps) MediaService {
+	return MediaService{
+		logger:        noopLogger{},
+		blobs:         deps,
+		mediaRecorder: deps,
+		mediaViewer:   deps,
+		imageMover:    deps,
+		batchSize:     2,
+	}
+}
+
+func TestMediaServiceStoreMedia(t *testing.T) {
+	deps := newFakeMediaDeps()
+	svc := newTestMediaService(deps)
+
+	media, err := svc.StoreMedia(context.Background(), []byte("abc"), "image/png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(deps.blobs[media.Hash]) != "abc" || deps.media[media.Hash].ContentType != "image/png" {
+		t.Fatalf("expected the blob stored and recorded, got %v %v", deps.blobs, deps.media)
+	}
+
+	if _, err = svc.StoreMedia(context.Background(), nil, "image/png"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+
+	deps = newFakeMediaDeps()
+	deps.putErr = errors.New("bucket is down")
+	if _, err = newTestMediaService(deps).StoreMedia(context.Background(), []byte("abc"), ""); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(deps.media) != 0 {
+		t.Fatalf("expected media not stored in the blob store to stay unrecorded, got %v", deps.media)
+	}
+}
+
+func TestMediaServiceGetMedia(t *testing.T) {
+	deps := newFakeMediaDeps()
+	svc := newTestMediaService(deps)
+	stored, err := svc.StoreMedia(context.Background(), []byte("abc"), "image/png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deps.viewers = map[string][]string{stored.Hash: {"alice"}, strings.Repeat("0", 64): {"alice"}}
+
+	media, data, err := svc.GetMedia(context.Background(), strings.ToUpper(stored.Hash), "alice")
+	if err != nil || media.Hash != stored.Hash || string(data) != "abc" {
+		t.Fatalf("expected the stored media, got %+v %q %v", media, data, err)
+	}
+
+	if _, _, err = svc.GetMedia(context.Background(), stored.Hash, "mallory"); !errors.Is(err, ErrMediaNotFound) {
+		t.Fatalf("expected ErrMediaNotFound for a user who may not view the media, got %v", err)
+	}
+	if _, _, err = svc.GetMedia(context.Background(), "nope", "alice"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if _, _, err = svc.GetMedia(context.Background(), strings.Repeat("0", 64), "alice"); !errors.Is(err,
+		ErrMediaNotFound) {
+		t.Fatalf("expected ErrMediaNotFound, got %v", err)
+	}
+	delete(deps.blobs, stored.Hash)
+	if _, _, err = svc.GetMedia(context.Background(), stored.Hash, "alice"); !errors.Is(err, ErrMediaNotFound) {
+		t.Fatalf("expected ErrMediaNotFound for a missing blob, got %v", err)
+	}
+}
+
+func TestMediaServiceMoveInlineImages(t *testing.T) {
+	deps := newFakeMediaDeps()
+	png := "image/png"
+	deps.inline = []models.InlineImage{
+		{Kind: models.InlineImageDispute, ID: "d1", Data: []byte("one"), ContentType: &png},
+		{Kind: models.InlineImageEvidence, ID: "e1", Data: []byte("two")},
+		{Kind: models.InlineImageEvidence, ID: "e2", Data: []byte("one")},
+	}
+
+	if err := newTestMediaService(deps).MoveInlineImages(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deps.inline) != 0 || len(deps.moved) != 3 {
+		t.Fatalf("expected every image moved, got %v", deps.moved)
+	}
+	if deps.moved["d1"] != deps.moved["e2"] || len(deps.blobs) != 2 {
+		t.Fatalf("expected equal images to share a blob, got %v", deps.moved)
+	}
+	if deps.media[deps.moved["d1"]].ContentType != png {
+		t.Fatalf("expected the inline content type kept, got %+v", deps.media)
+	}
+}
+
+func TestDisputeServiceCreateDisputeStoresImage(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": creator}}
+	media := newFakeMediaDeps()
+	svc := DisputeService{
+		logger:             noopLogger{},
+		disputeCreator:     repo,
+		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
+		userFinder:         repo,
+		betMaster:          testBetMaster,
+		msgSender:          &fakeMessageSender{},
+		txMonitor:          &fakeTxMonitor{},
+	}
+	req := models.CreateDisputeReq{
+		Title:           "test",
+		Description:     "desc",
+		AmountNano:      "100000000000",
+		DepositNano:     "20000000000",
+		EndsAt:          time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+		ContractAddress: "addr",
+		Boc:             "boc",
+		ImageData:       testPNG(t),
+		// The client's type isn't trusted, the image is sniffed.
+		ImageType: "image/webp",
+	}
+
+	if _, err := svc.CreateDispute(context.Background(), req, "alice"); err == nil {
+		t.Fatal("expected an error without a media store")
+	}
+
+	svc = svc.WithMediaStore(newTestMediaService(media))
+	if _, err := svc.CreateDispute(context.Background(), req, "alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := repo.dispute
+	if d.ImageHash == nil || media.blobs[*d.ImageHash] == nil || *d.ImageType != "image/png" {
+		t.Fatalf("expected the dispute to reference its image in the blob store, got %+v", d)
+	}
+	if d.ThumbnailHash == nil || media.media[*d.ThumbnailHash].ContentType != models.ThumbnailContentType {
+		t.Fatalf("expected the dispute to reference its thumbnail, got %+v", d)
+	}
+
+	req.ImageData = []byte("%PDF-1.7")
+	if _, err := svc.CreateDispute(context.Background(), req, "alice"); !errors.Is(err, ErrUnsupportedMedia) {
+		t.Fatalf("expected ErrUnsupportedMedia, got %v", err)
+	}
+}
+
+func TestDisputeServiceApplyLegacyImage(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": creator}}
+	media := newFakeMediaDeps()
+	svc := DisputeService{
+		logger:             noopLogger{},
+		disputeCreator:     repo,
+		participantCreator: repo,
+		offerCreator:       repo,
+		eventRecorder:      repo,
+		userFinder:         repo,
+		msgSender:          &fakeMessageSender{},
+	}.WithMediaStore(newTestMediaService(media))
+
+	// Operations recorded before the blob store carry the image as it was uploaded.
+	err := svc.createDispute(context.Background(), models.CreateDisputeReq{
+		Title:       "test",
+		Description: "desc",
+		AmountNano:  "100000000000",
+		DepositNano: "20000000000",
+		EndsAt:      time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+		ImageData:   []byte("webp"),
+		ImageType:   "image/webp",
+	}, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash := repo.dispute.ImageHash; hash == nil || string(media.blobs[*hash]) != "webp" {
+		t.Fatalf("expected the image stored as uploaded, got %+v", repo.dispute)
+	}
+}
+
+func TestMediaServiceStoreAttachment(t *testing.T) {
+	media := newFakeMediaDeps()
+	svc := newTestMediaService(media)
+
+	img, err := svc.StoreAttachment(context.Background(), testPNG(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.Kind != models.AttachmentKindImage || img.ThumbnailHash == nil || media.blobs[*img.ThumbnailHash] == nil {
+		t.Fatalf("expected an image with its thumbnail, got %+v", img)
+	}
+
+	pdf := []byte("%PDF-1.7\n%binary")
+	doc, err := svc.StoreAttachment(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Kind != models.AttachmentKindPDF || doc.ThumbnailHash != nil || !bytes.Equal(media.blobs[doc.Hash], pdf) ||
+		media.media[doc.Hash].ContentType != "application/pdf" {
+		t.Fatalf("expected the PDF stored as uploaded, got %+v", doc)
+	}
+
+	for data, want := range map[string]error{
+		"<html><script>alert(1)</script></html>":                        ErrUnsupportedMedia,
+		strings.Repeat("a", int(models.AttachmentKindText.MaxSize())+1): ErrMediaTooLarge,
+	} {
+		if _, err = svc.StoreAttachment(context.Background(), []byte(data)); !errors.Is(err, want) {
+			t.Fatalf("expected %v, got %v", want, err)
+		}
+	}
+}
+
+func TestEvidenceServiceProvideEvidenceStoresAttachments(t *testing.T) {
+	deps := &fakeEvidenceDeps{
+		isFirst:         true,
+		user:            models.User{ID: uuid.New(), Username: "alice"},
+		participantSelf: models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidence},
+	}
+	media := newFakeMediaDeps()
+	txMonitor := &fakeTxMonitor{}
+	svc := EvidenceService{
+		logger:             noopLogger{},
+		evidenceCreator:    deps,
+		evidenceChecker:    deps,
+		userFinder:         deps,
+		participantUpdater: deps,
+		participantGetter:  deps,
+		disputesFinder:     deps,
+		txMonitor:          txMonitor,
+	}.WithMediaStore(newTestMediaService(media))
+	opts := models.EvidenceOpts{
+		DisputeID: uuid.NewString(),
+		Username:  "alice",
+		Boc:       "boc",
+		Uploads: []models.AttachmentUpload{
+			{Data: testPNG(t), Caption: "  screenshot  "},
+			{Data: []byte("%PDF-1.7\n")},
+		},
+	}
+
+	tooMany := opts
+	tooMany.Uploads = make([]models.AttachmentUpload, models.MaxEvidenceAttachments+1)
+	if _, err := svc.ProvideEvidence(context.Background(), tooMany); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for too many attachments, got %v", err)
+	}
+	longCaption := opts
+	longCaption.Uploads = []models.AttachmentUpload{
+		{Data: []byte("%PDF-1.7\n"), Caption: strings.Repeat("я", models.MaxCaptionLength+1)},
+	}
+	if _, err := svc.ProvideEvidence(context.Background(), longCaption); !errors.Is(err, ErrValidation) ||
+		len(media.blobs) != 0 {
+		t.Fatalf("expected ErrValidation before storing anything, got %v", err)
+	}
+
+	if _, err := svc.ProvideEvidence(context.Background(), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deps.evidences) != 1 || len(deps.evidences[0].Attachments) != 2 {
+		t.Fatalf("expected one evidence with 2 attachments, got %+v", deps.evidences)
+	}
+	digest, err := models.EvidenceDigest("", []models.DigestedAttachment{
+		{UploadHash: models.MediaHash(testPNG(t)), Caption: new("screenshot")},
+		{UploadHash: models.MediaHash([]byte("%PDF-1.7\n"))},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash := deps.evidences[0].ContentHash; hash == nil || *hash != digest {
+		t.Fatalf("expected the digest of the uploads stored, got %v", hash)
+	}
+	if field := txMonitor.want.Fields; len(field) != 1 || field[0].Name != "hash" || field[0].Bits != 256 ||
+		field[0].BigValue.Text(16) != strings.TrimLeft(digest, "0") {
+		t.Fatalf("expected the message hash checked against the digest, got %+v", field)
+	}
+	first, second := deps.evidences[0].Attachments[0], deps.evidences[0].Attachments[1]
+	if first.Position != 0 || first.Kind != models.AttachmentKindImage || *first.Caption != "screenshot" ||
+		first.ThumbnailHash == nil || first.EvidenceID != deps.evidences[0].ID {
+		t.Fatalf("unexpected first attachment: %+v", first)
+	}
+	if second.Position != 1 || second.Kind != models.AttachmentKindPDF || second.Caption != nil ||
+		media.blobs[second.MediaHash] == nil {
+		t.Fatalf("unexpected second attachment: %+v", second)
+	}
+}
+
+func TestEvidenceServiceApplyLegacyImage(t *testing.T) {
+	deps := &fakeEvidenceDeps{
+		isFirst:         true,
+		user:            models.User{ID: uuid.New(), Username: "alice"},
+		participantSelf: models.Participant{ID: uuid.New(), Result: models.DisputesResultEvidence},
+	}
+	media := newFakeMediaDeps()
+	svc := EvidenceService{
+		logger:             noopLogger{},
+		evidenceCreator:    deps,
+		evidenceChecker:    deps,
+		userFinder:         deps,
+		participantUpdater: deps,
+		participantGetter:  deps,
+		disputesFinder:     deps,
+	}.WithMediaStore(newTestMediaService(media))
+
+	// Operations recorded before attachments carry a single image, inline or already in the blob store.
+	err := svc.provideEvidence(context.Background(), models.EvidenceOpts{
+		DisputeID: uuid.NewString(),
+		Username:  "alice",
+		ImageData: []byte("webp"),
+		ImageType: "image/webp",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = svc.provideEvidence(context.Background(), models.EvidenceOpts{
+		DisputeID:     uuid.NewString(),
+		Username:      "alice",
+		ImageHash:     new("ab12"),
+		ThumbnailHash: new("cd34"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deps.evidences) != 2 || len(deps.evidences[0].Attachments) != 1 || len(deps.evidences[1].Attachments) != 1 {
+		t.Fatalf("expected the images attached, got %+v", deps.evidences)
+	}
+	if a := deps.evidences[0].Attachments[0]; string(media.blobs[a.MediaHash]) != "webp" {
+		t.Fatalf("expected the inline image stored as uploaded, got %+v", a)
+	}
+	if a := deps.evidences[1].Attachments[0]; a.Kind != models.AttachmentKindImage || a.MediaHash != "ab12" ||
+		*a.ThumbnailHash != "cd34" {
+		t.Fatalf("expected the stored image attached, got %+v", a)
+	}
+}
+
+func TestEvidenceServiceGetEvidencesAttachments(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	deps := &fakeEvidenceDeps{
+		evidences: []models.Evidence{
+			{ID: first, ReleasedAt: new(time.Now())},
+			{ID: second, ReleasedAt: new(time.Now())},
+		},
+		attachments: []models.EvidenceAttachment{
+			{EvidenceID: second, Position: 0, MediaHash: "ab12", ThumbnailHash: new("cd34")},
+			{EvidenceID: second, Position: 1, MediaHash: "ef56"},
+		},
+	}
+	deps.canView = true
+	svc := EvidenceService{
+		logger:            noopLogger{},
+		evidenceGetter:    deps,
+		evidenceViewer:    deps,
+		attachmentLister:  deps,
+		userFinder:        deps,
+		participantGetter: deps,
+	}
+
+	evidences, err := svc.GetEvidences(context.Background(), uuid.NewString(), "juror")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(evidences) != 2 || evidences[0].Attachments != nil || len(evidences[1].Attachments) != 2 {
+		t.Fatalf("expected the attachments grouped by evidence, got %+v", evidences)
+	}
+	a := evidences[1].Attachments
+	if a[0].URL != "/api/v1/media/ab12" || *a[0].ThumbnailURL != "/api/v1/media/cd34" ||
+		a[1].URL != "/api/v1/media/ef56" || a[1].ThumbnailURL != nil {
+		t.Fatalf("expected media URLs, got %+v", a)
+	}
+}
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return buf.Bytes()
+}
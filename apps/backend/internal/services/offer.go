@@ -0,0 +1,474 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+type OfferCreator interface {
+	InsertOffer(ctx context.Context, offer models.DisputeOffer) error
+}
+
+type OfferFinder interface {
+	GetLatestOffer(ctx context.Context, disputeID uuid.UUID) (models.DisputeOffer, error)
+	GetOffer(ctx context.Context, disputeID uuid.UUID, version int) (models.DisputeOffer, error)
+	ListOffers(ctx context.Context, disputeID uuid.UUID) ([]models.OfferCard, error)
+}
+
+type OfferDecider interface {
+	DecideOffer(ctx context.Context, offerID uuid.UUID, status models.OfferStatus, funded *models.DisputeOffer,
+	) (bool, error)
+	SupersedePendingOffers(ctx context.Context, disputeID uuid.UUID) error
+}
+
+type DisputeTermsUpdater interface {
+	ApplyDisputeTerms(ctx context.Context, offer models.DisputeOffer) (bool, error)
+}
+
+// OfferService lets the two sides of a dispute negotiate its terms before the opponent accepts it.
+// The opponent counter-proposes off-chain; the creator revises the terms or adopts a counter-offer, funding a new
+// bet whenever the stake or the deadline changes, since the bet contract fixes both on deploy. The creator cancels
+// the bet funding the current terms in the same transaction, so its stake is refunded rather than stranded.
+type OfferService struct {
+	logger log.Logger
+
+	disputeFinder      DisputeFinder
+	termsUpdater       DisputeTermsUpdater
+	offerCreator       OfferCreator
+	offerFinder        OfferFinder
+	offerDecider       OfferDecider
+	participantLister  ParticipantLister
+	participantUpdater ParticipantUpdater
+	userFinder         UserFinder
+	msgSender          MessageSender
+	txMonitor          TransactionMonitor
+	txRunner           TxRunner
+	opRecorder         OperationRecorder
+	opStore            PendingOperationStore
+	walletStore        UserWalletStore
+	betMaster          string
+}
+
+func NewOfferService(repo *repository.Repository, log log.Logger, msgSender MessageSender) (OfferService, error) {
+	if repo == nil {
+		return OfferService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return OfferService{}, fmt.Errorf("logger is nil")
+	}
+	return OfferService{
+		logger:             log,
+		disputeFinder:      repo,
+		termsUpdater:       repo,
+		offerCreator:       repo,
+		offerFinder:        repo,
+		offerDecider:       repo,
+		participantLister:  repo,
+		participantUpdater: transitionUpdater{repo},
+		userFinder:         repo,
+		msgSender:          msgSender,
+		txRunner:           repo,
+		opRecorder:         repo,
+		opStore:            repo,
+		walletStore:        repo,
+	}, nil
+}
+
+func (s OfferService) WithTransactionMonitor(txMonitor TransactionMonitor) OfferService {
+	s.txMonitor = txMonitor
+	return s
+}
+
+// WithBetMaster sets the address of the BetMaster contract that deploys the bets funding revised terms.
+func (s OfferService) WithBetMaster(address string) OfferService {
+	s.betMaster = address
+	return s
+}
+
+func (s OfferService) operations() operationRunner {
+	return operationRunner{
+		txMonitor:  s.txMonitor,
+		txRunner:   s.txRunner,
+		recorder:   s.opRecorder,
+		store:      s.opStore,
+		wallets:    s.walletStore,
+		userFinder: s.userFinder,
+		msgSender:  s.msgSender,
+		logger:     s.logger,
+	}
+}
+
+// offerProposePayload is what ProposeOffer stores with its pending operation.
+type offerProposePayload struct {
+	models.DisputeOfferReq
+	FundedDepositNano int64 `json:"fundedDepositNano"`
+}
+
+// offerAcceptPayload is what AcceptOffer stores with its pending operation.
+type offerAcceptPayload struct {
+	Version           int    `json:"version"`
+	ContractAddress   string `json:"contractAddress"`
+	FundedDepositNano int64  `json:"fundedDepositNano"`
+}
+
+// negotiation is a classic dispute seen by one of its two sides.
+type negotiation struct {
+	dispute models.Dispute
+	actor   models.User
+	self    models.Participant
+	other   models.Participant
+}
+
+// ListOffers returns the versions of the dispute terms to one of its sides.
+func (s OfferService) ListOffers(ctx context.Context, disputeID string, actorUsername string,
+) ([]models.OfferCard, error) {
+	n, err := s.negotiation(ctx, disputeID, actorUsername)
+	if err != nil {
+		return nil, err
+	}
+	offers, err := s.offerFinder.ListOffers(ctx, n.dispute.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offers: %w", err)
+	}
+	return offers, nil
+}
+
+// ProposeOffer records new terms of a dispute nobody has accepted yet. The opponent's offer waits for the
+// creator; the creator's revision binds at once, after the bet funding it is confirmed when the stake or the
+// deadline changes.
+func (s OfferService) ProposeOffer(ctx context.Context, disputeID string, actorUsername string,
+	req models.DisputeOfferReq,
+) (models.PendingOperation, error) {
+	ctx = repository.WithEventSource(ctx, actorUsername, "")
+	n, err := s.negotiation(ctx, disputeID, actorUsername)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	offer, err := models.NewDisputeOffer(n.dispute, n.self.UserID, n.dispute.TermsVersion, req)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	payload := offerProposePayload{DisputeOfferReq: req}
+	apply := func(ctx context.Context) error {
+		return s.proposeOffer(ctx, disputeID, actorUsername, payload)
+	}
+	if !n.self.IsCreator || !offer.ChangesStake(n.dispute) {
+		return s.settle(ctx, models.OperationActionProposeOffer, disputeID, apply)
+	}
+	want, err := s.fundingMessage(ctx, n, &offer, req.ContractAddress, req.Boc)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	payload.FundedDepositNano = offer.DepositNano
+	return s.operations().submit(ctx, actorUsername, models.OperationActionProposeOffer, disputeID, req.Boc, want,
+		payload, apply)
+}
+
+// AcceptOffer makes the opponent's pending offer the terms of the dispute; the opponent then accepts the
+// dispute as usual.
+func (s OfferService) AcceptOffer(ctx context.Context, disputeID string, version int, actorUsername string,
+	contractAddress, boc string,
+) (models.PendingOperation, error) {
+	ctx = repository.WithEventSource(ctx, actorUsername, "")
+	n, err := s.negotiation(ctx, disputeID, actorUsername)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	offer, err := s.pendingOffer(ctx, n, version)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	payload := offerAcceptPayload{Version: version, ContractAddress: contractAddress}
+	apply := func(ctx context.Context) error {
+		return s.acceptOffer(ctx, disputeID, actorUsername, payload)
+	}
+	if !offer.ChangesStake(n.dispute) {
+		return s.settle(ctx, models.OperationActionAcceptOffer, disputeID, apply)
+	}
+	want, err := s.fundingMessage(ctx, n, &offer, contractAddress, boc)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	payload.FundedDepositNano = offer.DepositNano
+	return s.operations().submit(ctx, actorUsername, models.OperationActionAcceptOffer, disputeID, boc, want,
+		payload, apply)
+}
+
+// DeclineOffer turns down the opponent's pending offer; the dispute keeps its current terms.
+func (s OfferService) DeclineOffer(ctx context.Context, disputeID string, version int, actorUsername string) error {
+	ctx = repository.WithEventSource(ctx, actorUsername, "")
+	return inTx(ctx, s.txRunner, s.msgSender, s.logger, func(ctx context.Context) error {
+		n, err := s.negotiation(ctx, disputeID, actorUsername)
+		if err != nil {
+			return err
+		}
+		offer, err := s.pendingOffer(ctx, n, version)
+		if err != nil {
+			return err
+		}
+		decided, err := s.offerDecider.DecideOffer(ctx, offer.ID, models.OfferStatusDeclined, nil)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return fmt.Errorf("%w: offer %d is no longer pending", ErrValidation, version)
+		}
+		if err = s.resetSides(ctx, n); err != nil {
+			return err
+		}
+		return notifyUser(ctx, s.userFinder, s.msgSender, n.other.UserID,
+			fmt.Sprintf("Пользователь %s отклонил ваши условия пари %s", n.actor.Username, n.dispute.Title))
+	})
+}
+
+// ApplyOperation applies a confirmed operation recorded by ProposeOffer or AcceptOffer.
+func (s OfferService) ApplyOperation(ctx context.Context, op models.PendingOperation) error {
+	return s.operations().apply(ctx, op, func(ctx context.Context, username string) error {
+		switch op.Action {
+		case models.OperationActionProposeOffer:
+			var payload offerProposePayload
+			if err := decodePayload(op, &payload); err != nil {
+				return err
+			}
+			return s.proposeOffer(ctx, op.EntityID, username, payload)
+		case models.OperationActionAcceptOffer:
+			var payload offerAcceptPayload
+			if err := decodePayload(op, &payload); err != nil {
+				return err
+			}
+			return s.acceptOffer(ctx, op.EntityID, username, payload)
+		default:
+			return fmt.Errorf("%w: unsupported offer operation %s", ErrValidation, op.Action)
+		}
+	})
+}
+
+func (s OfferService) proposeOffer(ctx context.Context, disputeID string, actorUsername string,
+	payload offerProposePayload,
+) error {
+	n, err := s.negotiation(ctx, disputeID, actorUsername)
+	if err != nil {
+		return err
+	}
+	latest, err := s.offerFinder.GetLatestOffer(ctx, n.dispute.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get latest offer: %w", err)
+	}
+	offer, err := models.NewDisputeOffer(n.dispute, n.self.UserID, latest.Version, payload.DisputeOfferReq)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	if err = s.offerDecider.SupersedePendingOffers(ctx, n.dispute.ID); err != nil {
+		return err
+	}
+
+	if n.self.IsCreator {
+		if offer.ChangesStake(n.dispute) {
+			if err = fundOffer(&offer, payload.ContractAddress, payload.FundedDepositNano); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		offer.Status = models.OfferStatusAccepted
+		offer.DecidedAt = &now
+		if err = s.offerCreator.InsertOffer(ctx, offer); err != nil {
+			return err
+		}
+		return s.applyTerms(ctx, n, offer,
+			fmt.Sprintf("Пользователь %s изменил условия пари %s", n.actor.Username, n.dispute.Title))
+	}
+
+	if err = s.offerCreator.InsertOffer(ctx, offer); err != nil {
+		return err
+	}
+	opts, err := transition(n.self, models.DisputeActionCounterOffer, models.ActorSelf, models.DisputesResultCountered)
+	if err != nil {
+		return err
+	}
+	opts.Seen = new(true)
+	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+		return fmt.Errorf("failed to update proposer dispute status: %w", err)
+	}
+	opts, err = transition(n.other, models.DisputeActionCounterOffer, models.ActorPeer, models.DisputesResultOffered)
+	if err != nil {
+		return err
+	}
+	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+		return fmt.Errorf("failed to update creator dispute status: %w", err)
+	}
+	return notifyUser(ctx, s.userFinder, s.msgSender, n.other.UserID,
+		fmt.Sprintf("Пользователь %s предложил новые условия пари %s", n.actor.Username, n.dispute.Title))
+}
+
+func (s OfferService) acceptOffer(ctx context.Context, disputeID string, actorUsername string,
+	payload offerAcceptPayload,
+) error {
+	n, err := s.negotiation(ctx, disputeID, actorUsername)
+	if err != nil {
+		return err
+	}
+	offer, err := s.pendingOffer(ctx, n, payload.Version)
+	if err != nil {
+		return err
+	}
+	var funded *models.DisputeOffer
+	if offer.ChangesStake(n.dispute) {
+		if err = fundOffer(&offer, payload.ContractAddress, payload.FundedDepositNano); err != nil {
+			return err
+		}
+		funded = &offer
+	}
+	decided, err := s.offerDecider.DecideOffer(ctx, offer.ID, models.OfferStatusAccepted, funded)
+	if err != nil {
+		return err
+	}
+	if !decided {
+		return fmt.Errorf("%w: offer %d is no longer pending", ErrValidation, payload.Version)
+	}
+	return s.applyTerms(ctx, n, offer, fmt.Sprintf(
+		"Пользователь %s принял ваши условия пари %s. Примите пари, чтобы внести ставку.",
+		n.actor.Username, n.dispute.Title))
+}
+
+// applyTerms sets the dispute terms to offer and hands the dispute back to the opponent to accept.
+func (s OfferService) applyTerms(ctx context.Context, n negotiation, offer models.DisputeOffer, msg string) error {
+	applied, err := s.termsUpdater.ApplyDisputeTerms(ctx, offer)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("%w: dispute terms changed meanwhile", ErrValidation)
+	}
+	if err = s.resetSides(ctx, n); err != nil {
+		return err
+	}
+	return notifyUser(ctx, s.userFinder, s.msgSender, n.other.UserID, msg)
+}
+
+// resetSides marks the creator as waiting for the opponent to accept the current terms.
+func (s OfferService) resetSides(ctx context.Context, n negotiation) error {
+	opts, err := transition(n.self, models.DisputeActionSetTerms, models.ActorSelf, models.DisputesResultSent)
+	if err != nil {
+		return err
+	}
+	opts.Seen = new(true)
+	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+		return fmt.Errorf("failed to update creator dispute status: %w", err)
+	}
+	opts, err = transition(n.other, models.DisputeActionSetTerms, models.ActorPeer, models.DisputesResultNew)
+	if err != nil {
+		return err
+	}
+	if err = s.participantUpdater.UpdateParticipant(ctx, opts); err != nil {
+		return fmt.Errorf("failed to update opponent dispute status: %w", err)
+	}
+	return nil
+}
+
+// negotiation loads a dispute whose terms the actor may still change.
+func (s OfferService) negotiation(ctx context.Context, disputeID string, actorUsername string) (negotiation, error) {
+	disputeUUID, err := uuid.Parse(disputeID)
+	if err != nil {
+		return negotiation{}, fmt.Errorf("%w: invalid dispute ID format: %v", ErrValidation, err)
+	}
+	dispute, err := s.disputeFinder.GetDisputeByID(ctx, disputeUUID)
+	if err != nil {
+		return negotiation{}, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	actor, err := s.userFinder.GetUserByUsername(ctx, actorUsername)
+	if err != nil {
+		return negotiation{}, fmt.Errorf("failed to get actor user: %w", err)
+	}
+	participants, err := s.participantLister.ListParticipants(ctx, disputeUUID)
+	if err != nil {
+		return negotiation{}, fmt.Errorf("failed to list participants: %w", err)
+	}
+	if len(participants) != 2 {
+		return negotiation{}, fmt.Errorf("%w: dispute %s has no opponent to negotiate with", ErrValidation, dispute.ID)
+	}
+
+	n := negotiation{dispute: dispute, actor: actor, self: participants[0], other: participants[1]}
+	if n.self.UserID != actor.ID {
+		n.self, n.other = n.other, n.self
+	}
+	if n.self.UserID != actor.ID {
+		return negotiation{}, fmt.Errorf("%w: user is not a participant of dispute %s", ErrValidation, dispute.ID)
+	}
+	if !models.Negotiating(n.self, n.other) {
+		return negotiation{}, fmt.Errorf("%w: terms of dispute %s are settled: self: %s, opponent: %s",
+			ErrValidation, dispute.ID, n.self.Result, n.other.Result)
+	}
+	return n, nil
+}
+
+// pendingOffer returns the opponent's offer the creator is to answer.
+func (s OfferService) pendingOffer(ctx context.Context, n negotiation, version int) (models.DisputeOffer, error) {
+	if !n.self.IsCreator {
+		return models.DisputeOffer{}, fmt.Errorf("%w: only the creator answers offers", ErrValidation)
+	}
+	offer, err := s.offerFinder.GetOffer(ctx, n.dispute.ID, version)
+	if err != nil {
+		return models.DisputeOffer{}, fmt.Errorf("failed to get offer: %w", err)
+	}
+	if offer.Status != models.OfferStatusPending || offer.ProposerID == n.self.UserID {
+		return models.DisputeOffer{}, fmt.Errorf("%w: offer %d is not waiting for an answer", ErrValidation, version)
+	}
+	return offer, nil
+}
+
+// fundingMessage returns the CreateBet message that funds a new bet with the terms of offer, sent after the
+// Cancel that refunds the bet of the current terms. It sets the deposit of offer to the one BetMaster takes.
+func (s OfferService) fundingMessage(ctx context.Context, n negotiation, offer *models.DisputeOffer,
+	contractAddress, boc string,
+) (models.ExpectedMessage, error) {
+	if contractAddress == "" || boc == "" {
+		return models.ExpectedMessage{}, fmt.Errorf("%w: changing the stake or the deadline needs a new bet",
+			ErrValidation)
+	}
+	if s.betMaster == "" {
+		return models.ExpectedMessage{}, fmt.Errorf("bet master address is not configured")
+	}
+	if s.txMonitor == nil {
+		return models.ExpectedMessage{}, fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
+	}
+	minDeposit, err := s.txMonitor.CallGetter(ctx, s.betMaster, models.MinDepositGetter)
+	if err != nil {
+		return models.ExpectedMessage{}, fmt.Errorf("failed to get minimal bet deposit: %w", err)
+	}
+	offer.DepositNano = models.BetDeposit(offer.AmountNano, minDeposit)
+
+	want := models.CreateBetMessage(s.betMaster, offer.AmountNano+offer.DepositNano, offer.EndsAt)
+	want.Preceding = []models.ExpectedMessage{
+		{Destination: n.dispute.ContractAddress, Opcodes: []uint32{models.OpCancel}},
+	}
+	return want, nil
+}
+
+// fundOffer records the bet funded for offer and the deposit BetMaster took for it.
+func fundOffer(offer *models.DisputeOffer, contractAddress string, depositNano int64) error {
+	if contractAddress == "" {
+		return fmt.Errorf("%w: contract address is required to change the stake", ErrValidation)
+	}
+	if depositNano <= 0 {
+		return fmt.Errorf("%w: deposit of the new bet is unknown", ErrValidation)
+	}
+	offer.ContractAddress = &contractAddress
+	offer.DepositNano = depositNano
+	return nil
+}
+
+// settle applies a change that needs no transaction and returns it as an operation that already succeeded.
+func (s OfferService) settle(ctx context.Context, action models.OperationAction, disputeID string,
+	apply func(ctx context.Context) error,
+) (models.PendingOperation, error) {
+	if err := inTx(ctx, s.txRunner, s.msgSender, s.logger, apply); err != nil {
+		return models.PendingOperation{}, err
+	}
+	return settledOperation(action, disputeID, ""), nil
+}
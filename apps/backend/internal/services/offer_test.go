@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeOfferRepo struct {
+	*fakeDisputeRepo
+
+	decided      map[uuid.UUID]models.OfferStatus
+	superseded   int
+	appliedTerms []models.DisputeOffer
+}
+
+func (f *fakeOfferRepo) GetLatestOffer(context.Context, uuid.UUID) (models.DisputeOffer, error) {
+	if len(f.offers) == 0 {
+		return models.DisputeOffer{}, repository.ErrNotFound
+	}
+	return f.offers[len(f.offers)-1], nil
+}
+
+func (f *fakeOfferRepo) GetOffer(_ context.Context, _ uuid.UUID, version int) (models.DisputeOffer, error) {
+	for _, o := range f.offers {
+		if o.Version == version {
+			return o, nil
+		}
+	}
+	return models.DisputeOffer{}, repository.ErrNotFound
+}
+
+func (f *fakeOfferRepo) ListOffers(context.Context, uuid.UUID) ([]models.OfferCard, error) {
+	return nil, nil
+}
+
+func (f *fakeOfferRepo) DecideOffer(_ context.Context, offerID uuid.UUID, status models.OfferStatus,
+	funded *models.DisputeOffer,
+) (bool, error) {
+	for i, o := range f.offers {
+		if o.ID == offerID && o.Status == models.OfferStatusPending {
+			f.offers[i].Status = status
+			if funded != nil {
+				f.offers[i].ContractAddress = funded.ContractAddress
+				f.offers[i].DepositNano = funded.DepositNano
+			}
+			f.decided[offerID] = status
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeOfferRepo) SupersedePendingOffers(context.Context, uuid.UUID) error {
+	for i, o := range f.offers {
+		if o.Status == models.OfferStatusPending {
+			f.offers[i].Status = models.OfferStatusSuperseded
+			f.superseded++
+		}
+	}
+	return nil
+}
+
+func (f *fakeOfferRepo) ApplyDisputeTerms(_ context.Context, offer models.DisputeOffer) (bool, error) {
+	if offer.Version <= f.dispute.TermsVersion {
+		return false, nil
+	}
+	f.appliedTerms = append(f.appliedTerms, offer)
+	f.dispute.AmountNano = offer.AmountNano
+	f.dispute.DepositNano = offer.DepositNano
+	f.dispute.EndsAt = offer.EndsAt
+	f.dispute.Description = offer.Description
+	if offer.ContractAddress != nil {
+		f.dispute.ContractAddress = *offer.ContractAddress
+	}
+	f.dispute.TermsVersion = offer.Version
+	return true, nil
+}
+
+func TestOfferServiceNegotiation(t *testing.T) {
+	creator := models.User{ID: uuid.New(), Username: "alice", ChatID: 1, NotificationEnabled: true}
+	opponent := models.User{ID: uuid.New(), Username: "bob", ChatID: 2, NotificationEnabled: true}
+	disputeID := uuid.New()
+	endsAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	creatorParticipantID := uuid.New()
+	opponentParticipantID := uuid.New()
+
+	newRepo := func(creatorResult, opponentResult models.Result) *fakeOfferRepo {
+		dispute := models.Dispute{
+			ID:              disputeID,
+			Title:           "T",
+			Description:     "desc",
+			AmountNano:      100,
+			DepositNano:     20,
+			EndsAt:          endsAt,
+			ContractAddress: "bet-v1",
+			TermsVersion:    1,
+		}
+		return &fakeOfferRepo{
+			fakeDisputeRepo: &fakeDisputeRepo{
+				usersByUsername: map[string]models.User{"alice": creator, "bob": opponent},
+				usersByID:       map[uuid.UUID]models.User{creator.ID: creator, opponent.ID: opponent},
+				dispute:         dispute,
+				participants: []models.Participant{
+					{ID: creatorParticipantID, UserID: creator.ID, Status: models.DisputesStatusNew,
+						Result: creatorResult, IsCreator: true},
+					{ID: opponentParticipantID, UserID: opponent.ID, Status: models.DisputesStatusNew,
+						Result: opponentResult},
+				},
+				offers: []models.DisputeOffer{models.InitialOffer(dispute, creator.ID)},
+			},
+			decided: map[uuid.UUID]models.OfferStatus{},
+		}
+	}
+	newService := func(repo *fakeOfferRepo, sender *fakeMessageSender, txMonitor *fakeTxMonitor) OfferService {
+		return OfferService{
+			logger:             noopLogger{},
+			disputeFinder:      repo,
+			termsUpdater:       repo,
+			offerCreator:       repo,
+			offerFinder:        repo,
+			offerDecider:       repo,
+			participantLister:  repo,
+			participantUpdater: repo,
+			userFinder:         repo,
+			msgSender:          sender,
+			txMonitor:          txMonitor,
+			betMaster:          testBetMaster,
+		}
+	}
+
+	t.Run("opponent counter waits for the creator", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultSent, models.DisputesResultNew)
+		sender := &fakeMessageSender{}
+		txMonitor := &fakeTxMonitor{}
+
+		op, err := newService(repo, sender, txMonitor).ProposeOffer(context.Background(), disputeID.String(), "bob",
+			models.DisputeOfferReq{AmountNano: "50"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if op.Status != models.OperationStatusSucceeded {
+			t.Fatalf("expected settled operation, got %s", op.Status)
+		}
+		if txMonitor.calls != 0 {
+			t.Fatalf("expected no transaction for a counter-offer, got %d", txMonitor.calls)
+		}
+		if len(repo.offers) != 2 || repo.offers[1].Version != 2 || repo.offers[1].Status != models.OfferStatusPending ||
+			repo.offers[1].AmountNano != 50 {
+			t.Fatalf("unexpected offers: %+v", repo.offers)
+		}
+		if len(repo.appliedTerms) != 0 {
+			t.Fatal("expected dispute terms to stay until the creator answers")
+		}
+		if len(repo.updatedDP) != 2 || *repo.updatedDP[0].Result != models.DisputesResultCountered ||
+			*repo.updatedDP[1].Result != models.DisputesResultOffered {
+			t.Fatalf("unexpected participant updates: %+v", repo.updatedDP)
+		}
+		if sender.calls != 1 || sender.chatIDs[0] != creator.ChatID {
+			t.Fatalf("expected creator to be notified, got %v", sender.chatIDs)
+		}
+	})
+
+	t.Run("creator revises the description off-chain", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultSent, models.DisputesResultNew)
+		sender := &fakeMessageSender{}
+
+		_, err := newService(repo, sender, &fakeTxMonitor{}).ProposeOffer(context.Background(), disputeID.String(),
+			"alice", models.DisputeOfferReq{Description: "clearer"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.dispute.TermsVersion != 2 || repo.dispute.Description != "clearer" ||
+			repo.dispute.ContractAddress != "bet-v1" {
+			t.Fatalf("unexpected dispute terms: %+v", repo.dispute)
+		}
+		if repo.offers[1].Status != models.OfferStatusAccepted {
+			t.Fatalf("expected revision to bind at once, got %s", repo.offers[1].Status)
+		}
+		if sender.calls != 1 || sender.chatIDs[0] != opponent.ChatID {
+			t.Fatalf("expected opponent to be notified, got %v", sender.chatIDs)
+		}
+	})
+
+	t.Run("creator revision of the stake funds a new bet", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultSent, models.DisputesResultNew)
+		txMonitor := &fakeTxMonitor{getters: map[string]int64{testBetMaster + ".minDeposit": 30}}
+		svc := newService(repo, &fakeMessageSender{}, txMonitor)
+
+		_, err := svc.ProposeOffer(context.Background(), disputeID.String(), "alice",
+			models.DisputeOfferReq{AmountNano: "200"})
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected ErrValidation without a new bet, got %v", err)
+		}
+
+		_, err = svc.ProposeOffer(context.Background(), disputeID.String(), "alice",
+			models.DisputeOfferReq{AmountNano: "200", ContractAddress: "bet-v2", Boc: "boc"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// BetMaster takes the deposit from the stake: a tenth of it, but no less than its minimum.
+		if want := txMonitor.want; want.Destination != testBetMaster || want.ValueNano != 230 {
+			t.Fatalf("unexpected expected message: %+v", want)
+		}
+		if preceding := txMonitor.want.Preceding; len(preceding) != 1 || txMonitor.want.PrecedingOptional ||
+			preceding[0].Destination != "bet-v1" || preceding[0].Opcodes[0] != models.OpCancel {
+			t.Fatalf("expected the superseded bet to be cancelled first, got %+v", preceding)
+		}
+		if repo.dispute.AmountNano != 200 || repo.dispute.DepositNano != 30 ||
+			repo.dispute.ContractAddress != "bet-v2" {
+			t.Fatalf("unexpected dispute terms: %+v", repo.dispute)
+		}
+	})
+
+	t.Run("opponent stakes what the revised bet was funded with", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultSent, models.DisputesResultNew)
+		repo.dispute.ContractAddress = "bet-v2"
+		txMonitor := &fakeTxMonitor{verifyErr: ErrInvalidBOC}
+		svc := DisputeService{logger: noopLogger{}, disputeFinder: repo, userFinder: repo, txMonitor: txMonitor}
+
+		_, err := svc.AcceptDispute(context.Background(), disputeID.String(), "bob", "boc")
+		if !errors.Is(err, ErrInvalidBOC) {
+			t.Fatalf("expected the message check to fail, got %v", err)
+		}
+		if want := txMonitor.want; want.Destination != "bet-v2" || want.ValueNano != 0 ||
+			want.ValueGetter != models.StakeWithDepositGetter {
+			t.Fatalf("expected the value read from the bet, got %+v", want)
+		}
+	})
+
+	t.Run("creator funds the counter-offer", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultOffered, models.DisputesResultCountered)
+		counter := models.DisputeOffer{
+			ID: uuid.New(), DisputeID: disputeID, Version: 2, ProposerID: opponent.ID, AmountNano: 500,
+			DepositNano: 20, EndsAt: endsAt, Description: "desc", Status: models.OfferStatusPending,
+		}
+		repo.offers = append(repo.offers, counter)
+		txMonitor := &fakeTxMonitor{getters: map[string]int64{testBetMaster + ".minDeposit": 30}}
+
+		_, err := newService(repo, &fakeMessageSender{}, txMonitor).AcceptOffer(context.Background(),
+			disputeID.String(), 2, "alice", "bet-v2", "boc")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := txMonitor.want; want.ValueNano != 550 || len(want.Preceding) != 1 ||
+			want.Preceding[0].Destination != "bet-v1" {
+			t.Fatalf("unexpected expected message: %+v", want)
+		}
+		if funded := repo.offers[1]; *funded.ContractAddress != "bet-v2" || funded.DepositNano != 50 {
+			t.Fatalf("expected the offer to record the new bet, got %+v", funded)
+		}
+		if repo.dispute.DepositNano != 50 || repo.dispute.ContractAddress != "bet-v2" {
+			t.Fatalf("unexpected dispute terms: %+v", repo.dispute)
+		}
+	})
+
+	t.Run("creator accepts the counter-offer", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultOffered, models.DisputesResultCountered)
+		counter := models.DisputeOffer{
+			ID: uuid.New(), DisputeID: disputeID, Version: 2, ProposerID: opponent.ID, AmountNano: 100,
+			DepositNano: 20, EndsAt: endsAt, Description: "fairer", Status: models.OfferStatusPending,
+		}
+		repo.offers = append(repo.offers, counter)
+		sender := &fakeMessageSender{}
+
+		_, err := newService(repo, sender, &fakeTxMonitor{}).AcceptOffer(context.Background(), disputeID.String(), 2,
+			"alice", "", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.decided[counter.ID] != models.OfferStatusAccepted {
+			t.Fatalf("expected counter-offer to be accepted, got %q", repo.decided[counter.ID])
+		}
+		if repo.dispute.TermsVersion != 2 || repo.dispute.Description != "fairer" {
+			t.Fatalf("unexpected dispute terms: %+v", repo.dispute)
+		}
+		if len(repo.updatedDP) != 2 || *repo.updatedDP[0].Result != models.DisputesResultSent ||
+			*repo.updatedDP[1].Result != models.DisputesResultNew {
+			t.Fatalf("unexpected participant updates: %+v", repo.updatedDP)
+		}
+		if sender.calls != 1 || sender.chatIDs[0] != opponent.ChatID {
+			t.Fatalf("expected opponent to be notified, got %v", sender.chatIDs)
+		}
+	})
+
+	t.Run("opponent can't answer its own counter-offer", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultOffered, models.DisputesResultCountered)
+		repo.offers = append(repo.offers, models.DisputeOffer{
+			ID: uuid.New(), Version: 2, ProposerID: opponent.ID, Status: models.OfferStatusPending,
+		})
+
+		err := newService(repo, &fakeMessageSender{}, &fakeTxMonitor{}).DeclineOffer(context.Background(),
+			disputeID.String(), 2, "bob")
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected ErrValidation, got %v", err)
+		}
+		if len(repo.decided) != 0 {
+			t.Fatal("expected offer to stay pending")
+		}
+	})
+
+	t.Run("creator declines the counter-offer", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultOffered, models.DisputesResultCountered)
+		counter := models.DisputeOffer{
+			ID: uuid.New(), Version: 2, ProposerID: opponent.ID, Status: models.OfferStatusPending,
+		}
+		repo.offers = append(repo.offers, counter)
+
+		err := newService(repo, &fakeMessageSender{}, &fakeTxMonitor{}).DeclineOffer(context.Background(),
+			disputeID.String(), 2, "alice")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.decided[counter.ID] != models.OfferStatusDeclined {
+			t.Fatalf("expected counter-offer to be declined, got %q", repo.decided[counter.ID])
+		}
+		if repo.dispute.TermsVersion != 1 {
+			t.Fatalf("expected terms to stay, got version %d", repo.dispute.TermsVersion)
+		}
+	})
+
+	t.Run("accepted dispute is not negotiable", func(t *testing.T) {
+		repo := newRepo(models.DisputesResultProcessed, models.DisputesResultProcessed)
+		repo.participants[0].Status = models.DisputesStatusCurrent
+		repo.participants[1].Status = models.DisputesStatusCurrent
+
+		_, err := newService(repo, &fakeMessageSender{}, &fakeTxMonitor{}).ProposeOffer(context.Background(),
+			disputeID.String(), "bob", models.DisputeOfferReq{Description: "late"})
+		if !errors.Is(err, ErrValidation) {
+			t.Fatalf("expected ErrValidation, got %v", err)
+		}
+	})
+}
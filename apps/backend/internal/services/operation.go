@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+type OperationRecorder interface {
+	InsertProcessedOperation(ctx context.Context, op models.ProcessedOperation) (bool, error)
+	GetProcessedOperation(ctx context.Context, msgHash string) (models.ProcessedOperation, error)
+}
+
+type PendingOperationStore interface {
+	InsertPendingOperation(ctx context.Context, op models.PendingOperation) (bool, error)
+	GetPendingOperationByHash(ctx context.Context, msgHash string) (models.PendingOperation, error)
+}
+
+// UserWalletStore keeps the wallet each user signs operations with.
+type UserWalletStore interface {
+	GetUserWallet(ctx context.Context, userID uuid.UUID) (string, error)
+	BindUserWallet(ctx context.Context, userID uuid.UUID, wallet string) (bool, error)
+}
+
+// errOperationReplayed aborts the unit of work of an operation that another request has already applied.
+var errOperationReplayed = errors.New("operation already processed")
+
+// operationRunner applies BOC-backed state changes at most once per external message.
+// The normalized message hash is bound to the user, action and entity of the first request:
+// repeating that request returns the same operation, reusing the hash for anything else is rejected.
+type operationRunner struct {
+	txMonitor  TransactionMonitor
+	txRunner   TxRunner
+	recorder   OperationRecorder
+	store      PendingOperationStore
+	wallets    UserWalletStore
+	userFinder UserFinder
+	msgSender  MessageSender
+	logger     log.Logger
+}
+
+// submit records a pending operation for boc and returns at once; the confirmer applies it after the
+// transaction is finalized. want is the contract call the signed message must carry, and payload keeps the
+// request arguments apply needs besides the entity ID.
+// Without a pending operation store the transaction is confirmed and applied synchronously instead, and the
+// returned operation is already settled.
+func (r operationRunner) submit(ctx context.Context, username string, action models.OperationAction,
+	entityID, boc string, want models.ExpectedMessage, payload any, apply func(ctx context.Context) error,
+) (models.PendingOperation, error) {
+	if r.store == nil {
+		if err := r.run(ctx, username, action, entityID, boc, want, apply); err != nil {
+			return models.PendingOperation{}, err
+		}
+		return settledOperation(action, entityID, boc), nil
+	}
+	if r.txMonitor == nil {
+		return models.PendingOperation{}, fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
+	}
+
+	msgHash, err := r.txMonitor.MessageHash(boc)
+	if err != nil {
+		return models.PendingOperation{}, err
+	}
+	user, err := r.userFinder.GetUserByUsername(ctx, username)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("failed to get user by username: %w", err)
+	}
+	if err = r.verify(ctx, user, boc, want); err != nil {
+		return models.PendingOperation{}, err
+	}
+	var rawPayload []byte
+	if payload != nil {
+		if rawPayload, err = json.Marshal(payload); err != nil {
+			return models.PendingOperation{}, fmt.Errorf("failed to encode operation payload: %w", err)
+		}
+	}
+	op := models.NewPendingOperation(msgHash, user.ID, action, entityID, boc, rawPayload)
+
+	existing, err := r.store.GetPendingOperationByHash(ctx, msgHash)
+	switch {
+	case err == nil:
+		return replayPending(existing, op)
+	case !errors.Is(err, repository.ErrNotFound):
+		return models.PendingOperation{}, fmt.Errorf("failed to get pending operation: %w", err)
+	}
+
+	inserted, err := r.store.InsertPendingOperation(ctx, op)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("failed to record pending operation: %w", err)
+	}
+	if !inserted {
+		// A concurrent request with the same message won the race.
+		existing, err = r.store.GetPendingOperationByHash(ctx, msgHash)
+		if err != nil {
+			return models.PendingOperation{}, fmt.Errorf("failed to get pending operation: %w", err)
+		}
+		return replayPending(existing, op)
+	}
+	return op, nil
+}
+
+// settledOperation is what a request that changed the state without waiting for a confirmation returns.
+func settledOperation(action models.OperationAction, entityID, boc string) models.PendingOperation {
+	op := models.NewPendingOperation("", uuid.Nil, action, entityID, boc, nil)
+	op.Status = models.OperationStatusSucceeded
+	return op
+}
+
+// replayPending returns the operation already recorded for the message when op repeats it.
+func replayPending(existing, op models.PendingOperation) (models.PendingOperation, error) {
+	if err := checkReplay(existing.Processed(), op.Processed()); err != nil {
+		return models.PendingOperation{}, err
+	}
+	return existing, nil
+}
+
+// apply runs fn for a confirmed pending operation on behalf of its owner, in one unit of work with the
+// operation record. An operation that was already applied is skipped.
+func (r operationRunner) apply(ctx context.Context, op models.PendingOperation,
+	fn func(ctx context.Context, username string) error,
+) error {
+	user, err := r.userFinder.GetUserByID(ctx, op.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get operation owner: %w", err)
+	}
+	ctx = repository.WithEventSource(ctx, user.Username, op.MsgHash)
+	return r.record(ctx, op.Processed(), func(ctx context.Context) error {
+		return fn(ctx, user.Username)
+	})
+}
+
+// run checks that boc carries want, waits for the transaction and applies the change in one unit of work with
+// the operation record. Without a recorder the change is applied on every call.
+func (r operationRunner) run(ctx context.Context, username string, action models.OperationAction, entityID, boc string,
+	want models.ExpectedMessage, apply func(ctx context.Context) error,
+) error {
+	if r.txMonitor == nil {
+		return fmt.Errorf("%w: tx monitor is not configured", ErrTxMonitorUnavailable)
+	}
+	user, err := r.userFinder.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user by username: %w", err)
+	}
+	if err = r.verify(ctx, user, boc, want); err != nil {
+		return err
+	}
+	if r.recorder == nil {
+		if err = r.txMonitor.WaitForSuccess(ctx, boc); err != nil {
+			return err
+		}
+		return inTx(repository.WithEventSource(ctx, username, ""), r.txRunner, r.msgSender, r.logger, apply)
+	}
+
+	msgHash, err := r.txMonitor.MessageHash(boc)
+	if err != nil {
+		return err
+	}
+	op := models.NewProcessedOperation(msgHash, user.ID, action, entityID)
+
+	// Cheap check before waiting for the chain: replays return at once.
+	existing, err := r.recorder.GetProcessedOperation(ctx, msgHash)
+	switch {
+	case err == nil:
+		return checkReplay(existing, op)
+	case !errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("failed to get processed operation: %w", err)
+	}
+
+	if err = r.txMonitor.WaitForSuccess(ctx, boc); err != nil {
+		return err
+	}
+	return r.record(repository.WithEventSource(ctx, username, msgHash), op, apply)
+}
+
+// verify checks that boc carries want and is signed by the user's wallet, so nobody passes off a message another
+// user signed as their own. The first operation of a user binds the wallet that signed it, and every later one
+// must come from that wallet. Without a wallet store the wallet is left unchecked.
+func (r operationRunner) verify(ctx context.Context, user models.User, boc string, want models.ExpectedMessage,
+) error {
+	if r.wallets == nil {
+		return r.txMonitor.VerifyMessage(ctx, boc, want)
+	}
+	wallet, err := r.wallets.GetUserWallet(ctx, user.ID)
+	switch {
+	case err == nil:
+		want.Wallet = wallet
+		return r.txMonitor.VerifyMessage(ctx, boc, want)
+	case !errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("failed to get user wallet: %w", err)
+	}
+
+	if err = r.txMonitor.VerifyMessage(ctx, boc, want); err != nil {
+		return err
+	}
+	sender, err := r.txMonitor.SenderWallet(boc)
+	if err != nil {
+		return err
+	}
+	bound, err := r.wallets.BindUserWallet(ctx, user.ID, sender)
+	if err != nil {
+		return err
+	}
+	if !bound {
+		return &MessageMismatchError{Field: "wallet", Expected: "a wallet of " + user.Username, Actual: sender}
+	}
+	return nil
+}
+
+// record applies the change together with its operation record. Without a recorder it is applied unconditionally.
+func (r operationRunner) record(ctx context.Context, op models.ProcessedOperation,
+	apply func(ctx context.Context) error,
+) error {
+	err := inTx(ctx, r.txRunner, r.msgSender, r.logger, func(ctx context.Context) error {
+		if r.recorder == nil {
+			return apply(ctx)
+		}
+		inserted, err := r.recorder.InsertProcessedOperation(ctx, op)
+		if err != nil {
+			return fmt.Errorf("failed to record operation: %w", err)
+		}
+		if !inserted {
+			// A concurrent request with the same message won the race.
+			existing, err := r.recorder.GetProcessedOperation(ctx, op.MsgHash)
+			if err != nil {
+				return fmt.Errorf("failed to get processed operation: %w", err)
+			}
+			if err = checkReplay(existing, op); err != nil {
+				return err
+			}
+			return errOperationReplayed
+		}
+		return apply(ctx)
+	})
+	if errors.Is(err, errOperationReplayed) {
+		return nil
+	}
+	return err
+}
+
+func checkReplay(existing, op models.ProcessedOperation) error {
+	if !existing.SameAs(op) {
+		return fmt.Errorf("%w: %s was used for %s", ErrIdempotencyConflict, op.MsgHash, existing.Action)
+	}
+	return nil
+}
+
+// decodePayload reads the request arguments submit stored with the operation.
+func decodePayload(op models.PendingOperation, v any) error {
+	if err := json.Unmarshal(op.Payload, v); err != nil {
+		return fmt.Errorf("%w: invalid %s payload: %v", ErrValidation, op.Action, err)
+	}
+	return nil
+}
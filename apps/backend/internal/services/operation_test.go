@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeOperationRecorder struct {
+	ops         map[string]models.ProcessedOperation
+	lostRace    *models.ProcessedOperation
+	insertCalls int
+}
+
+func (f *fakeOperationRecorder) InsertProcessedOperation(_ context.Context, op models.ProcessedOperation) (bool, error) {
+	f.insertCalls++
+	if f.lostRace != nil {
+		f.ops[op.MsgHash] = *f.lostRace
+		return false, nil
+	}
+	if _, ok := f.ops[op.MsgHash]; ok {
+		return false, nil
+	}
+	f.ops[op.MsgHash] = op
+	return true, nil
+}
+
+func (f *fakeOperationRecorder) GetProcessedOperation(_ context.Context, msgHash string,
+) (models.ProcessedOperation, error) {
+	op, ok := f.ops[msgHash]
+	if !ok {
+		return models.ProcessedOperation{}, repository.ErrNotFound
+	}
+	return op, nil
+}
+
+func TestOperationRunnerRun(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	disputeID := uuid.New().String()
+
+	newRunner := func(recorder *fakeOperationRecorder) (operationRunner, *fakeTxMonitor) {
+		monitor := &fakeTxMonitor{}
+		return operationRunner{
+			txMonitor:  monitor,
+			recorder:   recorder,
+			userFinder: &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": alice}},
+			msgSender:  &fakeMessageSender{},
+		}, monitor
+	}
+
+	t.Run("applies first submission and records it", func(t *testing.T) {
+		recorder := &fakeOperationRecorder{ops: map[string]models.ProcessedOperation{}}
+		runner, monitor := newRunner(recorder)
+		applied := 0
+
+		err := runner.run(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, func(context.Context) error { applied++; return nil })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if applied != 1 || monitor.calls != 1 {
+			t.Fatalf("expected one apply after tx wait, got applied=%d waits=%d", applied, monitor.calls)
+		}
+		if op := recorder.ops["hash-boc"]; op.UserID != alice.ID || op.EntityID != disputeID {
+			t.Fatalf("unexpected recorded operation: %+v", op)
+		}
+	})
+
+	t.Run("replay returns success without applying", func(t *testing.T) {
+		recorder := &fakeOperationRecorder{ops: map[string]models.ProcessedOperation{
+			"hash-boc": models.NewProcessedOperation("hash-boc", alice.ID, models.OperationActionVoteDispute, disputeID),
+		}}
+		runner, monitor := newRunner(recorder)
+		applied := 0
+
+		err := runner.run(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, func(context.Context) error { applied++; return nil })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if applied != 0 || monitor.calls != 0 || recorder.insertCalls != 0 {
+			t.Fatalf("expected replay to be a no-op, got applied=%d waits=%d inserts=%d",
+				applied, monitor.calls, recorder.insertCalls)
+		}
+	})
+
+	t.Run("reusing hash for another action is rejected", func(t *testing.T) {
+		recorder := &fakeOperationRecorder{ops: map[string]models.ProcessedOperation{
+			"hash-boc": models.NewProcessedOperation("hash-boc", alice.ID, models.OperationActionAcceptDispute, disputeID),
+		}}
+		runner, _ := newRunner(recorder)
+
+		err := runner.run(context.Background(), "alice", models.OperationActionClaimDispute, disputeID, "boc",
+			models.ExpectedMessage{}, func(context.Context) error { t.Fatal("must not apply"); return nil })
+		if !errors.Is(err, ErrIdempotencyConflict) {
+			t.Fatalf("expected ErrIdempotencyConflict, got %v", err)
+		}
+	})
+
+	t.Run("concurrent duplicate is treated as replay", func(t *testing.T) {
+		winner := models.NewProcessedOperation("hash-boc", alice.ID, models.OperationActionVoteDispute, disputeID)
+		recorder := &fakeOperationRecorder{ops: map[string]models.ProcessedOperation{}, lostRace: &winner}
+		runner, _ := newRunner(recorder)
+		txRunner := &fakeTxRunner{}
+		runner.txRunner = txRunner
+
+		err := runner.run(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, func(context.Context) error { t.Fatal("must not apply"); return nil })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if txRunner.rollbacks != 1 {
+			t.Fatalf("expected the losing unit of work to roll back, got %d", txRunner.rollbacks)
+		}
+	})
+}
+
+type fakePendingOperationStore struct {
+	ops         map[string]models.PendingOperation
+	lostRace    *models.PendingOperation
+	insertCalls int
+}
+
+func (f *fakePendingOperationStore) InsertPendingOperation(_ context.Context, op models.PendingOperation,
+) (bool, error) {
+	f.insertCalls++
+	if f.lostRace != nil {
+		f.ops[op.MsgHash] = *f.lostRace
+		return false, nil
+	}
+	if _, ok := f.ops[op.MsgHash]; ok {
+		return false, nil
+	}
+	f.ops[op.MsgHash] = op
+	return true, nil
+}
+
+func (f *fakePendingOperationStore) GetPendingOperationByHash(_ context.Context, msgHash string,
+) (models.PendingOperation, error) {
+	op, ok := f.ops[msgHash]
+	if !ok {
+		return models.PendingOperation{}, repository.ErrNotFound
+	}
+	return op, nil
+}
+
+func TestOperationRunnerSubmit(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	disputeID := uuid.New().String()
+
+	newRunner := func(store *fakePendingOperationStore) (operationRunner, *fakeTxMonitor) {
+		monitor := &fakeTxMonitor{}
+		return operationRunner{
+			txMonitor:  monitor,
+			store:      store,
+			userFinder: &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": alice}},
+			msgSender:  &fakeMessageSender{},
+		}, monitor
+	}
+	mustNotApply := func(context.Context) error { t.Fatal("must not apply"); return nil }
+
+	t.Run("records pending operation without waiting for the chain", func(t *testing.T) {
+		store := &fakePendingOperationStore{ops: map[string]models.PendingOperation{}}
+		runner, monitor := newRunner(store)
+
+		op, err := runner.submit(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, disputeVotePayload{Vote: true}, mustNotApply)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if monitor.calls != 0 {
+			t.Fatalf("expected no tx wait, got %d", monitor.calls)
+		}
+		if op.Status != models.OperationStatusPending || op.UserID != alice.ID || op.MsgHash != "hash-boc" {
+			t.Fatalf("unexpected operation: %+v", op)
+		}
+		if string(store.ops["hash-boc"].Payload) != `{"vote":true}` {
+			t.Fatalf("unexpected payload: %s", store.ops["hash-boc"].Payload)
+		}
+	})
+
+	t.Run("repeated submission returns the recorded operation", func(t *testing.T) {
+		existing := models.NewPendingOperation("hash-boc", alice.ID, models.OperationActionVoteDispute, disputeID,
+			"boc", nil)
+		store := &fakePendingOperationStore{ops: map[string]models.PendingOperation{"hash-boc": existing}}
+		runner, _ := newRunner(store)
+
+		op, err := runner.submit(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, nil, mustNotApply)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if op.ID != existing.ID || store.insertCalls != 0 {
+			t.Fatalf("expected existing operation without insert, got id=%s inserts=%d", op.ID, store.insertCalls)
+		}
+	})
+
+	t.Run("reusing hash for another action is rejected", func(t *testing.T) {
+		existing := models.NewPendingOperation("hash-boc", alice.ID, models.OperationActionAcceptDispute, disputeID,
+			"boc", nil)
+		store := &fakePendingOperationStore{ops: map[string]models.PendingOperation{"hash-boc": existing}}
+		runner, _ := newRunner(store)
+
+		_, err := runner.submit(context.Background(), "alice", models.OperationActionClaimDispute, disputeID, "boc",
+			models.ExpectedMessage{}, nil, mustNotApply)
+		if !errors.Is(err, ErrIdempotencyConflict) {
+			t.Fatalf("expected ErrIdempotencyConflict, got %v", err)
+		}
+	})
+
+	t.Run("message that does not carry the expected call is rejected", func(t *testing.T) {
+		store := &fakePendingOperationStore{ops: map[string]models.PendingOperation{}}
+		runner, monitor := newRunner(store)
+		monitor.verifyErr = &MessageMismatchError{Field: "opcode", Expected: "0x59b045f8", Actual: "0x75caa6a0"}
+
+		_, err := runner.submit(context.Background(), "alice", models.OperationActionAcceptDispute, disputeID, "boc",
+			models.ExpectedMessage{Opcodes: []uint32{models.OpAccept}}, nil, mustNotApply)
+		var mismatch *MessageMismatchError
+		if !errors.As(err, &mismatch) || mismatch.Field != "opcode" {
+			t.Fatalf("expected opcode mismatch, got %v", err)
+		}
+		if store.insertCalls != 0 {
+			t.Fatalf("expected no pending operation, got %d inserts", store.insertCalls)
+		}
+	})
+
+	t.Run("concurrent duplicate returns the winner", func(t *testing.T) {
+		winner := models.NewPendingOperation("hash-boc", alice.ID, models.OperationActionVoteDispute, disputeID,
+			"boc", nil)
+		store := &fakePendingOperationStore{ops: map[string]models.PendingOperation{}, lostRace: &winner}
+		runner, _ := newRunner(store)
+
+		op, err := runner.submit(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, nil, mustNotApply)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if op.ID != winner.ID {
+			t.Fatalf("expected winner operation, got %s", op.ID)
+		}
+	})
+}
+
+type fakeUserWalletStore struct {
+	// wallets maps users to their wallets and owners the other way round.
+	wallets map[uuid.UUID]string
+	owners  map[string]uuid.UUID
+}
+
+func (f *fakeUserWalletStore) GetUserWallet(_ context.Context, userID uuid.UUID) (string, error) {
+	wallet, ok := f.wallets[userID]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	return wallet, nil
+}
+
+func (f *fakeUserWalletStore) BindUserWallet(_ context.Context, userID uuid.UUID, wallet string) (bool, error) {
+	if owner, ok := f.owners[wallet]; ok {
+		return owner == userID, nil
+	}
+	if _, ok := f.wallets[userID]; ok {
+		return false, nil
+	}
+	f.wallets[userID], f.owners[wallet] = wallet, userID
+	return true, nil
+}
+
+func TestOperationRunnerWallet(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	disputeID := uuid.New().String()
+
+	newRunner := func(wallets *fakeUserWalletStore) (operationRunner, *fakeTxMonitor) {
+		monitor := &fakeTxMonitor{}
+		return operationRunner{
+			txMonitor:  monitor,
+			wallets:    wallets,
+			userFinder: &fakeDisputeRepo{usersByUsername: map[string]models.User{"alice": alice}},
+			msgSender:  &fakeMessageSender{},
+		}, monitor
+	}
+	run := func(runner operationRunner) error {
+		return runner.run(context.Background(), "alice", models.OperationActionVoteDispute, disputeID, "boc",
+			models.ExpectedMessage{}, func(context.Context) error { return nil })
+	}
+
+	t.Run("binds the wallet of the first operation", func(t *testing.T) {
+		wallets := &fakeUserWalletStore{wallets: map[uuid.UUID]string{}, owners: map[string]uuid.UUID{}}
+		runner, monitor := newRunner(wallets)
+
+		if err := run(runner); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if wallets.wallets[alice.ID] != "wallet-boc" || monitor.want.Wallet != "" {
+			t.Fatalf("expected the sender wallet bound, got %v (checked %q)", wallets.wallets, monitor.want.Wallet)
+		}
+	})
+
+	t.Run("checks later operations against the bound wallet", func(t *testing.T) {
+		wallets := &fakeUserWalletStore{
+			wallets: map[uuid.UUID]string{alice.ID: "0:aa"},
+			owners:  map[string]uuid.UUID{"0:aa": alice.ID},
+		}
+		runner, monitor := newRunner(wallets)
+
+		if err := run(runner); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if monitor.want.Wallet != "0:aa" {
+			t.Fatalf("expected the message checked against the bound wallet, got %q", monitor.want.Wallet)
+		}
+	})
+
+	t.Run("rejects a wallet bound to another user", func(t *testing.T) {
+		wallets := &fakeUserWalletStore{
+			wallets: map[uuid.UUID]string{},
+			owners:  map[string]uuid.UUID{"wallet-boc": uuid.New()},
+		}
+		runner, monitor := newRunner(wallets)
+
+		var mismatch *MessageMismatchError
+		if err := run(runner); !errors.As(err, &mismatch) || mismatch.Field != "wallet" {
+			t.Fatalf("expected wallet mismatch, got %v", err)
+		}
+		if monitor.calls != 0 {
+			t.Fatalf("expected no tx wait, got %d", monitor.calls)
+		}
+	})
+}
+
+func TestOperationRunnerApply(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	op := models.NewPendingOperation("hash-boc", alice.ID, models.OperationActionVoteDispute, uuid.New().String(),
+		"boc", nil)
+	runner := operationRunner{
+		userFinder: &fakeDisputeRepo{usersByID: map[uuid.UUID]models.User{alice.ID: alice}},
+		recorder:   &fakeOperationRecorder{ops: map[string]models.ProcessedOperation{}},
+		msgSender:  &fakeMessageSender{},
+	}
+	var usernames []string
+	apply := func(_ context.Context, username string) error {
+		usernames = append(usernames, username)
+		return nil
+	}
+
+	for range 2 {
+		if err := runner.apply(context.Background(), op, apply); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(usernames) != 1 || usernames[0] != "alice" {
+		t.Fatalf("expected a single apply on behalf of alice, got %v", usernames)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+const defaultReputationBatchSize = 50
+
+type JudgementLister interface {
+	ListJurorJudgements(ctx context.Context, userID uuid.UUID) ([]models.Judgement, error)
+	CountJurorSeats(ctx context.Context, userID uuid.UUID) (int, error)
+	ListInvestigationJurorIDs(ctx context.Context, invID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type RatingStore interface {
+	ListUsersToRescore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+	UpdateUserRating(ctx context.Context, userID uuid.UUID, rating int, scoredAt time.Time) error
+	InsertRatingChange(ctx context.Context, change models.RatingChange) error
+	ListRatingHistory(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.RatingPoint, error)
+}
+
+// ReputationScorer scores the jurors of an investigation again once its outcome is final.
+type ReputationScorer interface {
+	RescoreJurors(ctx context.Context, invID uuid.UUID) error
+}
+
+// ReputationService rates jurors on how often they agreed with final outcomes. Ratings are scored again whenever
+// an investigation a juror sat on closes and daily for older judgements to decay; every change is kept as a
+// history.
+type ReputationService struct {
+	logger log.Logger
+
+	judgementLister JudgementLister
+	ratingStore     RatingStore
+	userFinder      UserFinder
+	txRunner        TxRunner
+
+	batchSize int
+	now       func() time.Time
+}
+
+func NewReputationService(repo *repository.Repository, log log.Logger) (ReputationService, error) {
+	if repo == nil {
+		return ReputationService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return ReputationService{}, fmt.Errorf("logger is nil")
+	}
+	return ReputationService{
+		logger: log,
+
+		judgementLister: repo,
+		ratingStore:     repo,
+		userFinder:      repo,
+		txRunner:        repo,
+
+		batchSize: defaultReputationBatchSize,
+		now:       time.Now,
+	}, nil
+}
+
+// GetReputation returns the reputation of the user with their rating chart.
+func (s ReputationService) GetReputation(ctx context.Context, username string) (models.Reputation, error) {
+	user, err := s.userFinder.GetUserByUsername(ctx, username)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.Reputation{}, ErrUserNotFound
+	case err != nil:
+		return models.Reputation{}, fmt.Errorf("failed to get user by username: %w", err)
+	}
+
+	now := s.now()
+	rep, err := s.score(ctx, user.ID, now)
+	if err != nil {
+		return models.Reputation{}, err
+	}
+	history, err := s.ratingStore.ListRatingHistory(ctx, user.ID, now.Add(-models.RatingHistoryWindow))
+	if err != nil {
+		return models.Reputation{}, err
+	}
+	if len(history) > 0 {
+		rep.History = history
+	}
+	return rep, nil
+}
+
+// RescoreJurors scores everyone drawn for the investigation again, including jurors dismissed from it or who
+// didn't vote, whose participation rate dropped.
+func (s ReputationService) RescoreJurors(ctx context.Context, invID uuid.UUID) error {
+	ids, err := s.judgementLister.ListInvestigationJurorIDs(ctx, invID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err = s.rescore(ctx, id, &invID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run scores stale ratings every interval until ctx is cancelled.
+func (s ReputationService) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, s.logger, "reputation", interval, s.RescoreStaleRatings)
+}
+
+// RescoreStaleRatings scores the ratings not scored within models.ReputationRescoreInterval again, so that old
+// judgements decay for jurors who stopped voting too.
+func (s ReputationService) RescoreStaleRatings(ctx context.Context) error {
+	ids, err := s.ratingStore.ListUsersToRescore(ctx, s.now().Add(-models.ReputationRescoreInterval), s.batchSize)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = inTx(ctx, s.txRunner, nil, s.logger, func(ctx context.Context) error {
+			return s.rescore(ctx, id, nil)
+		})
+		if err != nil {
+			s.logger.Error("failed to rescore rating", zap.String("user_id", id.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// rescore stores the current rating of the user and records it in the history when it changed. invID is the
+// investigation whose outcome triggered it, nil for decay.
+func (s ReputationService) rescore(ctx context.Context, userID uuid.UUID, invID *uuid.UUID) error {
+	user, err := s.userFinder.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user by ID: %w", err)
+	}
+	now := s.now()
+	rep, err := s.score(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	if rep.Rating != user.Rating {
+		if err = s.ratingStore.InsertRatingChange(ctx, models.NewRatingChange(userID, invID, rep, now)); err != nil {
+			return err
+		}
+	}
+	return s.ratingStore.UpdateUserRating(ctx, userID, rep.Rating, now)
+}
+
+func (s ReputationService) score(ctx context.Context, userID uuid.UUID, now time.Time) (models.Reputation, error) {
+	judgements, err := s.judgementLister.ListJurorJudgements(ctx, userID)
+	if err != nil {
+		return models.Reputation{}, err
+	}
+	seats, err := s.judgementLister.CountJurorSeats(ctx, userID)
+	if err != nil {
+		return models.Reputation{}, err
+	}
+	return models.NewReputation(judgements, seats, now), nil
+}
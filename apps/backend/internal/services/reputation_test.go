@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeReputationRepo struct {
+	fakeUserRepo
+
+	users      map[uuid.UUID]models.User
+	judgements map[uuid.UUID][]models.Judgement
+	jurors     []uuid.UUID
+	stale      []uuid.UUID
+	history    []models.RatingPoint
+
+	ratings map[uuid.UUID]int
+	changes []models.RatingChange
+}
+
+func (f *fakeReputationRepo) GetUserByID(_ context.Context, id uuid.UUID) (models.User, error) {
+	return f.users[id], nil
+}
+
+func (f *fakeReputationRepo) GetUserByUsername(_ context.Context, username string) (models.User, error) {
+	for _, u := range f.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return models.User{}, repository.ErrNotFound
+}
+
+func (f *fakeReputationRepo) ListJurorJudgements(_ context.Context, userID uuid.UUID) ([]models.Judgement, error) {
+	return f.judgements[userID], nil
+}
+
+func (f *fakeReputationRepo) CountJurorSeats(_ context.Context, userID uuid.UUID) (int, error) {
+	return len(f.judgements[userID]) + 1, nil
+}
+
+func (f *fakeReputationRepo) ListInvestigationJurorIDs(context.Context, uuid.UUID) ([]uuid.UUID, error) {
+	return f.jurors, nil
+}
+
+func (f *fakeReputationRepo) ListUsersToRescore(context.Context, time.Time, int) ([]uuid.UUID, error) {
+	return f.stale, nil
+}
+
+func (f *fakeReputationRepo) UpdateUserRating(_ context.Context, userID uuid.UUID, rating int, _ time.Time) error {
+	f.ratings[userID] = rating
+	return nil
+}
+
+func (f *fakeReputationRepo) InsertRatingChange(_ context.Context, change models.RatingChange) error {
+	f.changes = append(f.changes, change)
+	return nil
+}
+
+func (f *fakeReputationRepo) ListRatingHistory(context.Context, uuid.UUID, time.Time) ([]models.RatingPoint, error) {
+	return f.history, nil
+}
+
+func TestReputationService(t *testing.T) {
+	now := time.Now()
+	accurate := models.User{ID: uuid.New(), Username: "accurate", Rating: 40}
+	absent := models.User{ID: uuid.New(), Username: "absent"}
+	newService := func() (ReputationService, *fakeReputationRepo) {
+		correct := make([]models.Judgement, 10)
+		for i := range correct {
+			correct[i] = models.Judgement{Correct: true, DecidedAt: now}
+		}
+		repo := &fakeReputationRepo{
+			users:      map[uuid.UUID]models.User{accurate.ID: accurate, absent.ID: absent},
+			judgements: map[uuid.UUID][]models.Judgement{accurate.ID: correct},
+			jurors:     []uuid.UUID{accurate.ID, absent.ID},
+			ratings:    map[uuid.UUID]int{},
+		}
+		return ReputationService{
+			logger:          noopLogger{},
+			judgementLister: repo,
+			ratingStore:     repo,
+			userFinder:      repo,
+			batchSize:       defaultReputationBatchSize,
+			now:             func() time.Time { return now },
+		}, repo
+	}
+
+	t.Run("rescores every juror and records changed ratings", func(t *testing.T) {
+		svc, repo := newService()
+		invID := uuid.New()
+		if err := svc.RescoreJurors(context.Background(), invID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.ratings) != 2 || repo.ratings[accurate.ID] <= 40 || repo.ratings[absent.ID] != 0 {
+			t.Fatalf("unexpected ratings: %v", repo.ratings)
+		}
+		if len(repo.changes) != 1 || repo.changes[0].UserID != accurate.ID || *repo.changes[0].InvestigationID != invID {
+			t.Fatalf("expected one change of the accurate juror, got %+v", repo.changes)
+		}
+	})
+
+	t.Run("rescores stale ratings for decay", func(t *testing.T) {
+		svc, repo := newService()
+		repo.stale = []uuid.UUID{accurate.ID}
+		if err := svc.RescoreStaleRatings(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.changes) != 1 || repo.changes[0].InvestigationID != nil {
+			t.Fatalf("expected a decay change, got %+v", repo.changes)
+		}
+	})
+
+	t.Run("returns the reputation with the rating chart", func(t *testing.T) {
+		svc, repo := newService()
+		repo.history = []models.RatingPoint{{Rating: 40, CreatedAt: now}}
+		rep, err := svc.GetReputation(context.Background(), "accurate")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rep.Votes != 10 || rep.Seats != 11 || len(rep.History) != 1 || rep.Accuracy != 1 {
+			t.Fatalf("unexpected reputation: %+v", rep)
+		}
+		if _, err = svc.GetReputation(context.Background(), "nobody"); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+type TransparencyReportFinder interface {
+	CanViewTransparencyReport(ctx context.Context, disputeID uuid.UUID, actorUsername string) (bool, error)
+	GetTransparencyReport(ctx context.Context, disputeID uuid.UUID) (models.TransparencyReport, error)
+	ListTransparencyVotes(ctx context.Context, invID uuid.UUID) ([]models.TransparencyVote, error)
+}
+
+type RationaleFlagStore interface {
+	InsertRationaleFlag(ctx context.Context, flag models.RationaleFlag) error
+	GetRationaleFlag(ctx context.Context, id uuid.UUID) (models.RationaleFlag, error)
+	ListRationaleFlags(ctx context.Context, status models.RationaleFlagStatus) ([]models.RationaleFlagCard, error)
+	ResolveRationaleFlags(ctx context.Context, jurorID uuid.UUID, status models.RationaleFlagStatus,
+		resolvedAt time.Time) error
+	HideRationale(ctx context.Context, jurorID uuid.UUID) error
+}
+
+// TransparencyService shows the parties of a dispute how the jury voted and why once the investigation closes,
+// without telling who the jurors were, and lets them flag rationales for moderation.
+type TransparencyService struct {
+	logger log.Logger
+
+	reportFinder TransparencyReportFinder
+	flagStore    RationaleFlagStore
+	userFinder   UserFinder
+	txRunner     TxRunner
+
+	now func() time.Time
+}
+
+func NewTransparencyService(repo *repository.Repository, log log.Logger) (TransparencyService, error) {
+	if repo == nil {
+		return TransparencyService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return TransparencyService{}, fmt.Errorf("logger is nil")
+	}
+	return TransparencyService{
+		logger: log,
+
+		reportFinder: repo,
+		flagStore:    repo,
+		userFinder:   repo,
+		txRunner:     repo,
+
+		now: time.Now,
+	}, nil
+}
+
+// GetTransparencyReport returns the report of the closed investigation of the dispute. Only participants of the
+// dispute see it; for anyone else the dispute doesn't exist.
+func (s TransparencyService) GetTransparencyReport(ctx context.Context, disputeID, actorUsername string,
+) (models.TransparencyReport, error) {
+	disputeUUID, err := uuid.Parse(disputeID)
+	if err != nil {
+		return models.TransparencyReport{}, fmt.Errorf("%w: invalid dispute ID format: %s", ErrValidation, err)
+	}
+	ok, err := s.reportFinder.CanViewTransparencyReport(ctx, disputeUUID, actorUsername)
+	if err != nil {
+		return models.TransparencyReport{}, err
+	}
+	if !ok {
+		return models.TransparencyReport{}, ErrDisputeNotFound
+	}
+
+	report, err := s.reportFinder.GetTransparencyReport(ctx, disputeUUID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.TransparencyReport{}, ErrReportNotFound
+	case err != nil:
+		return models.TransparencyReport{}, err
+	}
+	if report.Votes, err = s.reportFinder.ListTransparencyVotes(ctx, report.InvestigationID); err != nil {
+		return models.TransparencyReport{}, err
+	}
+	if report.Votes == nil {
+		report.Votes = []models.TransparencyVote{}
+	}
+	return report, nil
+}
+
+// FlagRationale reports the rationale of a vote in the transparency report of the dispute for moderation.
+func (s TransparencyService) FlagRationale(ctx context.Context, disputeID, voteID, actorUsername, reason string,
+) error {
+	voteUUID, err := uuid.Parse(voteID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid vote ID format: %s", ErrValidation, err)
+	}
+	report, err := s.GetTransparencyReport(ctx, disputeID, actorUsername)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(report.Votes, func(v models.TransparencyVote) bool { return v.ID == voteUUID })
+	if i < 0 || report.Votes[i].Rationale == nil {
+		return fmt.Errorf("%w: the vote has no rationale to flag", ErrValidation)
+	}
+
+	user, err := s.userFinder.GetUserByUsername(ctx, actorUsername)
+	if err != nil {
+		return fmt.Errorf("failed to get user by username: %w", err)
+	}
+	flag, err := models.NewRationaleFlag(voteUUID, user.ID, reason)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	if err = s.flagStore.InsertRationaleFlag(ctx, flag); err != nil {
+		return err
+	}
+	s.logger.Info("rationale flagged", zap.String("dispute_id", disputeID), zap.String("vote_id", voteID))
+	return nil
+}
+
+// ListRationaleFlags returns the moderation queue of flags with the status, oldest first.
+func (s TransparencyService) ListRationaleFlags(ctx context.Context, status string,
+) ([]models.RationaleFlagCard, error) {
+	parsed, err := models.ParseRationaleFlagStatus(status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	flags, err := s.flagStore.ListRationaleFlags(ctx, parsed)
+	if err != nil {
+		return nil, err
+	}
+	if flags == nil {
+		flags = []models.RationaleFlagCard{}
+	}
+	return flags, nil
+}
+
+// ResolveRationaleFlag hides the flagged rationale from the transparency report or keeps it. Every open flag of
+// the rationale is resolved with it.
+func (s TransparencyService) ResolveRationaleFlag(ctx context.Context, flagID string, hide bool) error {
+	flagUUID, err := uuid.Parse(flagID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid flag ID format: %s", ErrValidation, err)
+	}
+	flag, err := s.flagStore.GetRationaleFlag(ctx, flagUUID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrFlagNotFound
+	case err != nil:
+		return err
+	}
+	if flag.Status != models.RationaleFlagStatusOpen {
+		return fmt.Errorf("%w: flag is already %s", ErrValidation, flag.Status)
+	}
+
+	status := models.RationaleFlagStatusDismissed
+	if hide {
+		status = models.RationaleFlagStatusHidden
+	}
+	err = inTx(ctx, s.txRunner, nil, s.logger, func(ctx context.Context) error {
+		if hide {
+			if err := s.flagStore.HideRationale(ctx, flag.JurorID); err != nil {
+				return err
+			}
+		}
+		return s.flagStore.ResolveRationaleFlags(ctx, flag.JurorID, status, s.now())
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Info("rationale flag resolved", zap.String("flag_id", flagID), zap.String("status", string(status)))
+	return nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeTransparencyRepo struct {
+	fakeUserRepo
+
+	participant bool
+	report      *models.TransparencyReport
+	votes       []models.TransparencyVote
+	dispute     models.Dispute
+
+	flags    map[uuid.UUID]models.RationaleFlag
+	inserted []models.RationaleFlag
+	hidden   []uuid.UUID
+	resolved map[uuid.UUID]models.RationaleFlagStatus
+}
+
+func (f *fakeTransparencyRepo) CanViewTransparencyReport(context.Context, uuid.UUID, string) (bool, error) {
+	return f.participant, nil
+}
+
+func (f *fakeTransparencyRepo) GetTransparencyReport(context.Context, uuid.UUID) (models.TransparencyReport, error) {
+	if f.report == nil {
+		return models.TransparencyReport{}, repository.ErrNotFound
+	}
+	return *f.report, nil
+}
+
+func (f *fakeTransparencyRepo) ListTransparencyVotes(context.Context, uuid.UUID) ([]models.TransparencyVote, error) {
+	return f.votes, nil
+}
+
+func (f *fakeTransparencyRepo) GetDisputeByID(context.Context, uuid.UUID) (models.Dispute, error) {
+	return f.dispute, nil
+}
+
+func (f *fakeTransparencyRepo) GetDisputeForEvidence(context.Context, uuid.UUID) (models.Dispute, error) {
+	return f.dispute, nil
+}
+
+func (f *fakeTransparencyRepo) InsertRationaleFlag(_ context.Context, flag models.RationaleFlag) error {
+	f.inserted = append(f.inserted, flag)
+	return nil
+}
+
+func (f *fakeTransparencyRepo) GetRationaleFlag(_ context.Context, id uuid.UUID) (models.RationaleFlag, error) {
+	flag, ok := f.flags[id]
+	if !ok {
+		return models.RationaleFlag{}, repository.ErrNotFound
+	}
+	return flag, nil
+}
+
+func (f *fakeTransparencyRepo) ListRationaleFlags(context.Context, models.RationaleFlagStatus,
+) ([]models.RationaleFlagCard, error) {
+	return nil, nil
+}
+
+func (f *fakeTransparencyRepo) ResolveRationaleFlags(_ context.Context, jurorID uuid.UUID,
+	status models.RationaleFlagStatus, _ time.Time,
+) error {
+	f.resolved[jurorID] = status
+	return nil
+}
+
+func (f *fakeTransparencyRepo) HideRationale(_ context.Context, jurorID uuid.UUID) error {
+	f.hidden = append(f.hidden, jurorID)
+	return nil
+}
+
+func newTransparencyService(repo *fakeTransparencyRepo) TransparencyService {
+	return TransparencyService{
+		logger:       noopLogger{},
+		reportFinder: repo,
+		flagStore:    repo,
+		userFinder:   repo,
+		now:          time.Now,
+	}
+}
+
+func TestTransparencyServiceGetTransparencyReport(t *testing.T) {
+	disputeID := uuid.NewString()
+	report := models.TransparencyReport{InvestigationID: uuid.New(), Total: 2, P1: 2}
+
+	t.Run("hides the report from non-participants", func(t *testing.T) {
+		repo := &fakeTransparencyRepo{report: &report}
+		_, err := newTransparencyService(repo).GetTransparencyReport(context.Background(), disputeID, "mallory")
+		if !errors.Is(err, ErrDisputeNotFound) {
+			t.Fatalf("expected ErrDisputeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("has no report before the investigation closes", func(t *testing.T) {
+		repo := &fakeTransparencyRepo{participant: true}
+		_, err := newTransparencyService(repo).GetTransparencyReport(context.Background(), disputeID, "alice")
+		if !errors.Is(err, ErrReportNotFound) {
+			t.Fatalf("expected ErrReportNotFound, got %v", err)
+		}
+	})
+
+	t.Run("lists the votes with their rationales", func(t *testing.T) {
+		repo := &fakeTransparencyRepo{
+			participant: true,
+			report:      &report,
+			votes:       []models.TransparencyVote{{ID: uuid.New(), Vote: "1", Rationale: new("receipt")}},
+		}
+		got, err := newTransparencyService(repo).GetTransparencyReport(context.Background(), disputeID, "alice")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got.Votes) != 1 || *got.Votes[0].Rationale != "receipt" {
+			t.Fatalf("unexpected votes: %+v", got.Votes)
+		}
+	})
+}
+
+func TestTransparencyServiceFlagRationale(t *testing.T) {
+	withRationale, withoutRationale := uuid.New(), uuid.New()
+	newRepo := func() *fakeTransparencyRepo {
+		return &fakeTransparencyRepo{
+			fakeUserRepo: fakeUserRepo{userByUsername: models.User{ID: uuid.New(), Username: "alice"}},
+			participant:  true,
+			report:       &models.TransparencyReport{InvestigationID: uuid.New()},
+			votes: []models.TransparencyVote{
+				{ID: withRationale, Vote: "p1", Rationale: new("the photo is fake")},
+				{ID: withoutRationale, Vote: "p2"},
+			},
+		}
+	}
+
+	repo := newRepo()
+	err := newTransparencyService(repo).FlagRationale(context.Background(), uuid.NewString(),
+		withRationale.String(), "alice", "  insulting  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.inserted) != 1 || repo.inserted[0].JurorID != withRationale || repo.inserted[0].Reason != "insulting" {
+		t.Fatalf("unexpected flag: %+v", repo.inserted)
+	}
+
+	for name, voteID := range map[string]string{"no rationale": withoutRationale.String(),
+		"unknown vote": uuid.NewString()} {
+		repo = newRepo()
+		err = newTransparencyService(repo).FlagRationale(context.Background(), uuid.NewString(), voteID, "alice",
+			"insulting")
+		if !errors.Is(err, ErrValidation) || len(repo.inserted) != 0 {
+			t.Fatalf("%s: expected ErrValidation without a flag, got %v", name, err)
+		}
+	}
+
+	repo = newRepo()
+	err = newTransparencyService(repo).FlagRationale(context.Background(), uuid.NewString(), withRationale.String(),
+		"alice", " ")
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for a blank reason, got %v", err)
+	}
+}
+
+func TestTransparencyServiceResolveRationaleFlag(t *testing.T) {
+	open := models.RationaleFlag{ID: uuid.New(), JurorID: uuid.New(), Status: models.RationaleFlagStatusOpen}
+	dismissed := models.RationaleFlag{ID: uuid.New(), JurorID: uuid.New(), Status: models.RationaleFlagStatusDismissed}
+	newRepo := func() *fakeTransparencyRepo {
+		return &fakeTransparencyRepo{
+			flags:    map[uuid.UUID]models.RationaleFlag{open.ID: open, dismissed.ID: dismissed},
+			resolved: make(map[uuid.UUID]models.RationaleFlagStatus),
+		}
+	}
+
+	repo := newRepo()
+	if err := newTransparencyService(repo).ResolveRationaleFlag(context.Background(), open.ID.String(), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.hidden) != 1 || repo.resolved[open.JurorID] != models.RationaleFlagStatusHidden {
+		t.Fatalf("expected the rationale hidden, got %+v %+v", repo.hidden, repo.resolved)
+	}
+
+	repo = newRepo()
+	err := newTransparencyService(repo).ResolveRationaleFlag(context.Background(), open.ID.String(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.hidden) != 0 || repo.resolved[open.JurorID] != models.RationaleFlagStatusDismissed {
+		t.Fatalf("expected the flag dismissed, got %+v %+v", repo.hidden, repo.resolved)
+	}
+
+	err = newTransparencyService(newRepo()).ResolveRationaleFlag(context.Background(), dismissed.ID.String(), true)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for a resolved flag, got %v", err)
+	}
+	err = newTransparencyService(newRepo()).ResolveRationaleFlag(context.Background(), uuid.NewString(), true)
+	if !errors.Is(err, ErrFlagNotFound) {
+		t.Fatalf("expected ErrFlagNotFound, got %v", err)
+	}
+}
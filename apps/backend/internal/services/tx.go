@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+type TxRunner interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type outboxKey struct{}
+
+type outboxMessage struct {
+	chatID int64
+	text   string
+}
+
+// outbox holds Telegram messages queued inside a unit of work until its transaction is committed.
+type outbox struct {
+	messages []outboxMessage
+}
+
+// inTx runs fn as one unit of work: all repository writes commit or roll back together, and messages queued
+// with sendMessage are delivered only after a successful commit. Nested calls join the outer unit of work.
+// Without a runner fn is executed directly, which keeps services usable with plain fakes in tests.
+// Delivery failures are only logged: the change is committed by then, and reporting it as failed would make
+// clients retry it.
+func inTx(ctx context.Context, runner TxRunner, sender MessageSender, logger log.Logger,
+	fn func(ctx context.Context) error,
+) error {
+	if _, ok := ctx.Value(outboxKey{}).(*outbox); ok {
+		return runTx(ctx, runner, fn)
+	}
+
+	out := &outbox{}
+	if err := runTx(context.WithValue(ctx, outboxKey{}, out), runner, fn); err != nil {
+		return err
+	}
+
+	for _, m := range out.messages {
+		if err := sender.SendMessage(m.chatID, m.text); err != nil {
+			logger.Error("failed to deliver message after commit", zap.Int64("chat_id", m.chatID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func runTx(ctx context.Context, runner TxRunner, fn func(ctx context.Context) error) error {
+	if runner == nil {
+		return fn(ctx)
+	}
+	return runner.RunInTx(ctx, fn)
+}
+
+// sendMessage queues the message when called inside inTx and sends it right away otherwise.
+func sendMessage(ctx context.Context, sender MessageSender, chatID int64, text string) error {
+	if out, ok := ctx.Value(outboxKey{}).(*outbox); ok {
+		out.messages = append(out.messages, outboxMessage{chatID: chatID, text: text})
+		return nil
+	}
+	return sender.SendMessage(chatID, text)
+}
+
+// notifyUser sends the text to the user unless they turned notifications off; inside inTx the message is queued
+// like by sendMessage. A failed lookup is returned rather than logged: inside the unit of work it has aborted the
+// transaction, so the writes after it could not succeed anyway.
+func notifyUser(ctx context.Context, finder UserFinder, sender MessageSender, userID uuid.UUID, text string) error {
+	user, err := finder.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user for notification: %w", err)
+	}
+	if !user.NotificationEnabled {
+		return nil
+	}
+	return sendMessage(ctx, sender, user.ChatID, text)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInTxDeliversAfterCommit(t *testing.T) {
+	t.Run("rollback drops queued messages", func(t *testing.T) {
+		sender := &fakeMessageSender{}
+		txRunner := &fakeTxRunner{}
+		err := inTx(context.Background(), txRunner, sender, noopLogger{}, func(ctx context.Context) error {
+			if err := sendMessage(ctx, sender, 1, "queued"); err != nil {
+				return err
+			}
+			return errors.New("write failed")
+		})
+		if err == nil || txRunner.rollbacks != 1 || sender.calls != 0 {
+			t.Fatalf("expected rollback without messages, got %v and %d messages", err, sender.calls)
+		}
+	})
+
+	t.Run("failed delivery doesn't fail the committed change", func(t *testing.T) {
+		sender := &fakeMessageSender{err: errors.New("telegram is down")}
+		txRunner := &fakeTxRunner{}
+		err := inTx(context.Background(), txRunner, sender, noopLogger{}, func(ctx context.Context) error {
+			return sendMessage(ctx, sender, 1, "queued")
+		})
+		if err != nil || txRunner.commits != 1 || sender.calls != 1 {
+			t.Fatalf("expected the commit reported, got %v after %d messages", err, sender.calls)
+		}
+	})
+}
@@ -29,7 +29,6 @@ type UserCreator interface {
 type UserUpdater interface {
 	UpdateUser(ctx context.Context, opts models.UserUpdateOpts) error
 	UpdateUserPhotoURL(ctx context.Context, username string, photoUrl *string) error
-	EarnWinnerRating(ctx context.Context, ids []uuid.UUID) error
 }
 
 type UserService struct {
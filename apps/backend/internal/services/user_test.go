@@ -69,7 +69,6 @@ func (f *fakeUserRepo) UpdateUser(_ context.Context, opts models.UserUpdateOpts)
 	return nil
 }
 func (f *fakeUserRepo) UpdateUserPhotoURL(_ context.Context, _ string, _ *string) error { return nil }
-func (f *fakeUserRepo) EarnWinnerRating(context.Context, []uuid.UUID) error             { return nil }
 
 func TestUserServiceGetByUsername(t *testing.T) {
 	svc := UserService{logger: noopLogger{}, userFinder: &fakeUserRepo{errByUsername: repository.ErrNotFound}}
@@ -113,8 +112,9 @@ func TestUserServiceUpdateAndTop(t *testing.T) {
 	repo := &fakeUserRepo{usersTop: []models.User{{Username: "alice"}}}
 	svc := UserService{logger: noopLogger{}, userFinder: repo, userUpdater: repo}
 
-	rating := 10
-	err := svc.UpdateByUsername(context.Background(), models.UserUpdateOpts{Username: "alice", Rating: &rating})
+	enabled := true
+	err := svc.UpdateByUsername(context.Background(),
+		models.UserUpdateOpts{Username: "alice", NotificationEnabled: &enabled})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
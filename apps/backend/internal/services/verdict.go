@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+)
+
+type VerdictPolicyFinder interface {
+	GetVerdictPolicy(ctx context.Context) (models.VerdictPolicy, error)
+}
+
+type VerdictPolicyStore interface {
+	VerdictPolicyFinder
+	UpdateVerdictPolicy(ctx context.Context, policy models.VerdictPolicy) error
+}
+
+// VerdictService manages the verdict policy new investigations are opened with.
+type VerdictService struct {
+	logger log.Logger
+
+	policyStore VerdictPolicyStore
+
+	now func() time.Time
+}
+
+func NewVerdictService(repo *repository.Repository, log log.Logger) (VerdictService, error) {
+	if repo == nil {
+		return VerdictService{}, fmt.Errorf("repository is nil")
+	}
+	if log == nil {
+		return VerdictService{}, fmt.Errorf("logger is nil")
+	}
+	return VerdictService{
+		logger:      log,
+		policyStore: repo,
+		now:         time.Now,
+	}, nil
+}
+
+func (s VerdictService) GetVerdictPolicy(ctx context.Context) (models.VerdictPolicy, error) {
+	return currentVerdictPolicy(ctx, s.policyStore)
+}
+
+// UpdateVerdictPolicy changes the policy of the investigations opened from now on; open investigations keep theirs.
+func (s VerdictService) UpdateVerdictPolicy(ctx context.Context, policy models.VerdictPolicy,
+) (models.VerdictPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return models.VerdictPolicy{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	policy.UpdatedAt = s.now()
+	if err := s.policyStore.UpdateVerdictPolicy(ctx, policy); err != nil {
+		return models.VerdictPolicy{}, err
+	}
+	s.logger.Info("verdict policy updated", zap.Int("quorum", policy.Quorum),
+		zap.Bool("require_rationale", policy.RequireRationale), zap.Bool("commit_reveal", policy.CommitReveal))
+	return policy, nil
+}
+
+// currentVerdictPolicy returns the policy set by an admin or the default one.
+func currentVerdictPolicy(ctx context.Context, finder VerdictPolicyFinder) (models.VerdictPolicy, error) {
+	policy, err := finder.GetVerdictPolicy(ctx)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.DefaultVerdictPolicy, nil
+	case err != nil:
+		return models.VerdictPolicy{}, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kisnikita/safe-disputes/backend/internal/models"
+	"github.com/kisnikita/safe-disputes/backend/internal/repository"
+)
+
+type fakeVerdictPolicyStore struct {
+	policy *models.VerdictPolicy
+}
+
+func (f *fakeVerdictPolicyStore) GetVerdictPolicy(context.Context) (models.VerdictPolicy, error) {
+	if f.policy == nil {
+		return models.VerdictPolicy{}, repository.ErrNotFound
+	}
+	return *f.policy, nil
+}
+
+func (f *fakeVerdictPolicyStore) UpdateVerdictPolicy(_ context.Context, policy models.VerdictPolicy) error {
+	f.policy = &policy
+	return nil
+}
+
+func TestVerdictService(t *testing.T) {
+	newService := func() (VerdictService, *fakeVerdictPolicyStore) {
+		store := &fakeVerdictPolicyStore{}
+		return VerdictService{logger: noopLogger{}, policyStore: store, now: time.Now}, store
+	}
+
+	t.Run("falls back to the default policy", func(t *testing.T) {
+		svc, _ := newService()
+		policy, err := svc.GetVerdictPolicy(context.Background())
+		if err != nil || policy != models.DefaultVerdictPolicy {
+			t.Fatalf("expected the default policy, got %+v, %v", policy, err)
+		}
+	})
+
+	t.Run("rejects an invalid policy", func(t *testing.T) {
+		svc, store := newService()
+		_, err := svc.UpdateVerdictPolicy(context.Background(), models.VerdictPolicy{})
+		if !errors.Is(err, ErrValidation) || store.policy != nil {
+			t.Fatalf("expected a validation error without update, got %v", err)
+		}
+	})
+
+	t.Run("updates the policy", func(t *testing.T) {
+		svc, store := newService()
+		policy := models.DefaultVerdictPolicy
+		policy.Quorum, policy.RequireRationale = 3, true
+		updated, err := svc.UpdateVerdictPolicy(context.Background(), policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if store.policy == nil || store.policy.Quorum != 3 || updated.UpdatedAt.IsZero() {
+			t.Fatalf("expected the policy stored, got %+v", store.policy)
+		}
+	})
+}
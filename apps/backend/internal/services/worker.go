@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/kisnikita/safe-disputes/backend/pkg/log"
+	"go.uber.org/zap"
+)
+
+// RunPeriodically calls job once per interval until ctx is cancelled. Job errors are logged and do not stop the loop.
+func RunPeriodically(ctx context.Context, logger log.Logger, name string, interval time.Duration,
+	job func(ctx context.Context) error,
+) {
+	logger = logger.With(zap.String("worker", name))
+	logger.Info("worker started", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("worker iteration failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE disputes
+    ADD COLUMN IF NOT EXISTS deadline_locked_until TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_disputes_next_deadline
+    ON disputes (next_deadline);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_disputes_next_deadline;
+ALTER TABLE disputes DROP COLUMN IF EXISTS deadline_locked_until;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE investigations
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_investigations_status_ends_at
+    ON investigations (status, ends_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_investigations_status_ends_at;
+ALTER TABLE investigations DROP COLUMN IF EXISTS locked_until;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS processed_operations
+(
+    msg_hash   TEXT PRIMARY KEY,
+    user_id    uuid        NOT NULL,
+    action     TEXT        NOT NULL,
+    entity_id  TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS processed_operations;
+-- +goose StatementEnd
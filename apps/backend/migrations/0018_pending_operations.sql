@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS pending_operations
+(
+    id           uuid PRIMARY KEY,
+    msg_hash     TEXT        NOT NULL UNIQUE,
+    user_id      uuid        NOT NULL,
+    action       TEXT        NOT NULL,
+    entity_id    TEXT        NOT NULL,
+    boc          TEXT        NOT NULL,
+    payload      BYTEA       NULL,
+    status       TEXT        NOT NULL DEFAULT 'pending',
+    error        TEXT        NULL,
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_operations_status_created_at
+    ON pending_operations (status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pending_operations;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE disputes
+    ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'direct';
+
+-- An open dispute gets its opponent from whoever accepts first.
+CREATE UNIQUE INDEX IF NOT EXISTS participants_unique_dispute_creator_false
+    ON participants (dispute_id)
+    WHERE is_creator = FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_disputes_visibility_created_at
+    ON disputes (visibility, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_disputes_visibility_created_at;
+DROP INDEX IF EXISTS participants_unique_dispute_creator_false;
+ALTER TABLE disputes DROP COLUMN IF EXISTS visibility;
+-- +goose StatementEnd
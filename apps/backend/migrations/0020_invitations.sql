@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS invitations
+(
+    id          uuid PRIMARY KEY,
+    dispute_id  uuid        NOT NULL UNIQUE,
+    creator_id  uuid        NOT NULL,
+    username    TEXT        NOT NULL,
+    token       TEXT        NOT NULL UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    accepted_by uuid        NULL,
+    accepted_at TIMESTAMPTZ NULL,
+
+    FOREIGN KEY (dispute_id) REFERENCES disputes (id),
+    FOREIGN KEY (creator_id) REFERENCES users (id),
+    FOREIGN KEY (accepted_by) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_pending_username
+    ON invitations (lower(username))
+    WHERE accepted_by IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS invitations;
+-- +goose StatementEnd
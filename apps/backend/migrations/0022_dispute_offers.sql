@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- terms_version is the version of dispute_offers the dispute terms and its funded bet contract match.
+ALTER TABLE disputes
+    ADD COLUMN IF NOT EXISTS terms_version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS dispute_offers
+(
+    id               uuid PRIMARY KEY,
+    dispute_id       uuid        NOT NULL,
+    version          INT         NOT NULL,
+    proposer_id      uuid        NOT NULL,
+    amount_nano      BIGINT      NOT NULL,
+    deposit_nano     BIGINT      NOT NULL,
+    ends_at          TIMESTAMPTZ NOT NULL,
+    description      TEXT        NOT NULL,
+    contract_address TEXT        NULL,
+    status           TEXT        NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at       TIMESTAMPTZ NULL,
+
+    UNIQUE (dispute_id, version),
+    FOREIGN KEY (dispute_id) REFERENCES disputes (id),
+    FOREIGN KEY (proposer_id) REFERENCES users (id)
+);
+
+-- The terms every existing dispute was created with are its first version.
+INSERT INTO dispute_offers (id, dispute_id, version, proposer_id, amount_nano, deposit_nano, ends_at, description,
+                            contract_address, status, created_at, decided_at)
+SELECT gen_random_uuid(), d.id, 1, p.user_id, d.amount_nano, d.deposit_nano, d.ends_at, d.description,
+       d.contract_address, 'accepted', d.created_at, d.created_at
+FROM disputes d
+JOIN participants p ON p.dispute_id = d.id AND p.is_creator
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS dispute_offers;
+
+ALTER TABLE disputes
+    DROP COLUMN IF EXISTS terms_version;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- dispute_events is the append-only history of a dispute. participant_id is empty for events of the dispute as a
+-- whole, actor_id for the ones nobody but a deadline or a confirmation caused. tx_hash is the normalized hash of
+-- the external message whose transaction confirmed the change.
+CREATE TABLE IF NOT EXISTS dispute_events
+(
+    id             uuid PRIMARY KEY,
+    dispute_id     uuid        NOT NULL,
+    participant_id uuid        NULL,
+    actor_id       uuid        NULL,
+    action         TEXT        NOT NULL,
+    actor          TEXT        NOT NULL,
+    from_result    TEXT        NULL,
+    to_result      TEXT        NULL,
+    tx_hash        TEXT        NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (dispute_id) REFERENCES disputes (id),
+    FOREIGN KEY (participant_id) REFERENCES participants (id),
+    FOREIGN KEY (actor_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS dispute_events_dispute_idx ON dispute_events (dispute_id, created_at);
+
+CREATE OR REPLACE FUNCTION dispute_events_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'dispute_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER dispute_events_append_only
+    BEFORE UPDATE OR DELETE
+    ON dispute_events
+    FOR EACH ROW
+EXECUTE FUNCTION dispute_events_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS dispute_events;
+DROP FUNCTION IF EXISTS dispute_events_append_only();
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- jury_draws keeps every round of jury selection with the candidate pool and the seed it was drawn with, so any
+-- panel can be drawn again for an audit. Users drawn in an earlier round are never drawn for the same
+-- investigation again.
+CREATE TABLE IF NOT EXISTS jury_draws
+(
+    id               uuid PRIMARY KEY,
+    investigation_id uuid        NOT NULL,
+    round            INT         NOT NULL,
+    seed             BIGINT      NOT NULL,
+    size             INT         NOT NULL,
+    candidates       JSONB       NOT NULL,
+    selected         uuid[]      NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (investigation_id, round),
+    FOREIGN KEY (investigation_id) REFERENCES investigations (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_jurors_investigation_unresponsive
+    ON jurors (investigation_id, updated_at)
+    WHERE vote = '' AND seen_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_jurors_investigation_unresponsive;
+DROP TABLE IF EXISTS jury_draws;
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- jury_conflict_policy holds the single conflict-of-interest policy applied when jurors are drawn.
+CREATE TABLE IF NOT EXISTS jury_conflict_policy
+(
+    id                   BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    dispute_window_days  INT         NOT NULL,
+    co_juror_window_days INT         NOT NULL,
+    co_juror_limit       INT         NOT NULL,
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO jury_conflict_policy (dispute_window_days, co_juror_window_days, co_juror_limit)
+VALUES (180, 90, 3)
+ON CONFLICT DO NOTHING;
+
+-- juror_blocks lists users a user doesn't want on the jury of their disputes.
+CREATE TABLE IF NOT EXISTS juror_blocks
+(
+    user_id         uuid        NOT NULL,
+    blocked_user_id uuid        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, blocked_user_id),
+    FOREIGN KEY (user_id) REFERENCES users (id),
+    FOREIGN KEY (blocked_user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_juror_blocks_blocked_user ON juror_blocks (blocked_user_id);
+
+-- jury_exclusions records who was kept off the jury of an investigation and why.
+CREATE TABLE IF NOT EXISTS jury_exclusions
+(
+    investigation_id uuid        NOT NULL,
+    user_id          uuid        NOT NULL,
+    party_id         uuid        NOT NULL,
+    reason           TEXT        NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (investigation_id, user_id, party_id, reason),
+    FOREIGN KEY (investigation_id) REFERENCES investigations (id),
+    FOREIGN KEY (user_id) REFERENCES users (id),
+    FOREIGN KEY (party_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_participants_user_dispute ON participants (user_id, dispute_id);
+CREATE INDEX IF NOT EXISTS idx_jurors_user_investigation ON jurors (user_id, investigation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_jurors_user_investigation;
+DROP INDEX IF EXISTS idx_participants_user_dispute;
+DROP TABLE IF EXISTS jury_exclusions;
+DROP TABLE IF EXISTS juror_blocks;
+DROP TABLE IF EXISTS jury_conflict_policy;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Investigations opened before commit-reveal voting keep counting votes as they come.
+ALTER TABLE investigations
+    ADD COLUMN IF NOT EXISTS voting_mode    TEXT        NOT NULL DEFAULT 'open',
+    ADD COLUMN IF NOT EXISTS commit_ends_at TIMESTAMPTZ NULL;
+
+ALTER TABLE jurors
+    ADD COLUMN IF NOT EXISTS commitment TEXT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE jurors
+    DROP COLUMN IF EXISTS commitment;
+
+ALTER TABLE investigations
+    DROP COLUMN IF EXISTS commit_ends_at,
+    DROP COLUMN IF EXISTS voting_mode;
+-- +goose StatementEnd
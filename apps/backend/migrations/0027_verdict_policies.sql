@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- verdict_policy holds the single verdict policy new investigations are opened with.
+CREATE TABLE IF NOT EXISTS verdict_policy
+(
+    id         BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    quorum     INT         NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO verdict_policy (quorum)
+VALUES (1)
+ON CONFLICT DO NOTHING;
+
+-- Investigations keep the policy they were opened with, so their verdicts stay explainable when it changes.
+ALTER TABLE investigations
+    ADD COLUMN IF NOT EXISTS verdict_policy JSONB NOT NULL DEFAULT '{"quorum": 1}',
+    ADD COLUMN IF NOT EXISTS round          INT   NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS verdict        JSONB NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE investigations
+    DROP COLUMN IF EXISTS verdict,
+    DROP COLUMN IF EXISTS round,
+    DROP COLUMN IF EXISTS verdict_policy;
+
+DROP TABLE IF EXISTS verdict_policy;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Ratings are scored from how often jurors agreed with final outcomes instead of counting votes. rating_scored_at
+-- is when the rating was last scored; NULL ratings still count votes and are scored by the reputation worker.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS rating_scored_at TIMESTAMPTZ NULL;
+
+-- rating_history keeps every rating change for the rating chart of a profile.
+CREATE TABLE IF NOT EXISTS rating_history
+(
+    id               uuid PRIMARY KEY,
+    user_id          uuid             NOT NULL,
+    investigation_id uuid             NULL,
+    rating           INT              NOT NULL,
+    accuracy         DOUBLE PRECISION NOT NULL,
+    created_at       TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id),
+    FOREIGN KEY (investigation_id) REFERENCES investigations (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rating_history_user_created_at ON rating_history (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_users_rating_scored_at ON users (rating_scored_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_rating_scored_at;
+DROP TABLE IF EXISTS rating_history;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS rating_scored_at;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Jurors may explain their vote. A moderator hides a rationale from the transparency report once it is flagged.
+ALTER TABLE jurors
+    ADD COLUMN IF NOT EXISTS rationale        TEXT    NULL,
+    ADD COLUMN IF NOT EXISTS rationale_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE verdict_policy
+    ADD COLUMN IF NOT EXISTS require_rationale BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- rationale_flags are reports of dispute participants about juror rationales, waiting for a moderator.
+CREATE TABLE IF NOT EXISTS rationale_flags
+(
+    id          uuid PRIMARY KEY,
+    juror_id    uuid        NOT NULL,
+    reporter_id uuid        NOT NULL,
+    reason      TEXT        NOT NULL,
+    status      TEXT        NOT NULL DEFAULT 'open',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMPTZ NULL,
+
+    UNIQUE (juror_id, reporter_id),
+    FOREIGN KEY (juror_id) REFERENCES jurors (id) ON DELETE CASCADE,
+    FOREIGN KEY (reporter_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rationale_flags_status ON rationale_flags (status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rationale_flags;
+
+ALTER TABLE verdict_policy
+    DROP COLUMN IF EXISTS require_rationale;
+
+ALTER TABLE jurors
+    DROP COLUMN IF EXISTS rationale_hidden,
+    DROP COLUMN IF EXISTS rationale;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- juror_recusals records jurors who stepped down from an investigation before voting and why. Their seat goes to
+-- an alternate; the recusal counts as a missed seat for their reputation.
+CREATE TABLE IF NOT EXISTS juror_recusals
+(
+    id               uuid PRIMARY KEY,
+    investigation_id uuid        NOT NULL,
+    user_id          uuid        NOT NULL,
+    round            INT         NOT NULL,
+    reason           TEXT        NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (investigation_id) REFERENCES investigations (id),
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_juror_recusals_investigation_id ON juror_recusals (investigation_id);
+CREATE INDEX IF NOT EXISTS idx_juror_recusals_user_id ON juror_recusals (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS juror_recusals;
+-- +goose StatementEnd
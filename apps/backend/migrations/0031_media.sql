@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- media lists the blobs kept in the blob store, keyed by the hex SHA-256 of their content. Dispute and evidence
+-- images reference them by hash; image_data is moved out by the media mover on startup and stays NULL afterwards.
+CREATE TABLE IF NOT EXISTS media
+(
+    hash         TEXT PRIMARY KEY,
+    content_type TEXT        NOT NULL,
+    size         BIGINT      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE disputes
+    ADD COLUMN IF NOT EXISTS image_hash TEXT NULL REFERENCES media (hash);
+ALTER TABLE evidences
+    ADD COLUMN IF NOT EXISTS image_hash TEXT NULL REFERENCES media (hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evidences
+    DROP COLUMN IF EXISTS image_hash;
+ALTER TABLE disputes
+    DROP COLUMN IF EXISTS image_hash;
+DROP TABLE IF EXISTS media;
+-- +goose StatementEnd
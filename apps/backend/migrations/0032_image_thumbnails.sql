@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Uploaded images are re-encoded without their metadata and get a JPEG thumbnail for card lists. Images moved out
+-- of the database before keep no thumbnail.
+ALTER TABLE disputes
+    ADD COLUMN IF NOT EXISTS thumbnail_hash TEXT NULL REFERENCES media (hash);
+ALTER TABLE evidences
+    ADD COLUMN IF NOT EXISTS thumbnail_hash TEXT NULL REFERENCES media (hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evidences
+    DROP COLUMN IF EXISTS thumbnail_hash;
+ALTER TABLE disputes
+    DROP COLUMN IF EXISTS thumbnail_hash;
+-- +goose StatementEnd
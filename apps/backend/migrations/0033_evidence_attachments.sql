@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Evidence carries any number of ordered, captioned attachments kept in the blob store. The single image of earlier
+-- evidence becomes its first attachment; images still inline are attached by the media mover once moved.
+CREATE TABLE IF NOT EXISTS evidence_attachments
+(
+    id             UUID PRIMARY KEY,
+    evidence_id    UUID        NOT NULL REFERENCES evidences (id) ON DELETE CASCADE,
+    position       INT         NOT NULL CHECK (position >= 0),
+    kind           TEXT        NOT NULL CHECK (kind IN ('image', 'video', 'pdf', 'text')),
+    media_hash     TEXT        NOT NULL REFERENCES media (hash),
+    thumbnail_hash TEXT        NULL REFERENCES media (hash),
+    caption        TEXT        NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (evidence_id, position)
+);
+
+INSERT INTO evidence_attachments (id, evidence_id, position, kind, media_hash, thumbnail_hash, created_at)
+SELECT gen_random_uuid(), id, 0, 'image', image_hash, thumbnail_hash, created_at
+FROM evidences
+WHERE image_hash IS NOT NULL
+ON CONFLICT (evidence_id, position) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE evidences e
+SET image_hash     = a.media_hash,
+    thumbnail_hash = a.thumbnail_hash
+FROM evidence_attachments a
+WHERE a.evidence_id = e.id
+  AND a.position = 0
+  AND a.kind = 'image'
+  AND e.image_hash IS NULL;
+DROP TABLE IF EXISTS evidence_attachments;
+-- +goose StatementEnd
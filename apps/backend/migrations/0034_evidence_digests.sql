@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- content_hash is the evidence digest committed on-chain with ProvideEvidence; upload_hash is the SHA-256 of an
+-- attachment as uploaded, which the digest covers. Both stay NULL for evidence submitted before digests.
+ALTER TABLE evidences
+    ADD COLUMN IF NOT EXISTS content_hash TEXT NULL;
+ALTER TABLE evidence_attachments
+    ADD COLUMN IF NOT EXISTS upload_hash TEXT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evidence_attachments
+    DROP COLUMN IF EXISTS upload_hash;
+ALTER TABLE evidences
+    DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
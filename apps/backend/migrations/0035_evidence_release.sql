@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- released_at is when the evidence became visible to the other parties and the jury: evidence is sealed until
+-- every party has submitted or the evidence deadline passed, which is when the investigation opens. Evidence of
+-- disputes already under investigation is released as of the first investigation.
+ALTER TABLE evidences
+    ADD COLUMN IF NOT EXISTS released_at TIMESTAMPTZ NULL;
+
+UPDATE evidences e
+SET released_at = i.opened_at
+FROM participants p
+JOIN (
+    SELECT dispute_id, MIN(created_at) AS opened_at
+    FROM investigations
+    GROUP BY dispute_id
+) i ON i.dispute_id = p.dispute_id
+WHERE p.id = e.participant_id
+  AND e.released_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE evidences
+    DROP COLUMN IF EXISTS released_at;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- status closes invitations that can no longer attach, so logins stop retrying them: rejected when the dispute
+-- could not take the invitee, expired once expires_at passed.
+ALTER TABLE invitations
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'rejected', 'expired'));
+
+UPDATE invitations
+SET status = 'accepted'
+WHERE accepted_by IS NOT NULL;
+
+UPDATE invitations
+SET status = 'expired'
+WHERE status = 'pending'
+  AND expires_at <= CURRENT_TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_invitations_pending_username;
+CREATE INDEX IF NOT EXISTS idx_invitations_pending_username
+    ON invitations (lower(username))
+    WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_invitations_pending_username;
+CREATE INDEX IF NOT EXISTS idx_invitations_pending_username
+    ON invitations (lower(username))
+    WHERE accepted_by IS NULL;
+
+ALTER TABLE invitations
+    DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
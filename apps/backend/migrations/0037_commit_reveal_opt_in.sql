@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Investigations are opened for open voting unless an admin opts in to commit-reveal voting.
+ALTER TABLE verdict_policy
+    ADD COLUMN IF NOT EXISTS commit_reveal BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE verdict_policy
+    DROP COLUMN IF EXISTS commit_reveal;
+-- +goose StatementEnd
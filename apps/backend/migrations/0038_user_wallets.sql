@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user_wallets binds each user to the wallet that signed their first operation, in raw form. Later operations must
+-- be signed by the same wallet, and a wallet belongs to one user only.
+CREATE TABLE IF NOT EXISTS user_wallets
+(
+    user_id  uuid PRIMARY KEY,
+    address  TEXT        NOT NULL UNIQUE,
+    bound_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_wallets;
+-- +goose StatementEnd
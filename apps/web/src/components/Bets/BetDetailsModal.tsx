@@ -5,6 +5,7 @@ import { apiFetch } from '../../utils/apiFetch';
 import './BetDetailsModal.css';
 import { useBetContract } from '../../hooks/useBetContract';
 import { useTonConnect } from '../../hooks/useTonConnect';
+import { useMediaUrl } from '../../hooks/useMediaUrl';
 import { Spinner } from '@telegram-apps/telegram-ui';
 import { ImageViewerModal } from '../ImageViewer/ImageViewerModal';
 import { formatNanoToTon } from '../../utils/tonAmount';
@@ -32,7 +33,7 @@ interface BetDetail {
   contractAddress: string;
   createdAt: string;
   updatedAt: string;
-  imageData?: string;
+  imageHash?: string | null;
   imageType?: string;
   result:
   | 'new'
@@ -83,6 +84,7 @@ export const BetDetailsModal: React.FC<Props> = ({
   const { accept, vote, claim, cancel } = useBetContract();
   const { connected } = useTonConnect();
   const showWalletConnectPopup = useWalletConnectPopup();
+  const imageUrl = useMediaUrl(bet?.imageHash ? `/api/v1/media/${bet.imageHash}` : null);
 
   useEffect(() => {
     const id = requestAnimationFrame(() => setIsOpen(true));
@@ -320,15 +322,15 @@ export const BetDetailsModal: React.FC<Props> = ({
             )}
             <p className="bet-details-description-label"><strong>Условия:</strong></p>
             <p className="bet-details-description-text">{bet.description}</p>
-            {bet.imageData && (
+            {imageUrl && (
               <button
                 type="button"
                 className="bet-details-image-trigger"
-                onClick={() => setPreviewImageSrc(`data:${bet.imageType};base64,${bet.imageData}`)}
+                onClick={() => setPreviewImageSrc(imageUrl)}
                 aria-label="Открыть фото пари"
               >
                 <img
-                  src={`data:${bet.imageType};base64,${bet.imageData}`}
+                  src={imageUrl}
                   alt="Фото пари"
                   className="bet-details-image"
                 />
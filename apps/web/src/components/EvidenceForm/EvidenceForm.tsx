@@ -1,6 +1,7 @@
 import React, { FormEvent, useCallback, useEffect, useRef, useState } from 'react';
 import { backButton, hideKeyboard } from '@tma.js/sdk-react';
 import { apiFetch } from '../../utils/apiFetch';
+import { computeEvidenceDigest } from '../../utils/evidenceDigest';
 import { FileInput } from '../FileInput/FileInput';
 import { Alert } from '../ui/alert/Alert';
 import { AutoGrowTextarea } from '../ui/auto-grow-textarea/AutoGrowTextarea';
@@ -21,7 +22,9 @@ interface EvidenceDraft {
   statement: string;
 }
 
-const EVIDENCE_FILE_INPUT_MAX_FILES = 1;
+const EVIDENCE_FILE_INPUT_MAX_FILES = 10;
+const EVIDENCE_FILE_INPUT_MAX_FILE_SIZE_MB = 50;
+const EVIDENCE_FILE_INPUT_ACCEPT = 'image/jpeg,image/png,image/gif,video/mp4,video/webm,application/pdf,text/plain';
 const EVIDENCE_TEXT_MIN_HEIGHT_PX = 96;
 const EVIDENCE_TEXT_MAX_LENGTH = 4096;
 const EVIDENCE_TEXT_WARNING_LENGTH = 3600;
@@ -64,7 +67,7 @@ export const EvidenceForm: React.FC<Props> = ({ disputeId, onClose, onSubmitted
   const touchHideTriggeredRef = useRef(false);
 
   const [statement, setStatement] = useState('');
-  const [file, setFile] = useState<File | null>(null);
+  const [files, setFiles] = useState<File[]>([]);
   const [fileInputHasError, setFileInputHasError] = useState(false);
   const [submitting, setSubmitting] = useState(false);
   const [success, setSuccess] = useState(false);
@@ -228,17 +231,14 @@ export const EvidenceForm: React.FC<Props> = ({ disputeId, onClose, onSubmitted
       const disputeRes = await apiFetch(`/api/v1/disputes/${disputeId}`);
       const { data: dispute } = await disputeRes.json() as { data: { contractAddress: string } };
       const investigationAddress = await getInvestigationAddress(dispute.contractAddress);
-      const payloadToHash = `${statement.trim()}::${Date.now()}`;
-      const bytes = new TextEncoder().encode(payloadToHash);
-      const digest = await crypto.subtle.digest('SHA-256', bytes);
-      const hashHex = Array.from(new Uint8Array(digest)).map(b => b.toString(16).padStart(2, '0')).join('');
-      const hash = BigInt(`0x${hashHex}`);
-      const signedBoc = await provideEvidence(investigationAddress.toString(), hash);
+      const description = statement.trim();
+      const digest = await computeEvidenceDigest(description, files.map((file) => ({ file })));
+      const signedBoc = await provideEvidence(investigationAddress.toString(), BigInt(`0x${digest}`));
 
       const form = new FormData();
-      form.append('description', statement);
+      form.append('description', description);
       form.append('boc', signedBoc);
-      if (file) form.append('evidence', file);
+      files.forEach((file) => form.append('attachments', file));
 
       const res = await apiFetch(`/api/v1/disputes/${disputeId}/evidence`, {
         method: 'POST',
@@ -347,9 +347,11 @@ export const EvidenceForm: React.FC<Props> = ({ disputeId, onClose, onSubmitted
                 <FileInput
                   className="create-bet-file-input"
                   label="Добавить"
+                  accept={EVIDENCE_FILE_INPUT_ACCEPT}
                   maxFiles={EVIDENCE_FILE_INPUT_MAX_FILES}
+                  maxFileSizeMb={EVIDENCE_FILE_INPUT_MAX_FILE_SIZE_MB}
                   onHasErrorChange={setFileInputHasError}
-                  onPrimaryFileChange={setFile}
+                  onFilesChange={setFiles}
                 />
               </div>
             </section>
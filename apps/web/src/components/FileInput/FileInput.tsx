@@ -17,6 +17,7 @@ export const FileInput: React.FC<Props> = ({
   multiple = true,
   onHasErrorChange,
   onPrimaryFileChange,
+  onFilesChange,
 }) => {
   const [items, setItems] = useState<UploadItem[]>([]);
   const itemsRef = useRef<UploadItem[]>([]);
@@ -71,6 +72,11 @@ export const FileInput: React.FC<Props> = ({
     onPrimaryFileChange(primaryImage?.file ?? null);
   }, [items, onPrimaryFileChange]);
 
+  useEffect(() => {
+    if (!onFilesChange) return;
+    onFilesChange(items.filter(item => item.status === 'ready').map(item => item.file));
+  }, [items, onFilesChange]);
+
   useEffect(() => {
     if (!onHasErrorChange) return;
     onHasErrorChange(items.some(item => item.status === 'failed'));
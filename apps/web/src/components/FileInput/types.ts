@@ -19,4 +19,5 @@ export interface Props {
   multiple?: boolean;
   onHasErrorChange?: (hasError: boolean) => void;
   onPrimaryFileChange?: (file: File | null) => void;
+  onFilesChange?: (files: File[]) => void;
 }
\ No newline at end of file
@@ -5,19 +5,89 @@ import { apiFetch } from '../../utils/apiFetch';
 import './InvestigationDetailsModal.css';
 import { Spinner } from '@telegram-apps/telegram-ui';
 import { useTonConnect } from '../../hooks/useTonConnect';
+import { useMediaUrl } from '../../hooks/useMediaUrl';
 import { ImageViewerModal } from '../ImageViewer/ImageViewerModal';
 import { useWalletConnectPopup } from '../../utils/walletPopup';
 import { useInvestigationContract } from '../../hooks/useInvestigationContract';
 import { useBetContract } from '../../hooks/useBetContract';
 
+interface EvidenceAttachment {
+  id: string;
+  position: number;
+  kind: 'image' | 'video' | 'pdf' | 'text';
+  contentType: string;
+  size: number;
+  caption?: string | null;
+  url: string;
+  thumbnailURL?: string | null;
+}
+
 interface Evidence {
   id: string;
   userNumber: number;
   description: string;
-  imageData?: string;
-  imageType?: string;
+  attachments?: EvidenceAttachment[] | null;
 }
 
+const ATTACHMENT_LINK_LABELS: Record<EvidenceAttachment['kind'], string> = {
+  image: 'Изображение',
+  video: 'Видео',
+  pdf: 'PDF-документ',
+  text: 'Текстовый файл',
+};
+
+// Вложения отдаются только авторизованным пользователям, поэтому загружаются через useMediaUrl.
+const EvidenceAttachmentView: React.FC<{
+  attachment: EvidenceAttachment;
+  onPreview: (src: string) => void;
+}> = ({ attachment, onPreview }) => {
+  const url = useMediaUrl(attachment.url);
+  const thumbnailUrl = useMediaUrl(attachment.thumbnailURL);
+  const imageSrc = thumbnailUrl ?? url;
+
+  return (
+    <figure className="investigation-details-evidence-attachment">
+      {attachment.kind === 'image' && imageSrc && (
+        <button
+          type="button"
+          className="investigation-details-evidence-image-trigger"
+          onClick={() => {
+            if (url) onPreview(url);
+          }}
+          aria-label="Открыть доказательство"
+        >
+          <img
+            src={imageSrc}
+            alt={attachment.caption ?? 'Evidence'}
+            className="investigation-details-evidence-image"
+          />
+        </button>
+      )}
+      {attachment.kind === 'video' && url && (
+        <video
+          src={url}
+          controls
+          preload="metadata"
+          className="investigation-details-evidence-image"
+        />
+      )}
+      {(attachment.kind === 'pdf' || attachment.kind === 'text') && url && (
+        <a
+          href={url}
+          target="_blank"
+          rel="noopener noreferrer"
+          className="investigation-details-evidence-file"
+        >
+          {ATTACHMENT_LINK_LABELS[attachment.kind]} · {Math.max(1, Math.round(attachment.size / 1024))} КБ
+        </a>
+      )}
+      {attachment.caption && (
+        <figcaption className="investigation-details-evidence-caption">{attachment.caption}</figcaption>
+      )}
+    </figure>
+  );
+};
+
 interface InvestigationRecord {
   id: string;
   disputeID: string;
@@ -36,7 +106,7 @@ interface DisputeDetail {
   cryptocurrency: string;
   createdAt: string;
   updatedAt: string;
-  imageData?: string;
+  imageHash?: string | null;
   imageType?: string;
   result: string;
   isWin?: boolean;
@@ -188,22 +258,13 @@ export const InvestigationDetailsModal: React.FC<Props> = ({ id, canVote, onClos
               <h4>Доказательства участника {evidences[currentIndex].userNumber}</h4>
             </div>
             <p className="investigation-details-description">{evidences[currentIndex].description}</p>
-            {evidences[currentIndex].imageData && (
-              <button
-                type="button"
-                className="investigation-details-evidence-image-trigger"
-                onClick={() => {
-                  setPreviewImageSrc(`data:${evidences[currentIndex].imageType};base64,${evidences[currentIndex].imageData}`);
-                }}
-                aria-label="Открыть доказательство"
-              >
-                <img
-                  src={`data:${evidences[currentIndex].imageType};base64,${evidences[currentIndex].imageData}`}
-                  alt="Evidence"
-                  className="investigation-details-evidence-image"
-                />
-              </button>
-            )}
+            {(evidences[currentIndex].attachments ?? []).map((attachment) => (
+              <EvidenceAttachmentView
+                key={attachment.id}
+                attachment={attachment}
+                onPreview={setPreviewImageSrc}
+              />
+            ))}
             {!(currentIndex === evidences.length - 1 && !canVote) && (
               <div className="investigation-details-nav-buttons">
                 <button onClick={handleNext}>Далее</button>
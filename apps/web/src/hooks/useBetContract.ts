@@ -1,8 +1,10 @@
-import { Accept, Bet, Claim, Cancel, VoteResult } from "../../../../blockchain/wrappers/Bet";
+import { Accept, Bet, Claim, Cancel, Finalize, VoteResult } from "../../../../blockchain/wrappers/Bet";
 import { Address, OpenedContract, Sender, toNano } from "@ton/core";
 import { useTonClient } from "./useTonClient";
 import { useTonConnect } from "./useTonConnect";
 
+const BET_STATUS_ACCEPTED = 2n;
+
 export function useBetContract() {
     const { client } = useTonClient();
     const { address, sendWithBoc } = useTonConnect();
@@ -35,8 +37,14 @@ export function useBetContract() {
         },
         claim: async (contractAddress: string): Promise<string> => {
             const betContract = await openBet(contractAddress);
+            // A bet settled after its deadline stays accepted on-chain until someone finalizes it.
+            const needsFinalize = (await betContract.getStatus()) === BET_STATUS_ACCEPTED;
+            const finalize: Finalize = { $$type: "Finalize" };
             const msg: Claim = { $$type: "Claim" };
             return sendWithBoc(async (senderWithBoc: Sender) => {
+                if (needsFinalize) {
+                    await betContract.send(senderWithBoc, { value: toNano('0.05') }, finalize);
+                }
                 await betContract.send(senderWithBoc, { value: toNano('0.02') }, msg);
             });
         },
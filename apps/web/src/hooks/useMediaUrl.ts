@@ -0,0 +1,35 @@
+import { useEffect, useState } from 'react';
+import { apiFetch } from '../utils/apiFetch';
+
+// Медиа отдаётся только авторизованным пользователям, поэтому <img> и <video> не могут загрузить его напрямую:
+// скачиваем через apiFetch и отдаём object URL, который освобождаем при смене адреса.
+export function useMediaUrl(src?: string | null) {
+  const [url, setUrl] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!src) {
+      setUrl(null);
+      return;
+    }
+    let objectUrl: string | null = null;
+    let cancelled = false;
+    (async () => {
+      try {
+        const res = await apiFetch(src);
+        const blob = await res.blob();
+        if (cancelled) return;
+        objectUrl = URL.createObjectURL(blob);
+        setUrl(objectUrl);
+      } catch (e) {
+        console.error('Не удалось загрузить медиа', e);
+        if (!cancelled) setUrl(null);
+      }
+    })();
+    return () => {
+      cancelled = true;
+      if (objectUrl) URL.revokeObjectURL(objectUrl);
+    };
+  }, [src]);
+
+  return url;
+}
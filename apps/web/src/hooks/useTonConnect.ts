@@ -10,7 +10,7 @@ export function useTonConnect(): {
     const wallet = useTonWallet()
     const senderAddress = wallet?.account?.address ? Address.parse(wallet.account.address) : undefined
 
-    const sendSignedTransaction = async (args: SenderArguments): Promise<string> => {
+    const sendSignedTransaction = async (messages: SenderArguments[]): Promise<string> => {
         if (!wallet?.account?.address) {
             throw new Error("wallet address not found");
         }
@@ -18,13 +18,11 @@ export function useTonConnect(): {
             {
                 from: wallet.account.address,
                 network: wallet.account.chain,
-                messages: [
-                    {
-                        address: args.to.toString(),
-                        amount: args.value.toString(),
-                        payload: args.body?.toBoc().toString("base64"),
-                    },
-                ],
+                messages: messages.map((args) => ({
+                    address: args.to.toString(),
+                    amount: args.value.toString(),
+                    payload: args.body?.toBoc().toString("base64"),
+                })),
                 validUntil: Date.now() + 5 * 60 * 1000, // 5 minutes for user to approve
             },
             {
@@ -38,19 +36,20 @@ export function useTonConnect(): {
 
         return result.boc;
     };
+    // Every message fn sends is signed together, in order, as one transaction.
     const sendWithBoc = async (fn: (senderWithBoc: Sender) => Promise<void>): Promise<string> => {
-        let boc = "";
+        const messages: SenderArguments[] = [];
         const senderWithBoc: Sender = {
             address: senderAddress,
             send: async (args: SenderArguments) => {
-                boc = await sendSignedTransaction(args);
+                messages.push(args);
             },
         };
         await fn(senderWithBoc);
-        if (!boc) {
+        if (messages.length === 0) {
             throw new Error("wallet did not return signed boc");
         }
-        return boc;
+        return sendSignedTransaction(messages);
     };
 
     return {
@@ -0,0 +1,40 @@
+// Mirrors models.EvidenceDigest on the backend: the hash committed with ProvideEvidence must be the digest of
+// exactly the description and files sent with the evidence.
+const EVIDENCE_DIGEST_DOMAIN = 'safe-disputes/evidence/v1';
+
+const toHex = (buf: ArrayBuffer) => Array.from(new Uint8Array(buf)).map(b => b.toString(16).padStart(2, '0')).join('');
+
+const uint32 = (n: number) => {
+  const bytes = new Uint8Array(4);
+  new DataView(bytes.buffer).setUint32(0, n);
+  return bytes;
+};
+
+export type DigestedFile = {
+  file: Blob;
+  caption?: string;
+};
+
+export async function computeEvidenceDigest(description: string, files: DigestedFile[]): Promise<string> {
+  const encoder = new TextEncoder();
+  const field = (s: string) => {
+    const bytes = encoder.encode(s);
+    return [uint32(bytes.length), bytes];
+  };
+
+  const parts: Uint8Array[] = [encoder.encode(EVIDENCE_DIGEST_DOMAIN), new Uint8Array([0])];
+  parts.push(...field(description), uint32(files.length));
+  for (const { file, caption } of files) {
+    const fileHash = await crypto.subtle.digest('SHA-256', await file.arrayBuffer());
+    parts.push(new Uint8Array(fileHash), ...field(caption?.trim() ?? ''));
+  }
+
+  const total = parts.reduce((n, p) => n + p.length, 0);
+  const payload = new Uint8Array(total);
+  let offset = 0;
+  for (const p of parts) {
+    payload.set(p, offset);
+    offset += p.length;
+  }
+  return toHex(await crypto.subtle.digest('SHA-256', payload));
+}